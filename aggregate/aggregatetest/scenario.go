@@ -0,0 +1,266 @@
+// Package aggregatetest provides a given, when, then test harness for aggregate.Root implementations.
+//
+// Example testing a bank account withdrawal:
+//  aggregatetest.NewScenario(t, bankAccountType).
+//  	Given(accountID, AccountOpened{AccountID: accountID}, AccountCredited{Amount: 100}).
+//  	When(func(root aggregate.Root) error {
+//  		return root.(*BankAccount).Withdraw(50)
+//  	}).
+//  	Then(AccountDebited{Amount: 50})
+package aggregatetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/driver/inmemory"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const streamName goengine.StreamName = "aggregatetest"
+
+type (
+	// TestingT is the subset of testing.TB used by the Scenario
+	TestingT interface {
+		Errorf(format string, args ...interface{})
+		FailNow()
+		Helper()
+	}
+
+	// Behavior is a func that invokes the behavior of an existing aggregate.Root
+	Behavior func(root aggregate.Root) error
+
+	// Creation is a func that creates a new aggregate.Root
+	Creation func() (aggregate.Root, error)
+
+	// Scenario is a given, when, then test for a aggregate.Root
+	Scenario struct {
+		t             TestingT
+		aggregateType *aggregate.Type
+
+		aggregateID aggregate.ID
+		given       []interface{}
+
+		executed bool
+		root     aggregate.Root
+		err      error
+		recorded []*aggregate.Changed
+	}
+)
+
+// NewScenario returns a new Scenario for aggregates of the provided type
+func NewScenario(t TestingT, aggregateType *aggregate.Type) *Scenario {
+	t.Helper()
+
+	if aggregateType == nil {
+		t.Errorf("aggregatetest: an aggregate type is required")
+		t.FailNow()
+	}
+
+	return &Scenario{
+		t:             t,
+		aggregateType: aggregateType,
+	}
+}
+
+// Given sets the events that were previously recorded by the aggregate with the provided ID.
+// The events are replayed onto a new aggregate instance before the behavior is invoked.
+func (s *Scenario) Given(aggregateID aggregate.ID, events ...interface{}) *Scenario {
+	s.t.Helper()
+
+	if aggregateID == "" {
+		s.t.Errorf("aggregatetest: given requires an aggregate ID")
+		s.t.FailNow()
+	}
+
+	s.aggregateID = aggregateID
+	s.given = events
+
+	return s
+}
+
+// When replays the given events and invokes the behavior on the resulting aggregate.Root
+func (s *Scenario) When(behavior Behavior) *Scenario {
+	s.t.Helper()
+
+	if len(s.given) == 0 {
+		s.t.Errorf("aggregatetest: when requires given events, use WhenCreating to create a new aggregate")
+		s.t.FailNow()
+	}
+
+	repository, store := s.newRepository()
+	ctx := context.Background()
+
+	if err := store.AppendTo(ctx, streamName, s.givenMessages()); err != nil {
+		s.t.Errorf("aggregatetest: failed to store given events: %s", err)
+		s.t.FailNow()
+	}
+
+	root, err := repository.GetAggregateRoot(ctx, s.aggregateID)
+	if err != nil {
+		s.t.Errorf("aggregatetest: failed to replay given events: %s", err)
+		s.t.FailNow()
+	}
+
+	s.execute(repository, store, root, behavior(root))
+
+	return s
+}
+
+// WhenCreating invokes the creation of a new aggregate.Root
+func (s *Scenario) WhenCreating(creation Creation) *Scenario {
+	s.t.Helper()
+
+	if len(s.given) != 0 {
+		s.t.Errorf("aggregatetest: when creating cannot be combined with given events")
+		s.t.FailNow()
+	}
+
+	repository, store := s.newRepository()
+	root, err := creation()
+
+	s.execute(repository, store, root, err)
+
+	return s
+}
+
+// Then asserts that the behavior succeeded and recorded exactly the provided event payloads in order
+func (s *Scenario) Then(events ...interface{}) {
+	s.t.Helper()
+	s.requireExecuted()
+
+	if s.err != nil {
+		s.t.Errorf("aggregatetest: expected the behavior to succeed but it returned an error: %s", s.err)
+		return
+	}
+
+	payloads := make([]interface{}, len(s.recorded))
+	for i, change := range s.recorded {
+		payloads[i] = change.Payload()
+	}
+
+	if len(events) == 0 {
+		assert.Empty(s.t, payloads, "aggregatetest: expected no events to be recorded")
+		return
+	}
+
+	assert.Equal(s.t, events, payloads, "aggregatetest: recorded events do not match the expected events")
+}
+
+// ThenError asserts that the behavior returned the expected error
+func (s *Scenario) ThenError(expected error) {
+	s.t.Helper()
+	s.requireExecuted()
+
+	if s.err == nil {
+		s.t.Errorf("aggregatetest: expected the behavior to return error %q but it succeeded", expected)
+		return
+	}
+
+	assert.Equal(s.t, expected, s.err, "aggregatetest: the returned error does not match the expected error")
+}
+
+// Root returns the aggregate.Root after the behavior was invoked so it's state can be inspected
+func (s *Scenario) Root() aggregate.Root {
+	s.t.Helper()
+	s.requireExecuted()
+
+	return s.root
+}
+
+// Recorded returns the aggregate.Changed messages that were recorded by the behavior
+func (s *Scenario) Recorded() []*aggregate.Changed {
+	s.t.Helper()
+	s.requireExecuted()
+
+	return s.recorded
+}
+
+func (s *Scenario) execute(repository *aggregate.Repository, store *inmemory.EventStore, root aggregate.Root, err error) {
+	s.t.Helper()
+
+	s.executed = true
+	s.root = root
+	s.err = err
+	if err != nil || root == nil {
+		return
+	}
+
+	// Save the aggregate in order to collect the recorded changes
+	ctx := context.Background()
+	if err := repository.SaveAggregateRoot(ctx, root); err != nil {
+		s.t.Errorf("aggregatetest: failed to collect the recorded events: %s", err)
+		s.t.FailNow()
+	}
+
+	stream, err := store.Load(ctx, streamName, int64(len(s.given)+1), nil, metadata.NewMatcher())
+	if err != nil {
+		s.t.Errorf("aggregatetest: failed to load the recorded events: %s", err)
+		s.t.FailNow()
+	}
+
+	messages, _, err := goengine.ReadEventStream(stream)
+	if err != nil {
+		s.t.Errorf("aggregatetest: failed to read the recorded events: %s", err)
+		s.t.FailNow()
+	}
+
+	s.recorded = make([]*aggregate.Changed, len(messages))
+	for i, msg := range messages {
+		s.recorded[i] = msg.(*aggregate.Changed)
+	}
+}
+
+func (s *Scenario) requireExecuted() {
+	s.t.Helper()
+
+	if !s.executed {
+		s.t.Errorf("aggregatetest: no behavior was invoked, call When or WhenCreating first")
+		s.t.FailNow()
+	}
+}
+
+func (s *Scenario) newRepository() (*aggregate.Repository, *inmemory.EventStore) {
+	s.t.Helper()
+
+	store := inmemory.NewEventStore(goengine.NopLogger)
+	if err := store.Create(context.Background(), streamName); err != nil {
+		s.t.Errorf("aggregatetest: failed to create event stream: %s", err)
+		s.t.FailNow()
+	}
+
+	repository, err := aggregate.NewRepository(store, streamName, s.aggregateType)
+	if err != nil {
+		s.t.Errorf("aggregatetest: failed to create repository: %s", err)
+		s.t.FailNow()
+	}
+
+	return repository, store
+}
+
+// givenMessages wraps the given events into aggregate.Changed messages as if they were saved by a repository
+func (s *Scenario) givenMessages() []goengine.Message {
+	createdAt := time.Now().UTC()
+	messages := make([]goengine.Message, len(s.given))
+	for i, event := range s.given {
+		version := uint(i + 1)
+
+		meta := metadata.New()
+		meta = metadata.WithValue(meta, aggregate.IDKey, s.aggregateID)
+		meta = metadata.WithValue(meta, aggregate.TypeKey, s.aggregateType.String())
+		meta = metadata.WithValue(meta, aggregate.VersionKey, version)
+
+		change, err := aggregate.ReconstituteChange(s.aggregateID, goengine.GenerateUUID(), event, meta, createdAt, version)
+		if err != nil {
+			s.t.Errorf("aggregatetest: invalid given event %d: %s", i, err)
+			s.t.FailNow()
+		}
+
+		messages[i] = change
+	}
+
+	return messages
+}
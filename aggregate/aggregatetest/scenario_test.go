@@ -0,0 +1,171 @@
+// +build unit
+
+package aggregatetest_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/aggregate/aggregatetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errInsufficientMoney = errors.New("insufficient money")
+
+type (
+	bankAccount struct {
+		aggregate.BaseRoot
+
+		accountID aggregate.ID
+		balance   uint
+	}
+
+	accountOpened struct {
+		AccountID aggregate.ID
+	}
+
+	accountCredited struct {
+		Amount uint
+	}
+
+	accountDebited struct {
+		Amount uint
+	}
+
+	// recordingT is a aggregatetest.TestingT that records the reported errors
+	recordingT struct {
+		errors []string
+	}
+)
+
+func (b *bankAccount) AggregateID() aggregate.ID {
+	return b.accountID
+}
+
+func (b *bankAccount) Apply(change *aggregate.Changed) {
+	switch event := change.Payload().(type) {
+	case accountOpened:
+		b.accountID = event.AccountID
+	case accountCredited:
+		b.balance += event.Amount
+	case accountDebited:
+		b.balance -= event.Amount
+	}
+}
+
+func (b *bankAccount) withdraw(amount uint) error {
+	if amount > b.balance {
+		return errInsufficientMoney
+	}
+
+	return aggregate.RecordChange(b, accountDebited{Amount: amount})
+}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingT) FailNow() {}
+
+func (r *recordingT) Helper() {}
+
+func TestScenario(t *testing.T) {
+	accountType, err := aggregate.NewType("bank_account", func() aggregate.Root {
+		return &bankAccount{}
+	})
+	require.NoError(t, err)
+
+	accountID := aggregate.GenerateID()
+
+	t.Run("given events when behavior then events", func(t *testing.T) {
+		scenario := aggregatetest.NewScenario(t, accountType).
+			Given(accountID, accountOpened{AccountID: accountID}, accountCredited{Amount: 100}).
+			When(func(root aggregate.Root) error {
+				return root.(*bankAccount).withdraw(30)
+			})
+
+		scenario.Then(accountDebited{Amount: 30})
+
+		assert.Equal(t, uint(70), scenario.Root().(*bankAccount).balance)
+		if recorded := scenario.Recorded(); assert.Len(t, recorded, 1) {
+			assert.Equal(t, uint(3), recorded[0].Version())
+			assert.Equal(t, accountID, recorded[0].AggregateID())
+		}
+	})
+
+	t.Run("given events when behavior then error", func(t *testing.T) {
+		aggregatetest.NewScenario(t, accountType).
+			Given(accountID, accountOpened{AccountID: accountID}).
+			When(func(root aggregate.Root) error {
+				return root.(*bankAccount).withdraw(30)
+			}).
+			ThenError(errInsufficientMoney)
+	})
+
+	t.Run("when creating then events", func(t *testing.T) {
+		aggregatetest.NewScenario(t, accountType).
+			WhenCreating(func() (aggregate.Root, error) {
+				account := &bankAccount{accountID: accountID}
+				return account, aggregate.RecordChange(account, accountOpened{AccountID: accountID})
+			}).
+			Then(accountOpened{AccountID: accountID})
+	})
+
+	t.Run("no events recorded", func(t *testing.T) {
+		aggregatetest.NewScenario(t, accountType).
+			Given(accountID, accountOpened{AccountID: accountID}).
+			When(func(root aggregate.Root) error {
+				return nil
+			}).
+			Then()
+	})
+
+	t.Run("report mismatching events", func(t *testing.T) {
+		recorder := &recordingT{}
+
+		aggregatetest.NewScenario(recorder, accountType).
+			Given(accountID, accountOpened{AccountID: accountID}, accountCredited{Amount: 100}).
+			When(func(root aggregate.Root) error {
+				return root.(*bankAccount).withdraw(30)
+			}).
+			Then(accountDebited{Amount: 20})
+
+		if assert.Len(t, recorder.errors, 1) {
+			assert.Contains(t, recorder.errors[0], "recorded events do not match the expected events")
+			assert.Contains(t, recorder.errors[0], "Diff:")
+		}
+	})
+
+	t.Run("report unexpected error", func(t *testing.T) {
+		recorder := &recordingT{}
+
+		aggregatetest.NewScenario(recorder, accountType).
+			Given(accountID, accountOpened{AccountID: accountID}).
+			When(func(root aggregate.Root) error {
+				return root.(*bankAccount).withdraw(30)
+			}).
+			Then(accountDebited{Amount: 30})
+
+		if assert.Len(t, recorder.errors, 1) {
+			assert.Contains(t, recorder.errors[0], "expected the behavior to succeed")
+		}
+	})
+
+	t.Run("report missing error", func(t *testing.T) {
+		recorder := &recordingT{}
+
+		aggregatetest.NewScenario(recorder, accountType).
+			Given(accountID, accountOpened{AccountID: accountID}, accountCredited{Amount: 100}).
+			When(func(root aggregate.Root) error {
+				return root.(*bankAccount).withdraw(30)
+			}).
+			ThenError(errInsufficientMoney)
+
+		if assert.Len(t, recorder.errors, 1) {
+			assert.Contains(t, recorder.errors[0], "but it succeeded")
+		}
+	})
+}
@@ -58,7 +58,7 @@ func NewRepository(
 }
 
 // SaveAggregateRoot stores the state changes of the aggregate.Root
-func (r *Repository) SaveAggregateRoot(ctx context.Context, aggregateRoot Root) error {
+func (r *Repository) SaveAggregateRoot(ctx context.Context, aggregateRoot Root) (err error) {
 	if !r.aggregateType.IsImplementedBy(aggregateRoot) {
 		return ErrUnsupportedAggregateType
 	}
@@ -72,16 +72,35 @@ func (r *Repository) SaveAggregateRoot(ctx context.Context, aggregateRoot Root)
 
 	aggregateID := aggregateRoot.AggregateID()
 
+	tracer := goengine.TracerFromContext(ctx)
+	ctx, span := tracer.StartSpan(ctx, "goengine.aggregate.save")
+	defer func() {
+		span.Error(err)
+		span.End()
+	}()
+	span.String("aggregate.type", r.aggregateType.String())
+	span.String("aggregate.id", string(aggregateID))
+	span.Int64("aggregate.event_count", int64(eventCount))
+
 	streamEvents := make([]goengine.Message, len(domainEvents))
 	for i, domainEvent := range domainEvents {
-		streamEvents[i] = r.enrichMetadata(domainEvent, aggregateID)
+		// Add the trace context so projections can link back to the span that produced the event
+		streamEvents[i] = tracer.Inject(ctx, r.enrichMetadata(domainEvent, aggregateID))
 	}
 
 	return r.eventStore.AppendTo(ctx, r.streamName, streamEvents)
 }
 
 // GetAggregateRoot returns nil if no stream events can be found for aggregate id otherwise the reconstituted aggregate root
-func (r *Repository) GetAggregateRoot(ctx context.Context, aggregateID ID) (Root, error) {
+func (r *Repository) GetAggregateRoot(ctx context.Context, aggregateID ID) (_ Root, err error) {
+	ctx, span := goengine.TracerFromContext(ctx).StartSpan(ctx, "goengine.aggregate.get")
+	defer func() {
+		span.Error(err)
+		span.End()
+	}()
+	span.String("aggregate.type", r.aggregateType.String())
+	span.String("aggregate.id", string(aggregateID))
+
 	matcher := metadata.NewMatcher()
 	matcher = metadata.WithConstraint(matcher, TypeKey, metadata.Equals, r.aggregateType.String())
 	matcher = metadata.WithConstraint(matcher, IDKey, metadata.Equals, aggregateID)
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/metadata"
+	strategyJSON "github.com/hellofresh/goengine/strategy/json"
+	"github.com/hellofresh/goengine/strategy/json/ndjson"
+)
+
+// operators contains the supported constraint operators, operators that are a prefix of another operator come last
+var operators = []metadata.Operator{
+	metadata.NotEquals,
+	metadata.GreaterThanEquals,
+	metadata.LowerThanEquals,
+	metadata.Equals,
+	metadata.GreaterThan,
+	metadata.LowerThan,
+}
+
+type (
+	// eventsQuery contains the constraints used to load events
+	eventsQuery struct {
+		streamName goengine.StreamName
+		fromNumber int64
+		count      uint
+		matcher    metadata.Matcher
+
+		follow   bool
+		interval time.Duration
+	}
+
+	// constraintFlags is a flag.Value collecting metadata constraints in the form of `key<operator>value`
+	constraintFlags struct {
+		matcher metadata.Matcher
+	}
+)
+
+// runEvents prints the events of a stream matching the provided constraints
+func runEvents(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags, dsn := newFlagSet("events", stderr)
+	stream := flags.String("stream", "", "the name of the event stream")
+	from := flags.Int64("from", 1, "the number of the first event")
+	count := flags.Uint("count", 0, "the maximum number of events to print, 0 for all events")
+	follow := flags.Bool("follow", false, "keep polling for new events")
+	interval := flags.Duration("interval", time.Second, "the poll interval used by -follow")
+	constraints := &constraintFlags{matcher: metadata.NewMatcher()}
+	flags.Var(constraints, "where", "a metadata constraint `key<op>value` where op is one of = != > >= < <= (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	switch {
+	case *stream == "":
+		return usageError(flags, "-stream is required")
+	case *follow && *interval <= 0:
+		return usageError(flags, "-interval must be positive")
+	}
+
+	return withEventStore(*dsn, func(store goengine.ReadOnlyEventStore, converter goengine.MessagePayloadConverter) error {
+		return printEvents(ctx, store, converter, stdout, eventsQuery{
+			streamName: goengine.StreamName(*stream),
+			fromNumber: *from,
+			count:      *count,
+			matcher:    constraints.matcher,
+			follow:     *follow,
+			interval:   *interval,
+		})
+	})
+}
+
+// runAggregate prints the events of a single aggregate
+func runAggregate(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags, dsn := newFlagSet("aggregate", stderr)
+	stream := flags.String("stream", "", "the name of the event stream")
+	aggregateType := flags.String("type", "", "the aggregate type")
+	aggregateID := flags.String("id", "", "the aggregate id")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	switch {
+	case *stream == "":
+		return usageError(flags, "-stream is required")
+	case *aggregateID == "":
+		return usageError(flags, "-id is required")
+	}
+
+	return withEventStore(*dsn, func(store goengine.ReadOnlyEventStore, converter goengine.MessagePayloadConverter) error {
+		return printEvents(ctx, store, converter, stdout, eventsQuery{
+			streamName: goengine.StreamName(*stream),
+			fromNumber: 1,
+			matcher:    aggregateMatcher(*aggregateType, *aggregateID),
+		})
+	})
+}
+
+// withEventStore calls f with a postgres event store for the dsn
+func withEventStore(dsn string, f func(store goengine.ReadOnlyEventStore, converter goengine.MessagePayloadConverter) error) error {
+	db, err := openDB(dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	transformer := strategyJSON.NewRawPayloadTransformer()
+	store, err := newEventStore(db, transformer)
+	if err != nil {
+		return err
+	}
+
+	return f(store, transformer)
+}
+
+// aggregateMatcher returns a matcher for the events of an aggregate, the aggregate type is only matched when not empty
+func aggregateMatcher(aggregateType, aggregateID string) metadata.Matcher {
+	matcher := metadata.NewMatcher()
+	if aggregateType != "" {
+		matcher = metadata.WithConstraint(matcher, aggregate.TypeKey, metadata.Equals, aggregateType)
+	}
+
+	return metadata.WithConstraint(matcher, aggregate.IDKey, metadata.Equals, aggregateID)
+}
+
+// printEvents writes the events matching the query as JSON lines in the ndjson export format.
+// When the query follows the stream new events are polled until the context is done.
+func printEvents(
+	ctx context.Context,
+	store goengine.ReadOnlyEventStore,
+	converter goengine.MessagePayloadConverter,
+	out io.Writer,
+	query eventsQuery,
+) error {
+	if !store.HasStream(ctx, query.streamName) {
+		return fmt.Errorf("unknown event stream %q", query.streamName)
+	}
+
+	encoder, err := ndjson.NewEncoder(out, converter)
+	if err != nil {
+		return err
+	}
+
+	fromNumber := query.fromNumber
+	remaining := query.count
+	for {
+		var count *uint
+		if query.count > 0 {
+			count = &remaining
+		}
+
+		stream, err := store.Load(ctx, query.streamName, fromNumber, count, query.matcher)
+		if err != nil {
+			return err
+		}
+
+		printed, lastNumber, err := encodeEventStream(stream, encoder)
+		if err != nil {
+			return err
+		}
+
+		if printed > 0 {
+			fromNumber = lastNumber + 1
+		}
+
+		if query.count > 0 {
+			remaining -= printed
+			if remaining == 0 {
+				return nil
+			}
+		}
+
+		if !query.follow {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(query.interval):
+		}
+	}
+}
+
+// encodeEventStream encodes all events in the stream and returns the amount of encoded events and the last event number
+func encodeEventStream(stream goengine.EventStream, encoder *ndjson.Encoder) (uint, int64, error) {
+	defer stream.Close()
+
+	var (
+		printed    uint
+		lastNumber int64
+	)
+	for stream.Next() {
+		msg, no, err := stream.Message()
+		if err != nil {
+			return printed, lastNumber, err
+		}
+
+		if err := encoder.Encode(msg, no); err != nil {
+			return printed, lastNumber, err
+		}
+
+		printed++
+		lastNumber = no
+	}
+
+	return printed, lastNumber, stream.Err()
+}
+
+// String returns the constraints separated by a comma
+func (c *constraintFlags) String() string {
+	if c == nil || c.matcher == nil {
+		return ""
+	}
+
+	var constraints []string
+	c.matcher.Iterate(func(constraint metadata.Constraint) {
+		constraints = append(constraints, fmt.Sprintf("%s%s%v", constraint.Field(), constraint.Operator(), constraint.Value()))
+	})
+
+	return strings.Join(constraints, ",")
+}
+
+// Set adds the constraint to the matcher
+func (c *constraintFlags) Set(value string) error {
+	field, operator, val, err := parseConstraint(value)
+	if err != nil {
+		return err
+	}
+
+	c.matcher = metadata.WithConstraint(c.matcher, field, operator, val)
+	return nil
+}
+
+// parseConstraint parses a `key<operator>value` constraint.
+// The value is used as a integer or boolean when possible and otherwise as a string.
+func parseConstraint(constraint string) (string, metadata.Operator, interface{}, error) {
+	for i := 0; i < len(constraint); i++ {
+		for _, operator := range operators {
+			if !strings.HasPrefix(constraint[i:], string(operator)) {
+				continue
+			}
+
+			field := strings.TrimSpace(constraint[:i])
+			if field == "" {
+				return "", "", nil, fmt.Errorf("constraint %q has no metadata key", constraint)
+			}
+
+			return field, operator, parseConstraintValue(strings.TrimSpace(constraint[i+len(operator):])), nil
+		}
+	}
+
+	return "", "", nil, fmt.Errorf("constraint %q has no operator", constraint)
+}
+
+func parseConstraintValue(value string) interface{} {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	return value
+}
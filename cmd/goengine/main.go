@@ -0,0 +1,137 @@
+// Command goengine is a command line tool to inspect postgres event streams and manage the projections of goengine.
+//
+// The tool is payload agnostic, events are read without registering payload types and printed as JSON lines.
+//
+// Usage:
+//
+//	goengine <command> [flags]
+//
+// The commands are:
+//
+//	streams     list the event streams
+//	events      print or tail the events of a stream
+//	aggregate   print the history of an aggregate
+//	projection  report the status of, reset or unlock a projection
+//
+// The database is selected using the -dsn flag which defaults to the POSTGRES_DSN environment variable.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+	strategyJSON "github.com/hellofresh/goengine/strategy/json"
+	strategySQL "github.com/hellofresh/goengine/strategy/json/sql"
+	strategyPostgres "github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	_ "github.com/lib/pq"
+)
+
+const usage = `Usage: goengine <command> [flags]
+
+Commands:
+  streams     list the event streams
+  events      print or tail the events of a stream
+  aggregate   print the history of an aggregate
+  projection  report the status of, reset or unlock a projection
+
+Run 'goengine <command> -h' for the flags of a command.
+`
+
+// errUsage occurs when the command line arguments are invalid, the problem and usage are printed before it's returned
+var errUsage = errors.New("invalid usage")
+
+type command func(ctx context.Context, args []string, stdout, stderr io.Writer) error
+
+var commands = map[string]command{
+	"streams":    runStreams,
+	"events":     runEvents,
+	"aggregate":  runAggregate,
+	"projection": runProjection,
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command in args and returns the exit code
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	cmd, found := commands[args[0]]
+	if !found {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	switch err := cmd(ctx, args[1:], stdout, stderr); {
+	case err == nil:
+		return 0
+	case err == errUsage:
+		return 2
+	default:
+		fmt.Fprintf(stderr, "goengine %s: %s\n", args[0], err)
+		return 1
+	}
+}
+
+// newFlagSet returns a flag set for the command with a dsn flag
+func newFlagSet(name string, stderr io.Writer) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet("goengine "+name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dsn := flags.String("dsn", os.Getenv("POSTGRES_DSN"), "postgres connection string (default $POSTGRES_DSN)")
+
+	return flags, dsn
+}
+
+// usageError prints the message and the flag usage and returns errUsage
+func usageError(flags *flag.FlagSet, format string, args ...interface{}) error {
+	fmt.Fprintf(flags.Output(), format+"\n", args...)
+	flags.Usage()
+
+	return errUsage
+}
+
+func openDB(dsn string) (*sql.DB, error) {
+	if dsn == "" {
+		return nil, errors.New("no dsn provided use -dsn or POSTGRES_DSN")
+	}
+
+	return sql.Open("postgres", dsn)
+}
+
+// newEventStore returns a postgres event store that reads the payloads as strategyJSON.RawPayload
+func newEventStore(db *sql.DB, transformer *strategyJSON.RawPayloadTransformer) (*postgres.EventStore, error) {
+	persistenceStrategy, err := strategyPostgres.NewSingleStreamStrategy(transformer)
+	if err != nil {
+		return nil, err
+	}
+
+	messageFactory, err := strategySQL.NewAggregateChangedFactory(transformer)
+	if err != nil {
+		return nil, err
+	}
+
+	return postgres.NewEventStore(persistenceStrategy, db, messageFactory, goengine.NopLogger)
+}
@@ -0,0 +1,192 @@
+// +build unit
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/driver/inmemory"
+	"github.com/hellofresh/goengine/eventstoretest"
+	"github.com/hellofresh/goengine/metadata"
+	strategyJSON "github.com/hellofresh/goengine/strategy/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStream goengine.StreamName = "orders"
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		title        string
+		args         []string
+		expectedCode int
+		expectedErr  string
+	}{
+		{"no command", nil, 2, "Usage: goengine <command>"},
+		{"unknown command", []string{"drop"}, 2, `unknown command "drop"`},
+		{"events without stream", []string{"events"}, 2, "-stream is required"},
+		{"events with invalid constraint", []string{"events", "-stream", "orders", "-where", "amount"}, 2, `constraint "amount" has no operator`},
+		{"aggregate without id", []string{"aggregate", "-stream", "orders"}, 2, "-id is required"},
+		{"projection without action", []string{"projection"}, 2, "Usage: goengine projection"},
+		{"projection without table", []string{"projection", "status", "-name", "orders"}, 2, "-table is required"},
+		{"projection reset without id", []string{"projection", "reset", "-table", "p", "-stream", "orders"}, 2, "-name or -aggregate-id is required"},
+		{"streams without dsn", []string{"streams", "-dsn", ""}, 1, "no dsn provided"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+
+			code := run(context.Background(), testCase.args, &stdout, &stderr)
+
+			assert.Equal(t, testCase.expectedCode, code)
+			assert.Contains(t, stderr.String(), testCase.expectedErr)
+			assert.Empty(t, stdout.String())
+		})
+	}
+}
+
+func TestParseConstraint(t *testing.T) {
+	testCases := []struct {
+		constraint       string
+		expectedField    string
+		expectedOperator metadata.Operator
+		expectedValue    interface{}
+	}{
+		{"_aggregate_type=order", "_aggregate_type", metadata.Equals, "order"},
+		{"_aggregate_version>=3", "_aggregate_version", metadata.GreaterThanEquals, int64(3)},
+		{"_aggregate_version <= 10", "_aggregate_version", metadata.LowerThanEquals, int64(10)},
+		{"paid!=true", "paid", metadata.NotEquals, true},
+		{"amount>-1", "amount", metadata.GreaterThan, int64(-1)},
+		{"amount<5", "amount", metadata.LowerThan, int64(5)},
+		{"note=a=b", "note", metadata.Equals, "a=b"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.constraint, func(t *testing.T) {
+			field, operator, value, err := parseConstraint(testCase.constraint)
+
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedField, field)
+			assert.Equal(t, testCase.expectedOperator, operator)
+			assert.Equal(t, testCase.expectedValue, value)
+		})
+	}
+
+	for _, constraint := range []string{"amount", "=5", " >1"} {
+		_, _, _, err := parseConstraint(constraint)
+		assert.Error(t, err, constraint)
+	}
+}
+
+func TestPrintEvents(t *testing.T) {
+	ctx := context.Background()
+	firstID, secondID := aggregate.GenerateID(), aggregate.GenerateID()
+
+	store := inmemory.NewEventStore(goengine.NopLogger)
+	require.NoError(t, store.Create(ctx, testStream))
+	require.NoError(t, store.AppendTo(ctx, testStream, []goengine.Message{
+		createMessage(t, firstID, 1, `{"amount":1}`),
+		createMessage(t, secondID, 1, `{"amount":2}`),
+		createMessage(t, firstID, 2, `{"amount":3}`),
+	}))
+
+	t.Run("aggregate history", func(t *testing.T) {
+		var out bytes.Buffer
+		err := printEvents(ctx, store, strategyJSON.NewRawPayloadTransformer(), &out, eventsQuery{
+			streamName: testStream,
+			fromNumber: 1,
+			matcher:    aggregateMatcher("order", string(firstID)),
+		})
+		require.NoError(t, err)
+
+		records := decodeRecords(t, &out)
+		require.Len(t, records, 2)
+		assert.Equal(t, int64(1), records[0].No)
+		assert.Equal(t, "order_placed", records[0].EventName)
+		assert.JSONEq(t, `{"amount":1}`, string(records[0].Payload))
+		assert.Equal(t, string(firstID), records[0].Metadata[aggregate.IDKey])
+		assert.Equal(t, int64(3), records[1].No)
+		assert.JSONEq(t, `{"amount":3}`, string(records[1].Payload))
+	})
+
+	t.Run("limit the number of events", func(t *testing.T) {
+		var out bytes.Buffer
+		err := printEvents(ctx, store, strategyJSON.NewRawPayloadTransformer(), &out, eventsQuery{
+			streamName: testStream,
+			fromNumber: 2,
+			count:      1,
+			matcher:    metadata.NewMatcher(),
+		})
+		require.NoError(t, err)
+
+		records := decodeRecords(t, &out)
+		require.Len(t, records, 1)
+		assert.Equal(t, int64(2), records[0].No)
+	})
+
+	t.Run("follow until the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		var out bytes.Buffer
+		err := printEvents(ctx, store, strategyJSON.NewRawPayloadTransformer(), &out, eventsQuery{
+			streamName: testStream,
+			fromNumber: 1,
+			matcher:    metadata.NewMatcher(),
+			follow:     true,
+			interval:   5 * time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		assert.Len(t, decodeRecords(t, &out), 3, "events must only be printed once")
+	})
+
+	t.Run("unknown stream", func(t *testing.T) {
+		var out bytes.Buffer
+		err := printEvents(ctx, store, strategyJSON.NewRawPayloadTransformer(), &out, eventsQuery{
+			streamName: "unknown",
+			fromNumber: 1,
+		})
+
+		assert.EqualError(t, err, `unknown event stream "unknown"`)
+		assert.Empty(t, out.String())
+	})
+}
+
+type decodedRecord struct {
+	No        int64                  `json:"no"`
+	EventID   string                 `json:"event_id"`
+	EventName string                 `json:"event_name"`
+	Payload   json.RawMessage        `json:"payload"`
+	Metadata  map[string]interface{} `json:"metadata"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+func decodeRecords(t *testing.T, out *bytes.Buffer) []decodedRecord {
+	var records []decodedRecord
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var record decodedRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func createMessage(t *testing.T, aggregateID aggregate.ID, version uint, data string) goengine.Message {
+	payload := strategyJSON.RawPayload{Name: "order_placed", Data: json.RawMessage(data)}
+
+	return eventstoretest.NewMessage(t, "order", aggregateID, version, payload)
+}
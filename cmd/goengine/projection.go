@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/hellofresh/goengine"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+	strategyJSON "github.com/hellofresh/goengine/strategy/json"
+	strategyPostgres "github.com/hellofresh/goengine/strategy/json/sql/postgres"
+)
+
+const projectionUsage = `Usage: goengine projection <status|reset|unlock> [flags]
+
+Stream projections are selected using -table and -name.
+Aggregate projections are selected using -table, -stream and -aggregate-id.
+Without -name and -aggregate-id the status command lists the locked and failed aggregate projections.
+`
+
+// runProjection reports the status of, resets or unlocks a stream or aggregate projection
+func runProjection(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, projectionUsage)
+		return errUsage
+	}
+
+	action := args[0]
+	switch action {
+	case "status", "reset", "unlock":
+	default:
+		fmt.Fprintf(stderr, "unknown projection command %q\n\n%s", action, projectionUsage)
+		return errUsage
+	}
+
+	flags, dsn := newFlagSet("projection "+action, stderr)
+	table := flags.String("table", "", "the projection table")
+	name := flags.String("name", "", "the name of the stream projection")
+	stream := flags.String("stream", "", "the event stream of the aggregate projection")
+	aggregateID := flags.String("aggregate-id", "", "the aggregate id of the aggregate projection")
+	limit := flags.Int("limit", 100, "the maximum number of locked or failed aggregate projections to list")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+
+	switch {
+	case *table == "":
+		return usageError(flags, "-table is required")
+	case *name != "" && *aggregateID != "":
+		return usageError(flags, "-name and -aggregate-id cannot be combined")
+	case *name == "" && *stream == "":
+		return usageError(flags, "-name or -stream is required")
+	case *name == "" && *aggregateID == "" && action != "status":
+		return usageError(flags, "-name or -aggregate-id is required")
+	}
+
+	db, err := openDB(*dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if *name != "" {
+		storage, err := postgres.NewAdvisoryLockStreamProjectionStorage(
+			*name,
+			*table,
+			driverSQL.GetProjectionStateSerialization(nil),
+			true,
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+
+		switch action {
+		case "status":
+			status, err := storage.LoadStatus(ctx, db)
+			if err != nil {
+				return err
+			}
+			return printProjectionStatuses(stdout, []postgres.ProjectionStatus{*status})
+		case "reset":
+			return withConn(ctx, db, storage.Reset)
+		default:
+			return withConn(ctx, db, storage.Unlock)
+		}
+	}
+
+	eventStoreTable, err := eventStreamTable(*stream)
+	if err != nil {
+		return err
+	}
+
+	storage, err := postgres.NewAdvisoryLockAggregateProjectionStorage(
+		eventStoreTable,
+		*table,
+		driverSQL.GetProjectionStateSerialization(nil),
+		true,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "status":
+		if *aggregateID == "" {
+			statuses, err := storage.LoadFailedStatuses(ctx, db, *limit)
+			if err != nil {
+				return err
+			}
+			return printProjectionStatuses(stdout, statuses)
+		}
+
+		status, err := storage.LoadStatus(ctx, db, *aggregateID)
+		if err != nil {
+			return err
+		}
+		return printProjectionStatuses(stdout, []postgres.ProjectionStatus{*status})
+	case "reset":
+		return withConn(ctx, db, func(ctx context.Context, conn *sql.Conn) error {
+			return storage.Reset(ctx, conn, *aggregateID)
+		})
+	default:
+		return withConn(ctx, db, func(ctx context.Context, conn *sql.Conn) error {
+			return storage.Unlock(ctx, conn, *aggregateID)
+		})
+	}
+}
+
+// eventStreamTable returns the table name of the event stream
+func eventStreamTable(stream string) (string, error) {
+	persistenceStrategy, err := strategyPostgres.NewSingleStreamStrategy(strategyJSON.NewRawPayloadTransformer())
+	if err != nil {
+		return "", err
+	}
+
+	return persistenceStrategy.GenerateTableName(goengine.StreamName(stream))
+}
+
+// withConn calls f with a dedicated connection, this is required since advisory locks are held by a connection
+func withConn(ctx context.Context, db *sql.DB, f func(ctx context.Context, conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return f(ctx, conn)
+}
+
+// printProjectionStatuses writes the statuses as a table
+func printProjectionStatuses(out io.Writer, statuses []postgres.ProjectionStatus) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPOSITION\tLOCKED\tFAILED")
+	for _, status := range statuses {
+		fmt.Fprintf(w, "%s\t%d\t%t\t%t\n", status.ID, status.Position, status.Locked, status.Failed)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// eventTablePrefix is the table name prefix used by the SingleStreamStrategy
+const eventTablePrefix = "events_"
+
+// queryStreamTables excludes the partitions of partitioned event stream tables
+const queryStreamTables = `SELECT table_name FROM information_schema.tables
+	WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' AND table_name LIKE 'events\_%'
+	AND NOT EXISTS(
+		SELECT 1 FROM pg_inherits WHERE inhrelid = (quote_ident(table_schema) || '.' || quote_ident(table_name))::regclass
+	)
+	ORDER BY table_name`
+
+// runStreams prints the name of each event stream table
+func runStreams(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags, dsn := newFlagSet("streams", stderr)
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	db, err := openDB(*dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, queryStreamTables)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(stdout, strings.TrimPrefix(table, eventTablePrefix))
+	}
+
+	return rows.Err()
+}
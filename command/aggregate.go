@@ -0,0 +1,73 @@
+package command
+
+import (
+	"context"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+)
+
+type (
+	// AggregateIdentifier returns the aggregate.ID of the aggregate.Root that the command targets
+	AggregateIdentifier func(command interface{}) (aggregate.ID, error)
+
+	// AggregateInvoker invokes the behavior of the aggregate.Root for the command
+	AggregateInvoker func(ctx context.Context, root aggregate.Root, command interface{}) error
+
+	// AggregateCreator creates a new aggregate.Root based on the command
+	AggregateCreator func(ctx context.Context, command interface{}) (aggregate.Root, error)
+)
+
+// AggregateHandler returns a Handler that loads the aggregate.Root targeted by the command from the repository,
+// invokes the behavior and saves the recorded changes.
+func AggregateHandler(
+	repository *aggregate.Repository,
+	identifier AggregateIdentifier,
+	invoker AggregateInvoker,
+) (Handler, error) {
+	switch {
+	case repository == nil:
+		return nil, goengine.InvalidArgumentError("repository")
+	case identifier == nil:
+		return nil, goengine.InvalidArgumentError("identifier")
+	case invoker == nil:
+		return nil, goengine.InvalidArgumentError("invoker")
+	}
+
+	return func(ctx context.Context, command interface{}) error {
+		aggregateID, err := identifier(command)
+		if err != nil {
+			return err
+		}
+
+		root, err := repository.GetAggregateRoot(ctx, aggregateID)
+		if err != nil {
+			return err
+		}
+
+		if err := invoker(ctx, root, command); err != nil {
+			return err
+		}
+
+		return repository.SaveAggregateRoot(ctx, root)
+	}, nil
+}
+
+// CreateAggregateHandler returns a Handler that creates a new aggregate.Root based on the command and saves it
+func CreateAggregateHandler(repository *aggregate.Repository, creator AggregateCreator) (Handler, error) {
+	switch {
+	case repository == nil:
+		return nil, goengine.InvalidArgumentError("repository")
+	case creator == nil:
+		return nil, goengine.InvalidArgumentError("creator")
+	}
+
+	return func(ctx context.Context, command interface{}) error {
+		root, err := creator(ctx, command)
+		if err != nil {
+			return err
+		}
+
+		return repository.SaveAggregateRoot(ctx, root)
+	}, nil
+}
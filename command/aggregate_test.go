@@ -0,0 +1,122 @@
+// +build unit
+
+package command_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/command"
+	"github.com/hellofresh/goengine/driver/inmemory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	account struct {
+		aggregate.BaseRoot
+
+		id     aggregate.ID
+		closed bool
+	}
+
+	accountOpened struct {
+		ID aggregate.ID
+	}
+
+	accountClosed struct{}
+)
+
+func (a *account) AggregateID() aggregate.ID {
+	return a.id
+}
+
+func (a *account) Apply(change *aggregate.Changed) {
+	switch event := change.Payload().(type) {
+	case accountOpened:
+		a.id = event.ID
+	case accountClosed:
+		a.closed = true
+	}
+}
+
+func TestAggregateHandler(t *testing.T) {
+	ctx := context.Background()
+	repository := newAccountRepository(t)
+
+	createHandler, err := command.CreateAggregateHandler(repository, func(ctx context.Context, cmd interface{}) (aggregate.Root, error) {
+		root := &account{id: aggregate.ID(cmd.(openAccount).Owner)}
+		return root, aggregate.RecordChange(root, accountOpened{ID: root.id})
+	})
+	require.NoError(t, err)
+
+	closeErr := errors.New("already closed")
+	closeHandler, err := command.AggregateHandler(
+		repository,
+		func(cmd interface{}) (aggregate.ID, error) {
+			return aggregate.ID(cmd.(closeAccount).AccountID), nil
+		},
+		func(ctx context.Context, root aggregate.Root, cmd interface{}) error {
+			if root.(*account).closed {
+				return closeErr
+			}
+			return aggregate.RecordChange(root, accountClosed{})
+		},
+	)
+	require.NoError(t, err)
+
+	bus := command.NewBus()
+	require.NoError(t, bus.Register(openAccount{}, createHandler))
+	require.NoError(t, bus.Register(closeAccount{}, closeHandler))
+
+	accountID := aggregate.GenerateID()
+	require.NoError(t, bus.Dispatch(ctx, openAccount{Owner: string(accountID)}))
+	require.NoError(t, bus.Dispatch(ctx, closeAccount{AccountID: string(accountID)}))
+
+	root, err := repository.GetAggregateRoot(ctx, accountID)
+	require.NoError(t, err)
+
+	loadedAccount := root.(*account)
+	assert.True(t, loadedAccount.closed)
+	assert.Equal(t, uint(2), loadedAccount.AggregateVersion())
+
+	assert.Equal(t, closeErr, bus.Dispatch(ctx, closeAccount{AccountID: string(accountID)}))
+	assert.Equal(t, aggregate.ErrEmptyEventStream, bus.Dispatch(ctx, closeAccount{AccountID: string(aggregate.GenerateID())}))
+}
+
+func TestAggregateHandler_InvalidArguments(t *testing.T) {
+	repository := newAccountRepository(t)
+	identifier := func(interface{}) (aggregate.ID, error) { return "", nil }
+	invoker := func(context.Context, aggregate.Root, interface{}) error { return nil }
+	creator := func(context.Context, interface{}) (aggregate.Root, error) { return nil, nil }
+
+	_, err := command.AggregateHandler(nil, identifier, invoker)
+	assert.Equal(t, goengine.InvalidArgumentError("repository"), err)
+	_, err = command.AggregateHandler(repository, nil, invoker)
+	assert.Equal(t, goengine.InvalidArgumentError("identifier"), err)
+	_, err = command.AggregateHandler(repository, identifier, nil)
+	assert.Equal(t, goengine.InvalidArgumentError("invoker"), err)
+
+	_, err = command.CreateAggregateHandler(nil, creator)
+	assert.Equal(t, goengine.InvalidArgumentError("repository"), err)
+	_, err = command.CreateAggregateHandler(repository, nil)
+	assert.Equal(t, goengine.InvalidArgumentError("creator"), err)
+}
+
+func newAccountRepository(t *testing.T) *aggregate.Repository {
+	store := inmemory.NewEventStore(nil)
+	require.NoError(t, store.Create(context.Background(), "accounts"))
+
+	accountType, err := aggregate.NewType("account", func() aggregate.Root {
+		return &account{}
+	})
+	require.NoError(t, err)
+
+	repository, err := aggregate.NewRepository(store, "accounts", accountType)
+	require.NoError(t, err)
+
+	return repository
+}
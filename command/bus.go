@@ -0,0 +1,104 @@
+package command
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/hellofresh/goengine"
+	reflectUtil "github.com/hellofresh/goengine/internal/reflect"
+)
+
+// Bus is a synchronous command bus that routes a command to the handler registered for its type
+type Bus struct {
+	sync.RWMutex
+
+	handlers   map[string]Handler
+	middleware []Middleware
+}
+
+// NewBus returns a new Bus which wraps every registered Handler with the provided middleware.
+// The first middleware is the outer most middleware and thus the first to be called.
+func NewBus(middleware ...Middleware) *Bus {
+	return &Bus{
+		handlers:   map[string]Handler{},
+		middleware: middleware,
+	}
+}
+
+// Register registers the handler for the type of the provided command.
+// Reflection is used to determine the full command type name.
+func (b *Bus) Register(command interface{}, handler Handler) error {
+	switch {
+	case command == nil:
+		return ErrInvalidCommand
+	case handler == nil:
+		return goengine.InvalidArgumentError("handler")
+	}
+
+	name := commandName(command)
+
+	b.Lock()
+	defer b.Unlock()
+
+	if _, found := b.handlers[name]; found {
+		return ErrDuplicateHandler
+	}
+
+	for i := len(b.middleware) - 1; i >= 0; i-- {
+		handler = b.middleware[i](handler)
+	}
+	b.handlers[name] = handler
+
+	return nil
+}
+
+// RegisterHandlers registers multiple handlers
+func (b *Bus) RegisterHandlers(handlers map[interface{}]Handler) error {
+	for command, handler := range handlers {
+		if err := b.Register(command, handler); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Dispatch calls the handler registered for the type of the command and returns it's result
+func (b *Bus) Dispatch(ctx context.Context, command interface{}) error {
+	if command == nil {
+		return ErrInvalidCommand
+	}
+
+	if rv := reflect.ValueOf(command); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return ErrInvalidCommand
+	}
+
+	b.RLock()
+	handler, found := b.handlers[commandName(command)]
+	b.RUnlock()
+
+	if !found {
+		return ErrHandlerNotFound
+	}
+
+	ctx, span := goengine.TracerFromContext(ctx).StartSpan(ctx, "goengine.command.dispatch")
+	defer span.End()
+	span.String("command", commandName(command))
+
+	err := handler(ctx, command)
+	span.Error(err)
+
+	return err
+}
+
+// commandName returns the full type name of the command.
+// A pointer to a command resolves to the same name as the command itself.
+func commandName(command interface{}) string {
+	t := reflect.TypeOf(command)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return reflectUtil.FullTypeName(t)
+}
@@ -0,0 +1,109 @@
+// +build unit
+
+package command_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	openAccount struct {
+		Owner string
+	}
+
+	closeAccount struct {
+		AccountID string
+	}
+)
+
+func TestBus_Register(t *testing.T) {
+	noopHandler := func(context.Context, interface{}) error {
+		return nil
+	}
+
+	t.Run("register a handler", func(t *testing.T) {
+		bus := command.NewBus()
+
+		assert.NoError(t, bus.Register(openAccount{}, noopHandler))
+		assert.NoError(t, bus.Register(&closeAccount{}, noopHandler))
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		bus := command.NewBus()
+
+		assert.Equal(t, command.ErrInvalidCommand, bus.Register(nil, noopHandler))
+		assert.Equal(t, goengine.InvalidArgumentError("handler"), bus.Register(openAccount{}, nil))
+	})
+
+	t.Run("cannot register a command twice", func(t *testing.T) {
+		bus := command.NewBus()
+		require.NoError(t, bus.Register(openAccount{}, noopHandler))
+
+		assert.Equal(t, command.ErrDuplicateHandler, bus.Register(openAccount{}, noopHandler))
+		assert.Equal(t, command.ErrDuplicateHandler, bus.Register(&openAccount{}, noopHandler))
+	})
+}
+
+func TestBus_Dispatch(t *testing.T) {
+	t.Run("dispatch to the registered handler", func(t *testing.T) {
+		var handled []interface{}
+		bus := command.NewBus()
+		require.NoError(t, bus.RegisterHandlers(map[interface{}]command.Handler{
+			openAccount{}: func(ctx context.Context, cmd interface{}) error {
+				handled = append(handled, cmd)
+				return nil
+			},
+			closeAccount{}: func(ctx context.Context, cmd interface{}) error {
+				return errors.New("close failed")
+			},
+		}))
+
+		ctx := context.Background()
+		assert.NoError(t, bus.Dispatch(ctx, openAccount{Owner: "a"}))
+		assert.NoError(t, bus.Dispatch(ctx, &openAccount{Owner: "b"}))
+		assert.EqualError(t, bus.Dispatch(ctx, closeAccount{}), "close failed")
+
+		assert.Equal(t, []interface{}{openAccount{Owner: "a"}, &openAccount{Owner: "b"}}, handled)
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		bus := command.NewBus()
+
+		assert.Equal(t, command.ErrHandlerNotFound, bus.Dispatch(context.Background(), openAccount{}))
+	})
+
+	t.Run("nil command", func(t *testing.T) {
+		bus := command.NewBus()
+
+		assert.Equal(t, command.ErrInvalidCommand, bus.Dispatch(context.Background(), nil))
+		assert.Equal(t, command.ErrInvalidCommand, bus.Dispatch(context.Background(), (*openAccount)(nil)))
+	})
+
+	t.Run("middleware is called in order", func(t *testing.T) {
+		var calls []string
+		middleware := func(name string) command.Middleware {
+			return func(next command.Handler) command.Handler {
+				return func(ctx context.Context, cmd interface{}) error {
+					calls = append(calls, name)
+					return next(ctx, cmd)
+				}
+			}
+		}
+
+		bus := command.NewBus(middleware("first"), middleware("second"))
+		require.NoError(t, bus.Register(openAccount{}, func(context.Context, interface{}) error {
+			calls = append(calls, "handler")
+			return nil
+		}))
+
+		assert.NoError(t, bus.Dispatch(context.Background(), openAccount{}))
+		assert.Equal(t, []string{"first", "second", "handler"}, calls)
+	})
+}
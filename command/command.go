@@ -0,0 +1,51 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidCommand occurs when a command is nil
+	ErrInvalidCommand = errors.New("goengine: nil is not a valid command")
+	// ErrHandlerNotFound occurs when no handler is registered for the type of the dispatched command
+	ErrHandlerNotFound = errors.New("goengine: no handler is registered for the command")
+	// ErrDuplicateHandler occurs when a handler is already registered for the command type
+	ErrDuplicateHandler = errors.New("goengine: a handler is already registered for the command")
+)
+
+type (
+	// Handler is a func that handles a command
+	Handler func(ctx context.Context, command interface{}) error
+
+	// Middleware wraps a Handler in order to add behavior before and/or after the command is handled
+	Middleware func(next Handler) Handler
+
+	// Validator is an interface that a command can implement in order to be validated by the ValidationMiddleware
+	Validator interface {
+		// Validate returns an error when the command is not valid
+		Validate() error
+	}
+)
+
+// ValidationError an error indicating that a command failed validation
+type ValidationError struct {
+	error
+}
+
+// NewValidationError return a ValidationError with the cause being the provided error
+func NewValidationError(err error) *ValidationError {
+	return &ValidationError{err}
+}
+
+// Error return the error message
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("goengine: the command is invalid. (%s)", e.error.Error())
+}
+
+// Cause returns the actual validation error.
+// This also adds support for github.com/pkg/errors.Cause
+func (e *ValidationError) Cause() error {
+	return e.error
+}
@@ -0,0 +1,87 @@
+package command
+
+import (
+	"context"
+	"time"
+
+	"github.com/hellofresh/goengine"
+)
+
+// LoggingMiddleware returns a Middleware that logs the handling of each command and any error that occurred
+func LoggingMiddleware(logger goengine.Logger) Middleware {
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, command interface{}) error {
+			start := time.Now()
+			err := next(ctx, command)
+
+			logFields := func(e goengine.LoggerEntry) {
+				e.String("command", commandName(command))
+				e.Int64("duration_ns", int64(time.Since(start)))
+				if err != nil {
+					e.Error(err)
+				}
+			}
+
+			if err != nil {
+				logger.Error("failed to handle command", logFields)
+			} else {
+				logger.Debug("handled command", logFields)
+			}
+
+			return err
+		}
+	}
+}
+
+// ValidationMiddleware returns a Middleware that validates any command implementing the Validator interface before
+// it is handled. When validation fails a ValidationError is returned and the command is not handled.
+func ValidationMiddleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, command interface{}) error {
+			if v, ok := command.(Validator); ok {
+				if err := v.Validate(); err != nil {
+					return NewValidationError(err)
+				}
+			}
+
+			return next(ctx, command)
+		}
+	}
+}
+
+// RetryMiddleware returns a Middleware that handles the command again when the handler returned an error for which
+// isConflict returns true, for example a unique constraint violation on the aggregate version.
+// The command is handled at most maxAttempts times after which the last error is returned.
+func RetryMiddleware(maxAttempts int, isConflict func(err error) bool) Middleware {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, command interface{}) error {
+			var err error
+			for i := 0; i < maxAttempts; i++ {
+				// Check if the context is expired
+				select {
+				default:
+				case <-ctx.Done():
+					if err != nil {
+						return err
+					}
+					return ctx.Err()
+				}
+
+				err = next(ctx, command)
+				if err == nil || isConflict == nil || !isConflict(err) {
+					return err
+				}
+			}
+
+			return err
+		}
+	}
+}
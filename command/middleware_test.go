@@ -0,0 +1,135 @@
+// +build unit
+
+package command_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hellofresh/goengine/command"
+	goengineLogger "github.com/hellofresh/goengine/extension/logrus"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type validatedCommand struct {
+	err error
+}
+
+func (c validatedCommand) Validate() error {
+	return c.err
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	logger, loggerHooks := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+
+	handlerErr := errors.New("failed")
+	handler := command.LoggingMiddleware(goengineLogger.Wrap(logger))(func(ctx context.Context, cmd interface{}) error {
+		if cmd.(openAccount).Owner == "" {
+			return handlerErr
+		}
+		return nil
+	})
+
+	assert.NoError(t, handler(context.Background(), openAccount{Owner: "me"}))
+	assert.Equal(t, handlerErr, handler(context.Background(), openAccount{}))
+
+	if assert.Len(t, loggerHooks.Entries, 2) {
+		assert.Equal(t, logrus.DebugLevel, loggerHooks.Entries[0].Level)
+		assert.Equal(t, "handled command", loggerHooks.Entries[0].Message)
+		assert.Equal(t, "github.com/hellofresh/goengine/command_test.openAccount", loggerHooks.Entries[0].Data["command"])
+
+		assert.Equal(t, logrus.ErrorLevel, loggerHooks.Entries[1].Level)
+		assert.Equal(t, "failed to handle command", loggerHooks.Entries[1].Message)
+		assert.Equal(t, handlerErr, loggerHooks.Entries[1].Data["error"])
+	}
+}
+
+func TestValidationMiddleware(t *testing.T) {
+	var calls int
+	handler := command.ValidationMiddleware()(func(context.Context, interface{}) error {
+		calls++
+		return nil
+	})
+
+	t.Run("valid command", func(t *testing.T) {
+		calls = 0
+
+		assert.NoError(t, handler(context.Background(), validatedCommand{}))
+		assert.NoError(t, handler(context.Background(), openAccount{}))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("invalid command", func(t *testing.T) {
+		calls = 0
+		validationErr := errors.New("owner is required")
+
+		err := handler(context.Background(), validatedCommand{err: validationErr})
+
+		if assert.IsType(t, &command.ValidationError{}, err) {
+			assert.Equal(t, validationErr, err.(*command.ValidationError).Cause())
+		}
+		assert.Equal(t, 0, calls)
+	})
+}
+
+func TestRetryMiddleware(t *testing.T) {
+	errConflict := errors.New("conflict")
+	isConflict := func(err error) bool {
+		return err == errConflict
+	}
+
+	t.Run("retry until success", func(t *testing.T) {
+		var calls int
+		handler := command.RetryMiddleware(3, isConflict)(func(context.Context, interface{}) error {
+			calls++
+			if calls < 3 {
+				return errConflict
+			}
+			return nil
+		})
+
+		assert.NoError(t, handler(context.Background(), openAccount{}))
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("give up after max attempts", func(t *testing.T) {
+		var calls int
+		handler := command.RetryMiddleware(2, isConflict)(func(context.Context, interface{}) error {
+			calls++
+			return errConflict
+		})
+
+		assert.Equal(t, errConflict, handler(context.Background(), openAccount{}))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("do not retry other errors", func(t *testing.T) {
+		var calls int
+		otherErr := errors.New("other")
+		handler := command.RetryMiddleware(3, isConflict)(func(context.Context, interface{}) error {
+			calls++
+			return otherErr
+		})
+
+		assert.Equal(t, otherErr, handler(context.Background(), openAccount{}))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stop retrying when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var calls int
+		handler := command.RetryMiddleware(3, isConflict)(func(context.Context, interface{}) error {
+			calls++
+			cancel()
+			return errConflict
+		})
+
+		assert.Equal(t, errConflict, handler(ctx, openAccount{}))
+		assert.Equal(t, 1, calls)
+	})
+}
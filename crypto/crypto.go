@@ -0,0 +1,97 @@
+// Package crypto provides the encryption of personal data with a key per subject.
+//
+// Personal data of a subject is encrypted using a key that is only used for that subject, once the key is deleted
+// from the KeyStore the personal data can no longer be decrypted. This allows personal data to be "forgotten" while
+// the events containing it are never modified, a technique also known as crypto-shredding.
+package crypto
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// KeySize is the size in bytes of the keys used to encrypt personal data
+const KeySize = 32
+
+var (
+	// ErrKeyNotFound occurs when the key of a subject does not exist or was deleted
+	ErrKeyNotFound = errors.New("goengine: no key found for the subject")
+	// ErrInvalidKey occurs when a key does not have the size of KeySize
+	ErrInvalidKey = errors.New("goengine: key has an invalid size")
+	// ErrInvalidCiphertext occurs when a ciphertext cannot be decrypted using the provided key
+	ErrInvalidCiphertext = errors.New("goengine: ciphertext cannot be decrypted")
+)
+
+// KeyStore stores the encryption key of every subject
+type KeyStore interface {
+	// Key returns the key of the subject or ErrKeyNotFound
+	Key(ctx context.Context, subjectID string) ([]byte, error)
+
+	// GetOrCreateKey returns the key of the subject and generates a new key when the subject has none
+	GetOrCreateKey(ctx context.Context, subjectID string) ([]byte, error)
+
+	// DeleteKey deletes the key of the subject making the personal data encrypted with it unreadable
+	DeleteKey(ctx context.Context, subjectID string) error
+}
+
+// GenerateKey returns a new random key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Encrypt encrypts and authenticates the plaintext and additional data using AES-GCM.
+// The returned ciphertext is prefixed with the random nonce.
+func Encrypt(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Decrypt decrypts a ciphertext produced by Encrypt
+func Decrypt(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	plaintext, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], additionalData)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
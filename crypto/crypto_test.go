@@ -0,0 +1,237 @@
+// +build unit
+
+package crypto_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncrypt(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	require.Len(t, key, crypto.KeySize)
+
+	plaintext := []byte(`"jane@example.com"`)
+	subject := []byte("user-1")
+
+	ciphertext, err := crypto.Encrypt(key, plaintext, subject)
+	require.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), "jane")
+
+	t.Run("decrypt", func(t *testing.T) {
+		decrypted, err := crypto.Decrypt(key, ciphertext, subject)
+
+		assert.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("decrypt with another key", func(t *testing.T) {
+		otherKey, err := crypto.GenerateKey()
+		require.NoError(t, err)
+
+		decrypted, err := crypto.Decrypt(otherKey, ciphertext, subject)
+
+		assert.Nil(t, decrypted)
+		assert.Equal(t, crypto.ErrInvalidCiphertext, err)
+	})
+
+	t.Run("decrypt with other additional data", func(t *testing.T) {
+		decrypted, err := crypto.Decrypt(key, ciphertext, []byte("user-2"))
+
+		assert.Nil(t, decrypted)
+		assert.Equal(t, crypto.ErrInvalidCiphertext, err)
+	})
+
+	t.Run("decrypt truncated ciphertext", func(t *testing.T) {
+		decrypted, err := crypto.Decrypt(key, ciphertext[:4], subject)
+
+		assert.Nil(t, decrypted)
+		assert.Equal(t, crypto.ErrInvalidCiphertext, err)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := crypto.Encrypt(key[:16], plaintext, subject)
+		assert.Equal(t, crypto.ErrInvalidKey, err)
+
+		_, err = crypto.Decrypt(key[:16], ciphertext, subject)
+		assert.Equal(t, crypto.ErrInvalidKey, err)
+	})
+}
+
+func TestInMemoryKeyStore(t *testing.T) {
+	ctx := context.Background()
+	store := crypto.NewInMemoryKeyStore()
+
+	key, err := store.Key(ctx, "user-1")
+	assert.Nil(t, key)
+	assert.Equal(t, crypto.ErrKeyNotFound, err)
+
+	created, err := store.GetOrCreateKey(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, created, crypto.KeySize)
+
+	key, err = store.GetOrCreateKey(ctx, "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, created, key)
+
+	key, err = store.Key(ctx, "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, created, key)
+
+	require.NoError(t, store.DeleteKey(ctx, "user-1"))
+
+	key, err = store.Key(ctx, "user-1")
+	assert.Nil(t, key)
+	assert.Equal(t, crypto.ErrKeyNotFound, err)
+}
+
+func TestNewCachedKeyStore(t *testing.T) {
+	_, err := crypto.NewCachedKeyStore(nil, time.Minute)
+	assert.Equal(t, goengine.InvalidArgumentError("keyStore"), err)
+
+	_, err = crypto.NewCachedKeyStore(crypto.NewInMemoryKeyStore(), 0)
+	assert.Equal(t, goengine.InvalidArgumentError("ttl"), err)
+}
+
+func TestCachedKeyStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("keys are cached per subject", func(t *testing.T) {
+		inner := crypto.NewInMemoryKeyStore()
+		store, err := crypto.NewCachedKeyStore(inner, time.Minute)
+		require.NoError(t, err)
+
+		created, err := store.GetOrCreateKey(ctx, "user-1")
+		require.NoError(t, err)
+
+		// The key is deleted by another process
+		require.NoError(t, inner.DeleteKey(ctx, "user-1"))
+
+		key, err := store.Key(ctx, "user-1")
+		assert.NoError(t, err)
+		assert.Equal(t, created, key)
+
+		key, err = store.Key(ctx, "user-2")
+		assert.Nil(t, key)
+		assert.Equal(t, crypto.ErrKeyNotFound, err)
+	})
+
+	t.Run("deleted keys are removed from the cache", func(t *testing.T) {
+		store, err := crypto.NewCachedKeyStore(crypto.NewInMemoryKeyStore(), time.Minute)
+		require.NoError(t, err)
+
+		_, err = store.GetOrCreateKey(ctx, "user-1")
+		require.NoError(t, err)
+
+		require.NoError(t, store.DeleteKey(ctx, "user-1"))
+
+		key, err := store.Key(ctx, "user-1")
+		assert.Nil(t, key)
+		assert.Equal(t, crypto.ErrKeyNotFound, err)
+	})
+
+	t.Run("keys loaded before a delete are not cached", func(t *testing.T) {
+		inner := &blockingKeyStore{
+			KeyStore: crypto.NewInMemoryKeyStore(),
+			loaded:   make(chan struct{}),
+			release:  make(chan struct{}),
+		}
+		store, err := crypto.NewCachedKeyStore(inner, time.Minute)
+		require.NoError(t, err)
+
+		_, err = inner.GetOrCreateKey(ctx, "user-1")
+		require.NoError(t, err)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+
+			key, err := store.Key(ctx, "user-1")
+			assert.NoError(t, err)
+			assert.NotNil(t, key)
+		}()
+
+		// Delete the key while the key is being loaded
+		<-inner.loaded
+		require.NoError(t, store.DeleteKey(ctx, "user-1"))
+		close(inner.release)
+		<-done
+
+		key, err := store.Key(ctx, "user-1")
+		assert.Nil(t, key)
+		assert.Equal(t, crypto.ErrKeyNotFound, err)
+	})
+
+	t.Run("concurrent deletes and loads", func(t *testing.T) {
+		store, err := crypto.NewCachedKeyStore(crypto.NewInMemoryKeyStore(), time.Minute)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					_, err := store.GetOrCreateKey(ctx, "user-1")
+					assert.NoError(t, err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					assert.NoError(t, store.DeleteKey(ctx, "user-1"))
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.NoError(t, store.DeleteKey(ctx, "user-1"))
+
+		key, err := store.Key(ctx, "user-1")
+		assert.Nil(t, key)
+		assert.Equal(t, crypto.ErrKeyNotFound, err)
+	})
+
+	t.Run("expired keys are loaded again", func(t *testing.T) {
+		inner := crypto.NewInMemoryKeyStore()
+		store, err := crypto.NewCachedKeyStore(inner, time.Millisecond)
+		require.NoError(t, err)
+
+		_, err = store.GetOrCreateKey(ctx, "user-1")
+		require.NoError(t, err)
+		require.NoError(t, inner.DeleteKey(ctx, "user-1"))
+
+		time.Sleep(2 * time.Millisecond)
+
+		key, err := store.Key(ctx, "user-1")
+		assert.Nil(t, key)
+		assert.Equal(t, crypto.ErrKeyNotFound, err)
+	})
+}
+
+// blockingKeyStore is a KeyStore that signals loaded and waits for release before returning the first loaded key
+type blockingKeyStore struct {
+	crypto.KeyStore
+
+	once    sync.Once
+	loaded  chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingKeyStore) Key(ctx context.Context, subjectID string) ([]byte, error) {
+	key, err := s.KeyStore.Key(ctx, subjectID)
+	s.once.Do(func() {
+		close(s.loaded)
+		<-s.release
+	})
+
+	return key, err
+}
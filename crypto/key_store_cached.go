@@ -0,0 +1,132 @@
+package crypto
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hellofresh/goengine"
+)
+
+// Ensure that CachedKeyStore satisfies the KeyStore interface
+var _ KeyStore = &CachedKeyStore{}
+
+type (
+	// CachedKeyStore is a KeyStore that caches the keys of the wrapped KeyStore per subject.
+	// A key deleted using the CachedKeyStore is removed from the cache once it's deleted from the wrapped KeyStore, a
+	// key deleted by another process is only forgotten once the cached key expires.
+	CachedKeyStore struct {
+		keyStore KeyStore
+		ttl      time.Duration
+
+		mu        sync.Mutex
+		keys      map[string]cachedKey
+		lastSweep time.Time
+		// generation is incremented by every DeleteKey so keys loaded before a delete are not cached after it
+		generation uint64
+	}
+
+	// cachedKey is a key and the time it expires
+	cachedKey struct {
+		key       []byte
+		expiresAt time.Time
+	}
+)
+
+// NewCachedKeyStore returns a new CachedKeyStore caching the keys of the keyStore for the ttl
+func NewCachedKeyStore(keyStore KeyStore, ttl time.Duration) (*CachedKeyStore, error) {
+	switch {
+	case keyStore == nil:
+		return nil, goengine.InvalidArgumentError("keyStore")
+	case ttl <= 0:
+		return nil, goengine.InvalidArgumentError("ttl")
+	}
+
+	return &CachedKeyStore{
+		keyStore:  keyStore,
+		ttl:       ttl,
+		keys:      map[string]cachedKey{},
+		lastSweep: time.Now(),
+	}, nil
+}
+
+// Key returns the cached key of the subject or loads it from the wrapped KeyStore
+func (s *CachedKeyStore) Key(ctx context.Context, subjectID string) ([]byte, error) {
+	key, found, generation := s.cached(subjectID)
+	if found {
+		return key, nil
+	}
+
+	key, err := s.keyStore.Key(ctx, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	s.cache(subjectID, key, generation)
+
+	return key, nil
+}
+
+// GetOrCreateKey returns the cached key of the subject or gets or creates it using the wrapped KeyStore
+func (s *CachedKeyStore) GetOrCreateKey(ctx context.Context, subjectID string) ([]byte, error) {
+	key, found, generation := s.cached(subjectID)
+	if found {
+		return key, nil
+	}
+
+	key, err := s.keyStore.GetOrCreateKey(ctx, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	s.cache(subjectID, key, generation)
+
+	return key, nil
+}
+
+// DeleteKey deletes the key of the subject from the wrapped KeyStore and removes it from the cache
+func (s *CachedKeyStore) DeleteKey(ctx context.Context, subjectID string) error {
+	err := s.keyStore.DeleteKey(ctx, subjectID)
+
+	// Remove the key after deleting it so a key loaded by a concurrent call can not be cached again
+	s.mu.Lock()
+	delete(s.keys, subjectID)
+	s.generation++
+	s.mu.Unlock()
+
+	return err
+}
+
+// cached returns the cached key of the subject and the current generation of the cache
+func (s *CachedKeyStore) cached(subjectID string) ([]byte, bool, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cached, found := s.keys[subjectID]
+	if !found || !time.Now().Before(cached.expiresAt) {
+		return nil, false, s.generation
+	}
+
+	return cached.key, true, s.generation
+}
+
+// cache stores the key of the subject, loaded during the provided generation, and removes the expired keys at most
+// once per ttl. The key is not stored when a key was deleted since it was loaded.
+func (s *CachedKeyStore) cache(subjectID string, key []byte, generation uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if generation != s.generation {
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= s.ttl {
+		for id, cached := range s.keys {
+			if !now.Before(cached.expiresAt) {
+				delete(s.keys, id)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	s.keys[subjectID] = cachedKey{key: key, expiresAt: now.Add(s.ttl)}
+}
@@ -0,0 +1,64 @@
+package crypto
+
+import (
+	"context"
+	"sync"
+)
+
+// Ensure that InMemoryKeyStore satisfies the KeyStore interface
+var _ KeyStore = &InMemoryKeyStore{}
+
+// InMemoryKeyStore is a KeyStore keeping the keys in memory
+type InMemoryKeyStore struct {
+	sync.RWMutex
+
+	keys map[string][]byte
+}
+
+// NewInMemoryKeyStore returns a new InMemoryKeyStore
+func NewInMemoryKeyStore() *InMemoryKeyStore {
+	return &InMemoryKeyStore{
+		keys: map[string][]byte{},
+	}
+}
+
+// Key returns the key of the subject or ErrKeyNotFound
+func (s *InMemoryKeyStore) Key(ctx context.Context, subjectID string) ([]byte, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	key, found := s.keys[subjectID]
+	if !found {
+		return nil, ErrKeyNotFound
+	}
+
+	return key, nil
+}
+
+// GetOrCreateKey returns the key of the subject and generates a new key when the subject has none
+func (s *InMemoryKeyStore) GetOrCreateKey(ctx context.Context, subjectID string) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if key, found := s.keys[subjectID]; found {
+		return key, nil
+	}
+
+	key, err := GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	s.keys[subjectID] = key
+
+	return key, nil
+}
+
+// DeleteKey deletes the key of the subject
+func (s *InMemoryKeyStore) DeleteKey(ctx context.Context, subjectID string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.keys, subjectID)
+
+	return nil
+}
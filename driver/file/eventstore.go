@@ -0,0 +1,287 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sync"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/inmemory"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/strategy/json/record"
+)
+
+var (
+	// Ensure that we satisfy the goengine.EventStore interface
+	_ goengine.EventStore = &EventStore{}
+
+	streamNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_\-.]+$`)
+)
+
+// EventStore a file based event store implementation
+type EventStore struct {
+	sync.RWMutex
+
+	dir            string
+	segmentSize    int64
+	syncPolicy     SyncPolicy
+	converter      goengine.MessagePayloadConverter
+	messageFactory record.MessageFactory
+
+	streams map[goengine.StreamName]*stream
+	closed  bool
+
+	logger goengine.Logger
+}
+
+// NewEventStore opens or creates a file.EventStore within the provided directory.
+// The existing streams are recovered and indexed before the event store is returned.
+func NewEventStore(
+	dir string,
+	converter goengine.MessagePayloadConverter,
+	messageFactory record.MessageFactory,
+	segmentSize int64,
+	syncPolicy SyncPolicy,
+	logger goengine.Logger,
+) (*EventStore, error) {
+	switch {
+	case dir == "":
+		return nil, goengine.InvalidArgumentError("dir")
+	case converter == nil:
+		return nil, goengine.InvalidArgumentError("converter")
+	case messageFactory == nil:
+		return nil, goengine.InvalidArgumentError("messageFactory")
+	case segmentSize <= 0:
+		return nil, goengine.InvalidArgumentError("segmentSize")
+	case syncPolicy < SyncNever:
+		return nil, goengine.InvalidArgumentError("syncPolicy")
+	}
+
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	store := &EventStore{
+		dir:            dir,
+		segmentSize:    segmentSize,
+		syncPolicy:     syncPolicy,
+		converter:      converter,
+		messageFactory: messageFactory,
+		streams:        map[goengine.StreamName]*stream{},
+		logger:         logger,
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if !f.IsDir() || !streamNameRegex.MatchString(f.Name()) {
+			continue
+		}
+
+		streamName := goengine.StreamName(f.Name())
+		s, err := openStream(filepath.Join(dir, f.Name()), logger.WithFields(func(e goengine.LoggerEntry) {
+			e.String("stream", string(streamName))
+		}))
+		if err != nil {
+			_ = store.Close()
+			return nil, err
+		}
+
+		store.streams[streamName] = s
+	}
+
+	return store, nil
+}
+
+// Create creates a event stream
+func (e *EventStore) Create(ctx context.Context, streamName goengine.StreamName) error {
+	if !validStreamName(streamName) {
+		return ErrInvalidStreamName
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	if e.closed {
+		return ErrEventStoreClosed
+	}
+
+	if _, found := e.streams[streamName]; found {
+		return ErrStreamExistsAlready
+	}
+
+	s, err := createStream(filepath.Join(e.dir, string(streamName)))
+	if err != nil {
+		if os.IsExist(err) {
+			return ErrStreamExistsAlready
+		}
+		return err
+	}
+
+	e.streams[streamName] = s
+
+	// Ensure the stream directory is persisted
+	return syncDir(e.dir)
+}
+
+// HasStream returns true if the stream exists
+func (e *EventStore) HasStream(ctx context.Context, streamName goengine.StreamName) bool {
+	e.RLock()
+	defer e.RUnlock()
+
+	_, found := e.streams[streamName]
+
+	return found
+}
+
+// Load returns a list of events based on the provided conditions
+func (e *EventStore) Load(
+	ctx context.Context,
+	streamName goengine.StreamName,
+	fromNumber int64,
+	count *uint,
+	matcher metadata.Matcher,
+) (_ goengine.EventStream, err error) {
+	_, span := goengine.TracerFromContext(ctx).StartSpan(ctx, "goengine.eventstore.load")
+	defer func() {
+		span.Error(err)
+		span.End()
+	}()
+	span.String("stream", string(streamName))
+	span.Int64("from_number", fromNumber)
+
+	if matcher == nil {
+		matcher = metadata.NewMatcher()
+	}
+
+	metadataMatcher, err := inmemory.NewMetadataMatcher(matcher, e.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	e.RLock()
+	defer e.RUnlock()
+
+	if e.closed {
+		return nil, ErrEventStoreClosed
+	}
+
+	s, knownStream := e.streams[streamName]
+	if !knownStream {
+		return nil, ErrStreamNotFound
+	}
+
+	numbers := s.candidates(fromNumber, matcher)
+	locations := make([]location, len(numbers))
+	for i, number := range numbers {
+		locations[i] = s.locations[number-1]
+	}
+
+	return newEventStream(numbers, locations, count, metadataMatcher, e.messageFactory), nil
+}
+
+// AppendTo appends the provided messages to the stream
+func (e *EventStore) AppendTo(ctx context.Context, streamName goengine.StreamName, streamEvents []goengine.Message) (err error) {
+	_, span := goengine.TracerFromContext(ctx).StartSpan(ctx, "goengine.eventstore.append")
+	defer func() {
+		span.Error(err)
+		span.End()
+	}()
+	span.String("stream", string(streamName))
+	span.Int64("event_count", int64(len(streamEvents)))
+
+	for _, msg := range streamEvents {
+		if msg == nil || reflect.ValueOf(msg).IsNil() {
+			return ErrNilMessage
+		}
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	if e.closed {
+		return ErrEventStoreClosed
+	}
+
+	s, knownStream := e.streams[streamName]
+	if !knownStream {
+		return ErrStreamNotFound
+	}
+
+	if len(streamEvents) == 0 {
+		return nil
+	}
+
+	var frames []byte
+	records := make([]pendingRecord, len(streamEvents))
+	nextNumber := s.nextNumber()
+	for i, msg := range streamEvents {
+		eventName, payload, err := e.converter.ConvertPayload(msg.Payload())
+		if err != nil {
+			return err
+		}
+
+		meta, err := json.Marshal(msg.Metadata())
+		if err != nil {
+			return err
+		}
+
+		header := recordHeader{
+			Number:    nextNumber + int64(i),
+			UUID:      msg.UUID(),
+			EventName: eventName,
+			Metadata:  meta,
+			CreatedAt: msg.CreatedAt(),
+			Commit:    i == len(streamEvents)-1,
+		}
+
+		frame, err := encodeFrame(header, payload)
+		if err != nil {
+			return err
+		}
+
+		records[i], err = newPendingRecord(location{offset: int64(len(frames))}, header)
+		if err != nil {
+			return err
+		}
+		frames = append(frames, frame...)
+	}
+
+	return s.write(frames, records, e.segmentSize, e.syncPolicy)
+}
+
+// Close fsyncs and closes all the streams of the event store
+func (e *EventStore) Close() error {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	var firstErr error
+	for _, s := range e.streams {
+		if err := s.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func validStreamName(streamName goengine.StreamName) bool {
+	return streamName != "." && streamName != ".." && streamNameRegex.MatchString(string(streamName))
+}
@@ -0,0 +1,267 @@
+// +build unit
+
+package file_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/driver/file"
+	"github.com/hellofresh/goengine/eventstoretest"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/strategy/json"
+	"github.com/hellofresh/goengine/strategy/json/record"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStream goengine.StreamName = "event_stream"
+
+type accountDeposited struct {
+	Amount int `json:"amount"`
+}
+
+func TestEventStoreConformance(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	transformer := json.NewPayloadTransformer()
+	require.NoError(t,
+		transformer.RegisterPayload(eventstoretest.PayloadName, func() interface{} { return eventstoretest.Payload{} }),
+	)
+
+	factory, err := record.NewAggregateChangedFactory(transformer)
+	require.NoError(t, err)
+
+	store, err := file.NewEventStore(dir, transformer, factory, 1024, file.SyncAlways, nil)
+	require.NoError(t, err)
+	defer store.Close()
+
+	eventstoretest.Run(t, func(t *testing.T) goengine.EventStore {
+		return store
+	})
+}
+
+func TestNewEventStore(t *testing.T) {
+	transformer := json.NewPayloadTransformer()
+	factory, err := record.NewAggregateChangedFactory(transformer)
+	require.NoError(t, err)
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		testCases := []struct {
+			title          string
+			dir            string
+			converter      goengine.MessagePayloadConverter
+			messageFactory record.MessageFactory
+			segmentSize    int64
+			syncPolicy     file.SyncPolicy
+			expectedError  error
+		}{
+			{"dir", "", transformer, factory, file.DefaultSegmentSize, file.SyncAlways, goengine.InvalidArgumentError("dir")},
+			{"converter", "dir", nil, factory, file.DefaultSegmentSize, file.SyncAlways, goengine.InvalidArgumentError("converter")},
+			{"message factory", "dir", transformer, nil, file.DefaultSegmentSize, file.SyncAlways, goengine.InvalidArgumentError("messageFactory")},
+			{"segment size", "dir", transformer, factory, 0, file.SyncAlways, goengine.InvalidArgumentError("segmentSize")},
+			{"sync policy", "dir", transformer, factory, file.DefaultSegmentSize, file.SyncPolicy(-2), goengine.InvalidArgumentError("syncPolicy")},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				store, err := file.NewEventStore(
+					testCase.dir,
+					testCase.converter,
+					testCase.messageFactory,
+					testCase.segmentSize,
+					testCase.syncPolicy,
+					nil,
+				)
+
+				assert.Equal(t, testCase.expectedError, err)
+				assert.Nil(t, store)
+			})
+		}
+	})
+}
+
+func TestEventStore_Create(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	store := openEventStore(t, dir, file.DefaultSegmentSize)
+	defer store.Close()
+
+	ctx := context.Background()
+	invalidNames := []goengine.StreamName{"", ".", "..", "a/b", "../escape", "with space"}
+	for _, streamName := range invalidNames {
+		assert.Equal(t, file.ErrInvalidStreamName, store.Create(ctx, streamName), "stream name %q", streamName)
+	}
+
+	require.NoError(t, store.Create(ctx, testStream))
+	assert.Equal(t, file.ErrStreamExistsAlready, store.Create(ctx, testStream))
+	assert.DirExists(t, filepath.Join(dir, string(testStream)))
+}
+
+func TestEventStore_Reopen(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	firstID, secondID := aggregate.GenerateID(), aggregate.GenerateID()
+
+	store := openEventStore(t, dir, 512)
+	require.NoError(t, store.Create(ctx, testStream))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.AppendTo(ctx, testStream, createMessages(t, firstID, uint(i+1), 1)))
+		require.NoError(t, store.AppendTo(ctx, testStream, createMessages(t, secondID, uint(i+1), 1)))
+	}
+	require.NoError(t, store.Close())
+
+	assert.Equal(t, file.ErrEventStoreClosed, store.AppendTo(ctx, testStream, createMessages(t, firstID, 6, 1)))
+
+	// The small segment size must have caused multiple segments to be created
+	segments, err := filepath.Glob(filepath.Join(dir, string(testStream), "*.log"))
+	require.NoError(t, err)
+	assert.True(t, len(segments) > 1, "expected multiple segments")
+
+	store = openEventStore(t, dir, 512)
+	defer store.Close()
+
+	assert.True(t, store.HasStream(ctx, testStream))
+
+	matcher := metadata.WithConstraint(metadata.NewMatcher(), aggregate.IDKey, metadata.Equals, string(secondID))
+	messages, numbers := loadMessages(t, store, 1, matcher)
+	assert.Equal(t, []int64{2, 4, 6, 8, 10}, numbers)
+	for i, msg := range messages {
+		assert.Equal(t, accountDeposited{Amount: i + 1}, msg.Payload())
+	}
+
+	// Appending continues with the next number
+	require.NoError(t, store.AppendTo(ctx, testStream, createMessages(t, firstID, 6, 1)))
+	_, numbers = loadMessages(t, store, 10, metadata.NewMatcher())
+	assert.Equal(t, []int64{10, 11}, numbers)
+}
+
+func TestEventStore_Recovery(t *testing.T) {
+	ctx := context.Background()
+	aggregateID := aggregate.GenerateID()
+
+	t.Run("Truncate partially written append", func(t *testing.T) {
+		dir := createTempDir(t)
+		defer os.RemoveAll(dir)
+
+		store := openEventStore(t, dir, file.DefaultSegmentSize)
+		require.NoError(t, store.Create(ctx, testStream))
+		require.NoError(t, store.AppendTo(ctx, testStream, createMessages(t, aggregateID, 1, 2)))
+		require.NoError(t, store.Close())
+
+		segment := filepath.Join(dir, string(testStream), "00000000000000000001.log")
+		committedSize := fileSize(t, segment)
+
+		store = openEventStore(t, dir, file.DefaultSegmentSize)
+		require.NoError(t, store.AppendTo(ctx, testStream, createMessages(t, aggregateID, 3, 2)))
+		require.NoError(t, store.Close())
+
+		// Simulate a crash while writing the second record of the append
+		require.NoError(t, os.Truncate(segment, fileSize(t, segment)-3))
+
+		store = openEventStore(t, dir, file.DefaultSegmentSize)
+		defer store.Close()
+
+		assert.Equal(t, committedSize, fileSize(t, segment), "the uncommitted append must be removed")
+
+		_, numbers := loadMessages(t, store, 1, metadata.NewMatcher())
+		assert.Equal(t, []int64{1, 2}, numbers)
+
+		require.NoError(t, store.AppendTo(ctx, testStream, createMessages(t, aggregateID, 3, 1)))
+		_, numbers = loadMessages(t, store, 1, metadata.NewMatcher())
+		assert.Equal(t, []int64{1, 2, 3}, numbers)
+	})
+
+	t.Run("Fail on a corrupt segment", func(t *testing.T) {
+		dir := createTempDir(t)
+		defer os.RemoveAll(dir)
+
+		store := openEventStore(t, dir, 256)
+		require.NoError(t, store.Create(ctx, testStream))
+		for i := 0; i < 3; i++ {
+			require.NoError(t, store.AppendTo(ctx, testStream, createMessages(t, aggregateID, uint(i+1), 1)))
+		}
+		require.NoError(t, store.Close())
+
+		// Corrupt a record in the first segment
+		segment := filepath.Join(dir, string(testStream), "00000000000000000001.log")
+		data, err := ioutil.ReadFile(segment)
+		require.NoError(t, err)
+		data[len(data)/2] ^= 0xff
+		require.NoError(t, ioutil.WriteFile(segment, data, 0644))
+
+		transformer, factory := createTransformerAndFactory(t)
+		store, err = file.NewEventStore(dir, transformer, factory, 256, file.SyncAlways, nil)
+
+		assert.Nil(t, store)
+		assert.Equal(t, file.ErrCorruptSegment, errors.Cause(err))
+	})
+}
+
+func TestSyncInterval(t *testing.T) {
+	assert.Equal(t, file.SyncAlways, file.SyncInterval(0))
+	assert.Equal(t, file.SyncPolicy(time.Second), file.SyncInterval(time.Second))
+}
+
+func createTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "goengine-file")
+	require.NoError(t, err)
+
+	return dir
+}
+
+func createTransformerAndFactory(t *testing.T) (*json.PayloadTransformer, *record.AggregateChangedFactory) {
+	transformer := json.NewPayloadTransformer()
+	require.NoError(t,
+		transformer.RegisterPayload("account_deposited", func() interface{} { return accountDeposited{} }),
+	)
+
+	factory, err := record.NewAggregateChangedFactory(transformer)
+	require.NoError(t, err)
+
+	return transformer, factory
+}
+
+func openEventStore(t *testing.T, dir string, segmentSize int64) *file.EventStore {
+	transformer, factory := createTransformerAndFactory(t)
+
+	store, err := file.NewEventStore(dir, transformer, factory, segmentSize, file.SyncInterval(time.Minute), nil)
+	require.NoError(t, err)
+
+	return store
+}
+
+func createMessages(t *testing.T, aggregateID aggregate.ID, fromVersion uint, count int) []goengine.Message {
+	return eventstoretest.NewMessages(t, "account", aggregateID, fromVersion, count, func(version uint) interface{} {
+		return accountDeposited{Amount: int(version)}
+	})
+}
+
+func loadMessages(t *testing.T, store *file.EventStore, fromNumber int64, matcher metadata.Matcher) ([]goengine.Message, []int64) {
+	stream, err := store.Load(context.Background(), testStream, fromNumber, nil, matcher)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	messages, numbers, err := goengine.ReadEventStream(stream)
+	require.NoError(t, err)
+
+	return messages, numbers
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	return info.Size()
+}
@@ -0,0 +1,136 @@
+package file
+
+import (
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/inmemory"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/strategy/json/record"
+)
+
+// Ensure that eventStream satisfies the goengine.EventStream interface
+var _ goengine.EventStream = &eventStream{}
+
+// eventStream lazily reads the records of a stream and yields the ones satisfying the matcher
+type eventStream struct {
+	numbers   []int64
+	locations []location
+	remaining int64
+
+	matcher        *inmemory.MetadataMatcher
+	messageFactory record.MessageFactory
+
+	index   int
+	message goengine.Message
+	err     error
+	closed  bool
+}
+
+func newEventStream(
+	numbers []int64,
+	locations []location,
+	count *uint,
+	matcher *inmemory.MetadataMatcher,
+	messageFactory record.MessageFactory,
+) *eventStream {
+	remaining := int64(-1)
+	if count != nil {
+		remaining = int64(*count)
+	}
+
+	return &eventStream{
+		numbers:        numbers,
+		locations:      locations,
+		remaining:      remaining,
+		matcher:        matcher,
+		messageFactory: messageFactory,
+		index:          -1,
+	}
+}
+
+// Next prepares the next result for reading.
+// It returns true on success, or false if there is no next result or an error happened while preparing it.
+// Err should be consulted to distinguish between the two cases.
+func (s *eventStream) Next() bool {
+	s.message = nil
+	if s.closed || s.err != nil || s.remaining == 0 {
+		return false
+	}
+
+	for s.index+1 < len(s.numbers) {
+		s.index++
+
+		record, err := s.readRecord(s.index)
+		if err != nil {
+			s.err = err
+			return false
+		}
+
+		if !s.matcher.Matches(record.Metadata) {
+			continue
+		}
+
+		s.message, s.err = s.messageFactory.CreateMessage(record)
+		if s.err != nil {
+			return false
+		}
+
+		if s.remaining > 0 {
+			s.remaining--
+		}
+		return true
+	}
+
+	return false
+}
+
+// Err returns the error, if any, that was encountered during iteration.
+func (s *eventStream) Err() error {
+	return s.err
+}
+
+// Close closes the EventStream, preventing further enumeration.
+func (s *eventStream) Close() error {
+	s.closed = true
+	s.message = nil
+	s.numbers = nil
+	s.locations = nil
+
+	return nil
+}
+
+// Message returns the current message and it's number within the EventStream.
+func (s *eventStream) Message() (goengine.Message, int64, error) {
+	if s.message == nil {
+		return nil, 0, s.err
+	}
+
+	return s.message, s.numbers[s.index], nil
+}
+
+func (s *eventStream) readRecord(index int) (*record.Record, error) {
+	loc := s.locations[index]
+
+	body, err := readFrame(loc.segment.file, loc.offset)
+	if err != nil {
+		return nil, err
+	}
+
+	header, payload, err := decodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := metadata.UnmarshalJSON(header.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return &record.Record{
+		Number:    header.Number,
+		UUID:      header.UUID,
+		EventName: header.EventName,
+		Payload:   payload,
+		Metadata:  meta,
+		CreatedAt: header.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,50 @@
+// Package file provides a goengine.EventStore that persists event streams into append-only segmented log files.
+//
+// Every stream is stored in it's own directory containing segment files which are named after the number of the
+// first message they contain. Messages are never modified once written and an append is only considered committed
+// when all it's records are written, a partially written append is discarded when the event store is opened.
+package file
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultSegmentSize is the recommended maximum size of a segment file in bytes
+const DefaultSegmentSize int64 = 64 * 1024 * 1024
+
+const (
+	// SyncAlways fsyncs the segment before AppendTo returns
+	SyncAlways SyncPolicy = 0
+	// SyncNever leaves flushing the segment to disk to the operating system
+	SyncNever SyncPolicy = -1
+)
+
+var (
+	// ErrStreamExistsAlready occurs when create is called for an already created stream
+	ErrStreamExistsAlready = errors.New("goengine: stream already exists")
+	// ErrStreamNotFound occurs when an unknown streamName is provided
+	ErrStreamNotFound = errors.New("goengine: unknown stream")
+	// ErrInvalidStreamName occurs when a stream name cannot be safely used as a directory name
+	ErrInvalidStreamName = errors.New("goengine: stream name may only contain letters, numbers, '-', '_' and '.'")
+	// ErrNilMessage occurs when a goengine.Message that is being appended to a stream is nil or a reference to nil
+	ErrNilMessage = errors.New("goengine: nil is not a valid message")
+	// ErrCorruptSegment occurs when a segment, other then the last segment of a stream, contains an invalid record
+	ErrCorruptSegment = errors.New("goengine: segment contains a corrupt record")
+	// ErrEventStoreClosed occurs when the event store is used after it was closed
+	ErrEventStoreClosed = errors.New("goengine: the event store is closed")
+)
+
+// SyncPolicy determines when appended messages are fsynced to disk
+type SyncPolicy time.Duration
+
+// SyncInterval returns a SyncPolicy that fsyncs the segment at most once per interval.
+// The sync is done by AppendTo so messages appended within the interval of the last sync are only guaranteed to be
+// on disk once a later append is done or the event store is closed.
+func SyncInterval(interval time.Duration) SyncPolicy {
+	if interval <= 0 {
+		return SyncAlways
+	}
+
+	return SyncPolicy(interval)
+}
@@ -0,0 +1,108 @@
+package file
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"github.com/hellofresh/goengine"
+)
+
+// frameHeaderSize is the size of the length and checksum that prefix every record
+const frameHeaderSize = 8
+
+// errInvalidFrame occurs when a frame is incomplete or it's checksum does not match
+var errInvalidFrame = errors.New("goengine: invalid record frame")
+
+type (
+	// recordHeader contains the information of a record other then the payload
+	recordHeader struct {
+		Number    int64           `json:"no"`
+		UUID      goengine.UUID   `json:"uuid"`
+		EventName string          `json:"event_name"`
+		Metadata  json.RawMessage `json:"metadata"`
+		CreatedAt time.Time       `json:"created_at"`
+		// Commit is set on the last record of an append
+		Commit bool `json:"commit,omitempty"`
+	}
+)
+
+// encodeFrame encodes the record into a frame.
+//
+// A frame is laid out as:
+//  uint32 length of the body | uint32 crc32 of the body | body
+// with the body being:
+//  uint32 length of the header | json encoded header | payload
+func encodeFrame(header recordHeader, payload []byte) ([]byte, error) {
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	bodySize := 4 + len(headerData) + len(payload)
+	frame := make([]byte, frameHeaderSize+bodySize)
+
+	body := frame[frameHeaderSize:]
+	binary.BigEndian.PutUint32(body, uint32(len(headerData)))
+	copy(body[4:], headerData)
+	copy(body[4+len(headerData):], payload)
+
+	binary.BigEndian.PutUint32(frame, uint32(bodySize))
+	binary.BigEndian.PutUint32(frame[4:], crc32.ChecksumIEEE(body))
+
+	return frame, nil
+}
+
+// readFrame reads the frame starting at the offset and returns the body of the frame.
+// errInvalidFrame is returned when the frame is incomplete or corrupt and io.EOF when no frame starts at the offset.
+func readFrame(r io.ReaderAt, offset int64) ([]byte, error) {
+	var prefix [frameHeaderSize]byte
+	if n, err := r.ReadAt(prefix[:], offset); err != nil {
+		if err == io.EOF && n == 0 {
+			return nil, io.EOF
+		}
+		if err == io.EOF {
+			return nil, errInvalidFrame
+		}
+		return nil, err
+	}
+
+	bodySize := binary.BigEndian.Uint32(prefix[:])
+	checksum := binary.BigEndian.Uint32(prefix[4:])
+	if bodySize < 4 {
+		return nil, errInvalidFrame
+	}
+
+	body := make([]byte, bodySize)
+	if _, err := r.ReadAt(body, offset+frameHeaderSize); err != nil {
+		if err == io.EOF {
+			return nil, errInvalidFrame
+		}
+		return nil, err
+	}
+
+	if crc32.ChecksumIEEE(body) != checksum {
+		return nil, errInvalidFrame
+	}
+
+	return body, nil
+}
+
+// decodeBody decodes the body of a frame into the record header and payload
+func decodeBody(body []byte) (recordHeader, []byte, error) {
+	var header recordHeader
+
+	headerSize := int(binary.BigEndian.Uint32(body))
+	if headerSize > len(body)-4 {
+		return header, nil, errInvalidFrame
+	}
+
+	if err := json.Unmarshal(body[4:4+headerSize], &header); err != nil {
+		return header, nil, err
+	}
+
+	return header, body[4+headerSize:], nil
+}
@@ -0,0 +1,430 @@
+package file
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/pkg/errors"
+)
+
+const segmentExtension = ".log"
+
+type (
+	// segment is a single log file of a stream
+	segment struct {
+		firstNumber int64
+		file        *os.File
+		size        int64
+	}
+
+	// location is the position of a record within the segments of a stream
+	location struct {
+		segment *segment
+		offset  int64
+	}
+
+	// stream is the on disk log of a event stream together with it's index
+	stream struct {
+		dir      string
+		segments []*segment
+
+		// locations contains the location of every message where the index is the message number minus one
+		locations       []location
+		byAggregateID   map[string][]int64
+		byAggregateType map[string][]int64
+
+		unsynced bool
+		lastSync time.Time
+	}
+
+	// pendingRecord is a record that is written but not yet part of the index
+	pendingRecord struct {
+		location      location
+		aggregateID   string
+		aggregateType string
+	}
+)
+
+// createStream creates the directory and first segment of a new stream
+func createStream(dir string) (*stream, error) {
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	s := newStream(dir)
+	if err := s.addSegment(1); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// openStream opens the segments of a existing stream and rebuilds the index.
+// A partially written append at the end of the last segment is truncated.
+func openStream(dir string, logger goengine.Logger) (*stream, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var firstNumbers []int64
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), segmentExtension) {
+			continue
+		}
+
+		firstNumber, err := strconv.ParseInt(strings.TrimSuffix(f.Name(), segmentExtension), 10, 64)
+		if err != nil {
+			continue
+		}
+		firstNumbers = append(firstNumbers, firstNumber)
+	}
+	sort.Slice(firstNumbers, func(i, j int) bool {
+		return firstNumbers[i] < firstNumbers[j]
+	})
+
+	s := newStream(dir)
+	if len(firstNumbers) == 0 {
+		if err := s.addSegment(1); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	for i, firstNumber := range firstNumbers {
+		last := i == len(firstNumbers)-1
+
+		path := segmentPath(dir, firstNumber)
+		file, err := os.OpenFile(path, os.O_RDWR, 0644)
+		if err != nil {
+			s.close()
+			return nil, err
+		}
+
+		seg := &segment{firstNumber: firstNumber, file: file}
+		s.segments = append(s.segments, seg)
+
+		if firstNumber != int64(len(s.locations))+1 {
+			s.close()
+			return nil, errors.Wrapf(ErrCorruptSegment, "segment %s does not continue the previous segment", path)
+		}
+
+		if err := s.recoverSegment(seg, last, logger); err != nil {
+			s.close()
+			return nil, errors.Wrapf(err, "failed to open segment %s", path)
+		}
+	}
+
+	return s, nil
+}
+
+func newStream(dir string) *stream {
+	return &stream{
+		dir:             dir,
+		byAggregateID:   map[string][]int64{},
+		byAggregateType: map[string][]int64{},
+		lastSync:        time.Now(),
+	}
+}
+
+// recoverSegment reads all records of the segment and adds the committed records to the index
+func (s *stream) recoverSegment(seg *segment, last bool, logger goengine.Logger) error {
+	var (
+		offset          int64
+		committedOffset int64
+		pending         []pendingRecord
+		readErr         error
+	)
+	for {
+		body, err := readFrame(seg.file, offset)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+
+		header, _, err := decodeBody(body)
+		if err != nil {
+			readErr = err
+			break
+		}
+
+		expectedNumber := int64(len(s.locations)+len(pending)) + 1
+		if header.Number != expectedNumber {
+			readErr = errors.Errorf("expected message number %d but found %d", expectedNumber, header.Number)
+			break
+		}
+
+		record, err := newPendingRecord(location{segment: seg, offset: offset}, header)
+		if err != nil {
+			readErr = err
+			break
+		}
+		pending = append(pending, record)
+
+		offset += int64(frameHeaderSize + len(body))
+		if header.Commit {
+			s.index(pending)
+			pending = nil
+			committedOffset = offset
+		}
+	}
+
+	if readErr == nil && len(pending) == 0 {
+		seg.size = offset
+		return nil
+	}
+
+	if !last {
+		if readErr != nil {
+			return errors.Wrap(ErrCorruptSegment, readErr.Error())
+		}
+		return errors.Wrap(ErrCorruptSegment, "segment ends with an uncommitted append")
+	}
+
+	// The last append was not completely written so remove it
+	logger.Warn("truncating partially written append", func(e goengine.LoggerEntry) {
+		e.String("segment", seg.file.Name())
+		e.Int64("offset", committedOffset)
+		e.Int("discarded_records", len(pending))
+		if readErr != nil {
+			e.Error(readErr)
+		}
+	})
+
+	if err := seg.file.Truncate(committedOffset); err != nil {
+		return err
+	}
+	if err := seg.file.Sync(); err != nil {
+		return err
+	}
+	seg.size = committedOffset
+
+	return nil
+}
+
+// addSegment creates a new segment starting with the provided message number
+func (s *stream) addSegment(firstNumber int64) error {
+	file, err := os.OpenFile(segmentPath(s.dir, firstNumber), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	s.segments = append(s.segments, &segment{firstNumber: firstNumber, file: file})
+
+	// Ensure the new segment file is persisted within the stream directory
+	return syncDir(s.dir)
+}
+
+// nextNumber returns the number of the next message that will be appended
+func (s *stream) nextNumber() int64 {
+	return int64(len(s.locations)) + 1
+}
+
+// write writes the frames as a single append and adds the records to the index
+func (s *stream) write(frames []byte, records []pendingRecord, segmentSize int64, policy SyncPolicy) error {
+	seg := s.segments[len(s.segments)-1]
+	if seg.size > 0 && seg.size+int64(len(frames)) > segmentSize {
+		// Sync the full segment since only the active segment is synced by the SyncPolicy
+		if err := s.sync(); err != nil {
+			return err
+		}
+
+		if err := s.addSegment(s.nextNumber()); err != nil {
+			return err
+		}
+		seg = s.segments[len(s.segments)-1]
+	}
+
+	if _, err := seg.file.WriteAt(frames, seg.size); err != nil {
+		// Remove anything that was partially written
+		if truncErr := seg.file.Truncate(seg.size); truncErr != nil {
+			return errors.Wrapf(err, "failed to truncate segment after failed write (%s)", truncErr)
+		}
+		return err
+	}
+
+	for i := range records {
+		records[i].location.segment = seg
+		records[i].location.offset += seg.size
+	}
+	seg.size += int64(len(frames))
+	s.index(records)
+
+	s.unsynced = true
+	if policy == SyncNever || (policy > 0 && time.Since(s.lastSync) < time.Duration(policy)) {
+		return nil
+	}
+
+	return s.sync()
+}
+
+// sync fsyncs the active segment
+func (s *stream) sync() error {
+	if !s.unsynced {
+		return nil
+	}
+
+	if err := s.segments[len(s.segments)-1].file.Sync(); err != nil {
+		return err
+	}
+
+	s.unsynced = false
+	s.lastSync = time.Now()
+
+	return nil
+}
+
+func (s *stream) index(records []pendingRecord) {
+	for _, record := range records {
+		s.locations = append(s.locations, record.location)
+
+		number := int64(len(s.locations))
+		if record.aggregateID != "" {
+			s.byAggregateID[record.aggregateID] = append(s.byAggregateID[record.aggregateID], number)
+		}
+		if record.aggregateType != "" {
+			s.byAggregateType[record.aggregateType] = append(s.byAggregateType[record.aggregateType], number)
+		}
+	}
+}
+
+// candidates returns the numbers of the messages starting at fromNumber that may satisfy the matcher.
+// The index is used to narrow down the messages when the matcher contains an equals constraint on the aggregate id
+// or type, the returned messages still need to be matched against all constraints.
+func (s *stream) candidates(fromNumber int64, matcher metadata.Matcher) []int64 {
+	if fromNumber < 1 {
+		fromNumber = 1
+	}
+
+	var indexed []int64
+	useIndex := false
+	matcher.Iterate(func(c metadata.Constraint) {
+		if c.Operator() != metadata.Equals {
+			return
+		}
+
+		var numbers []int64
+		switch c.Field() {
+		case aggregate.IDKey:
+			numbers = s.byAggregateID[fmt.Sprint(c.Value())]
+		case aggregate.TypeKey:
+			numbers = s.byAggregateType[fmt.Sprint(c.Value())]
+		default:
+			return
+		}
+
+		if !useIndex {
+			indexed = numbers
+			useIndex = true
+			return
+		}
+		indexed = intersect(indexed, numbers)
+	})
+
+	if !useIndex {
+		total := int64(len(s.locations))
+		if fromNumber > total {
+			return nil
+		}
+
+		numbers := make([]int64, 0, total-fromNumber+1)
+		for number := fromNumber; number <= total; number++ {
+			numbers = append(numbers, number)
+		}
+		return numbers
+	}
+
+	start := sort.Search(len(indexed), func(i int) bool {
+		return indexed[i] >= fromNumber
+	})
+
+	// Copy the numbers since the index is appended to after the lock is released
+	numbers := make([]int64, len(indexed)-start)
+	copy(numbers, indexed[start:])
+
+	return numbers
+}
+
+// close fsyncs and closes all segments
+func (s *stream) close() error {
+	var firstErr error
+	if len(s.segments) > 0 {
+		firstErr = s.sync()
+	}
+
+	for _, seg := range s.segments {
+		if err := seg.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func newPendingRecord(loc location, header recordHeader) (pendingRecord, error) {
+	meta, err := metadata.UnmarshalJSON(header.Metadata)
+	if err != nil {
+		return pendingRecord{}, err
+	}
+
+	record := pendingRecord{location: loc}
+	if val := meta.Value(aggregate.IDKey); val != nil {
+		record.aggregateID = fmt.Sprint(val)
+	}
+	if val := meta.Value(aggregate.TypeKey); val != nil {
+		record.aggregateType = fmt.Sprint(val)
+	}
+
+	return record, nil
+}
+
+// intersect returns the numbers that are contained by both sorted slices
+func intersect(a, b []int64) []int64 {
+	var res []int64
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			res = append(res, a[i])
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return res
+}
+
+func segmentPath(dir string, firstNumber int64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", firstNumber, segmentExtension))
+}
+
+// syncDir fsyncs the directory so newly created files are persisted
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	if err := d.Sync(); err != nil {
+		_ = d.Close()
+		return err
+	}
+
+	return d.Close()
+}
@@ -0,0 +1,205 @@
+package grpc
+
+import (
+	"context"
+	"io"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/grpc/eventstorepb"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/strategy/json/record"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Ensure that EventStore satisfies the goengine.EventStore interface
+var _ goengine.EventStore = &EventStore{}
+
+// EventStore is a goengine.EventStore that uses a remote event store served by a Server
+type EventStore struct {
+	client         eventstorepb.EventStoreClient
+	converter      goengine.MessagePayloadConverter
+	messageFactory record.MessageFactory
+
+	logger goengine.Logger
+}
+
+// NewEventStore returns a new EventStore using the provided connection
+func NewEventStore(
+	conn grpc.ClientConnInterface,
+	converter goengine.MessagePayloadConverter,
+	messageFactory record.MessageFactory,
+	logger goengine.Logger,
+) (*EventStore, error) {
+	switch {
+	case conn == nil:
+		return nil, goengine.InvalidArgumentError("conn")
+	case converter == nil:
+		return nil, goengine.InvalidArgumentError("converter")
+	case messageFactory == nil:
+		return nil, goengine.InvalidArgumentError("messageFactory")
+	}
+
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+
+	return &EventStore{
+		client:         eventstorepb.NewEventStoreClient(conn),
+		converter:      converter,
+		messageFactory: messageFactory,
+		logger:         logger,
+	}, nil
+}
+
+// Create creates a event stream
+func (e *EventStore) Create(ctx context.Context, streamName goengine.StreamName) error {
+	_, err := e.client.Create(ctx, &eventstorepb.CreateRequest{StreamName: string(streamName)})
+
+	return fromStatusError(err)
+}
+
+// HasStream returns true if the stream exists
+func (e *EventStore) HasStream(ctx context.Context, streamName goengine.StreamName) bool {
+	res, err := e.client.HasStream(ctx, &eventstorepb.HasStreamRequest{StreamName: string(streamName)})
+	if err != nil {
+		e.logger.Error("error while checking if stream exists", func(entry goengine.LoggerEntry) {
+			entry.Error(err)
+			entry.String("stream", string(streamName))
+		})
+		return false
+	}
+
+	return res.GetExists()
+}
+
+// Load returns a list of events based on the provided conditions
+func (e *EventStore) Load(
+	ctx context.Context,
+	streamName goengine.StreamName,
+	fromNumber int64,
+	count *uint,
+	matcher metadata.Matcher,
+) (_ goengine.EventStream, err error) {
+	_, span := goengine.TracerFromContext(ctx).StartSpan(ctx, "goengine.eventstore.load")
+	defer func() {
+		span.Error(err)
+		span.End()
+	}()
+	span.String("stream", string(streamName))
+	span.Int64("from_number", fromNumber)
+
+	constraints, err := constraintsFromMatcher(matcher)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &eventstorepb.LoadRequest{
+		StreamName:  string(streamName),
+		FromNumber:  fromNumber,
+		Constraints: constraints,
+	}
+	if count != nil {
+		c := uint64(*count)
+		req.Count = &c
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	client, err := e.client.Load(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, fromStatusError(err)
+	}
+
+	stream := newEventStream(client, cancel, e.messageFactory)
+	// Receive the first event in order to return an error when the stream does not exist
+	if err := stream.prefetch(); err != nil {
+		_ = stream.Close()
+		return nil, err
+	}
+
+	return stream, nil
+}
+
+// Subscribe returns a goengine.EventStream of the events in the stream starting at fromNumber that satisfy the matcher.
+// Contrary to Load the EventStream does not end with the last event in the stream but waits for new events to be
+// appended, Next will only return false once the provided context is done or the EventStream is closed.
+func (e *EventStore) Subscribe(
+	ctx context.Context,
+	streamName goengine.StreamName,
+	fromNumber int64,
+	matcher metadata.Matcher,
+) (goengine.EventStream, error) {
+	constraints, err := constraintsFromMatcher(matcher)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	client, err := e.client.Subscribe(ctx, &eventstorepb.SubscribeRequest{
+		StreamName:  string(streamName),
+		FromNumber:  fromNumber,
+		Constraints: constraints,
+	})
+	if err != nil {
+		cancel()
+		return nil, fromStatusError(err)
+	}
+
+	// Wait for the subscription to start, when it did not the server will have returned an error
+	if header, err := client.Header(); err != nil || len(header.Get(subscribedHeader)) == 0 {
+		if err == nil {
+			_, err = client.Recv()
+		}
+		cancel()
+		return nil, fromStatusError(err)
+	}
+
+	return newEventStream(client, cancel, e.messageFactory), nil
+}
+
+// AppendTo appends the provided messages to the stream
+func (e *EventStore) AppendTo(ctx context.Context, streamName goengine.StreamName, streamEvents []goengine.Message) (err error) {
+	_, span := goengine.TracerFromContext(ctx).StartSpan(ctx, "goengine.eventstore.append")
+	defer func() {
+		span.Error(err)
+		span.End()
+	}()
+	span.String("stream", string(streamName))
+	span.Int64("event_count", int64(len(streamEvents)))
+
+	events := make([]*eventstorepb.Event, len(streamEvents))
+	for i, msg := range streamEvents {
+		if events[i], err = eventFromMessage(msg, 0, e.converter); err != nil {
+			return err
+		}
+	}
+
+	_, err = e.client.AppendTo(ctx, &eventstorepb.AppendToRequest{
+		StreamName: string(streamName),
+		Events:     events,
+	})
+
+	return fromStatusError(err)
+}
+
+// fromStatusError converts the gRPC status errors with a known meaning back into the errors of this package
+func fromStatusError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+
+	switch status.Code(err) {
+	case codes.AlreadyExists:
+		return ErrStreamExistsAlready
+	case codes.NotFound:
+		return ErrStreamNotFound
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	}
+
+	return err
+}
@@ -0,0 +1,364 @@
+// +build unit
+
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	driverGRPC "github.com/hellofresh/goengine/driver/grpc"
+	"github.com/hellofresh/goengine/driver/grpc/eventstorepb"
+	"github.com/hellofresh/goengine/driver/inmemory"
+	"github.com/hellofresh/goengine/eventstoretest"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/strategy/json"
+	"github.com/hellofresh/goengine/strategy/json/record"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testStream goengine.StreamName = "event_stream"
+
+func TestEventStoreConformance(t *testing.T) {
+	store, stop := serve(t)
+	defer stop()
+
+	eventstoretest.Run(t, func(t *testing.T) goengine.EventStore {
+		return store
+	})
+}
+
+func TestNewServer(t *testing.T) {
+	transformer := json.NewRawPayloadTransformer()
+	factory, err := record.NewAggregateChangedFactory(transformer)
+	require.NoError(t, err)
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		testCases := []struct {
+			title        string
+			store        goengine.EventStore
+			converter    goengine.MessagePayloadConverter
+			factory      record.MessageFactory
+			pollInterval time.Duration
+			expectedErr  error
+		}{
+			{"nil store", nil, transformer, factory, time.Second, goengine.InvalidArgumentError("store")},
+			{"nil converter", inmemory.NewEventStore(nil), nil, factory, time.Second, goengine.InvalidArgumentError("converter")},
+			{"nil message factory", inmemory.NewEventStore(nil), transformer, nil, time.Second, goengine.InvalidArgumentError("messageFactory")},
+			{"zero poll interval", inmemory.NewEventStore(nil), transformer, factory, 0, goengine.InvalidArgumentError("pollInterval")},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				server, err := driverGRPC.NewServer(testCase.store, testCase.converter, testCase.factory, testCase.pollInterval, nil)
+
+				assert.Nil(t, server)
+				assert.Equal(t, testCase.expectedErr, err)
+			})
+		}
+	})
+}
+
+func TestNewEventStore(t *testing.T) {
+	transformer := newPayloadTransformer(t)
+	factory, err := record.NewAggregateChangedFactory(transformer)
+	require.NoError(t, err)
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		testCases := []struct {
+			title       string
+			conn        grpc.ClientConnInterface
+			converter   goengine.MessagePayloadConverter
+			factory     record.MessageFactory
+			expectedErr error
+		}{
+			{"nil conn", nil, transformer, factory, goengine.InvalidArgumentError("conn")},
+			{"nil converter", &grpc.ClientConn{}, nil, factory, goengine.InvalidArgumentError("converter")},
+			{"nil message factory", &grpc.ClientConn{}, transformer, nil, goengine.InvalidArgumentError("messageFactory")},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				store, err := driverGRPC.NewEventStore(testCase.conn, testCase.converter, testCase.factory, nil)
+
+				assert.Nil(t, store)
+				assert.Equal(t, testCase.expectedErr, err)
+			})
+		}
+	})
+}
+
+func TestEventStore_Create(t *testing.T) {
+	store, stop := serve(t)
+	defer stop()
+	ctx := context.Background()
+
+	require.NoError(t, store.Create(ctx, testStream))
+	assert.Equal(t, driverGRPC.ErrStreamExistsAlready, store.Create(ctx, testStream))
+}
+
+func TestEventStore_Load(t *testing.T) {
+	t.Run("Unknown stream", func(t *testing.T) {
+		store, stop := serve(t)
+		defer stop()
+
+		stream, err := store.Load(context.Background(), testStream, 1, nil, nil)
+
+		assert.Nil(t, stream)
+		assert.Equal(t, driverGRPC.ErrStreamNotFound, err)
+	})
+
+	t.Run("Unsupported constraint value", func(t *testing.T) {
+		store, stop := serve(t)
+		defer stop()
+
+		matcher := metadata.WithConstraint(metadata.NewMatcher(), aggregate.IDKey, metadata.Equals, []string{"a"})
+		stream, err := store.Load(context.Background(), testStream, 1, nil, matcher)
+
+		assert.Nil(t, stream)
+		assert.Equal(t, driverGRPC.ErrUnsupportedConstraintValue, errors.Cause(err))
+	})
+
+	t.Run("Invalid constraint", func(t *testing.T) {
+		conn, stop := serveConn(t)
+		defer stop()
+
+		client, err := eventstorepb.NewEventStoreClient(conn).Load(context.Background(), &eventstorepb.LoadRequest{
+			StreamName: string(testStream),
+			FromNumber: 1,
+			Constraints: []*eventstorepb.Constraint{
+				{Field: aggregate.IDKey, Operator: "~"},
+			},
+		})
+		require.NoError(t, err)
+
+		_, err = client.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestEventStore_Subscribe(t *testing.T) {
+	t.Run("Receive appended events", func(t *testing.T) {
+		store, stop := serve(t)
+		defer stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		aggregateID := aggregate.GenerateID()
+		otherAggregateID := aggregate.GenerateID()
+
+		require.NoError(t, store.Create(ctx, testStream))
+		require.NoError(t, store.AppendTo(ctx, testStream, []goengine.Message{
+			newMessage(t, aggregateID, 1),
+			newMessage(t, otherAggregateID, 1),
+		}))
+
+		matcher := metadata.WithConstraint(metadata.NewMatcher(), aggregate.IDKey, metadata.Equals, aggregateID)
+		stream, err := store.Subscribe(ctx, testStream, 1, matcher)
+		require.NoError(t, err)
+		defer stream.Close()
+
+		assertNext(t, stream, aggregateID, 1, 1)
+
+		require.NoError(t, store.AppendTo(ctx, testStream, []goengine.Message{
+			newMessage(t, otherAggregateID, 2),
+			newMessage(t, aggregateID, 2),
+		}))
+
+		assertNext(t, stream, aggregateID, 2, 4)
+
+		require.NoError(t, stream.Close())
+		assert.False(t, stream.Next())
+		assert.NoError(t, stream.Err())
+	})
+
+	t.Run("Filtered events are not scanned again", func(t *testing.T) {
+		recorder := &loadRecorder{EventStore: inmemory.NewEventStore(goengine.NopLogger)}
+		store, stop := serveStore(t, recorder)
+		defer stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		aggregateID := aggregate.GenerateID()
+		otherAggregateID := aggregate.GenerateID()
+
+		require.NoError(t, store.Create(ctx, testStream))
+		require.NoError(t, store.AppendTo(ctx, testStream, []goengine.Message{
+			newMessage(t, otherAggregateID, 1),
+			newMessage(t, otherAggregateID, 2),
+			newMessage(t, otherAggregateID, 3),
+		}))
+
+		matcher := metadata.WithConstraint(metadata.NewMatcher(), aggregate.IDKey, metadata.Equals, aggregateID)
+		stream, err := store.Subscribe(ctx, testStream, 1, matcher)
+		require.NoError(t, err)
+		defer stream.Close()
+
+		// Once the filtered events are scanned the following polls start after them
+		assert.Eventually(t, func() bool {
+			fromNumbers := recorder.filteredFromNumbers()
+			return len(fromNumbers) > 1 && fromNumbers[len(fromNumbers)-1] == 4
+		}, time.Second, 5*time.Millisecond)
+
+		require.NoError(t, store.AppendTo(ctx, testStream, []goengine.Message{
+			newMessage(t, aggregateID, 1),
+		}))
+
+		assertNext(t, stream, aggregateID, 1, 4)
+	})
+
+	t.Run("Unknown stream", func(t *testing.T) {
+		store, stop := serve(t)
+		defer stop()
+
+		stream, err := store.Subscribe(context.Background(), testStream, 1, nil)
+
+		assert.Nil(t, stream)
+		assert.Equal(t, driverGRPC.ErrStreamNotFound, err)
+	})
+
+	t.Run("Context is done", func(t *testing.T) {
+		store, stop := serve(t)
+		defer stop()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		require.NoError(t, store.Create(ctx, testStream))
+
+		stream, err := store.Subscribe(ctx, testStream, 1, nil)
+		require.NoError(t, err)
+		defer stream.Close()
+
+		cancel()
+
+		assert.False(t, stream.Next())
+		assert.Equal(t, context.Canceled, stream.Err())
+	})
+}
+
+// serve starts a Server for an inmemory event store and returns a EventStore connected to it
+func serve(t *testing.T) (*driverGRPC.EventStore, func()) {
+	return serveStore(t, inmemory.NewEventStore(goengine.NopLogger))
+}
+
+// serveStore starts a Server for the event store and returns a EventStore connected to it
+func serveStore(t *testing.T, eventStore goengine.EventStore) (*driverGRPC.EventStore, func()) {
+	conn, stop := serveStoreConn(t, eventStore)
+
+	transformer := newPayloadTransformer(t)
+	factory, err := record.NewAggregateChangedFactory(transformer)
+	require.NoError(t, err)
+
+	store, err := driverGRPC.NewEventStore(conn, transformer, factory, nil)
+	require.NoError(t, err)
+
+	return store, stop
+}
+
+// serveConn starts a Server for an inmemory event store and returns a connection to it
+func serveConn(t *testing.T) (*grpc.ClientConn, func()) {
+	return serveStoreConn(t, inmemory.NewEventStore(goengine.NopLogger))
+}
+
+// serveStoreConn starts a Server for the event store and returns a connection to it
+func serveStoreConn(t *testing.T, eventStore goengine.EventStore) (*grpc.ClientConn, func()) {
+	// The server only passes the payloads along so it does not need to know them
+	transformer := json.NewRawPayloadTransformer()
+	factory, err := record.NewAggregateChangedFactory(transformer)
+	require.NoError(t, err)
+
+	server, err := driverGRPC.NewServer(eventStore, transformer, factory, 10*time.Millisecond, nil)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	eventstorepb.RegisterEventStoreServer(grpcServer, server)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+
+	conn, err := grpc.Dial(
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithInsecure(),
+	)
+	require.NoError(t, err)
+
+	return conn, func() {
+		_ = conn.Close()
+		grpcServer.Stop()
+	}
+}
+
+func newPayloadTransformer(t *testing.T) *json.PayloadTransformer {
+	transformer := json.NewPayloadTransformer()
+	require.NoError(t,
+		transformer.RegisterPayload(eventstoretest.PayloadName, func() interface{} { return eventstoretest.Payload{} }),
+	)
+
+	return transformer
+}
+
+func newMessage(t *testing.T, aggregateID aggregate.ID, version uint) goengine.Message {
+	payload := eventstoretest.Payload{Name: string(aggregateID), Number: int(version)}
+
+	return eventstoretest.NewMessage(t, eventstoretest.AggregateType, aggregateID, version, payload)
+}
+
+func assertNext(t *testing.T, stream goengine.EventStream, aggregateID aggregate.ID, version uint, number int64) {
+	require.True(t, stream.Next(), "expected a message: %v", stream.Err())
+
+	msg, msgNumber, err := stream.Message()
+	require.NoError(t, err)
+	assert.Equal(t, number, msgNumber)
+	assert.Equal(t, eventstoretest.Payload{Name: string(aggregateID), Number: int(version)}, msg.Payload())
+}
+
+// loadRecorder is a goengine.EventStore recording the from number of the loads filtered by a metadata constraint
+type loadRecorder struct {
+	goengine.EventStore
+
+	mu          sync.Mutex
+	fromNumbers []int64
+}
+
+func (r *loadRecorder) Load(
+	ctx context.Context,
+	streamName goengine.StreamName,
+	fromNumber int64,
+	count *uint,
+	matcher metadata.Matcher,
+) (goengine.EventStream, error) {
+	filtered := false
+	matcher.Iterate(func(metadata.Constraint) {
+		filtered = true
+	})
+
+	if filtered {
+		r.mu.Lock()
+		r.fromNumbers = append(r.fromNumbers, fromNumber)
+		r.mu.Unlock()
+	}
+
+	return r.EventStore.Load(ctx, streamName, fromNumber, count, matcher)
+}
+
+func (r *loadRecorder) filteredFromNumbers() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]int64(nil), r.fromNumbers...)
+}
@@ -0,0 +1,310 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package eventstorepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// EventStoreClient is the client API for EventStore service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EventStoreClient interface {
+	// Create creates an event stream
+	Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	// HasStream returns whether the stream exists
+	HasStream(ctx context.Context, in *HasStreamRequest, opts ...grpc.CallOption) (*HasStreamResponse, error)
+	// AppendTo appends the provided events to the stream
+	AppendTo(ctx context.Context, in *AppendToRequest, opts ...grpc.CallOption) (*AppendToResponse, error)
+	// Load streams the events matching the request
+	Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (EventStore_LoadClient, error)
+	// Subscribe streams the events matching the request and keeps streaming newly appended events
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (EventStore_SubscribeClient, error)
+}
+
+type eventStoreClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEventStoreClient(cc grpc.ClientConnInterface) EventStoreClient {
+	return &eventStoreClient{cc}
+}
+
+func (c *eventStoreClient) Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, "/goengine.eventstore.v1.EventStore/Create", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventStoreClient) HasStream(ctx context.Context, in *HasStreamRequest, opts ...grpc.CallOption) (*HasStreamResponse, error) {
+	out := new(HasStreamResponse)
+	err := c.cc.Invoke(ctx, "/goengine.eventstore.v1.EventStore/HasStream", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventStoreClient) AppendTo(ctx context.Context, in *AppendToRequest, opts ...grpc.CallOption) (*AppendToResponse, error) {
+	out := new(AppendToResponse)
+	err := c.cc.Invoke(ctx, "/goengine.eventstore.v1.EventStore/AppendTo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventStoreClient) Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (EventStore_LoadClient, error) {
+	stream, err := c.cc.NewStream(ctx, &EventStore_ServiceDesc.Streams[0], "/goengine.eventstore.v1.EventStore/Load", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventStoreLoadClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type EventStore_LoadClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type eventStoreLoadClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventStoreLoadClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *eventStoreClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (EventStore_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &EventStore_ServiceDesc.Streams[1], "/goengine.eventstore.v1.EventStore/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventStoreSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type EventStore_SubscribeClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type eventStoreSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventStoreSubscribeClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EventStoreServer is the server API for EventStore service.
+// All implementations must embed UnimplementedEventStoreServer
+// for forward compatibility
+type EventStoreServer interface {
+	// Create creates an event stream
+	Create(context.Context, *CreateRequest) (*CreateResponse, error)
+	// HasStream returns whether the stream exists
+	HasStream(context.Context, *HasStreamRequest) (*HasStreamResponse, error)
+	// AppendTo appends the provided events to the stream
+	AppendTo(context.Context, *AppendToRequest) (*AppendToResponse, error)
+	// Load streams the events matching the request
+	Load(*LoadRequest, EventStore_LoadServer) error
+	// Subscribe streams the events matching the request and keeps streaming newly appended events
+	Subscribe(*SubscribeRequest, EventStore_SubscribeServer) error
+	mustEmbedUnimplementedEventStoreServer()
+}
+
+// UnimplementedEventStoreServer must be embedded to have forward compatible implementations.
+type UnimplementedEventStoreServer struct {
+}
+
+func (UnimplementedEventStoreServer) Create(context.Context, *CreateRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedEventStoreServer) HasStream(context.Context, *HasStreamRequest) (*HasStreamResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HasStream not implemented")
+}
+func (UnimplementedEventStoreServer) AppendTo(context.Context, *AppendToRequest) (*AppendToResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AppendTo not implemented")
+}
+func (UnimplementedEventStoreServer) Load(*LoadRequest, EventStore_LoadServer) error {
+	return status.Errorf(codes.Unimplemented, "method Load not implemented")
+}
+func (UnimplementedEventStoreServer) Subscribe(*SubscribeRequest, EventStore_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedEventStoreServer) mustEmbedUnimplementedEventStoreServer() {}
+
+// UnsafeEventStoreServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EventStoreServer will
+// result in compilation errors.
+type UnsafeEventStoreServer interface {
+	mustEmbedUnimplementedEventStoreServer()
+}
+
+func RegisterEventStoreServer(s grpc.ServiceRegistrar, srv EventStoreServer) {
+	s.RegisterService(&EventStore_ServiceDesc, srv)
+}
+
+func _EventStore_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventStoreServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goengine.eventstore.v1.EventStore/Create",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventStoreServer).Create(ctx, req.(*CreateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventStore_HasStream_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HasStreamRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventStoreServer).HasStream(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goengine.eventstore.v1.EventStore/HasStream",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventStoreServer).HasStream(ctx, req.(*HasStreamRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventStore_AppendTo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendToRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventStoreServer).AppendTo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goengine.eventstore.v1.EventStore/AppendTo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventStoreServer).AppendTo(ctx, req.(*AppendToRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventStore_Load_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LoadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EventStoreServer).Load(m, &eventStoreLoadServer{stream})
+}
+
+type EventStore_LoadServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type eventStoreLoadServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventStoreLoadServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _EventStore_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EventStoreServer).Subscribe(m, &eventStoreSubscribeServer{stream})
+}
+
+type EventStore_SubscribeServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type eventStoreSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventStoreSubscribeServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// EventStore_ServiceDesc is the grpc.ServiceDesc for EventStore service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var EventStore_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "goengine.eventstore.v1.EventStore",
+	HandlerType: (*EventStoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _EventStore_Create_Handler,
+		},
+		{
+			MethodName: "HasStream",
+			Handler:    _EventStore_HasStream_Handler,
+		},
+		{
+			MethodName: "AppendTo",
+			Handler:    _EventStore_AppendTo_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Load",
+			Handler:       _EventStore_Load_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Subscribe",
+			Handler:       _EventStore_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "eventstore.proto",
+}
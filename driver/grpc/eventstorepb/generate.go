@@ -0,0 +1,4 @@
+// Package eventstorepb contains the protocol buffer messages and gRPC service definition used by the grpc driver.
+package eventstorepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative eventstore.proto
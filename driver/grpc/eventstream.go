@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"io"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/grpc/eventstorepb"
+	"github.com/hellofresh/goengine/strategy/json/record"
+)
+
+// Ensure that eventStream satisfies the goengine.EventStream interface
+var _ goengine.EventStream = &eventStream{}
+
+type (
+	// eventReceiver is the client side of a server streaming Load or Subscribe call
+	eventReceiver interface {
+		Recv() (*eventstorepb.Event, error)
+	}
+
+	// eventStream reconstructs the messages of the events received from the server
+	eventStream struct {
+		receiver       eventReceiver
+		cancel         context.CancelFunc
+		messageFactory record.MessageFactory
+
+		prefetched *eventstorepb.Event
+		done       bool
+
+		message goengine.Message
+		number  int64
+		err     error
+		closed  bool
+	}
+)
+
+func newEventStream(receiver eventReceiver, cancel context.CancelFunc, messageFactory record.MessageFactory) *eventStream {
+	return &eventStream{
+		receiver:       receiver,
+		cancel:         cancel,
+		messageFactory: messageFactory,
+	}
+}
+
+// prefetch receives the first event so that errors of the server surface before the stream is read
+func (s *eventStream) prefetch() error {
+	event, err := s.receiver.Recv()
+	switch {
+	case err == io.EOF:
+		s.done = true
+	case err != nil:
+		return fromStatusError(err)
+	}
+
+	s.prefetched = event
+
+	return nil
+}
+
+// Next prepares the next result for reading.
+// It returns true on success, or false if there is no next result or an error happened while preparing it.
+// Err should be consulted to distinguish between the two cases.
+func (s *eventStream) Next() bool {
+	s.message, s.number = nil, 0
+	if s.closed || s.done || s.err != nil {
+		return false
+	}
+
+	event := s.prefetched
+	s.prefetched = nil
+	if event == nil {
+		var err error
+		if event, err = s.receiver.Recv(); err != nil {
+			if err == io.EOF {
+				s.done = true
+			} else {
+				s.err = fromStatusError(err)
+			}
+			return false
+		}
+	}
+
+	record, err := recordFromEvent(event)
+	if err != nil {
+		s.err = err
+		return false
+	}
+
+	if s.message, s.err = s.messageFactory.CreateMessage(record); s.err != nil {
+		return false
+	}
+	s.number = record.Number
+
+	return true
+}
+
+// Err returns the error, if any, that was encountered during iteration.
+func (s *eventStream) Err() error {
+	return s.err
+}
+
+// Close closes the EventStream and cancels the call to the server
+func (s *eventStream) Close() error {
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+	s.message, s.number = nil, 0
+	s.cancel()
+
+	return nil
+}
+
+// Message returns the current message and it's number within the EventStream.
+func (s *eventStream) Message() (goengine.Message, int64, error) {
+	if s.message == nil {
+		return nil, 0, s.err
+	}
+
+	return s.message, s.number, nil
+}
@@ -0,0 +1,15 @@
+module github.com/hellofresh/goengine/driver/grpc
+
+go 1.15
+
+require (
+	github.com/google/uuid v1.1.2
+	github.com/hellofresh/goengine v1.4.0
+	github.com/pkg/errors v0.8.0
+	github.com/stretchr/testify v1.7.0
+	google.golang.org/grpc v1.43.0
+	google.golang.org/protobuf v1.27.1
+)
+
+// The replace is only used for local development, a release requires the root module version it is tagged with
+replace github.com/hellofresh/goengine => ../..
@@ -0,0 +1,176 @@
+// Package grpc provides a gRPC service exposing a goengine.EventStore and an goengine.EventStore client for it.
+//
+// The Server serves Create, HasStream, AppendTo and Load of any goengine.EventStore and allows clients to Subscribe
+// to a stream in order to receive events as they are appended. The EventStore is the client side and can be used
+// anywhere a goengine.EventStore or goengine.ReadOnlyEventStore is expected.
+//
+// Payloads are transferred as produced by the goengine.MessagePayloadConverter and metadata is transferred as JSON.
+// Metadata matcher constraints are part of the protocol and only support string, integer, float and boolean values.
+package grpc
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/google/uuid"
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/grpc/eventstorepb"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/strategy/json/record"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	// ErrStreamExistsAlready occurs when create is called for an already created stream
+	ErrStreamExistsAlready = errors.New("goengine: stream already exists")
+	// ErrStreamNotFound occurs when an unknown streamName is provided
+	ErrStreamNotFound = errors.New("goengine: unknown stream")
+	// ErrNilMessage occurs when a goengine.Message that is being appended to a stream is nil or a reference to nil
+	ErrNilMessage = errors.New("goengine: nil is not a valid message")
+	// ErrUnsupportedConstraintValue occurs when the value of a metadata constraint cannot be send to the server
+	ErrUnsupportedConstraintValue = errors.New("goengine: constraint value type is not supported")
+	// ErrInvalidConstraint occurs when a received metadata constraint has an unknown operator or no value
+	ErrInvalidConstraint = errors.New("goengine: constraint has an unknown operator or no value")
+	// ErrInvalidEvent occurs when a received event is missing the event id, event name or creation time
+	ErrInvalidEvent = errors.New("goengine: event is missing the event id, event name or created at")
+)
+
+// constraintsFromMatcher converts the constraints of the matcher into their protocol representation
+func constraintsFromMatcher(matcher metadata.Matcher) ([]*eventstorepb.Constraint, error) {
+	if matcher == nil {
+		return nil, nil
+	}
+
+	var (
+		constraints []*eventstorepb.Constraint
+		err         error
+	)
+	matcher.Iterate(func(c metadata.Constraint) {
+		if err != nil {
+			return
+		}
+
+		constraint := &eventstorepb.Constraint{
+			Field:    c.Field(),
+			Operator: string(c.Operator()),
+		}
+
+		value := reflect.ValueOf(c.Value())
+		switch value.Kind() {
+		case reflect.String:
+			constraint.Value = &eventstorepb.Constraint_StringValue{StringValue: value.String()}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			constraint.Value = &eventstorepb.Constraint_IntValue{IntValue: value.Int()}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			constraint.Value = &eventstorepb.Constraint_UintValue{UintValue: value.Uint()}
+		case reflect.Float32, reflect.Float64:
+			constraint.Value = &eventstorepb.Constraint_FloatValue{FloatValue: value.Float()}
+		case reflect.Bool:
+			constraint.Value = &eventstorepb.Constraint_BoolValue{BoolValue: value.Bool()}
+		default:
+			err = errors.Wrapf(ErrUnsupportedConstraintValue, "constraint on %s with value %v", c.Field(), c.Value())
+			return
+		}
+
+		constraints = append(constraints, constraint)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return constraints, nil
+}
+
+// matcherFromConstraints converts the protocol representation of constraints into a metadata.Matcher
+func matcherFromConstraints(constraints []*eventstorepb.Constraint) (metadata.Matcher, error) {
+	matcher := metadata.NewMatcher()
+	for _, c := range constraints {
+		operator := metadata.Operator(c.GetOperator())
+		switch operator {
+		case metadata.Equals, metadata.NotEquals,
+			metadata.GreaterThan, metadata.GreaterThanEquals,
+			metadata.LowerThan, metadata.LowerThanEquals:
+		default:
+			return nil, ErrInvalidConstraint
+		}
+
+		var value interface{}
+		switch v := c.GetValue().(type) {
+		case *eventstorepb.Constraint_StringValue:
+			value = v.StringValue
+		case *eventstorepb.Constraint_IntValue:
+			value = v.IntValue
+		case *eventstorepb.Constraint_UintValue:
+			value = v.UintValue
+		case *eventstorepb.Constraint_FloatValue:
+			value = v.FloatValue
+		case *eventstorepb.Constraint_BoolValue:
+			value = v.BoolValue
+		default:
+			return nil, ErrInvalidConstraint
+		}
+
+		matcher = metadata.WithConstraint(matcher, c.GetField(), operator, value)
+	}
+
+	return matcher, nil
+}
+
+// eventFromMessage converts the message into it's protocol representation
+func eventFromMessage(msg goengine.Message, number int64, converter goengine.MessagePayloadConverter) (*eventstorepb.Event, error) {
+	if msg == nil || reflect.ValueOf(msg).IsNil() {
+		return nil, ErrNilMessage
+	}
+
+	eventName, payload, err := converter.ConvertPayload(msg.Payload())
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := json.Marshal(msg.Metadata())
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventstorepb.Event{
+		Number:    number,
+		EventId:   msg.UUID().String(),
+		EventName: eventName,
+		Payload:   payload,
+		Metadata:  meta,
+		CreatedAt: timestamppb.New(msg.CreatedAt()),
+	}, nil
+}
+
+// recordFromEvent converts the protocol representation of an event into a record.Record
+func recordFromEvent(event *eventstorepb.Event) (*record.Record, error) {
+	if event.GetEventName() == "" || event.GetCreatedAt() == nil {
+		return nil, ErrInvalidEvent
+	}
+
+	id, err := uuid.Parse(event.GetEventId())
+	if err != nil || goengine.IsUUIDEmpty(id) {
+		return nil, ErrInvalidEvent
+	}
+
+	if err := event.GetCreatedAt().CheckValid(); err != nil {
+		return nil, ErrInvalidEvent
+	}
+
+	meta := metadata.New()
+	if len(event.GetMetadata()) > 0 {
+		if meta, err = metadata.UnmarshalJSON(event.GetMetadata()); err != nil {
+			return nil, err
+		}
+	}
+
+	return &record.Record{
+		Number:    event.GetNumber(),
+		UUID:      id,
+		EventName: event.GetEventName(),
+		Payload:   event.GetPayload(),
+		Metadata:  meta,
+		CreatedAt: event.GetCreatedAt().AsTime(),
+	}, nil
+}
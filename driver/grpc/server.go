@@ -0,0 +1,301 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/grpc/eventstorepb"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/strategy/json/record"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	grpcMetadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// subscribedHeader is the header send by the Server once a subscription started
+const subscribedHeader = "goengine-subscribed"
+
+// eventSender is the server side of a server streaming Load or Subscribe call
+type eventSender interface {
+	Send(*eventstorepb.Event) error
+}
+
+// Ensure that Server satisfies the eventstorepb.EventStoreServer interface
+var _ eventstorepb.EventStoreServer = &Server{}
+
+// Server is a eventstorepb.EventStoreServer that serves a goengine.EventStore.
+// Register it using eventstorepb.RegisterEventStoreServer.
+type Server struct {
+	eventstorepb.UnimplementedEventStoreServer
+
+	store          goengine.EventStore
+	converter      goengine.MessagePayloadConverter
+	messageFactory record.MessageFactory
+	pollInterval   time.Duration
+
+	logger goengine.Logger
+}
+
+// NewServer returns a new Server for the provided event store.
+// The pollInterval determines how often the event store is queried for new events of a subscribed stream.
+func NewServer(
+	store goengine.EventStore,
+	converter goengine.MessagePayloadConverter,
+	messageFactory record.MessageFactory,
+	pollInterval time.Duration,
+	logger goengine.Logger,
+) (*Server, error) {
+	switch {
+	case store == nil:
+		return nil, goengine.InvalidArgumentError("store")
+	case converter == nil:
+		return nil, goengine.InvalidArgumentError("converter")
+	case messageFactory == nil:
+		return nil, goengine.InvalidArgumentError("messageFactory")
+	case pollInterval <= 0:
+		return nil, goengine.InvalidArgumentError("pollInterval")
+	}
+
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+
+	return &Server{
+		store:          store,
+		converter:      converter,
+		messageFactory: messageFactory,
+		pollInterval:   pollInterval,
+		logger:         logger,
+	}, nil
+}
+
+// Create creates the requested event stream
+func (s *Server) Create(ctx context.Context, req *eventstorepb.CreateRequest) (*eventstorepb.CreateResponse, error) {
+	streamName := goengine.StreamName(req.GetStreamName())
+	if s.store.HasStream(ctx, streamName) {
+		return nil, status.Error(codes.AlreadyExists, ErrStreamExistsAlready.Error())
+	}
+
+	if err := s.store.Create(ctx, streamName); err != nil {
+		if s.store.HasStream(ctx, streamName) {
+			return nil, status.Error(codes.AlreadyExists, ErrStreamExistsAlready.Error())
+		}
+		return nil, s.statusError(ctx, streamName, err)
+	}
+
+	return &eventstorepb.CreateResponse{}, nil
+}
+
+// HasStream returns whether the requested event stream exists
+func (s *Server) HasStream(ctx context.Context, req *eventstorepb.HasStreamRequest) (*eventstorepb.HasStreamResponse, error) {
+	return &eventstorepb.HasStreamResponse{
+		Exists: s.store.HasStream(ctx, goengine.StreamName(req.GetStreamName())),
+	}, nil
+}
+
+// AppendTo appends the events of the request to the requested event stream
+func (s *Server) AppendTo(ctx context.Context, req *eventstorepb.AppendToRequest) (*eventstorepb.AppendToResponse, error) {
+	streamName := goengine.StreamName(req.GetStreamName())
+
+	messages := make([]goengine.Message, len(req.GetEvents()))
+	for i, event := range req.GetEvents() {
+		record, err := recordFromEvent(event)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		messages[i], err = s.messageFactory.CreateMessage(record)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	if err := s.store.AppendTo(ctx, streamName, messages); err != nil {
+		return nil, s.statusError(ctx, streamName, err)
+	}
+
+	return &eventstorepb.AppendToResponse{}, nil
+}
+
+// Load sends the events of the requested event stream
+func (s *Server) Load(req *eventstorepb.LoadRequest, srv eventstorepb.EventStore_LoadServer) error {
+	ctx := srv.Context()
+	streamName := goengine.StreamName(req.GetStreamName())
+
+	matcher, err := matcherFromConstraints(req.GetConstraints())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	var count *uint
+	if req.Count != nil {
+		c := uint(req.GetCount())
+		count = &c
+	}
+
+	_, err = s.send(ctx, srv, streamName, req.GetFromNumber(), count, matcher)
+
+	return err
+}
+
+// Subscribe sends the events of the requested event stream and keeps sending newly appended events until the
+// client cancels the subscription.
+//
+// Like the projectors a subscription continues after the highest event number it scanned, so an event of a
+// transaction that commits after an event with a higher number was scanned is never send.
+func (s *Server) Subscribe(req *eventstorepb.SubscribeRequest, srv eventstorepb.EventStore_SubscribeServer) error {
+	ctx := srv.Context()
+	streamName := goengine.StreamName(req.GetStreamName())
+
+	matcher, err := matcherFromConstraints(req.GetConstraints())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if !s.store.HasStream(ctx, streamName) {
+		return status.Error(codes.NotFound, ErrStreamNotFound.Error())
+	}
+
+	// Let the client know the subscription started since it may take a while before the first event is send
+	if err := srv.SendHeader(grpcMetadata.Pairs(subscribedHeader, "true")); err != nil {
+		return err
+	}
+
+	fromNumber := req.GetFromNumber()
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	filtered := len(req.GetConstraints()) > 0
+	for {
+		// Scan the stream before sending the matching events, this way the events that are appended in between are
+		// send and the events that did not match are not scanned again
+		var scannedNumber int64
+		if filtered {
+			if scannedNumber, err = s.lastNumber(ctx, streamName, fromNumber); err != nil {
+				return err
+			}
+		}
+
+		lastNumber, err := s.send(ctx, srv, streamName, fromNumber, nil, matcher)
+		if err != nil {
+			return err
+		}
+		if scannedNumber > lastNumber {
+			lastNumber = scannedNumber
+		}
+		if lastNumber >= fromNumber {
+			fromNumber = lastNumber + 1
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// send loads the events from the event store and sends them to the client.
+// The number of the last event that was send is returned.
+func (s *Server) send(
+	ctx context.Context,
+	srv eventSender,
+	streamName goengine.StreamName,
+	fromNumber int64,
+	count *uint,
+	matcher metadata.Matcher,
+) (lastNumber int64, err error) {
+	stream, err := s.store.Load(ctx, streamName, fromNumber, count, matcher)
+	if err != nil {
+		return 0, s.statusError(ctx, streamName, err)
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			s.logger.Warn("failed to close event stream", func(e goengine.LoggerEntry) {
+				e.Error(err)
+				e.String("stream", string(streamName))
+			})
+		}
+	}()
+
+	for stream.Next() {
+		msg, number, err := stream.Message()
+		if err != nil {
+			return lastNumber, s.statusError(ctx, streamName, err)
+		}
+
+		event, err := eventFromMessage(msg, number, s.converter)
+		if err != nil {
+			return lastNumber, s.statusError(ctx, streamName, err)
+		}
+
+		if err := srv.Send(event); err != nil {
+			return lastNumber, err
+		}
+		lastNumber = number
+	}
+
+	if err := stream.Err(); err != nil {
+		return lastNumber, s.statusError(ctx, streamName, err)
+	}
+
+	return lastNumber, nil
+}
+
+// lastNumber returns the number of the last event of the event stream starting at fromNumber or 0 when there is none
+func (s *Server) lastNumber(ctx context.Context, streamName goengine.StreamName, fromNumber int64) (lastNumber int64, err error) {
+	stream, err := s.store.Load(ctx, streamName, fromNumber, nil, metadata.NewMatcher())
+	if err != nil {
+		return 0, s.statusError(ctx, streamName, err)
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			s.logger.Warn("failed to close event stream", func(e goengine.LoggerEntry) {
+				e.Error(err)
+				e.String("stream", string(streamName))
+			})
+		}
+	}()
+
+	for stream.Next() {
+		_, number, err := stream.Message()
+		if err != nil {
+			return 0, s.statusError(ctx, streamName, err)
+		}
+		lastNumber = number
+	}
+
+	if err := stream.Err(); err != nil {
+		return 0, s.statusError(ctx, streamName, err)
+	}
+
+	return lastNumber, nil
+}
+
+// statusError converts an error of the event store into a gRPC status error
+func (s *Server) statusError(ctx context.Context, streamName goengine.StreamName, err error) error {
+	cause := errors.Cause(err)
+	if _, ok := cause.(goengine.InvalidArgumentError); ok {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	switch cause {
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	if !s.store.HasStream(ctx, streamName) {
+		return status.Error(codes.NotFound, ErrStreamNotFound.Error())
+	}
+
+	s.logger.Error("event store request failed", func(e goengine.LoggerEntry) {
+		e.Error(err)
+		e.String("stream", string(streamName))
+	})
+
+	return status.Error(codes.Internal, err.Error())
+}
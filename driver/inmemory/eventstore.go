@@ -21,13 +21,23 @@ var (
 	_ goengine.EventStore = &EventStore{}
 )
 
-// EventStore a in memory event store implementation
-type EventStore struct {
-	sync.RWMutex
+type (
+	// EventStore a in memory event store implementation
+	EventStore struct {
+		sync.RWMutex
 
-	logger  goengine.Logger
-	streams map[goengine.StreamName][]goengine.Message
-}
+		logger  goengine.Logger
+		streams map[goengine.StreamName][]goengine.Message
+
+		hooksLock sync.RWMutex
+		hooks     map[int]appendHook
+		hookSeq   int
+	}
+
+	// appendHook is called after messages are appended to a stream with the context passed to AppendTo and the number
+	// of the first appended message
+	appendHook func(ctx context.Context, streamName goengine.StreamName, messages []goengine.Message, fromNumber int64)
+)
 
 // NewEventStore return a new inmemory.EventStore
 func NewEventStore(logger goengine.Logger) *EventStore {
@@ -39,6 +49,9 @@ func NewEventStore(logger goengine.Logger) *EventStore {
 
 // Create creates a event stream
 func (i *EventStore) Create(ctx context.Context, streamName goengine.StreamName) error {
+	i.Lock()
+	defer i.Unlock()
+
 	if _, found := i.streams[streamName]; found {
 		return ErrStreamExistsAlready
 	}
@@ -50,6 +63,9 @@ func (i *EventStore) Create(ctx context.Context, streamName goengine.StreamName)
 
 // HasStream returns true if the stream exists
 func (i *EventStore) HasStream(ctx context.Context, streamName goengine.StreamName) bool {
+	i.RLock()
+	defer i.RUnlock()
+
 	_, found := i.streams[streamName]
 
 	return found
@@ -62,7 +78,15 @@ func (i *EventStore) Load(
 	fromNumber int64,
 	count *uint,
 	matcher metadata.Matcher,
-) (goengine.EventStream, error) {
+) (_ goengine.EventStream, err error) {
+	_, span := goengine.TracerFromContext(ctx).StartSpan(ctx, "goengine.eventstore.load")
+	defer func() {
+		span.Error(err)
+		span.End()
+	}()
+	span.String("stream", string(streamName))
+	span.Int64("from_number", fromNumber)
+
 	i.RLock()
 	defer i.RUnlock()
 
@@ -71,6 +95,10 @@ func (i *EventStore) Load(
 		return nil, ErrStreamNotFound
 	}
 
+	if count != nil && *count == 0 {
+		return NewEventStream(nil, nil)
+	}
+
 	metadataMatcher, err := NewMetadataMatcher(matcher, i.logger)
 	if err != nil {
 		return nil, err
@@ -97,17 +125,44 @@ func (i *EventStore) Load(
 
 // AppendTo appends the provided messages to the stream
 func (i *EventStore) AppendTo(ctx context.Context, streamName goengine.StreamName, streamEvents []goengine.Message) error {
+	_, span := goengine.TracerFromContext(ctx).StartSpan(ctx, "goengine.eventstore.append")
+	span.String("stream", string(streamName))
+	span.Int64("event_count", int64(len(streamEvents)))
+
+	fromNumber, err := i.append(streamName, streamEvents)
+	span.Error(err)
+	span.End()
+	if err != nil || len(streamEvents) == 0 {
+		return err
+	}
+
+	// Notify the hooks after the lock is released so they are able to load the stream
+	i.hooksLock.RLock()
+	hooks := make([]appendHook, 0, len(i.hooks))
+	for _, hook := range i.hooks {
+		hooks = append(hooks, hook)
+	}
+	i.hooksLock.RUnlock()
+
+	for _, hook := range hooks {
+		hook(ctx, streamName, streamEvents, fromNumber)
+	}
+
+	return nil
+}
+
+func (i *EventStore) append(streamName goengine.StreamName, streamEvents []goengine.Message) (int64, error) {
 	i.Lock()
 	defer i.Unlock()
 
 	storedEvents, knownStream := i.streams[streamName]
 	if !knownStream {
-		return ErrStreamNotFound
+		return 0, ErrStreamNotFound
 	}
 
 	for _, msg := range streamEvents {
 		if msg == nil || reflect.ValueOf(msg).IsNil() {
-			return ErrNilMessage
+			return 0, ErrNilMessage
 		}
 	}
 
@@ -117,5 +172,26 @@ func (i *EventStore) AppendTo(ctx context.Context, streamName goengine.StreamNam
 	copy(eventsToStore, storedEvents)
 	i.streams[streamName] = append(eventsToStore, streamEvents...)
 
-	return nil
+	return int64(storedEventCount + 1), nil
+}
+
+// subscribe registers a hook that is called after messages are appended and returns a func to remove the hook
+func (i *EventStore) subscribe(hook appendHook) func() {
+	i.hooksLock.Lock()
+	defer i.hooksLock.Unlock()
+
+	if i.hooks == nil {
+		i.hooks = map[int]appendHook{}
+	}
+
+	i.hookSeq++
+	id := i.hookSeq
+	i.hooks[id] = hook
+
+	return func() {
+		i.hooksLock.Lock()
+		defer i.hooksLock.Unlock()
+
+		delete(i.hooks, id)
+	}
 }
@@ -0,0 +1,17 @@
+// +build unit
+
+package inmemory_test
+
+import (
+	"testing"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/inmemory"
+	"github.com/hellofresh/goengine/eventstoretest"
+)
+
+func TestEventStoreConformance(t *testing.T) {
+	eventstoretest.Run(t, func(t *testing.T) goengine.EventStore {
+		return inmemory.NewEventStore(goengine.NopLogger)
+	})
+}
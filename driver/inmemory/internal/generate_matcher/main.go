@@ -107,13 +107,13 @@ func compare{{ .String | ucFirst }}(rValue {{ . }}, operator metadata.Operator,
 		return rValue != lValue, nil
 	{{- if . | basicType }}{{ else }}
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	{{- end }}
 	}
 
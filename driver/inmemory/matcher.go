@@ -101,6 +101,11 @@ func (m *MetadataMatcher) Matches(metadata metadata.Metadata) bool {
 
 // Matches returns true if the value satisfies the constraint
 func (c *metadataConstraint) Matches(val interface{}) (bool, error) {
+	// A missing value never satisfies a constraint
+	if val == nil {
+		return false, nil
+	}
+
 	// Ensure the value's are of the same type
 	if valType := reflect.TypeOf(val); valType != c.valueType {
 		// The types do not match let's see if they can be converted
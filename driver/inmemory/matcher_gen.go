@@ -227,13 +227,13 @@ func compareInt(rValue int, operator metadata.Operator, lValue int) (bool, error
 	case metadata.NotEquals:
 		return rValue != lValue, nil
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	}
 
 	return false, ErrUnsupportedOperator
@@ -246,13 +246,13 @@ func compareInt8(rValue int8, operator metadata.Operator, lValue int8) (bool, er
 	case metadata.NotEquals:
 		return rValue != lValue, nil
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	}
 
 	return false, ErrUnsupportedOperator
@@ -265,13 +265,13 @@ func compareInt16(rValue int16, operator metadata.Operator, lValue int16) (bool,
 	case metadata.NotEquals:
 		return rValue != lValue, nil
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	}
 
 	return false, ErrUnsupportedOperator
@@ -284,13 +284,13 @@ func compareInt32(rValue int32, operator metadata.Operator, lValue int32) (bool,
 	case metadata.NotEquals:
 		return rValue != lValue, nil
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	}
 
 	return false, ErrUnsupportedOperator
@@ -303,13 +303,13 @@ func compareInt64(rValue int64, operator metadata.Operator, lValue int64) (bool,
 	case metadata.NotEquals:
 		return rValue != lValue, nil
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	}
 
 	return false, ErrUnsupportedOperator
@@ -322,13 +322,13 @@ func compareUint(rValue uint, operator metadata.Operator, lValue uint) (bool, er
 	case metadata.NotEquals:
 		return rValue != lValue, nil
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	}
 
 	return false, ErrUnsupportedOperator
@@ -341,13 +341,13 @@ func compareUint8(rValue uint8, operator metadata.Operator, lValue uint8) (bool,
 	case metadata.NotEquals:
 		return rValue != lValue, nil
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	}
 
 	return false, ErrUnsupportedOperator
@@ -360,13 +360,13 @@ func compareUint16(rValue uint16, operator metadata.Operator, lValue uint16) (bo
 	case metadata.NotEquals:
 		return rValue != lValue, nil
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	}
 
 	return false, ErrUnsupportedOperator
@@ -379,13 +379,13 @@ func compareUint32(rValue uint32, operator metadata.Operator, lValue uint32) (bo
 	case metadata.NotEquals:
 		return rValue != lValue, nil
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	}
 
 	return false, ErrUnsupportedOperator
@@ -398,13 +398,13 @@ func compareUint64(rValue uint64, operator metadata.Operator, lValue uint64) (bo
 	case metadata.NotEquals:
 		return rValue != lValue, nil
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	}
 
 	return false, ErrUnsupportedOperator
@@ -417,13 +417,13 @@ func compareFloat32(rValue float32, operator metadata.Operator, lValue float32)
 	case metadata.NotEquals:
 		return rValue != lValue, nil
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	}
 
 	return false, ErrUnsupportedOperator
@@ -436,13 +436,13 @@ func compareFloat64(rValue float64, operator metadata.Operator, lValue float64)
 	case metadata.NotEquals:
 		return rValue != lValue, nil
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	}
 
 	return false, ErrUnsupportedOperator
@@ -455,13 +455,13 @@ func compareString(rValue string, operator metadata.Operator, lValue string) (bo
 	case metadata.NotEquals:
 		return rValue != lValue, nil
 	case metadata.GreaterThan:
-		return rValue > lValue, nil
+		return lValue > rValue, nil
 	case metadata.GreaterThanEquals:
-		return rValue >= lValue, nil
+		return lValue >= rValue, nil
 	case metadata.LowerThan:
-		return rValue < lValue, nil
+		return lValue < rValue, nil
 	case metadata.LowerThanEquals:
-		return rValue <= lValue, nil
+		return lValue <= rValue, nil
 	}
 
 	return false, ErrUnsupportedOperator
@@ -0,0 +1,180 @@
+package inmemory
+
+import (
+	"context"
+
+	"github.com/hellofresh/goengine"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/metadata"
+)
+
+const (
+	// NotifyAsynchronous projects appended messages in the background so AppendTo does not wait for the projection
+	NotifyAsynchronous NotificationMode = iota
+	// NotifySynchronous projects appended messages before AppendTo returns.
+	// Messages appended by a projection handler, using the context passed to the handler, are projected after the
+	// handler returns since the projection is in progress. Appending with any other context from within a handler blocks
+	// forever when the messages need to be projected by the same projection.
+	NotifySynchronous
+)
+
+type (
+	// NotificationMode determines how a projector projects the messages appended to the event store
+	NotificationMode int
+
+	// projectionRawState is the encoded state of a projection
+	projectionRawState struct {
+		position int64
+		state    []byte
+	}
+
+	// projectionRunKey is the context key of the projectionRun of a raw state
+	projectionRunKey struct {
+		rawState *projectionRawState
+	}
+
+	// projectionRun is stored in the context passed to the projection handlers and records if the handlers appended
+	// messages that need to be projected in the same run
+	projectionRun struct {
+		appended bool
+	}
+
+	// projectionExecutor contains the logic for loading the messages of a projection and projecting them
+	projectionExecutor struct {
+		store      *EventStore
+		streamName goengine.StreamName
+
+		handlers           map[string]goengine.MessageHandler
+		resolver           goengine.MessagePayloadResolver
+		stateSerialization driverSQL.ProjectionStateSerialization
+
+		logger goengine.Logger
+	}
+)
+
+func newProjectionExecutor(
+	store *EventStore,
+	streamName goengine.StreamName,
+	resolver goengine.MessagePayloadResolver,
+	projection goengine.Projection,
+	logger goengine.Logger,
+) (*projectionExecutor, error) {
+	handlers := projection.Handlers()
+	if len(handlers) == 0 {
+		return nil, goengine.InvalidArgumentError("projection")
+	}
+
+	return &projectionExecutor{
+		store:              store,
+		streamName:         streamName,
+		handlers:           driverSQL.WrapProjectionHandlers(handlers),
+		resolver:           resolver,
+		stateSerialization: driverSQL.GetProjectionStateSerialization(projection),
+		logger:             logger,
+	}, nil
+}
+
+// project loads the messages after the position of the raw state and projects them.
+// The raw state is updated after every projected message.
+// Messages appended by the handlers while projecting are projected before project returns, see deferToRun.
+func (p *projectionExecutor) project(ctx context.Context, rawState *projectionRawState, matcher metadata.Matcher) error {
+	run := &projectionRun{}
+	ctx = context.WithValue(ctx, projectionRunKey{rawState}, run)
+
+	for {
+		run.appended = false
+		if err := p.projectStream(ctx, rawState, matcher); err != nil || !run.appended {
+			return err
+		}
+	}
+}
+
+// deferToRun returns true when the context belongs to a handler that is projecting the raw state.
+// In this case the projection of the appended messages is left to the running projection since projecting them
+// would wait for the running projection to finish.
+func (p *projectionExecutor) deferToRun(ctx context.Context, rawState *projectionRawState) bool {
+	run, ok := ctx.Value(projectionRunKey{rawState}).(*projectionRun)
+	if !ok {
+		return false
+	}
+
+	run.appended = true
+	return true
+}
+
+// projectStream loads the messages after the position of the raw state and projects them
+func (p *projectionExecutor) projectStream(ctx context.Context, rawState *projectionRawState, matcher metadata.Matcher) error {
+	stream, err := p.store.Load(ctx, p.streamName, rawState.position+1, nil, matcher)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			p.logger.Warn("failed to close the event stream", func(e goengine.LoggerEntry) {
+				e.Error(err)
+			})
+		}
+	}()
+
+	var (
+		state         interface{}
+		stateAcquired bool
+	)
+	for stream.Next() {
+		// Check if the context is expired
+		select {
+		default:
+		case <-ctx.Done():
+			return nil
+		}
+
+		msg, msgNumber, err := stream.Message()
+		if err != nil {
+			return err
+		}
+
+		eventName, err := p.resolver.ResolveName(msg.Payload())
+		if err != nil {
+			return err
+		}
+
+		handler, found := p.handlers[eventName]
+		if !found {
+			continue
+		}
+
+		// Acquire the state if we have none
+		if !stateAcquired {
+			if state, err = p.decodeState(ctx, rawState); err != nil {
+				return err
+			}
+			stateAcquired = true
+		}
+
+		// Execute the handler
+		if state, err = handler(ctx, state, msg); err != nil {
+			return err
+		}
+
+		// Persist state and position changes
+		encodedState, err := p.stateSerialization.EncodeState(state)
+		if err != nil {
+			return err
+		}
+
+		rawState.position = msgNumber
+		rawState.state = encodedState
+	}
+
+	return stream.Err()
+}
+
+// decodeState returns the projection state of the raw state
+func (p *projectionExecutor) decodeState(ctx context.Context, rawState *projectionRawState) (interface{}, error) {
+	if rawState.position == 0 {
+		// This is the fist time the projection runs so initialize the state
+		return p.stateSerialization.Init(ctx)
+	}
+
+	return p.stateSerialization.DecodeState(rawState.state)
+}
@@ -0,0 +1,390 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/metadata"
+)
+
+type (
+	// AggregateProjector is a in memory projector used to execute a projection per aggregate instance against an event stream
+	AggregateProjector struct {
+		sync.Mutex
+
+		backgroundProcessor *driverSQL.ProjectionNotificationProcessor
+		executor            *projectionExecutor
+		mode                NotificationMode
+
+		aggregateTypeName string
+		matcher           metadata.Matcher
+
+		projectionsLock sync.Mutex
+		projections     map[string]*aggregateProjection
+
+		projectionErrorHandler driverSQL.ProjectionErrorCallback
+
+		logger goengine.Logger
+	}
+
+	// aggregateProjection is the projection state of a single aggregate instance
+	aggregateProjection struct {
+		sync.Mutex
+
+		rawState projectionRawState
+		failed   bool
+	}
+)
+
+// NewAggregateProjector creates a new projector for a projection
+func NewAggregateProjector(
+	store *EventStore,
+	resolver goengine.MessagePayloadResolver,
+	aggregateTypeName string,
+	projection goengine.Projection,
+	projectionErrorHandler driverSQL.ProjectionErrorCallback,
+	mode NotificationMode,
+	logger goengine.Logger,
+) (*AggregateProjector, error) {
+	switch {
+	case store == nil:
+		return nil, goengine.InvalidArgumentError("store")
+	case resolver == nil:
+		return nil, goengine.InvalidArgumentError("resolver")
+	case aggregateTypeName == "":
+		return nil, goengine.InvalidArgumentError("aggregateTypeName")
+	case projection == nil:
+		return nil, goengine.InvalidArgumentError("projection")
+	case projectionErrorHandler == nil:
+		return nil, goengine.InvalidArgumentError("projectionErrorHandler")
+	case mode != NotifyAsynchronous && mode != NotifySynchronous:
+		return nil, goengine.InvalidArgumentError("mode")
+	}
+
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+	logger = logger.WithFields(func(e goengine.LoggerEntry) {
+		e.String("projection", projection.Name())
+	})
+
+	processor, err := driverSQL.NewBackgroundProcessor(10, 32, logger, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	executor, err := newProjectionExecutor(store, projection.FromStream(), resolver, projection, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AggregateProjector{
+		backgroundProcessor:    processor,
+		executor:               executor,
+		mode:                   mode,
+		aggregateTypeName:      aggregateTypeName,
+		matcher:                metadata.WithConstraint(metadata.NewMatcher(), aggregate.TypeKey, metadata.Equals, aggregateTypeName),
+		projections:            map[string]*aggregateProjection{},
+		projectionErrorHandler: projectionErrorHandler,
+		logger:                 logger,
+	}, nil
+}
+
+// Run executes the projection and manages the state of the projection
+func (a *AggregateProjector) Run(ctx context.Context) error {
+	a.Lock()
+	defer a.Unlock()
+
+	// Check if the context is expired
+	select {
+	default:
+	case <-ctx.Done():
+		return nil
+	}
+
+	return a.backgroundProcessor.Execute(ctx, a.processNotification, nil)
+}
+
+// RunAndListen executes the projection and projects any messages appended to the event stream until the context is done
+func (a *AggregateProjector) RunAndListen(ctx context.Context) error {
+	a.Lock()
+	defer a.Unlock()
+
+	// Check if the context is expired
+	select {
+	default:
+	case <-ctx.Done():
+		return nil
+	}
+
+	stopExecutor := a.backgroundProcessor.Start(ctx, a.processNotification)
+	defer stopExecutor()
+
+	unsubscribe := a.executor.store.subscribe(func(
+		appendCtx context.Context,
+		streamName goengine.StreamName,
+		messages []goengine.Message,
+		fromNumber int64,
+	) {
+		if streamName != a.executor.streamName {
+			return
+		}
+
+		for _, notification := range a.notifications(messages, fromNumber) {
+			if a.mode == NotifySynchronous {
+				if a.executor.deferToRun(appendCtx, &a.projection(notification.AggregateID).rawState) {
+					continue
+				}
+
+				a.processSynchronously(ctx, notification)
+				continue
+			}
+
+			if err := a.backgroundProcessor.Queue(ctx, notification); err != nil {
+				a.logger.Error("failed to queue notification", func(e goengine.LoggerEntry) {
+					e.Error(err)
+					e.Int64("notification.no", notification.No)
+					e.String("notification.aggregate_id", notification.AggregateID)
+				})
+			}
+		}
+	})
+	defer unsubscribe()
+
+	// Execute an initial run of the projection.
+	// This is done after subscribing to avoid losing messages that are appended in the meantime.
+	if err := a.backgroundProcessor.Queue(ctx, nil); err != nil {
+		return err
+	}
+
+	<-ctx.Done()
+	a.logger.Debug("context closed stopping projection", nil)
+
+	return nil
+}
+
+// State returns the current state of the projection for the aggregate instance
+func (a *AggregateProjector) State(ctx context.Context, aggregateID string) (driverSQL.ProjectionState, error) {
+	projection := a.projection(aggregateID)
+	projection.Lock()
+	defer projection.Unlock()
+
+	state, err := a.executor.decodeState(ctx, &projection.rawState)
+	if err != nil {
+		return driverSQL.ProjectionState{}, err
+	}
+
+	return driverSQL.ProjectionState{
+		Position:        projection.rawState.position,
+		ProjectionState: state,
+	}, nil
+}
+
+// Failed returns true if the projection of the aggregate instance was marked as failed
+func (a *AggregateProjector) Failed(aggregateID string) bool {
+	projection := a.projection(aggregateID)
+	projection.Lock()
+	defer projection.Unlock()
+
+	return projection.failed
+}
+
+func (a *AggregateProjector) processNotification(
+	ctx context.Context,
+	notification *driverSQL.ProjectionNotification,
+	queue driverSQL.ProjectionTrigger,
+) error {
+	var (
+		err       error
+		logFields func(e goengine.LoggerEntry)
+	)
+	if notification != nil {
+		err = a.project(ctx, notification)
+		logFields = func(e goengine.LoggerEntry) {
+			e.Error(err)
+			e.Int64("notification.no", notification.No)
+			e.String("notification.aggregate_id", notification.AggregateID)
+		}
+	} else {
+		err = a.triggerOutOfSyncProjections(ctx, queue)
+		logFields = func(e goengine.LoggerEntry) {
+			e.Error(err)
+		}
+	}
+
+	// No error occurred during projection so return
+	if err == nil {
+		return nil
+	}
+
+	// Resolve the action to take based on the error that occurred
+	switch driverSQL.ResolveProjectorErrorAction(a.projectionErrorHandler, notification, err) {
+	case driverSQL.ProjectorErrorFail:
+		a.logger.Debug("ProcessHandler->ErrorHandler: marking projection as failed", logFields)
+		a.markProjectionAsFailed(notification)
+		return nil
+	case driverSQL.ProjectorErrorIgnore:
+		a.logger.Debug("ProcessHandler->ErrorHandler: ignoring error", logFields)
+		return nil
+	case driverSQL.ProjectorErrorRetry:
+		a.logger.Debug("ProcessHandler->ErrorHandler: re-queueing notification", logFields)
+		return queue(ctx, notification)
+	}
+
+	a.logger.Debug("ProcessHandler->ErrorHandler: error fallthrough", logFields)
+	return err
+}
+
+// processSynchronously processes the notification and any retries before returning
+func (a *AggregateProjector) processSynchronously(ctx context.Context, notification *driverSQL.ProjectionNotification) {
+	for i := 0; i < math.MaxInt16; i++ {
+		var retry bool
+		err := a.processNotification(ctx, notification, func(context.Context, *driverSQL.ProjectionNotification) error {
+			retry = true
+			return nil
+		})
+		if err != nil {
+			a.logger.Error("the ProcessHandler produced an error", func(e goengine.LoggerEntry) {
+				e.Error(err)
+				e.Any("notification", notification)
+			})
+		}
+
+		if !retry {
+			return
+		}
+	}
+
+	a.logger.Error("stopped retrying notification after too many retries", func(e goengine.LoggerEntry) {
+		e.Int64("notification.no", notification.No)
+		e.String("notification.aggregate_id", notification.AggregateID)
+	})
+}
+
+func (a *AggregateProjector) project(ctx context.Context, notification *driverSQL.ProjectionNotification) error {
+	projection := a.projection(notification.AggregateID)
+	projection.Lock()
+	defer projection.Unlock()
+
+	if projection.failed {
+		return driverSQL.ErrProjectionPreviouslyLocked
+	}
+
+	if notification.No <= projection.rawState.position {
+		return driverSQL.ErrNoProjectionRequired
+	}
+
+	matcher := metadata.WithConstraint(a.matcher, aggregate.IDKey, metadata.Equals, notification.AggregateID)
+
+	return a.executor.project(ctx, &projection.rawState, matcher)
+}
+
+func (a *AggregateProjector) triggerOutOfSyncProjections(ctx context.Context, queue driverSQL.ProjectionTrigger) error {
+	// A nil notification was received this mean that we need to find and trigger any missed notifications
+	stream, err := a.executor.store.Load(ctx, a.executor.streamName, 1, nil, a.matcher)
+	if err != nil {
+		return err
+	}
+
+	messages, numbers, err := goengine.ReadEventStream(stream)
+	if err != nil {
+		return err
+	}
+
+	for _, notification := range groupNotifications(messages, numbers) {
+		// Check if the context is expired
+		select {
+		default:
+		case <-ctx.Done():
+			return nil
+		}
+
+		projection := a.projection(notification.AggregateID)
+		projection.Lock()
+		inSync := projection.failed || projection.rawState.position >= notification.No
+		projection.Unlock()
+		if inSync {
+			continue
+		}
+
+		if err := queue(ctx, notification); err != nil {
+			a.logger.Error("failed to queue notification", func(e goengine.LoggerEntry) {
+				e.Error(err)
+				e.Int64("notification.no", notification.No)
+				e.String("notification.aggregate_id", notification.AggregateID)
+			})
+			return err
+		}
+
+		a.logger.Debug("send catchup", func(e goengine.LoggerEntry) {
+			e.Int64("notification.no", notification.No)
+			e.String("notification.aggregate_id", notification.AggregateID)
+		})
+	}
+
+	return nil
+}
+
+func (a *AggregateProjector) markProjectionAsFailed(notification *driverSQL.ProjectionNotification) {
+	projection := a.projection(notification.AggregateID)
+	projection.Lock()
+	defer projection.Unlock()
+
+	projection.failed = true
+}
+
+// projection returns the projection of the aggregate instance
+func (a *AggregateProjector) projection(aggregateID string) *aggregateProjection {
+	a.projectionsLock.Lock()
+	defer a.projectionsLock.Unlock()
+
+	projection, found := a.projections[aggregateID]
+	if !found {
+		projection = &aggregateProjection{}
+		a.projections[aggregateID] = projection
+	}
+
+	return projection
+}
+
+// notifications returns a notification for every aggregate instance of the projected type in the appended messages
+func (a *AggregateProjector) notifications(messages []goengine.Message, fromNumber int64) []*driverSQL.ProjectionNotification {
+	var (
+		matching []goengine.Message
+		numbers  []int64
+	)
+	for i, msg := range messages {
+		if fmt.Sprint(msg.Metadata().Value(aggregate.TypeKey)) != a.aggregateTypeName {
+			continue
+		}
+
+		matching = append(matching, msg)
+		numbers = append(numbers, fromNumber+int64(i))
+	}
+
+	return groupNotifications(matching, numbers)
+}
+
+// groupNotifications returns a notification containing the last message number for every aggregate instance
+func groupNotifications(messages []goengine.Message, numbers []int64) []*driverSQL.ProjectionNotification {
+	var notifications []*driverSQL.ProjectionNotification
+	byAggregateID := map[string]*driverSQL.ProjectionNotification{}
+	for i, msg := range messages {
+		aggregateID := fmt.Sprint(msg.Metadata().Value(aggregate.IDKey))
+
+		notification, found := byAggregateID[aggregateID]
+		if !found {
+			notification = &driverSQL.ProjectionNotification{AggregateID: aggregateID}
+			byAggregateID[aggregateID] = notification
+			notifications = append(notifications, notification)
+		}
+		notification.No = numbers[i]
+	}
+
+	return notifications
+}
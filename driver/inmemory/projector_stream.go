@@ -0,0 +1,235 @@
+package inmemory
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/hellofresh/goengine"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/pkg/errors"
+)
+
+// StreamProjector is a in memory projector used to execute a projection against an event stream
+type StreamProjector struct {
+	sync.Mutex
+
+	executor *projectionExecutor
+	mode     NotificationMode
+
+	stateLock sync.Mutex
+	state     projectionRawState
+
+	projectionErrorHandler driverSQL.ProjectionErrorCallback
+
+	logger goengine.Logger
+}
+
+// NewStreamProjector creates a new projector for a projection
+func NewStreamProjector(
+	store *EventStore,
+	resolver goengine.MessagePayloadResolver,
+	projection goengine.Projection,
+	projectionErrorHandler driverSQL.ProjectionErrorCallback,
+	mode NotificationMode,
+	logger goengine.Logger,
+) (*StreamProjector, error) {
+	switch {
+	case store == nil:
+		return nil, goengine.InvalidArgumentError("store")
+	case resolver == nil:
+		return nil, goengine.InvalidArgumentError("resolver")
+	case projection == nil:
+		return nil, goengine.InvalidArgumentError("projection")
+	case projectionErrorHandler == nil:
+		return nil, goengine.InvalidArgumentError("projectionErrorHandler")
+	case mode != NotifyAsynchronous && mode != NotifySynchronous:
+		return nil, goengine.InvalidArgumentError("mode")
+	}
+
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+	logger = logger.WithFields(func(e goengine.LoggerEntry) {
+		e.String("projection", projection.Name())
+	})
+
+	executor, err := newProjectionExecutor(store, projection.FromStream(), resolver, projection, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamProjector{
+		executor:               executor,
+		mode:                   mode,
+		projectionErrorHandler: projectionErrorHandler,
+		logger:                 logger,
+	}, nil
+}
+
+// Run executes the projection and manages the state of the projection
+func (s *StreamProjector) Run(ctx context.Context) error {
+	s.Lock()
+	defer s.Unlock()
+
+	// Check if the context is expired
+	select {
+	default:
+	case <-ctx.Done():
+		return nil
+	}
+
+	return s.processNotification(ctx, nil)
+}
+
+// RunAndListen executes the projection and projects any messages appended to the event stream until the context is done.
+// An error is returned when the projection fails.
+func (s *StreamProjector) RunAndListen(ctx context.Context) error {
+	s.Lock()
+	defer s.Unlock()
+
+	// Check if the context is expired
+	select {
+	default:
+	case <-ctx.Done():
+		return nil
+	}
+
+	var (
+		failed  = make(chan error, 1)
+		notify  = make(chan struct{}, 1)
+		pending *driverSQL.ProjectionNotification
+		m       sync.Mutex
+	)
+	unsubscribe := s.executor.store.subscribe(func(
+		appendCtx context.Context,
+		streamName goengine.StreamName,
+		messages []goengine.Message,
+		fromNumber int64,
+	) {
+		if streamName != s.executor.streamName {
+			return
+		}
+
+		notification := &driverSQL.ProjectionNotification{
+			No: fromNumber + int64(len(messages)) - 1,
+		}
+
+		if s.mode == NotifySynchronous {
+			if s.executor.deferToRun(appendCtx, &s.state) {
+				return
+			}
+
+			if err := s.processNotification(ctx, notification); err != nil {
+				select {
+				case failed <- err:
+				default:
+				}
+			}
+			return
+		}
+
+		// Only the latest notification is kept since every run projects all the messages after the current position
+		m.Lock()
+		pending = notification
+		m.Unlock()
+
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	// Execute an initial run of the projection.
+	// This is done after subscribing to avoid losing messages that are appended in the meantime.
+	if err := s.processNotification(ctx, nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-notify:
+			m.Lock()
+			notification := pending
+			m.Unlock()
+
+			if err := s.processNotification(ctx, notification); err != nil {
+				return err
+			}
+		case err := <-failed:
+			return err
+		case <-ctx.Done():
+			s.logger.Debug("context closed stopping projection", nil)
+			return nil
+		}
+	}
+}
+
+// State returns the current state of the projection
+func (s *StreamProjector) State(ctx context.Context) (driverSQL.ProjectionState, error) {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+
+	state, err := s.executor.decodeState(ctx, &s.state)
+	if err != nil {
+		return driverSQL.ProjectionState{}, err
+	}
+
+	return driverSQL.ProjectionState{
+		Position:        s.state.position,
+		ProjectionState: state,
+	}, nil
+}
+
+func (s *StreamProjector) processNotification(
+	ctx context.Context,
+	notification *driverSQL.ProjectionNotification,
+) error {
+	for i := 0; i < math.MaxInt16; i++ {
+		err := s.project(ctx, notification)
+
+		// No error occurred during projection so return
+		if err == nil {
+			return err
+		}
+
+		// Resolve the action to take based on the error that occurred
+		logFields := func(e goengine.LoggerEntry) {
+			e.Error(err)
+			if notification == nil {
+				e.Any("notification", notification)
+			} else {
+				e.Int64("notification.no", notification.No)
+			}
+		}
+		switch driverSQL.ResolveProjectorErrorAction(s.projectionErrorHandler, notification, err) {
+		case driverSQL.ProjectorErrorRetry:
+			s.logger.Debug("Trigger->ErrorHandler: retrying notification", logFields)
+			continue
+		case driverSQL.ProjectorErrorIgnore:
+			s.logger.Debug("Trigger->ErrorHandler: ignoring error", logFields)
+			return nil
+		case driverSQL.ProjectorErrorFail, driverSQL.ProjectorErrorFallthrough:
+			s.logger.Debug("Trigger->ErrorHandler: error fallthrough", logFields)
+			return err
+		}
+	}
+
+	return errors.Errorf(
+		"seriously %d retries is enough! maybe it's time to fix your projection or error handling code?",
+		math.MaxInt16,
+	)
+}
+
+func (s *StreamProjector) project(ctx context.Context, notification *driverSQL.ProjectionNotification) error {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+
+	if notification != nil && notification.No <= s.state.position {
+		return driverSQL.ErrNoProjectionRequired
+	}
+
+	return s.executor.project(ctx, &s.state, metadata.NewMatcher())
+}
@@ -0,0 +1,524 @@
+// +build unit
+
+package inmemory_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/driver/inmemory"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const projectorStream goengine.StreamName = "event_stream"
+
+var errProjectionBoom = errors.New("boom")
+
+type (
+	projectedDeposit struct {
+		Amount int
+	}
+
+	projectedBoom struct{}
+
+	depositProjectionState struct {
+		Total int `json:"total"`
+		Times int `json:"times"`
+	}
+
+	// depositProjection is a goengine.ProjectionSaga summing the deposits
+	depositProjection struct{}
+
+	// cashbackProjection is a depositProjection that appends a deposit of a tenth of every deposit of 10 or more
+	cashbackProjection struct {
+		depositProjection
+
+		t     *testing.T
+		store *inmemory.EventStore
+	}
+)
+
+func (*depositProjection) Name() string {
+	return "deposits"
+}
+
+func (*depositProjection) FromStream() goengine.StreamName {
+	return projectorStream
+}
+
+func (*depositProjection) Init(ctx context.Context) (interface{}, error) {
+	return depositProjectionState{}, nil
+}
+
+func (*depositProjection) Handlers() map[string]goengine.MessageHandler {
+	return map[string]goengine.MessageHandler{
+		"deposit": func(ctx context.Context, state interface{}, message goengine.Message) (interface{}, error) {
+			s := state.(depositProjectionState)
+			s.Total += message.Payload().(projectedDeposit).Amount
+			s.Times++
+
+			return s, nil
+		},
+		"boom": func(ctx context.Context, state interface{}, message goengine.Message) (interface{}, error) {
+			return nil, errProjectionBoom
+		},
+	}
+}
+
+func (p *cashbackProjection) Handlers() map[string]goengine.MessageHandler {
+	deposit := p.depositProjection.Handlers()["deposit"]
+
+	return map[string]goengine.MessageHandler{
+		"deposit": func(ctx context.Context, state interface{}, message goengine.Message) (interface{}, error) {
+			if amount := message.Payload().(projectedDeposit).Amount; amount >= 10 {
+				aggregateID := message.Metadata().Value(aggregate.IDKey).(string)
+				cashback := projectorMessages(p.t, "account", aggregateID, projectedDeposit{Amount: amount / 10})
+				if err := p.store.AppendTo(ctx, projectorStream, cashback); err != nil {
+					return nil, err
+				}
+			}
+
+			return deposit(ctx, state, message)
+		},
+	}
+}
+
+func (*depositProjection) DecodeState(data []byte) (interface{}, error) {
+	var state depositProjectionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func (*depositProjection) EncodeState(obj interface{}) ([]byte, error) {
+	return json.Marshal(obj)
+}
+
+func TestNewStreamProjector(t *testing.T) {
+	store := inmemory.NewEventStore(nil)
+	resolver := &inmemory.PayloadRegistry{}
+	errorHandler := func(error, *driverSQL.ProjectionNotification) driverSQL.ProjectionErrorAction {
+		return driverSQL.ProjectionFail
+	}
+
+	t.Run("Create projector", func(t *testing.T) {
+		projector, err := inmemory.NewStreamProjector(store, resolver, &depositProjection{}, errorHandler, inmemory.NotifySynchronous, nil)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, projector)
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		testCases := []struct {
+			title         string
+			store         *inmemory.EventStore
+			resolver      goengine.MessagePayloadResolver
+			projection    goengine.Projection
+			errorHandler  driverSQL.ProjectionErrorCallback
+			mode          inmemory.NotificationMode
+			expectedError error
+		}{
+			{"store", nil, resolver, &depositProjection{}, errorHandler, inmemory.NotifySynchronous, goengine.InvalidArgumentError("store")},
+			{"resolver", store, nil, &depositProjection{}, errorHandler, inmemory.NotifySynchronous, goengine.InvalidArgumentError("resolver")},
+			{"projection", store, resolver, nil, errorHandler, inmemory.NotifySynchronous, goengine.InvalidArgumentError("projection")},
+			{"error handler", store, resolver, &depositProjection{}, nil, inmemory.NotifySynchronous, goengine.InvalidArgumentError("projectionErrorHandler")},
+			{"mode", store, resolver, &depositProjection{}, errorHandler, inmemory.NotificationMode(-1), goengine.InvalidArgumentError("mode")},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				projector, err := inmemory.NewStreamProjector(
+					testCase.store,
+					testCase.resolver,
+					testCase.projection,
+					testCase.errorHandler,
+					testCase.mode,
+					nil,
+				)
+
+				assert.Equal(t, testCase.expectedError, err)
+				assert.Nil(t, projector)
+			})
+		}
+	})
+}
+
+func TestStreamProjector_Run(t *testing.T) {
+	ctx := context.Background()
+	store, resolver := createProjectorEventStore(t)
+	appendProjectorMessages(t, store, "account", goengine.UUID{1}.String(),
+		projectedDeposit{Amount: 10},
+		projectedDeposit{Amount: 5},
+	)
+
+	projector, err := inmemory.NewStreamProjector(store, resolver, &depositProjection{}, failProjection, inmemory.NotifySynchronous, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, projector.Run(ctx))
+	assertStreamProjectionState(t, projector, 2, depositProjectionState{Total: 15, Times: 2})
+
+	// A second run only projects the new messages
+	appendProjectorMessages(t, store, "account", goengine.UUID{1}.String(), projectedDeposit{Amount: 1})
+	require.NoError(t, projector.Run(ctx))
+	assertStreamProjectionState(t, projector, 3, depositProjectionState{Total: 16, Times: 3})
+}
+
+func TestStreamProjector_RunAndListen(t *testing.T) {
+	t.Run("Project synchronously", func(t *testing.T) {
+		store, resolver := createProjectorEventStore(t)
+		appendProjectorMessages(t, store, "account", goengine.UUID{1}.String(), projectedDeposit{Amount: 10})
+
+		projector, err := inmemory.NewStreamProjector(store, resolver, &depositProjection{}, failProjection, inmemory.NotifySynchronous, nil)
+		require.NoError(t, err)
+
+		stop := runInBackground(t, projector.RunAndListen)
+		defer stop()
+
+		waitForStreamProjection(t, projector, 1)
+
+		// The messages are projected before AppendTo returns
+		appendProjectorMessages(t, store, "account", goengine.UUID{1}.String(), projectedDeposit{Amount: 5}, projectedDeposit{Amount: 1})
+		assertStreamProjectionState(t, projector, 3, depositProjectionState{Total: 16, Times: 3})
+	})
+
+	t.Run("Project messages appended by a handler synchronously", func(t *testing.T) {
+		store, resolver := createProjectorEventStore(t)
+		appendProjectorMessages(t, store, "account", goengine.UUID{1}.String(), projectedDeposit{Amount: 1})
+
+		projection := &cashbackProjection{t: t, store: store}
+		projector, err := inmemory.NewStreamProjector(store, resolver, projection, failProjection, inmemory.NotifySynchronous, nil)
+		require.NoError(t, err)
+
+		stop := runInBackground(t, projector.RunAndListen)
+		defer stop()
+
+		waitForStreamProjection(t, projector, 1)
+
+		// The messages appended by the handler are projected before AppendTo returns
+		appendProjectorMessages(t, store, "account", goengine.UUID{1}.String(), projectedDeposit{Amount: 100})
+		assertStreamProjectionState(t, projector, 4, depositProjectionState{Total: 112, Times: 4})
+	})
+
+	t.Run("Project asynchronously", func(t *testing.T) {
+		store, resolver := createProjectorEventStore(t)
+
+		projector, err := inmemory.NewStreamProjector(store, resolver, &depositProjection{}, failProjection, inmemory.NotifyAsynchronous, nil)
+		require.NoError(t, err)
+
+		stop := runInBackground(t, projector.RunAndListen)
+		defer stop()
+
+		appendProjectorMessages(t, store, "account", goengine.UUID{1}.String(), projectedDeposit{Amount: 5}, projectedDeposit{Amount: 1})
+
+		waitForStreamProjection(t, projector, 2)
+		assertStreamProjectionState(t, projector, 2, depositProjectionState{Total: 6, Times: 2})
+	})
+
+	t.Run("Stop on projection failure", func(t *testing.T) {
+		store, resolver := createProjectorEventStore(t)
+
+		var callbackErr error
+		projector, err := inmemory.NewStreamProjector(
+			store,
+			resolver,
+			&depositProjection{},
+			func(err error, notification *driverSQL.ProjectionNotification) driverSQL.ProjectionErrorAction {
+				callbackErr = err
+				return driverSQL.ProjectionFail
+			},
+			inmemory.NotifySynchronous,
+			nil,
+		)
+		require.NoError(t, err)
+
+		appendProjectorMessages(t, store, "account", goengine.UUID{1}.String(), projectedDeposit{Amount: 5}, projectedBoom{})
+
+		err = projector.RunAndListen(context.Background())
+
+		if assert.IsType(t, &driverSQL.ProjectionHandlerError{}, err) {
+			assert.Equal(t, errProjectionBoom, err.(*driverSQL.ProjectionHandlerError).Cause())
+		}
+		assert.Equal(t, errProjectionBoom, callbackErr)
+		assertStreamProjectionState(t, projector, 1, depositProjectionState{Total: 5, Times: 1})
+	})
+
+	t.Run("Ignore projection failure", func(t *testing.T) {
+		store, resolver := createProjectorEventStore(t)
+
+		projector, err := inmemory.NewStreamProjector(
+			store,
+			resolver,
+			&depositProjection{},
+			func(error, *driverSQL.ProjectionNotification) driverSQL.ProjectionErrorAction {
+				return driverSQL.ProjectionIgnoreError
+			},
+			inmemory.NotifySynchronous,
+			nil,
+		)
+		require.NoError(t, err)
+
+		stop := runInBackground(t, projector.RunAndListen)
+		defer stop()
+
+		appendProjectorMessages(t, store, "account", goengine.UUID{1}.String(), projectedDeposit{Amount: 5}, projectedBoom{})
+		waitForStreamProjection(t, projector, 1)
+
+		assertStreamProjectionState(t, projector, 1, depositProjectionState{Total: 5, Times: 1})
+	})
+}
+
+func TestAggregateProjector_Run(t *testing.T) {
+	ctx := context.Background()
+	store, resolver := createProjectorEventStore(t)
+	firstID, secondID := goengine.UUID{1}.String(), goengine.UUID{2}.String()
+
+	appendProjectorMessages(t, store, "account", firstID, projectedDeposit{Amount: 10})
+	appendProjectorMessages(t, store, "account", secondID, projectedDeposit{Amount: 3})
+	appendProjectorMessages(t, store, "other", firstID, projectedDeposit{Amount: 100})
+	appendProjectorMessages(t, store, "account", firstID, projectedDeposit{Amount: 5})
+
+	projector, err := inmemory.NewAggregateProjector(store, resolver, "account", &depositProjection{}, failProjection, inmemory.NotifySynchronous, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, projector.Run(ctx))
+
+	assertAggregateProjectionState(t, projector, firstID, 4, depositProjectionState{Total: 15, Times: 2})
+	assertAggregateProjectionState(t, projector, secondID, 2, depositProjectionState{Total: 3, Times: 1})
+}
+
+func TestAggregateProjector_RunAndListen(t *testing.T) {
+	t.Run("Project synchronously", func(t *testing.T) {
+		store, resolver := createProjectorEventStore(t)
+		firstID, secondID := goengine.UUID{1}.String(), goengine.UUID{2}.String()
+
+		appendProjectorMessages(t, store, "account", firstID, projectedDeposit{Amount: 10})
+
+		projector, err := inmemory.NewAggregateProjector(store, resolver, "account", &depositProjection{}, failProjection, inmemory.NotifySynchronous, nil)
+		require.NoError(t, err)
+
+		stop := runInBackground(t, projector.RunAndListen)
+		defer stop()
+
+		waitForAggregateProjection(t, projector, firstID, 1)
+
+		// The messages are projected before AppendTo returns
+		appendProjectorMessages(t, store, "account", secondID, projectedDeposit{Amount: 3})
+		appendProjectorMessages(t, store, "account", firstID, projectedDeposit{Amount: 5})
+
+		assertAggregateProjectionState(t, projector, firstID, 3, depositProjectionState{Total: 15, Times: 2})
+		assertAggregateProjectionState(t, projector, secondID, 2, depositProjectionState{Total: 3, Times: 1})
+	})
+
+	t.Run("Project messages appended by a handler synchronously", func(t *testing.T) {
+		store, resolver := createProjectorEventStore(t)
+		firstID, secondID := goengine.UUID{1}.String(), goengine.UUID{2}.String()
+
+		appendProjectorMessages(t, store, "account", secondID, projectedDeposit{Amount: 1})
+
+		projection := &cashbackProjection{t: t, store: store}
+		projector, err := inmemory.NewAggregateProjector(store, resolver, "account", projection, failProjection, inmemory.NotifySynchronous, nil)
+		require.NoError(t, err)
+
+		stop := runInBackground(t, projector.RunAndListen)
+		defer stop()
+
+		waitForAggregateProjection(t, projector, secondID, 1)
+
+		// The messages appended by the handler are projected before AppendTo returns
+		appendProjectorMessages(t, store, "account", firstID, projectedDeposit{Amount: 100})
+		assertAggregateProjectionState(t, projector, firstID, 4, depositProjectionState{Total: 111, Times: 3})
+	})
+
+	t.Run("Project asynchronously", func(t *testing.T) {
+		store, resolver := createProjectorEventStore(t)
+		firstID := goengine.UUID{1}.String()
+
+		projector, err := inmemory.NewAggregateProjector(store, resolver, "account", &depositProjection{}, failProjection, inmemory.NotifyAsynchronous, nil)
+		require.NoError(t, err)
+
+		stop := runInBackground(t, projector.RunAndListen)
+		defer stop()
+
+		appendProjectorMessages(t, store, "account", firstID, projectedDeposit{Amount: 10}, projectedDeposit{Amount: 5})
+
+		waitForAggregateProjection(t, projector, firstID, 2)
+		assertAggregateProjectionState(t, projector, firstID, 2, depositProjectionState{Total: 15, Times: 2})
+	})
+
+	t.Run("Mark failed aggregate projections", func(t *testing.T) {
+		store, resolver := createProjectorEventStore(t)
+		firstID, secondID := goengine.UUID{1}.String(), goengine.UUID{2}.String()
+
+		var notifications []*driverSQL.ProjectionNotification
+		projector, err := inmemory.NewAggregateProjector(
+			store,
+			resolver,
+			"account",
+			&depositProjection{},
+			func(err error, notification *driverSQL.ProjectionNotification) driverSQL.ProjectionErrorAction {
+				notifications = append(notifications, notification)
+				return driverSQL.ProjectionFail
+			},
+			inmemory.NotifySynchronous,
+			nil,
+		)
+		require.NoError(t, err)
+
+		appendProjectorMessages(t, store, "account", secondID, projectedDeposit{Amount: 1})
+
+		stop := runInBackground(t, projector.RunAndListen)
+		defer stop()
+
+		waitForAggregateProjection(t, projector, secondID, 1)
+
+		appendProjectorMessages(t, store, "account", firstID, projectedDeposit{Amount: 10}, projectedBoom{})
+		appendProjectorMessages(t, store, "account", firstID, projectedDeposit{Amount: 5})
+		appendProjectorMessages(t, store, "account", secondID, projectedDeposit{Amount: 3})
+
+		assert.True(t, projector.Failed(firstID))
+		assert.False(t, projector.Failed(secondID))
+		if assert.Len(t, notifications, 1) {
+			assert.Equal(t, &driverSQL.ProjectionNotification{No: 3, AggregateID: firstID}, notifications[0])
+		}
+
+		assertAggregateProjectionState(t, projector, firstID, 2, depositProjectionState{Total: 10, Times: 1})
+		assertAggregateProjectionState(t, projector, secondID, 5, depositProjectionState{Total: 4, Times: 2})
+	})
+
+	t.Run("Retry failed aggregate projections", func(t *testing.T) {
+		store, resolver := createProjectorEventStore(t)
+		firstID, secondID := goengine.UUID{1}.String(), goengine.UUID{2}.String()
+
+		var retries int
+		projector, err := inmemory.NewAggregateProjector(
+			store,
+			resolver,
+			"account",
+			&depositProjection{},
+			func(err error, notification *driverSQL.ProjectionNotification) driverSQL.ProjectionErrorAction {
+				retries++
+				if retries < 3 {
+					return driverSQL.ProjectionRetry
+				}
+				return driverSQL.ProjectionIgnoreError
+			},
+			inmemory.NotifySynchronous,
+			nil,
+		)
+		require.NoError(t, err)
+
+		// Wait for the initial run to ensure appended messages are projected synchronously
+		appendProjectorMessages(t, store, "account", secondID, projectedDeposit{Amount: 1})
+
+		stop := runInBackground(t, projector.RunAndListen)
+		defer stop()
+		waitForAggregateProjection(t, projector, secondID, 1)
+
+		appendProjectorMessages(t, store, "account", firstID, projectedDeposit{Amount: 10}, projectedBoom{})
+
+		assert.Equal(t, 3, retries)
+		assert.False(t, projector.Failed(firstID))
+		assertAggregateProjectionState(t, projector, firstID, 2, depositProjectionState{Total: 10, Times: 1})
+	})
+}
+
+func failProjection(error, *driverSQL.ProjectionNotification) driverSQL.ProjectionErrorAction {
+	return driverSQL.ProjectionFail
+}
+
+func createProjectorEventStore(t *testing.T) (*inmemory.EventStore, *inmemory.PayloadRegistry) {
+	store := inmemory.NewEventStore(nil)
+	require.NoError(t, store.Create(context.Background(), projectorStream))
+
+	resolver := &inmemory.PayloadRegistry{}
+	require.NoError(t, resolver.RegisterPayload("deposit", projectedDeposit{}))
+	require.NoError(t, resolver.RegisterPayload("boom", projectedBoom{}))
+
+	return store, resolver
+}
+
+func appendProjectorMessages(t *testing.T, store *inmemory.EventStore, aggregateType string, aggregateID string, payloads ...interface{}) {
+	messages := projectorMessages(t, aggregateType, aggregateID, payloads...)
+
+	require.NoError(t, store.AppendTo(context.Background(), projectorStream, messages))
+}
+
+func projectorMessages(t *testing.T, aggregateType string, aggregateID string, payloads ...interface{}) []goengine.Message {
+	messages := make([]goengine.Message, len(payloads))
+	for i, payload := range payloads {
+		meta := metadata.New()
+		meta = metadata.WithValue(meta, aggregate.TypeKey, aggregateType)
+		meta = metadata.WithValue(meta, aggregate.IDKey, aggregateID)
+
+		msg, err := aggregate.ReconstituteChange(aggregate.ID(aggregateID), goengine.GenerateUUID(), payload, meta, time.Now(), uint(i+1))
+		require.NoError(t, err)
+
+		messages[i] = msg
+	}
+
+	return messages
+}
+
+func runInBackground(t *testing.T, run func(ctx context.Context) error) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, run(ctx))
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func waitForStreamProjection(t *testing.T, projector *inmemory.StreamProjector, position int64) {
+	waitFor(t, func() bool {
+		state, err := projector.State(context.Background())
+		return err == nil && state.Position >= position
+	})
+}
+
+func waitForAggregateProjection(t *testing.T, projector *inmemory.AggregateProjector, aggregateID string, position int64) {
+	waitFor(t, func() bool {
+		state, err := projector.State(context.Background(), aggregateID)
+		return err == nil && state.Position >= position
+	})
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			require.FailNow(t, "condition was not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func assertStreamProjectionState(t *testing.T, projector *inmemory.StreamProjector, position int64, expected depositProjectionState) {
+	state, err := projector.State(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, position, state.Position)
+	assert.Equal(t, expected, state.ProjectionState)
+}
+
+func assertAggregateProjectionState(t *testing.T, projector *inmemory.AggregateProjector, aggregateID string, position int64, expected depositProjectionState) {
+	state, err := projector.State(context.Background(), aggregateID)
+	require.NoError(t, err)
+
+	assert.Equal(t, position, state.Position)
+	assert.Equal(t, expected, state.ProjectionState)
+}
@@ -0,0 +1,124 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/saga"
+)
+
+// Ensure that we satisfy the saga.Storage interface
+var _ saga.Storage = &SagaStorage{}
+
+// SagaStorage is an in memory saga.Storage implementation
+type SagaStorage struct {
+	sync.RWMutex
+
+	positions map[string]int64
+	instances map[string]map[string]saga.RawInstance
+	deadlines map[string]map[goengine.UUID]saga.Deadline
+}
+
+// NewSagaStorage returns a new inmemory.SagaStorage
+func NewSagaStorage() *SagaStorage {
+	return &SagaStorage{
+		positions: map[string]int64{},
+		instances: map[string]map[string]saga.RawInstance{},
+		deadlines: map[string]map[goengine.UUID]saga.Deadline{},
+	}
+}
+
+// LoadPosition returns the position within the event stream up to which the saga processed messages
+func (s *SagaStorage) LoadPosition(ctx context.Context, sagaName string) (int64, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.positions[sagaName], nil
+}
+
+// LoadInstance returns the raw saga instance or nil when the instance is unknown
+func (s *SagaStorage) LoadInstance(ctx context.Context, sagaName string, correlationID string) (*saga.RawInstance, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	instance, found := s.instances[sagaName][correlationID]
+	if !found {
+		return nil, nil
+	}
+
+	return &instance, nil
+}
+
+// LoadDueDeadlines returns the deadlines of the saga that are due at the provided time ordered by their due time
+func (s *SagaStorage) LoadDueDeadlines(ctx context.Context, sagaName string, now time.Time) ([]saga.Deadline, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	var due []saga.Deadline
+	for _, deadline := range s.deadlines[sagaName] {
+		if !deadline.DueAt.After(now) {
+			due = append(due, deadline)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].DueAt.Before(due[j].DueAt)
+	})
+
+	return due, nil
+}
+
+// Commit persists the provided changes
+func (s *SagaStorage) Commit(ctx context.Context, sagaName string, commit saga.Commit) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if commit.Position > 0 {
+		s.positions[sagaName] = commit.Position
+	}
+
+	deadlines, found := s.deadlines[sagaName]
+	if !found {
+		deadlines = map[goengine.UUID]saga.Deadline{}
+		s.deadlines[sagaName] = deadlines
+	}
+
+	if !goengine.IsUUIDEmpty(commit.FiredDeadline) {
+		delete(deadlines, commit.FiredDeadline)
+	}
+
+	if commit.CorrelationID == "" {
+		return nil
+	}
+
+	instances, found := s.instances[sagaName]
+	if !found {
+		instances = map[string]saga.RawInstance{}
+		s.instances[sagaName] = instances
+	}
+
+	state := make([]byte, len(commit.State))
+	copy(state, commit.State)
+	instances[commit.CorrelationID] = saga.RawInstance{
+		State:     state,
+		Completed: commit.Completed,
+	}
+
+	if commit.Completed {
+		for id, deadline := range deadlines {
+			if deadline.CorrelationID == commit.CorrelationID {
+				delete(deadlines, id)
+			}
+		}
+		return nil
+	}
+
+	for _, deadline := range commit.Deadlines {
+		deadlines[deadline.ID] = deadline
+	}
+
+	return nil
+}
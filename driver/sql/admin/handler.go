@@ -0,0 +1,268 @@
+// Package admin provides the status, health checks and admin actions of running projectors over http
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hellofresh/goengine"
+)
+
+// DefaultStaleThreshold is the time a notification may be queued or processed before a projection is considered stale
+const DefaultStaleThreshold = 5 * time.Minute
+
+// Ensure Handler implements http.Handler
+var _ http.Handler = &Handler{}
+
+type (
+	// Handler is a http.Handler exposing the status of projectors, health checks and admin actions.
+	//
+	// The following endpoints are provided:
+	//  GET  /status                          the status of all projections
+	//  GET  /health/live                     fails when a listener stopped with an error or a projection is stale
+	//  GET  /health/ready                    fails when a listener is not listening or not connected
+	//  POST /projections/{name}/catch-up     trigger the projector to project all missed events
+	//  POST /projections/{name}/pause        stop triggering the projector
+	//  POST /projections/{name}/resume       resume triggering the projector
+	// Use http.StripPrefix to mount the handler on a sub path.
+	Handler struct {
+		monitor        *Monitor
+		listeners      map[string]*Listener
+		staleThreshold time.Duration
+
+		logger goengine.Logger
+	}
+
+	// HandlerOption configures optional behaviour of a Handler
+	HandlerOption func(*Handler)
+
+	// ProjectionStatus is the status of a single projection
+	ProjectionStatus struct {
+		// Listener is nil when no Listener was registered for the projection
+		Listener *ListenerStatus `json:"listener,omitempty"`
+		Stats    ProjectionStats `json:"stats"`
+	}
+
+	statusResponse struct {
+		Projections map[string]ProjectionStatus `json:"projections"`
+	}
+
+	healthResponse struct {
+		Status      string   `json:"status"`
+		Projections []string `json:"projections,omitempty"`
+	}
+
+	errorResponse struct {
+		Error string `json:"error"`
+	}
+)
+
+// WithStaleThreshold sets the time a notification may be queued or processed before the projection is considered
+// stale and thus not alive. A threshold of zero disables the staleness check.
+func WithStaleThreshold(threshold time.Duration) HandlerOption {
+	return func(h *Handler) {
+		h.staleThreshold = threshold
+	}
+}
+
+// NewHandler returns a new Handler.
+// The monitor is optional, without it only the status of the listeners is reported and no staleness check is done.
+func NewHandler(monitor *Monitor, listeners []*Listener, logger goengine.Logger, options ...HandlerOption) (*Handler, error) {
+	listenerMap := make(map[string]*Listener, len(listeners))
+	for _, l := range listeners {
+		if l == nil {
+			return nil, goengine.InvalidArgumentError("listeners")
+		}
+
+		if _, found := listenerMap[l.ProjectionName()]; found {
+			return nil, goengine.InvalidArgumentError("listeners")
+		}
+		listenerMap[l.ProjectionName()] = l
+	}
+
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+
+	handler := &Handler{
+		monitor:        monitor,
+		listeners:      listenerMap,
+		staleThreshold: DefaultStaleThreshold,
+		logger:         logger,
+	}
+	for _, option := range options {
+		option(handler)
+	}
+
+	if handler.staleThreshold < 0 {
+		return nil, goengine.InvalidArgumentError("staleThreshold")
+	}
+
+	return handler, nil
+}
+
+// ServeHTTP routes the request to the endpoint
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	switch path {
+	case "status":
+		if h.allowMethod(w, r, http.MethodGet) {
+			h.writeJSON(w, http.StatusOK, statusResponse{Projections: h.Status()})
+		}
+		return
+	case "health/live":
+		if h.allowMethod(w, r, http.MethodGet) {
+			unhealthy := h.unhealthy(func(status ListenerStatus) bool {
+				return !status.Listening && status.StopError != ""
+			})
+			h.writeHealth(w, mergeNames(unhealthy, h.stale()))
+		}
+		return
+	case "health/ready":
+		if h.allowMethod(w, r, http.MethodGet) {
+			h.writeHealth(w, h.unhealthy(func(status ListenerStatus) bool {
+				return !status.Listening || (status.Connected != nil && !*status.Connected)
+			}))
+		}
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 3 || parts[0] != "projections" {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not found"})
+		return
+	}
+
+	if !h.allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	listener, found := h.listeners[parts[1]]
+	if !found {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"unknown projection"})
+		return
+	}
+
+	switch parts[2] {
+	case "catch-up":
+		if err := listener.CatchUp(); err != nil {
+			h.writeJSON(w, http.StatusConflict, errorResponse{err.Error()})
+			return
+		}
+		h.writeJSON(w, http.StatusAccepted, listener.Status())
+	case "pause":
+		listener.Pause()
+		h.writeJSON(w, http.StatusOK, listener.Status())
+	case "resume":
+		listener.Resume()
+		h.writeJSON(w, http.StatusOK, listener.Status())
+	default:
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"unknown action"})
+	}
+}
+
+// Status returns the status of all projections known to the monitor or with a registered listener
+func (h *Handler) Status() map[string]ProjectionStatus {
+	projections := map[string]ProjectionStatus{}
+	if h.monitor != nil {
+		for name, stats := range h.monitor.Projections() {
+			projections[name] = ProjectionStatus{Stats: stats}
+		}
+	}
+
+	for name, l := range h.listeners {
+		listenerStatus := l.Status()
+
+		status := projections[name]
+		status.Listener = &listenerStatus
+		projections[name] = status
+	}
+
+	return projections
+}
+
+// unhealthy returns the sorted names of the projections with a listener status that is unhealthy
+func (h *Handler) unhealthy(isUnhealthy func(status ListenerStatus) bool) []string {
+	var names []string
+	for name, l := range h.listeners {
+		if isUnhealthy(l.Status()) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// stale returns the sorted names of the projections with a notification that is queued or processed for longer than
+// the stale threshold
+func (h *Handler) stale() []string {
+	if h.monitor == nil || h.staleThreshold == 0 {
+		return nil
+	}
+
+	staleBefore := time.Now().Add(-h.staleThreshold)
+	isStale := func(t *time.Time) bool {
+		return t != nil && t.Before(staleBefore)
+	}
+
+	var names []string
+	for name, stats := range h.monitor.Projections() {
+		if isStale(stats.OldestQueuedAt) || isStale(stats.OldestProcessingAt) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// mergeNames returns the sorted and unique names of both lists
+func mergeNames(a, b []string) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	names := make([]string, 0, len(a)+len(b))
+	for _, list := range [][]string{a, b} {
+		for _, name := range list {
+			if _, found := seen[name]; !found {
+				seen[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (h *Handler) writeHealth(w http.ResponseWriter, unhealthy []string) {
+	if len(unhealthy) > 0 {
+		h.writeJSON(w, http.StatusServiceUnavailable, healthResponse{Status: "unavailable", Projections: unhealthy})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+func (h *Handler) allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+
+	w.Header().Set("Allow", method)
+	h.writeJSON(w, http.StatusMethodNotAllowed, errorResponse{"method not allowed"})
+	return false
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Warn("failed to write admin response", func(e goengine.LoggerEntry) {
+			e.Error(err)
+		})
+	}
+}
@@ -0,0 +1,296 @@
+// +build unit
+
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/driver/sql/admin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHandler(t *testing.T) {
+	listener, err := admin.NewListener("balance", &fakeListener{}, nil)
+	require.NoError(t, err)
+
+	_, err = admin.NewHandler(nil, []*admin.Listener{listener, listener}, nil)
+	assert.Equal(t, goengine.InvalidArgumentError("listeners"), err)
+
+	_, err = admin.NewHandler(nil, []*admin.Listener{nil}, nil)
+	assert.Equal(t, goengine.InvalidArgumentError("listeners"), err)
+
+	_, err = admin.NewHandler(nil, nil, nil, admin.WithStaleThreshold(-time.Second))
+	assert.Equal(t, goengine.InvalidArgumentError("staleThreshold"), err)
+}
+
+func TestMonitor(t *testing.T) {
+	monitor := admin.NewMonitor(nil)
+	notification := &driverSQL.ProjectionNotification{No: 3}
+
+	monitor.QueueProjectionNotification("balance", notification)
+	monitor.QueueProjectionNotification("balance", notification)
+	monitor.StartProjectionNotificationProcessing("balance", notification)
+	monitor.ExecuteProjectionHandler("balance", "account_debited", time.Millisecond, false)
+	monitor.CommitProjectionState("balance", 3, time.Millisecond, true)
+	monitor.CommitProjectionState("balance", 2, time.Millisecond, true)
+	monitor.CommitProjectionState("balance", 4, time.Millisecond, false)
+	monitor.FinishProjectionNotificationProcessing("balance", notification, time.Millisecond, false)
+	monitor.FailedToLockProjection("balance")
+
+	stats := monitor.Projections()["balance"]
+	require.NotNil(t, stats.LastCommitAt)
+	require.NotNil(t, stats.OldestQueuedAt)
+	assert.Nil(t, stats.OldestProcessingAt)
+	stats.LastCommitAt = nil
+	stats.OldestQueuedAt = nil
+
+	assert.Equal(t, admin.ProjectionStats{
+		QueueDepth:             1,
+		ProcessedNotifications: 1,
+		FailedNotifications:    1,
+		HandlerErrors:          1,
+		CommitErrors:           1,
+		LockFailures:           1,
+		Position:               3,
+	}, stats)
+}
+
+func TestMonitor_PendingNotifications(t *testing.T) {
+	monitor := admin.NewMonitor(nil)
+	first := &driverSQL.ProjectionNotification{No: 1}
+	second := &driverSQL.ProjectionNotification{No: 2}
+
+	// The processing of a notification can be recorded before it's queueing
+	monitor.StartProjectionNotificationProcessing("balance", first)
+	monitor.QueueProjectionNotification("balance", first)
+	monitor.QueueProjectionNotification("balance", second)
+
+	stats := monitor.Projections()["balance"]
+	assert.Equal(t, int64(1), stats.QueueDepth)
+	assert.Equal(t, int64(1), stats.ProcessingNotifications)
+	assert.NotNil(t, stats.OldestQueuedAt)
+	assert.NotNil(t, stats.OldestProcessingAt)
+
+	monitor.FinishProjectionNotificationProcessing("balance", first, time.Millisecond, true)
+
+	stats = monitor.Projections()["balance"]
+	assert.NotNil(t, stats.OldestQueuedAt)
+	assert.Nil(t, stats.OldestProcessingAt)
+
+	// Notifications that are still queued are dropped when the processor stops
+	monitor.StopProjectionNotificationProcessing("balance")
+
+	stats = monitor.Projections()["balance"]
+	assert.Equal(t, int64(0), stats.QueueDepth)
+	assert.Equal(t, int64(0), stats.ProcessingNotifications)
+	assert.Nil(t, stats.OldestQueuedAt)
+	assert.Nil(t, stats.OldestProcessingAt)
+}
+
+func TestMonitor_FinishAfterStop(t *testing.T) {
+	monitor := admin.NewMonitor(nil)
+	first := &driverSQL.ProjectionNotification{No: 1}
+	second := &driverSQL.ProjectionNotification{No: 2}
+
+	monitor.QueueProjectionNotification("balance", first)
+	monitor.StartProjectionNotificationProcessing("balance", first)
+	monitor.StopProjectionNotificationProcessing("balance")
+
+	// The processing of a notification that was in flight when the processing stopped finishes afterwards
+	monitor.FinishProjectionNotificationProcessing("balance", first, time.Millisecond, true)
+
+	stats := monitor.Projections()["balance"]
+	assert.Equal(t, int64(0), stats.ProcessingNotifications)
+	assert.Equal(t, int64(1), stats.ProcessedNotifications)
+	assert.Nil(t, stats.OldestProcessingAt)
+
+	// A restarted processor is not affected by the notification
+	monitor.QueueProjectionNotification("balance", second)
+	monitor.StartProjectionNotificationProcessing("balance", second)
+	monitor.FinishProjectionNotificationProcessing("balance", first, time.Millisecond, true)
+
+	stats = monitor.Projections()["balance"]
+	assert.Equal(t, int64(1), stats.ProcessingNotifications)
+	assert.NotNil(t, stats.OldestProcessingAt)
+
+	monitor.FinishProjectionNotificationProcessing("balance", second, time.Millisecond, true)
+
+	stats = monitor.Projections()["balance"]
+	assert.Equal(t, int64(0), stats.ProcessingNotifications)
+	assert.Equal(t, int64(3), stats.ProcessedNotifications)
+	assert.Nil(t, stats.OldestProcessingAt)
+}
+
+func TestMonitor_ForwardsToMetrics(t *testing.T) {
+	metrics := &notificationMetrics{}
+	monitor := admin.NewMonitor(metrics)
+	notification := &driverSQL.ProjectionNotification{No: 3}
+
+	monitor.ReceivedNotification(true)
+	monitor.QueueProjectionNotification("balance", notification)
+	monitor.StartProjectionNotificationProcessing("balance", notification)
+	monitor.FinishProjectionNotificationProcessing("balance", notification, time.Millisecond, true)
+	monitor.StopProjectionNotificationProcessing("balance")
+	monitor.CommitProjectionState("balance", 3, time.Millisecond, true)
+
+	assert.Equal(t, []string{"received", "queue", "start", "finish:true"}, metrics.calls)
+}
+
+// notificationMetrics records the calls to a driverSQL.Metrics that does not implement driverSQL.ProjectionMetrics
+type notificationMetrics struct {
+	calls []string
+}
+
+func (m *notificationMetrics) ReceivedNotification(bool) {
+	m.calls = append(m.calls, "received")
+}
+
+func (m *notificationMetrics) QueueNotification(*driverSQL.ProjectionNotification) {
+	m.calls = append(m.calls, "queue")
+}
+
+func (m *notificationMetrics) StartNotificationProcessing(*driverSQL.ProjectionNotification) {
+	m.calls = append(m.calls, "start")
+}
+
+func (m *notificationMetrics) FinishNotificationProcessing(_ *driverSQL.ProjectionNotification, success bool) {
+	m.calls = append(m.calls, fmt.Sprintf("finish:%t", success))
+}
+
+func TestHandler(t *testing.T) {
+	monitor := admin.NewMonitor(nil)
+	monitor.CommitProjectionState("balance", 12, time.Millisecond, true)
+
+	inner := &fakeListener{connected: true}
+	listener, err := admin.NewListener("balance", inner, nil)
+	require.NoError(t, err)
+
+	stoppedInner := &fakeListener{connected: true, stopErr: errors.New("listener failed")}
+	stoppedListener, err := admin.NewListener("report", stoppedInner, nil)
+	require.NoError(t, err)
+
+	handler, err := admin.NewHandler(monitor, []*admin.Listener{listener, stoppedListener}, nil)
+	require.NoError(t, err)
+
+	triggers := make(chan *driverSQL.ProjectionNotification, 10)
+	stop := startListening(t, listener, inner, func(ctx context.Context, notification *driverSQL.ProjectionNotification) error {
+		triggers <- notification
+		return nil
+	})
+	defer stop()
+
+	t.Run("status", func(t *testing.T) {
+		res := serve(handler, http.MethodGet, "/status")
+		require.Equal(t, http.StatusOK, res.Code)
+
+		var body struct {
+			Projections map[string]struct {
+				Listener *admin.ListenerStatus
+				Stats    admin.ProjectionStats
+			}
+		}
+		require.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+
+		require.Contains(t, body.Projections, "balance")
+		assert.Equal(t, int64(12), body.Projections["balance"].Stats.Position)
+		require.NotNil(t, body.Projections["balance"].Listener)
+		assert.True(t, body.Projections["balance"].Listener.Listening)
+
+		require.Contains(t, body.Projections, "report")
+		assert.False(t, body.Projections["report"].Listener.Listening)
+	})
+
+	t.Run("health", func(t *testing.T) {
+		res := serve(handler, http.MethodGet, "/health/live")
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, res.Body.String())
+
+		res = serve(handler, http.MethodGet, "/health/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+		assert.JSONEq(t, `{"status":"unavailable","projections":["report"]}`, res.Body.String())
+
+		// A listener that stopped with an error is not alive
+		stopReport := startListening(t, stoppedListener, stoppedInner, func(context.Context, *driverSQL.ProjectionNotification) error {
+			return nil
+		})
+		assert.Equal(t, http.StatusOK, serve(handler, http.MethodGet, "/health/ready").Code)
+		require.Error(t, stopReport())
+
+		res = serve(handler, http.MethodGet, "/health/live")
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+		assert.JSONEq(t, `{"status":"unavailable","projections":["report"]}`, res.Body.String())
+	})
+
+	t.Run("stale projection", func(t *testing.T) {
+		staleMonitor := admin.NewMonitor(nil)
+		staleMonitor.QueueProjectionNotification("balance", &driverSQL.ProjectionNotification{No: 1})
+
+		staleHandler, err := admin.NewHandler(staleMonitor, []*admin.Listener{listener}, nil, admin.WithStaleThreshold(time.Millisecond))
+		require.NoError(t, err)
+
+		disabledHandler, err := admin.NewHandler(staleMonitor, []*admin.Listener{listener}, nil, admin.WithStaleThreshold(0))
+		require.NoError(t, err)
+
+		time.Sleep(5 * time.Millisecond)
+
+		res := serve(staleHandler, http.MethodGet, "/health/live")
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+		assert.JSONEq(t, `{"status":"unavailable","projections":["balance"]}`, res.Body.String())
+
+		assert.Equal(t, http.StatusOK, serve(disabledHandler, http.MethodGet, "/health/live").Code)
+	})
+
+	t.Run("pause, resume and catch-up", func(t *testing.T) {
+		res := serve(handler, http.MethodPost, "/projections/balance/pause")
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.True(t, listener.Status().Paused)
+
+		res = serve(handler, http.MethodPost, "/projections/balance/catch-up")
+		assert.Equal(t, http.StatusConflict, res.Code)
+
+		res = serve(handler, http.MethodPost, "/projections/balance/resume")
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.False(t, listener.Status().Paused)
+
+		res = serve(handler, http.MethodPost, "/projections/balance/catch-up")
+		assert.Equal(t, http.StatusAccepted, res.Code)
+		assert.Nil(t, receive(t, triggers))
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		testCases := []struct {
+			method       string
+			path         string
+			expectedCode int
+		}{
+			{http.MethodPost, "/status", http.StatusMethodNotAllowed},
+			{http.MethodGet, "/projections/balance/pause", http.StatusMethodNotAllowed},
+			{http.MethodPost, "/projections/unknown/pause", http.StatusNotFound},
+			{http.MethodPost, "/projections/balance/drop", http.StatusNotFound},
+			{http.MethodGet, "/unknown", http.StatusNotFound},
+		}
+
+		for _, testCase := range testCases {
+			res := serve(handler, testCase.method, testCase.path)
+			assert.Equal(t, testCase.expectedCode, res.Code, "%s %s", testCase.method, testCase.path)
+			assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+		}
+	})
+}
+
+func serve(handler http.Handler, method, path string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(method, path, nil))
+
+	return res
+}
@@ -0,0 +1,250 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
+)
+
+var (
+	// ErrAlreadyListening occurs when Listen is called while the Listener is already listening
+	ErrAlreadyListening = errors.New("goengine: listener is already listening")
+	// ErrNotListening occurs when a catch-up is requested for a Listener that is not listening
+	ErrNotListening = errors.New("goengine: listener is not listening")
+	// ErrListenerPaused occurs when a catch-up is requested for a paused Listener
+	ErrListenerPaused = errors.New("goengine: listener is paused")
+
+	// Ensure Listener implements driverSQL.Listener
+	_ driverSQL.Listener = &Listener{}
+)
+
+type (
+	// ConnectionStater is implemented by a driverSQL.Listener that knows whether it's connected to the database
+	ConnectionStater interface {
+		// Connected returns true when the listener is connected to the database
+		Connected() bool
+	}
+
+	// Listener wraps the driverSQL.Listener of a projector in order to report it's status and to pause, resume or
+	// catch-up the projector.
+	// The wrapped listener is passed to StreamProjector.RunAndListen or AggregateProjector.RunAndListen.
+	Listener struct {
+		projectionName string
+		listener       driverSQL.Listener
+
+		logger goengine.Logger
+
+		// triggerLock ensures the projector is never triggered concurrently by the listener and a catch-up
+		triggerLock sync.Mutex
+		catchUp     chan struct{}
+
+		mu             sync.Mutex
+		listening      bool
+		paused         bool
+		pending        bool
+		stopErr        error
+		triggerErrors  int64
+		lastTriggerErr error
+		lastTriggerAt  time.Time
+	}
+
+	// ListenerStatus is the status of a Listener
+	ListenerStatus struct {
+		Listening bool `json:"listening"`
+		// Connected is nil when the wrapped listener does not implement ConnectionStater
+		Connected *bool `json:"connected,omitempty"`
+		Paused    bool  `json:"paused"`
+		// CatchUpPending is true when notifications where received while the Listener was paused
+		CatchUpPending   bool       `json:"catch_up_pending"`
+		LastTriggerAt    *time.Time `json:"last_trigger_at,omitempty"`
+		TriggerErrors    int64      `json:"trigger_errors"`
+		LastTriggerError string     `json:"last_trigger_error,omitempty"`
+		// StopError is the error returned by the last call to Listen
+		StopError string `json:"stop_error,omitempty"`
+	}
+)
+
+// NewListener returns a new Listener for the projection
+func NewListener(projectionName string, listener driverSQL.Listener, logger goengine.Logger) (*Listener, error) {
+	switch {
+	case strings.TrimSpace(projectionName) == "":
+		return nil, goengine.InvalidArgumentError("projectionName")
+	case listener == nil:
+		return nil, goengine.InvalidArgumentError("listener")
+	}
+
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+
+	return &Listener{
+		projectionName: projectionName,
+		listener:       listener,
+		logger: logger.WithFields(func(e goengine.LoggerEntry) {
+			e.String("projection", projectionName)
+		}),
+		catchUp: make(chan struct{}, 1),
+	}, nil
+}
+
+// ProjectionName returns the name of the projection the listener triggers
+func (l *Listener) ProjectionName() string {
+	return l.projectionName
+}
+
+// Listen starts the wrapped listener and processes the requested catch-ups until the wrapped listener stops
+func (l *Listener) Listen(ctx context.Context, trigger driverSQL.ProjectionTrigger) error {
+	l.mu.Lock()
+	if l.listening {
+		l.mu.Unlock()
+		return ErrAlreadyListening
+	}
+	l.listening = true
+	l.stopErr = nil
+	l.mu.Unlock()
+
+	catchUpCtx, stopCatchUp := context.WithCancel(ctx)
+	catchUpDone := make(chan struct{})
+	go func() {
+		defer close(catchUpDone)
+		l.processCatchUps(catchUpCtx, trigger)
+	}()
+
+	err := l.listener.Listen(ctx, func(ctx context.Context, notification *driverSQL.ProjectionNotification) error {
+		return l.execute(ctx, trigger, notification)
+	})
+
+	stopCatchUp()
+	<-catchUpDone
+
+	l.mu.Lock()
+	l.listening = false
+	l.stopErr = err
+	l.mu.Unlock()
+
+	return err
+}
+
+// CatchUp requests the projector to be triggered without a notification, this will project all missed events.
+// The catch-up is executed in the background.
+func (l *Listener) CatchUp() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch {
+	case !l.listening:
+		return ErrNotListening
+	case l.paused:
+		return ErrListenerPaused
+	}
+
+	l.requestCatchUp()
+	return nil
+}
+
+// Pause stops triggering the projector.
+// A trigger that is in progress and notifications that are already queued by the projector are still processed.
+func (l *Listener) Pause() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.paused = true
+	l.logger.Info("projection listener paused", nil)
+}
+
+// Resume starts triggering the projector again and requests a catch-up when notifications were received while paused
+func (l *Listener) Resume() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.paused = false
+	if l.pending {
+		l.pending = false
+		l.requestCatchUp()
+	}
+	l.logger.Info("projection listener resumed", nil)
+}
+
+// Status returns the status of the listener
+func (l *Listener) Status() ListenerStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	status := ListenerStatus{
+		Listening:      l.listening,
+		Paused:         l.paused,
+		CatchUpPending: l.pending,
+		TriggerErrors:  l.triggerErrors,
+	}
+
+	if c, ok := l.listener.(ConnectionStater); ok {
+		connected := l.listening && c.Connected()
+		status.Connected = &connected
+	}
+	if !l.lastTriggerAt.IsZero() {
+		lastTriggerAt := l.lastTriggerAt
+		status.LastTriggerAt = &lastTriggerAt
+	}
+	if l.lastTriggerErr != nil {
+		status.LastTriggerError = l.lastTriggerErr.Error()
+	}
+	if l.stopErr != nil {
+		status.StopError = l.stopErr.Error()
+	}
+
+	return status
+}
+
+// requestCatchUp signals the catch-up processor, multiple requests are merged into one catch-up
+func (l *Listener) requestCatchUp() {
+	select {
+	case l.catchUp <- struct{}{}:
+	default:
+	}
+}
+
+func (l *Listener) processCatchUps(ctx context.Context, trigger driverSQL.ProjectionTrigger) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.catchUp:
+			l.logger.Debug("executing projection catch-up", nil)
+			if err := l.execute(ctx, trigger, nil); err != nil {
+				l.logger.Error("projection catch-up failed", func(e goengine.LoggerEntry) {
+					e.Error(err)
+				})
+			}
+		}
+	}
+}
+
+// execute calls the trigger unless the listener is paused
+func (l *Listener) execute(ctx context.Context, trigger driverSQL.ProjectionTrigger, notification *driverSQL.ProjectionNotification) error {
+	l.mu.Lock()
+	l.lastTriggerAt = time.Now()
+	if l.paused {
+		l.pending = true
+		l.mu.Unlock()
+		return nil
+	}
+	l.mu.Unlock()
+
+	l.triggerLock.Lock()
+	err := trigger(ctx, notification)
+	l.triggerLock.Unlock()
+
+	if err != nil {
+		l.mu.Lock()
+		l.triggerErrors++
+		l.lastTriggerErr = err
+		l.mu.Unlock()
+	}
+
+	return err
+}
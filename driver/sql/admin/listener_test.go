@@ -0,0 +1,167 @@
+// +build unit
+
+package admin_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/driver/sql/admin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewListener(t *testing.T) {
+	_, err := admin.NewListener(" ", &fakeListener{}, nil)
+	assert.Equal(t, goengine.InvalidArgumentError("projectionName"), err)
+
+	_, err = admin.NewListener("balance", nil, nil)
+	assert.Equal(t, goengine.InvalidArgumentError("listener"), err)
+}
+
+func TestListener(t *testing.T) {
+	t.Run("pause, resume and catch-up", func(t *testing.T) {
+		inner := &fakeListener{connected: true}
+		listener, err := admin.NewListener("balance", inner, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, admin.ErrNotListening, listener.CatchUp())
+
+		triggers := make(chan *driverSQL.ProjectionNotification, 10)
+		stop := startListening(t, listener, inner, func(ctx context.Context, notification *driverSQL.ProjectionNotification) error {
+			triggers <- notification
+			return nil
+		})
+		defer stop()
+
+		status := listener.Status()
+		assert.True(t, status.Listening)
+		require.NotNil(t, status.Connected)
+		assert.True(t, *status.Connected)
+
+		// Notifications are passed to the projector
+		notification := &driverSQL.ProjectionNotification{No: 1, AggregateID: "abc"}
+		require.NoError(t, inner.notify(notification))
+		assert.Equal(t, notification, <-triggers)
+
+		// Notifications are dropped while paused
+		listener.Pause()
+		require.NoError(t, inner.notify(&driverSQL.ProjectionNotification{No: 2}))
+		assert.Equal(t, admin.ErrListenerPaused, listener.CatchUp())
+		assert.True(t, listener.Status().CatchUpPending)
+		assert.Len(t, triggers, 0)
+
+		// Resuming triggers a catch-up
+		listener.Resume()
+		assert.Nil(t, receive(t, triggers))
+		assert.False(t, listener.Status().CatchUpPending)
+
+		// Catch-up triggers the projector without a notification
+		require.NoError(t, listener.CatchUp())
+		assert.Nil(t, receive(t, triggers))
+	})
+
+	t.Run("report trigger errors and stop errors", func(t *testing.T) {
+		expectedErr := errors.New("projection failed")
+		stopErr := errors.New("listener failed")
+
+		inner := &fakeListener{connected: true, stopErr: stopErr}
+		listener, err := admin.NewListener("balance", inner, nil)
+		require.NoError(t, err)
+
+		stop := startListening(t, listener, inner, func(ctx context.Context, notification *driverSQL.ProjectionNotification) error {
+			return expectedErr
+		})
+
+		assert.Equal(t, expectedErr, inner.notify(nil))
+		assert.Equal(t, admin.ErrAlreadyListening, listener.Listen(context.Background(), nil))
+
+		assert.Equal(t, stopErr, stop())
+
+		status := listener.Status()
+		assert.False(t, status.Listening)
+		require.NotNil(t, status.Connected)
+		assert.False(t, *status.Connected, "a listener that is not listening is not connected")
+		assert.Equal(t, int64(1), status.TriggerErrors)
+		assert.Equal(t, expectedErr.Error(), status.LastTriggerError)
+		assert.Equal(t, stopErr.Error(), status.StopError)
+		assert.NotNil(t, status.LastTriggerAt)
+	})
+}
+
+// fakeListener is a driverSQL.Listener that triggers the projector when notify is called
+type fakeListener struct {
+	mu        sync.Mutex
+	ctx       context.Context
+	trigger   driverSQL.ProjectionTrigger
+	connected bool
+	stopErr   error
+}
+
+func (f *fakeListener) Listen(ctx context.Context, trigger driverSQL.ProjectionTrigger) error {
+	f.mu.Lock()
+	f.ctx, f.trigger = ctx, trigger
+	f.mu.Unlock()
+
+	<-ctx.Done()
+	return f.stopErr
+}
+
+func (f *fakeListener) Connected() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.connected
+}
+
+func (f *fakeListener) notify(notification *driverSQL.ProjectionNotification) error {
+	f.mu.Lock()
+	ctx, trigger := f.ctx, f.trigger
+	f.mu.Unlock()
+
+	return trigger(ctx, notification)
+}
+
+func (f *fakeListener) listening() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.trigger != nil
+}
+
+// startListening runs listener.Listen in the background until the returned func is called
+func startListening(t *testing.T, listener *admin.Listener, inner *fakeListener, trigger driverSQL.ProjectionTrigger) func() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- listener.Listen(ctx, trigger)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !inner.listening() {
+		if time.Now().After(deadline) {
+			require.FailNow(t, "expected the listener to start listening")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	return func() error {
+		cancel()
+		return <-done
+	}
+}
+
+func receive(t *testing.T, triggers chan *driverSQL.ProjectionNotification) *driverSQL.ProjectionNotification {
+	select {
+	case notification := <-triggers:
+		return notification
+	case <-time.After(time.Second):
+		require.FailNow(t, "expected the projector to be triggered")
+		return nil
+	}
+}
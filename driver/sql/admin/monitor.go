@@ -0,0 +1,336 @@
+package admin
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
+)
+
+var (
+	// Ensure that Monitor satisfies the driverSQL.Metrics interface
+	_ driverSQL.Metrics = &Monitor{}
+	// Ensure that Monitor satisfies the driverSQL.ProjectionMetrics interface
+	_ driverSQL.ProjectionMetrics = &Monitor{}
+	// Ensure that Monitor satisfies the driverSQL.EventStoreMetrics interface
+	_ driverSQL.EventStoreMetrics = &Monitor{}
+)
+
+type (
+	// Monitor is a driverSQL.ProjectionMetrics that keeps track of the status of projections.
+	// All calls are forwarded to the wrapped driverSQL.Metrics so a Monitor can be combined with for example prometheus.
+	// Projection and event store calls are only forwarded when the wrapped metrics implement
+	// driverSQL.ProjectionMetrics or driverSQL.EventStoreMetrics.
+	Monitor struct {
+		metrics           driverSQL.Metrics
+		projectionMetrics driverSQL.ProjectionMetrics
+		eventStoreMetrics driverSQL.EventStoreMetrics
+
+		mu          sync.RWMutex
+		projections map[string]*projectionState
+	}
+
+	// ProjectionStats contains the statistics of a projection since the start of the process
+	ProjectionStats struct {
+		// QueueDepth is the amount of notifications waiting in the queue of the background processor
+		QueueDepth int64 `json:"queue_depth"`
+		// ProcessingNotifications is the amount of notifications that are being processed
+		ProcessingNotifications int64 `json:"processing_notifications"`
+		ProcessedNotifications  int64 `json:"processed_notifications"`
+		FailedNotifications     int64 `json:"failed_notifications"`
+
+		HandlerErrors int64 `json:"handler_errors"`
+		CommitErrors  int64 `json:"commit_errors"`
+		LockFailures  int64 `json:"lock_failures"`
+
+		// Position is the highest event number committed by the projection
+		Position     int64      `json:"position"`
+		LastCommitAt *time.Time `json:"last_commit_at,omitempty"`
+
+		// OldestQueuedAt is the time the notification that is waiting the longest in the queue was queued
+		OldestQueuedAt *time.Time `json:"oldest_queued_at,omitempty"`
+		// OldestProcessingAt is the time the processing of the longest running notification started
+		OldestProcessingAt *time.Time `json:"oldest_processing_at,omitempty"`
+	}
+
+	// projectionState contains the statistics and the pending notifications of a projection
+	projectionState struct {
+		stats      ProjectionStats
+		queued     pendingNotifications
+		processing pendingNotifications
+	}
+
+	// pendingNotifications keeps track of the time since when notifications are pending
+	pendingNotifications map[*driverSQL.ProjectionNotification]*pendingNotification
+
+	// pendingNotification is the amount of times a notification is pending and the time it became pending.
+	// The count is negative when a notification was removed before it was added.
+	pendingNotification struct {
+		count int
+		since time.Time
+	}
+)
+
+// NewMonitor returns a new Monitor that forwards all calls to metrics
+func NewMonitor(metrics driverSQL.Metrics) *Monitor {
+	if metrics == nil {
+		metrics = driverSQL.NopMetrics
+	}
+
+	monitor := &Monitor{
+		metrics:     metrics,
+		projections: map[string]*projectionState{},
+	}
+	monitor.projectionMetrics, _ = metrics.(driverSQL.ProjectionMetrics)
+	monitor.eventStoreMetrics, _ = metrics.(driverSQL.EventStoreMetrics)
+
+	return monitor
+}
+
+// Projections returns a copy of the statistics of all projections known to the monitor
+func (m *Monitor) Projections() map[string]ProjectionStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	projections := make(map[string]ProjectionStats, len(m.projections))
+	for name, state := range m.projections {
+		stats := state.stats
+		stats.OldestQueuedAt = state.queued.oldest()
+		stats.OldestProcessingAt = state.processing.oldest()
+
+		projections[name] = stats
+	}
+
+	return projections
+}
+
+// ReceivedNotification forwards the call to the wrapped metrics
+func (m *Monitor) ReceivedNotification(isNotification bool) {
+	m.metrics.ReceivedNotification(isNotification)
+}
+
+// QueueNotification forwards the call to the wrapped metrics
+func (m *Monitor) QueueNotification(notification *driverSQL.ProjectionNotification) {
+	m.metrics.QueueNotification(notification)
+}
+
+// StartNotificationProcessing forwards the call to the wrapped metrics
+func (m *Monitor) StartNotificationProcessing(notification *driverSQL.ProjectionNotification) {
+	m.metrics.StartNotificationProcessing(notification)
+}
+
+// FinishNotificationProcessing forwards the call to the wrapped metrics
+func (m *Monitor) FinishNotificationProcessing(notification *driverSQL.ProjectionNotification, success bool) {
+	m.metrics.FinishNotificationProcessing(notification, success)
+}
+
+// QueueProjectionNotification increases the queue depth of the projection
+func (m *Monitor) QueueProjectionNotification(projectionName string, notification *driverSQL.ProjectionNotification) {
+	queuedAt := notification.QueuedAt()
+	if queuedAt.IsZero() {
+		queuedAt = time.Now()
+	}
+	m.updateState(projectionName, func(state *projectionState) {
+		state.stats.QueueDepth++
+		state.queued.add(notification, queuedAt)
+	})
+
+	if m.projectionMetrics != nil {
+		m.projectionMetrics.QueueProjectionNotification(projectionName, notification)
+		return
+	}
+	m.metrics.QueueNotification(notification)
+}
+
+// StartProjectionNotificationProcessing moves a notification of the projection from the queue to processing
+func (m *Monitor) StartProjectionNotificationProcessing(projectionName string, notification *driverSQL.ProjectionNotification) {
+	now := time.Now()
+	m.updateState(projectionName, func(state *projectionState) {
+		state.stats.QueueDepth--
+		state.stats.ProcessingNotifications++
+		state.queued.remove(notification, true)
+		state.processing.add(notification, now)
+	})
+
+	if m.projectionMetrics != nil {
+		m.projectionMetrics.StartProjectionNotificationProcessing(projectionName, notification)
+		return
+	}
+	m.metrics.StartNotificationProcessing(notification)
+}
+
+// FinishProjectionNotificationProcessing counts the processed and failed notifications of the projection
+func (m *Monitor) FinishProjectionNotificationProcessing(
+	projectionName string,
+	notification *driverSQL.ProjectionNotification,
+	duration time.Duration,
+	success bool,
+) {
+	m.updateState(projectionName, func(state *projectionState) {
+		state.stats.ProcessedNotifications++
+		if !success {
+			state.stats.FailedNotifications++
+		}
+
+		// The processing always starts before it finishes so a notification that is not processing was dropped when
+		// the processing was stopped
+		if state.processing.remove(notification, false) {
+			state.stats.ProcessingNotifications--
+		}
+	})
+
+	if m.projectionMetrics != nil {
+		m.projectionMetrics.FinishProjectionNotificationProcessing(projectionName, notification, duration, success)
+		return
+	}
+	m.metrics.FinishNotificationProcessing(notification, success)
+}
+
+// StopProjectionNotificationProcessing resets the queue depth of the projection since the queued notifications are
+// dropped
+func (m *Monitor) StopProjectionNotificationProcessing(projectionName string) {
+	m.updateState(projectionName, func(state *projectionState) {
+		state.stats.QueueDepth = 0
+		state.stats.ProcessingNotifications = 0
+		state.queued = pendingNotifications{}
+		state.processing = pendingNotifications{}
+	})
+
+	if m.projectionMetrics != nil {
+		m.projectionMetrics.StopProjectionNotificationProcessing(projectionName)
+	}
+}
+
+// AppendToStream forwards the call to the wrapped metrics
+func (m *Monitor) AppendToStream(streamName goengine.StreamName, eventCount int, duration time.Duration, success bool) {
+	if m.eventStoreMetrics != nil {
+		m.eventStoreMetrics.AppendToStream(streamName, eventCount, duration, success)
+	}
+}
+
+// LoadStream forwards the call to the wrapped metrics
+func (m *Monitor) LoadStream(streamName goengine.StreamName, duration time.Duration, success bool) {
+	if m.eventStoreMetrics != nil {
+		m.eventStoreMetrics.LoadStream(streamName, duration, success)
+	}
+}
+
+// ExecuteProjectionHandler counts the handler errors of the projection
+func (m *Monitor) ExecuteProjectionHandler(projectionName string, eventName string, duration time.Duration, success bool) {
+	if !success {
+		m.update(projectionName, func(stats *ProjectionStats) {
+			stats.HandlerErrors++
+		})
+	}
+
+	if m.projectionMetrics != nil {
+		m.projectionMetrics.ExecuteProjectionHandler(projectionName, eventName, duration, success)
+	}
+}
+
+// CommitProjectionState records the position of the projection or counts the failed commit
+func (m *Monitor) CommitProjectionState(projectionName string, position int64, duration time.Duration, success bool) {
+	m.update(projectionName, func(stats *ProjectionStats) {
+		if !success {
+			stats.CommitErrors++
+			return
+		}
+
+		if position > stats.Position {
+			stats.Position = position
+		}
+		now := time.Now()
+		stats.LastCommitAt = &now
+	})
+
+	if m.projectionMetrics != nil {
+		m.projectionMetrics.CommitProjectionState(projectionName, position, duration, success)
+	}
+}
+
+// FailedToLockProjection counts the lock failures of the projection
+func (m *Monitor) FailedToLockProjection(projectionName string) {
+	m.update(projectionName, func(stats *ProjectionStats) {
+		stats.LockFailures++
+	})
+
+	if m.projectionMetrics != nil {
+		m.projectionMetrics.FailedToLockProjection(projectionName)
+	}
+}
+
+func (m *Monitor) update(projectionName string, f func(stats *ProjectionStats)) {
+	m.updateState(projectionName, func(state *projectionState) {
+		f(&state.stats)
+	})
+}
+
+func (m *Monitor) updateState(projectionName string, f func(state *projectionState)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, found := m.projections[projectionName]
+	if !found {
+		state = &projectionState{
+			queued:     pendingNotifications{},
+			processing: pendingNotifications{},
+		}
+		m.projections[projectionName] = state
+	}
+
+	f(state)
+}
+
+// add marks the notification as pending since the provided time unless it is already pending
+func (p pendingNotifications) add(notification *driverSQL.ProjectionNotification, since time.Time) {
+	pending, found := p[notification]
+	if !found {
+		pending = &pendingNotification{}
+		p[notification] = pending
+	}
+
+	pending.count++
+	switch pending.count {
+	case 0:
+		delete(p, notification)
+	case 1:
+		pending.since = since
+	}
+}
+
+// remove marks the notification as no longer pending and returns false when the notification was not pending.
+// When tombstone is true a notification that is not pending is recorded as removed before it was added.
+func (p pendingNotifications) remove(notification *driverSQL.ProjectionNotification, tombstone bool) bool {
+	pending, found := p[notification]
+	if !found || pending.count <= 0 {
+		if tombstone {
+			if !found {
+				pending = &pendingNotification{}
+				p[notification] = pending
+			}
+			pending.count--
+		}
+		return false
+	}
+
+	pending.count--
+	if pending.count == 0 {
+		delete(p, notification)
+	}
+
+	return true
+}
+
+// oldest returns the time since when the oldest notification is pending or nil when no notification is pending
+func (p pendingNotifications) oldest() *time.Time {
+	var oldest *time.Time
+	for _, pending := range p {
+		if pending.count > 0 && (oldest == nil || pending.since.Before(*oldest)) {
+			since := pending.since
+			oldest = &since
+		}
+	}
+
+	return oldest
+}
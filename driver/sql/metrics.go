@@ -1,5 +1,11 @@
 package sql
 
+import (
+	"time"
+
+	"github.com/hellofresh/goengine"
+)
+
 type (
 	// Metrics a structured metrics interface
 	Metrics interface {
@@ -15,4 +21,44 @@ type (
 		// It actually sends metrics calculating duration for which a notification spends in queue and then processed by background processor
 		FinishNotificationProcessing(notification *ProjectionNotification, success bool)
 	}
+
+	// ProjectionMetrics is an optional interface of Metrics providing metrics per projection.
+	// When the Metrics passed to a background processor implement ProjectionMetrics the notification methods of
+	// ProjectionMetrics are called instead of QueueNotification, StartNotificationProcessing and
+	// FinishNotificationProcessing.
+	ProjectionMetrics interface {
+		// QueueProjectionNotification is called after a notification is queued by the background processor of the
+		// projection. The time the notification is queued is available using ProjectionNotification.QueuedAt.
+		// Since the notification is already queued StartProjectionNotificationProcessing may be called first.
+		QueueProjectionNotification(projectionName string, notification *ProjectionNotification)
+		// StartProjectionNotificationProcessing is called when a notification is picked to be processed by the
+		// background processor of the projection
+		StartProjectionNotificationProcessing(projectionName string, notification *ProjectionNotification)
+		// FinishProjectionNotificationProcessing is called when a notification processing is finished with the duration
+		// of the processing
+		FinishProjectionNotificationProcessing(
+			projectionName string,
+			notification *ProjectionNotification,
+			duration time.Duration,
+			success bool,
+		)
+		// StopProjectionNotificationProcessing is called when the background processor of the projection is stopped.
+		// Notifications that are still queued at this time are never processed.
+		StopProjectionNotificationProcessing(projectionName string)
+
+		// ExecuteProjectionHandler is called after a projection handler is executed for a message
+		ExecuteProjectionHandler(projectionName string, eventName string, duration time.Duration, success bool)
+		// CommitProjectionState is called after the state and position of a projection are persisted
+		CommitProjectionState(projectionName string, position int64, duration time.Duration, success bool)
+		// FailedToLockProjection is called when the projector was unable to acquire the projection lock
+		FailedToLockProjection(projectionName string)
+	}
+
+	// EventStoreMetrics is an optional interface of Metrics providing metrics of event stores
+	EventStoreMetrics interface {
+		// AppendToStream is called after messages are appended to an event stream
+		AppendToStream(streamName goengine.StreamName, eventCount int, duration time.Duration, success bool)
+		// LoadStream is called after the query to load an event stream is executed
+		LoadStream(streamName goengine.StreamName, duration time.Duration, success bool)
+	}
 )
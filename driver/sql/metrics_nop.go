@@ -1,7 +1,19 @@
 package sql
 
-// NopMetrics is default Metrics handler in case nil is passed
-var NopMetrics Metrics = &nopMetrics{}
+import (
+	"time"
+
+	"github.com/hellofresh/goengine"
+)
+
+var (
+	// NopMetrics is default Metrics handler in case nil is passed
+	NopMetrics Metrics = &nopMetrics{}
+
+	// Ensure that nopMetrics implements the optional metrics interfaces
+	_ ProjectionMetrics = &nopMetrics{}
+	_ EventStoreMetrics = &nopMetrics{}
+)
 
 type nopMetrics struct{}
 
@@ -10,3 +22,19 @@ func (nm *nopMetrics) QueueNotification(notification *ProjectionNotification)
 func (nm *nopMetrics) StartNotificationProcessing(notification *ProjectionNotification) {}
 func (nm *nopMetrics) FinishNotificationProcessing(notification *ProjectionNotification, success bool) {
 }
+func (nm *nopMetrics) QueueProjectionNotification(projectionName string, notification *ProjectionNotification) {
+}
+func (nm *nopMetrics) StartProjectionNotificationProcessing(projectionName string, notification *ProjectionNotification) {
+}
+func (nm *nopMetrics) FinishProjectionNotificationProcessing(projectionName string, notification *ProjectionNotification, duration time.Duration, success bool) {
+}
+func (nm *nopMetrics) StopProjectionNotificationProcessing(projectionName string) {}
+func (nm *nopMetrics) ExecuteProjectionHandler(projectionName string, eventName string, duration time.Duration, success bool) {
+}
+func (nm *nopMetrics) CommitProjectionState(projectionName string, position int64, duration time.Duration, success bool) {
+}
+func (nm *nopMetrics) FailedToLockProjection(projectionName string) {}
+func (nm *nopMetrics) AppendToStream(streamName goengine.StreamName, eventCount int, duration time.Duration, success bool) {
+}
+func (nm *nopMetrics) LoadStream(streamName goengine.StreamName, duration time.Duration, success bool) {
+}
@@ -1,6 +1,8 @@
 package sql
 
 import (
+	"context"
+
 	"github.com/hellofresh/goengine"
 	"github.com/hellofresh/goengine/metadata"
 )
@@ -16,3 +18,11 @@ type PersistenceStrategy interface {
 	PrepareSearch(metadata.Matcher) ([]byte, []interface{})
 	GenerateTableName(streamName goengine.StreamName) (string, error)
 }
+
+// ContextPersistenceStrategy is a PersistenceStrategy that is able to prepare the data of messages using the context
+// of the append
+type ContextPersistenceStrategy interface {
+	PersistenceStrategy
+
+	PrepareDataContext(context.Context, []goengine.Message) ([]interface{}, error)
+}
@@ -9,8 +9,20 @@ import (
 	driverSQL "github.com/hellofresh/goengine/driver/sql"
 )
 
+// ErrProjectionNotFound occurs when the projection to inspect or update does not exist in the projection table
+var ErrProjectionNotFound = errors.New("goengine: projection not found")
+
 var _ driverSQL.ProjectorTransaction = &advisoryLockProjectorTransaction{}
 
+// ProjectionStatus is the state of a projection row without the projection state itself
+type ProjectionStatus struct {
+	// ID is the stream projection name or the aggregate id of the projection
+	ID       string
+	Position int64
+	Locked   bool
+	Failed   bool
+}
+
 type advisoryLockProjectorTransaction struct {
 	conn              *sql.Conn
 	queryPersistState string
@@ -126,3 +138,73 @@ func (t *advisoryLockWithUpdateProjectorTransaction) Close() error {
 
 	return t.advisoryLockProjectorTransaction.Close()
 }
+
+// scanProjectionStatus scans the current row containing the id, position, locked and failed columns of a projection
+func scanProjectionStatus(row *sql.Rows) (ProjectionStatus, error) {
+	var status ProjectionStatus
+	err := row.Scan(&status.ID, &status.Position, &status.Locked, &status.Failed)
+
+	return status, err
+}
+
+// loadProjectionStatus loads the status of a single projection
+func loadProjectionStatus(ctx context.Context, conn driverSQL.Queryer, query string, projectionID string) (*ProjectionStatus, error) {
+	rows, err := conn.QueryContext(ctx, query, projectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrProjectionNotFound
+	}
+
+	status, err := scanProjectionStatus(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &status, rows.Err()
+}
+
+// updateWithAdvisoryLock executes queryUpdate for the projection while holding it's advisory lock.
+// This ensures that no projector is running the projection while it's row is being updated.
+func updateWithAdvisoryLock(
+	ctx context.Context,
+	conn *sql.Conn,
+	queryAcquireLock,
+	queryUpdate,
+	queryReleaseLock string,
+	projectionID string,
+) (err error) {
+	var acquiredLock bool
+	if err := conn.QueryRowContext(ctx, queryAcquireLock, projectionID).Scan(&acquiredLock); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrProjectionNotFound
+		}
+
+		return err
+	}
+
+	if !acquiredLock {
+		return driverSQL.ErrProjectionFailedToLock
+	}
+
+	defer func() {
+		var unlocked bool
+		releaseErr := conn.QueryRowContext(context.Background(), queryReleaseLock, projectionID).Scan(&unlocked)
+		if releaseErr == nil && !unlocked {
+			releaseErr = errors.New("failed to release db connection projection lock")
+		}
+
+		if err == nil {
+			err = releaseErr
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, queryUpdate, projectionID)
+	return err
+}
@@ -26,6 +26,11 @@ type AdvisoryLockAggregateProjectionStorage struct {
 	queryAcquireLock          string
 	queryReleaseLock          string
 	querySetRowLocked         string
+	queryLoadStatus           string
+	queryLoadFailedStatuses   string
+	queryTryLock              string
+	queryReset                string
+	queryUnlock               string
 }
 
 // NewAdvisoryLockAggregateProjectionStorage returns a new AdvisoryLockAggregateProjectionStorage
@@ -48,9 +53,9 @@ func NewAdvisoryLockAggregateProjectionStorage(
 		logger = goengine.NopLogger
 	}
 
-	projectionTableQuoted := QuoteIdentifier(projectionTable)
+	projectionTableQuoted := QuoteTableName(projectionTable)
 	projectionTableStr := QuoteString(projectionTable)
-	eventStoreTableQuoted := QuoteIdentifier(eventStoreTable)
+	eventStoreTableQuoted := QuoteTableName(eventStoreTable)
 
 	/* #nosec G201 */
 	return &AdvisoryLockAggregateProjectionStorage{
@@ -105,6 +110,27 @@ func NewAdvisoryLockAggregateProjectionStorage(
 			`UPDATE ONLY %[1]s SET locked = $2 WHERE aggregate_id = $1`,
 			projectionTableQuoted,
 		),
+		queryLoadStatus: fmt.Sprintf(
+			`SELECT aggregate_id, position, locked, failed FROM %[1]s WHERE aggregate_id = $1`,
+			projectionTableQuoted,
+		),
+		queryLoadFailedStatuses: fmt.Sprintf(
+			`SELECT aggregate_id, position, locked, failed FROM %[1]s WHERE locked OR failed ORDER BY no LIMIT $1`,
+			projectionTableQuoted,
+		),
+		queryTryLock: fmt.Sprintf(
+			`SELECT pg_try_advisory_lock(%[2]s::regclass::oid::int, no) FROM %[1]s WHERE aggregate_id = $1`,
+			projectionTableQuoted,
+			projectionTableStr,
+		),
+		queryReset: fmt.Sprintf(
+			`UPDATE ONLY %[1]s SET position = 0, state = 'null', locked = FALSE, failed = FALSE WHERE aggregate_id = $1`,
+			projectionTableQuoted,
+		),
+		queryUnlock: fmt.Sprintf(
+			`UPDATE ONLY %[1]s SET locked = FALSE, failed = FALSE WHERE aggregate_id = $1`,
+			projectionTableQuoted,
+		),
 	}, nil
 }
 
@@ -119,6 +145,42 @@ func (a *AdvisoryLockAggregateProjectionStorage) PersistFailure(conn driverSQL.E
 	return err
 }
 
+// LoadStatus returns the status of the aggregate projection or ErrProjectionNotFound when the projection does not exist
+func (a *AdvisoryLockAggregateProjectionStorage) LoadStatus(ctx context.Context, conn driverSQL.Queryer, aggregateID string) (*ProjectionStatus, error) {
+	return loadProjectionStatus(ctx, conn, a.queryLoadStatus, aggregateID)
+}
+
+// LoadFailedStatuses returns the status of at most limit aggregate projections that are locked or failed
+func (a *AdvisoryLockAggregateProjectionStorage) LoadFailedStatuses(ctx context.Context, conn driverSQL.Queryer, limit int) ([]ProjectionStatus, error) {
+	rows, err := conn.QueryContext(ctx, a.queryLoadFailedStatuses, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statuses []ProjectionStatus
+	for rows.Next() {
+		status, err := scanProjectionStatus(rows)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, rows.Err()
+}
+
+// Reset resets the position and state of the aggregate projection and clears the locked and failed flags.
+// The aggregate will be projected from it's first event when the next notification for the aggregate is received.
+func (a *AdvisoryLockAggregateProjectionStorage) Reset(ctx context.Context, conn *sql.Conn, aggregateID string) error {
+	return updateWithAdvisoryLock(ctx, conn, a.queryTryLock, a.queryReset, a.queryReleaseLock, aggregateID)
+}
+
+// Unlock clears the locked and failed flags of the aggregate projection so that it will be retried
+func (a *AdvisoryLockAggregateProjectionStorage) Unlock(ctx context.Context, conn *sql.Conn, aggregateID string) error {
+	return updateWithAdvisoryLock(ctx, conn, a.queryTryLock, a.queryUnlock, a.queryReleaseLock, aggregateID)
+}
+
 // Acquire returns a driverSQL.ProjectorTransaction and the position of the projection within the event stream when a
 // lock is acquired for the specified aggregate_id. Otherwise an error is returned indicating why the lock could not be acquired.
 func (a *AdvisoryLockAggregateProjectionStorage) Acquire(
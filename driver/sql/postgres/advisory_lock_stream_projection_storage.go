@@ -27,6 +27,10 @@ type AdvisoryLockStreamProjectionStorage struct {
 	queryReleaseLock         string
 	queryPersistState        string
 	querySetRowLocked        string
+	queryLoadStatus          string
+	queryTryLock             string
+	queryReset               string
+	queryUnlock              string
 }
 
 // NewAdvisoryLockStreamProjectionStorage returns a new AdvisoryLockStreamProjectionStorage
@@ -50,7 +54,7 @@ func NewAdvisoryLockStreamProjectionStorage(
 		logger = goengine.NopLogger
 	}
 
-	projectionTableQuoted := QuoteIdentifier(projectionTable)
+	projectionTableQuoted := QuoteTableName(projectionTable)
 	projectionTableStr := QuoteString(projectionTable)
 
 	/* #nosec G201 */
@@ -87,6 +91,23 @@ func NewAdvisoryLockStreamProjectionStorage(
 			`UPDATE ONLY %[1]s SET locked = $2 WHERE name = $1`,
 			projectionTableQuoted,
 		),
+		queryLoadStatus: fmt.Sprintf(
+			`SELECT name, position, locked, FALSE FROM %[1]s WHERE name = $1`,
+			projectionTableQuoted,
+		),
+		queryTryLock: fmt.Sprintf(
+			`SELECT pg_try_advisory_lock(%[2]s::regclass::oid::int, no) FROM %[1]s WHERE name = $1`,
+			projectionTableQuoted,
+			projectionTableStr,
+		),
+		queryReset: fmt.Sprintf(
+			`UPDATE ONLY %[1]s SET position = 0, state = '{}', locked = FALSE WHERE name = $1`,
+			projectionTableQuoted,
+		),
+		queryUnlock: fmt.Sprintf(
+			`UPDATE ONLY %[1]s SET locked = FALSE WHERE name = $1`,
+			projectionTableQuoted,
+		),
 	}, nil
 }
 
@@ -96,6 +117,22 @@ func (s *AdvisoryLockStreamProjectionStorage) CreateProjection(ctx context.Conte
 	return err
 }
 
+// LoadStatus returns the status of the stream projection or ErrProjectionNotFound when the projection does not exist
+func (s *AdvisoryLockStreamProjectionStorage) LoadStatus(ctx context.Context, conn driverSQL.Queryer) (*ProjectionStatus, error) {
+	return loadProjectionStatus(ctx, conn, s.queryLoadStatus, s.projectionName)
+}
+
+// Reset resets the position and state of the stream projection so that it will be projected from the start of the event stream.
+// The data created by the projection is not removed and must be cleared by the caller.
+func (s *AdvisoryLockStreamProjectionStorage) Reset(ctx context.Context, conn *sql.Conn) error {
+	return updateWithAdvisoryLock(ctx, conn, s.queryTryLock, s.queryReset, s.queryReleaseLock, s.projectionName)
+}
+
+// Unlock removes the row lock left behind by a projector that was not able to release the projection
+func (s *AdvisoryLockStreamProjectionStorage) Unlock(ctx context.Context, conn *sql.Conn) error {
+	return updateWithAdvisoryLock(ctx, conn, s.queryTryLock, s.queryUnlock, s.queryReleaseLock, s.projectionName)
+}
+
 // Acquire returns a driverSQL.ProjectorTransaction and the position of the projection within the event stream when a
 // lock is acquire for the specified aggregate_id. Otherwise an error is returned indicating why the lock could not be acquired.
 func (s *AdvisoryLockStreamProjectionStorage) Acquire(
@@ -0,0 +1,172 @@
+// +build unit
+
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/driver/sql/internal/test"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAggregateID = "20a151cc-e44e-4133-9491-8dc341032d37"
+
+var statusColumns = []string{"id", "position", "locked", "failed"}
+
+func TestAdvisoryLockStreamProjectionStorage_LoadStatus(t *testing.T) {
+	query := regexp.QuoteMeta(`SELECT name, position, locked, FALSE FROM "projections" WHERE name = $1`)
+
+	test.RunWithMockDB(t, "known projection", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage := newStreamProjectionStorage(t)
+
+		dbMock.ExpectQuery(query).WithArgs("my_projection").
+			WillReturnRows(sqlmock.NewRows(statusColumns).AddRow("my_projection", 12, true, false))
+
+		status, err := storage.LoadStatus(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, &postgres.ProjectionStatus{ID: "my_projection", Position: 12, Locked: true}, status)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	test.RunWithMockDB(t, "unknown projection", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage := newStreamProjectionStorage(t)
+
+		dbMock.ExpectQuery(query).WithArgs("my_projection").WillReturnRows(sqlmock.NewRows(statusColumns))
+
+		status, err := storage.LoadStatus(context.Background(), db)
+		assert.Equal(t, postgres.ErrProjectionNotFound, err)
+		assert.Nil(t, status)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestAdvisoryLockStreamProjectionStorage_Reset(t *testing.T) {
+	queryLock := regexp.QuoteMeta(`SELECT pg_try_advisory_lock('projections'::regclass::oid::int, no) FROM "projections" WHERE name = $1`)
+	queryReset := regexp.QuoteMeta(`UPDATE ONLY "projections" SET position = 0, state = '{}', locked = FALSE WHERE name = $1`)
+	queryRelease := regexp.QuoteMeta(`SELECT pg_advisory_unlock('projections'::regclass::oid::int, no) FROM "projections" WHERE name = $1`)
+
+	test.RunWithMockDB(t, "reset", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage := newStreamProjectionStorage(t)
+
+		dbMock.ExpectQuery(queryLock).WithArgs("my_projection").WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(true))
+		dbMock.ExpectExec(queryReset).WithArgs("my_projection").WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectQuery(queryRelease).WithArgs("my_projection").WillReturnRows(sqlmock.NewRows([]string{"unlock"}).AddRow(true))
+
+		err := storage.Reset(context.Background(), acquireConn(t, db))
+		assert.NoError(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	test.RunWithMockDB(t, "projection is running", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage := newStreamProjectionStorage(t)
+
+		dbMock.ExpectQuery(queryLock).WithArgs("my_projection").WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(false))
+
+		err := storage.Reset(context.Background(), acquireConn(t, db))
+		assert.Equal(t, driverSQL.ErrProjectionFailedToLock, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	test.RunWithMockDB(t, "unlock unknown projection", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage := newStreamProjectionStorage(t)
+
+		dbMock.ExpectQuery(queryLock).WithArgs("my_projection").WillReturnRows(sqlmock.NewRows([]string{"lock"}))
+
+		err := storage.Unlock(context.Background(), acquireConn(t, db))
+		assert.Equal(t, postgres.ErrProjectionNotFound, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestAdvisoryLockAggregateProjectionStorage_LoadFailedStatuses(t *testing.T) {
+	query := regexp.QuoteMeta(`SELECT aggregate_id, position, locked, failed FROM "projections" WHERE locked OR failed ORDER BY no LIMIT $1`)
+
+	test.RunWithMockDB(t, "failed projections", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage := newAggregateProjectionStorage(t)
+
+		dbMock.ExpectQuery(query).WithArgs(10).WillReturnRows(
+			sqlmock.NewRows(statusColumns).
+				AddRow(testAggregateID, 3, false, true).
+				AddRow("f9e2da6e-6d2b-4b6b-a3c4-9c2ee4b6fc4b", 1, true, false),
+		)
+
+		statuses, err := storage.LoadFailedStatuses(context.Background(), db, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []postgres.ProjectionStatus{
+			{ID: testAggregateID, Position: 3, Failed: true},
+			{ID: "f9e2da6e-6d2b-4b6b-a3c4-9c2ee4b6fc4b", Position: 1, Locked: true},
+		}, statuses)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestAdvisoryLockAggregateProjectionStorage_Unlock(t *testing.T) {
+	queryLock := regexp.QuoteMeta(`SELECT pg_try_advisory_lock('projections'::regclass::oid::int, no) FROM "projections" WHERE aggregate_id = $1`)
+	queryUnlock := regexp.QuoteMeta(`UPDATE ONLY "projections" SET locked = FALSE, failed = FALSE WHERE aggregate_id = $1`)
+	queryRelease := regexp.QuoteMeta(`SELECT pg_advisory_unlock('projections'::regclass::oid::int, no) FROM "projections" WHERE aggregate_id = $1`)
+
+	test.RunWithMockDB(t, "unlock", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage := newAggregateProjectionStorage(t)
+
+		dbMock.ExpectQuery(queryLock).WithArgs(testAggregateID).WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(true))
+		dbMock.ExpectExec(queryUnlock).WithArgs(testAggregateID).WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectQuery(queryRelease).WithArgs(testAggregateID).WillReturnRows(sqlmock.NewRows([]string{"unlock"}).AddRow(true))
+
+		err := storage.Unlock(context.Background(), acquireConn(t, db), testAggregateID)
+		assert.NoError(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	test.RunWithMockDB(t, "release the lock when the update fails", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage := newAggregateProjectionStorage(t)
+		expectedErr := sql.ErrConnDone
+
+		dbMock.ExpectQuery(queryLock).WithArgs(testAggregateID).WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(true))
+		dbMock.ExpectExec(queryUnlock).WithArgs(testAggregateID).WillReturnError(expectedErr)
+		dbMock.ExpectQuery(queryRelease).WithArgs(testAggregateID).WillReturnRows(sqlmock.NewRows([]string{"unlock"}).AddRow(true))
+
+		err := storage.Unlock(context.Background(), acquireConn(t, db), testAggregateID)
+		assert.Equal(t, expectedErr, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func newStreamProjectionStorage(t *testing.T) *postgres.AdvisoryLockStreamProjectionStorage {
+	storage, err := postgres.NewAdvisoryLockStreamProjectionStorage(
+		"my_projection",
+		"projections",
+		driverSQL.GetProjectionStateSerialization(nil),
+		true,
+		nil,
+	)
+	require.NoError(t, err)
+
+	return storage
+}
+
+func newAggregateProjectionStorage(t *testing.T) *postgres.AdvisoryLockAggregateProjectionStorage {
+	storage, err := postgres.NewAdvisoryLockAggregateProjectionStorage(
+		"events_orders",
+		"projections",
+		driverSQL.GetProjectionStateSerialization(nil),
+		true,
+		nil,
+	)
+	require.NoError(t, err)
+
+	return storage
+}
+
+func acquireConn(t *testing.T, db *sql.DB) *sql.Conn {
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+
+	return conn
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hellofresh/goengine"
 	driverSQL "github.com/hellofresh/goengine/driver/sql"
@@ -35,6 +36,17 @@ type EventStore struct {
 	columnCount         int
 	eventColumns        string
 	logger              goengine.Logger
+	metrics             driverSQL.EventStoreMetrics
+}
+
+// EventStoreOption configures optional behaviour of an EventStore
+type EventStoreOption func(*EventStore)
+
+// WithEventStoreMetrics sets the metrics used to observe appending and loading event streams
+func WithEventStoreMetrics(metrics driverSQL.EventStoreMetrics) EventStoreOption {
+	return func(e *EventStore) {
+		e.metrics = metrics
+	}
 }
 
 // NewEventStore return a new postgres.EventStore
@@ -43,6 +55,7 @@ func NewEventStore(
 	db *sql.DB,
 	messageFactory driverSQL.MessageFactory,
 	logger goengine.Logger,
+	options ...EventStoreOption,
 ) (*EventStore, error) {
 	switch {
 	case persistenceStrategy == nil:
@@ -68,7 +81,7 @@ func NewEventStore(
 		selectColumns[i] = QuoteIdentifier(c)
 	}
 
-	return &EventStore{
+	eventStore := &EventStore{
 		persistenceStrategy: persistenceStrategy,
 		db:                  db,
 		messageFactory:      messageFactory,
@@ -76,7 +89,15 @@ func NewEventStore(
 		columnCount:         len(insertColumns),
 		eventColumns:        strings.Join(selectColumns, ", "),
 		logger:              logger,
-	}, nil
+	}
+	for _, option := range options {
+		option(eventStore)
+	}
+	if eventStore.metrics == nil {
+		eventStore.metrics = driverSQL.NopMetrics.(driverSQL.EventStoreMetrics)
+	}
+
+	return eventStore, nil
 }
 
 // Create creates the database table, index etc needed for the event stream
@@ -134,6 +155,36 @@ func (e *EventStore) HasStream(ctx context.Context, streamName goengine.StreamNa
 	return e.tableExists(ctx, tableName)
 }
 
+// EventNames returns the distinct names of the events in the event stream.
+// This can be used to check that all events of a stream can be loaded before loading them.
+func (e *EventStore) EventNames(ctx context.Context, streamName goengine.StreamName) ([]string, error) {
+	tableName, err := e.tableName(streamName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := e.db.QueryContext(
+		ctx,
+		"SELECT DISTINCT event_name FROM "+QuoteTableName(tableName)+" ORDER BY event_name",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
 // Load returns an eventstream based on the provided constraints
 func (e *EventStore) Load(
 	ctx context.Context,
@@ -166,7 +217,15 @@ func (e *EventStore) loadQuery(
 	fromNumber int64,
 	count *uint,
 	matcher metadata.Matcher,
-) (goengine.EventStream, error) {
+) (_ goengine.EventStream, err error) {
+	ctx, span := goengine.TracerFromContext(ctx).StartSpan(ctx, "goengine.eventstore.load")
+	defer func() {
+		span.Error(err)
+		span.End()
+	}()
+	span.String("stream", string(streamName))
+	span.Int64("from_number", fromNumber)
+
 	tableName, err := e.tableName(streamName)
 	if err != nil {
 		return nil, err
@@ -195,7 +254,9 @@ func (e *EventStore) loadQuery(
 		selectQuery = append(selectQuery, strconv.FormatUint(uint64(*count), 10)...)
 	}
 
+	start := time.Now()
 	rows, err := db.QueryContext(ctx, string(selectQuery), params...)
+	e.metrics.LoadStream(streamName, time.Since(start), err == nil)
 	if err != nil {
 		return nil, err
 	}
@@ -209,18 +270,31 @@ func (e *EventStore) AppendTo(ctx context.Context, streamName goengine.StreamNam
 }
 
 // AppendToWithExecer batch inserts Messages into the event stream table using the provided Connection/Execer
-func (e *EventStore) AppendToWithExecer(ctx context.Context, conn driverSQL.Execer, streamName goengine.StreamName, streamEvents []goengine.Message) error {
+func (e *EventStore) AppendToWithExecer(ctx context.Context, conn driverSQL.Execer, streamName goengine.StreamName, streamEvents []goengine.Message) (err error) {
 	eventCount := len(streamEvents)
 	if eventCount == 0 {
 		return nil
 	}
 
+	ctx, span := goengine.TracerFromContext(ctx).StartSpan(ctx, "goengine.eventstore.append")
+	defer func() {
+		span.Error(err)
+		span.End()
+	}()
+	span.String("stream", string(streamName))
+	span.Int64("event_count", int64(eventCount))
+
 	tableName, err := e.tableName(streamName)
 	if err != nil {
 		return err
 	}
 
-	data, err := e.persistenceStrategy.PrepareData(streamEvents)
+	var data []interface{}
+	if strategy, ok := e.persistenceStrategy.(driverSQL.ContextPersistenceStrategy); ok {
+		data, err = strategy.PrepareDataContext(ctx, streamEvents)
+	} else {
+		data, err = e.persistenceStrategy.PrepareData(streamEvents)
+	}
 	if err != nil {
 		return err
 	}
@@ -248,7 +322,9 @@ func (e *EventStore) AppendToWithExecer(ctx context.Context, conn driverSQL.Exec
 		insertQuery = append(insertQuery, ')')
 	}
 
+	start := time.Now()
 	result, err := conn.ExecContext(ctx, string(insertQuery), data...)
+	e.metrics.AppendToStream(streamName, eventCount, time.Since(start), err == nil)
 	if err != nil {
 		e.logger.Warn("failed to insert messages into the event stream", func(e goengine.LoggerEntry) {
 			e.Error(err)
@@ -280,12 +356,17 @@ func (e *EventStore) tableName(s goengine.StreamName) (string, error) {
 	return tableName, nil
 }
 
+// tableExists returns true if the table exists, a table name that is not qualified with a schema is looked up in the
+// current schema
 func (e *EventStore) tableExists(ctx context.Context, tableName string) bool {
+	schema, table := SplitTableName(tableName)
+
 	var exists bool
 	err := e.db.QueryRowContext(
 		ctx,
-		`SELECT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_schema = 'public' AND table_name = $1)`,
-		tableName,
+		`SELECT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_schema = COALESCE(NULLIF($2, ''), current_schema()) AND table_name = $1)`,
+		table,
+		schema,
 	).Scan(&exists)
 
 	if err != nil {
@@ -178,6 +178,47 @@ func TestEventStore_HasStream(t *testing.T) {
 	}
 }
 
+func TestEventStore_HasStreamInSchema(t *testing.T) {
+	test.RunWithMockDB(t, "Stream exists in schema", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		mockRows := sqlmock.NewRows([]string{"type"}).AddRow(true)
+		dbMock.ExpectQuery(`SELECT EXISTS\((.+)`).WithArgs("events_orders", "billing").WillReturnRows(mockRows)
+
+		persistenceStrategy, err := strategyPostgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, strategyPostgres.WithSchema("billing"))
+		require.NoError(t, err)
+
+		store, err := postgres.NewEventStore(persistenceStrategy, db, &mockSQL.MessageFactory{}, nil)
+		require.NoError(t, err)
+
+		assert.True(t, store.HasStream(context.Background(), "orders"))
+	})
+}
+
+func TestEventStore_EventNames(t *testing.T) {
+	test.RunWithMockDB(t, "Distinct event names", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		dbMock.ExpectQuery(`SELECT DISTINCT event_name FROM "events_orders" ORDER BY event_name`).
+			WillReturnRows(sqlmock.NewRows([]string{"event_name"}).AddRow("order_created").AddRow("order_paid"))
+
+		store := createEventStore(t, db, &mocks.MessagePayloadConverter{})
+
+		names, err := store.EventNames(context.Background(), "orders")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"order_created", "order_paid"}, names)
+	})
+
+	test.RunWithMockDB(t, "Query error", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		expectedErr := errors.New("relation does not exist")
+		dbMock.ExpectQuery(`SELECT DISTINCT event_name`).WillReturnError(expectedErr)
+
+		store := createEventStore(t, db, &mocks.MessagePayloadConverter{})
+
+		names, err := store.EventNames(context.Background(), "orders")
+
+		assert.Equal(t, expectedErr, err)
+		assert.Nil(t, names)
+	})
+}
+
 func TestEventStore_AppendTo(t *testing.T) {
 	test.RunWithMockDB(t, "Insert successfully", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
 		ctrl := gomock.NewController(t)
@@ -194,6 +235,28 @@ func TestEventStore_AppendTo(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	test.RunWithMockDB(t, "Record metrics", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payloadConverter, messages := mockMessages(ctrl)
+
+		dbMock.ExpectExec(`INSERT(.+)`).WillReturnResult(sqlmock.NewResult(111, 3))
+		dbMock.ExpectExec(`INSERT(.+)`).WillReturnError(errors.New("insert failed"))
+
+		persistenceStrategy, err := strategyPostgres.NewSingleStreamStrategy(payloadConverter)
+		require.NoError(t, err)
+
+		metrics := &appendMetrics{EventStoreMetrics: driverSQL.NopMetrics.(driverSQL.EventStoreMetrics)}
+		eventStore, err := postgres.NewEventStore(persistenceStrategy, db, &mockSQL.MessageFactory{}, nil, postgres.WithEventStoreMetrics(metrics))
+		require.NoError(t, err)
+
+		assert.NoError(t, eventStore.AppendTo(context.Background(), "orders", messages))
+		assert.Error(t, eventStore.AppendTo(context.Background(), "orders", messages[:1]))
+
+		assert.Equal(t, []string{"orders:3:true", "orders:1:false"}, metrics.appends)
+	})
+
 	test.RunWithMockDB(t, "Empty stream name", func(t *testing.T, db *sql.DB, _ sqlmock.Sqlmock) {
 		messages := []goengine.Message{
 			mocks.NewDummyMessage(
@@ -384,7 +447,7 @@ func TestEventStore_Load(t *testing.T) {
 
 func mockHasStreamQuery(result bool, mock sqlmock.Sqlmock) {
 	mockRows := sqlmock.NewRows([]string{"type"}).AddRow(result)
-	mock.ExpectQuery(`SELECT EXISTS\((.+)`).WithArgs("events_orders").WillReturnRows(mockRows)
+	mock.ExpectQuery(`SELECT EXISTS\((.+)`).WithArgs("events_orders", "").WillReturnRows(mockRows)
 }
 
 func mockMessages(ctrl *gomock.Controller) (*mocks.MessagePayloadConverter, []goengine.Message) {
@@ -409,6 +472,16 @@ func mockMessages(ctrl *gomock.Controller) (*mocks.MessagePayloadConverter, []go
 	return pc, messages
 }
 
+// appendMetrics records the calls to AppendToStream
+type appendMetrics struct {
+	driverSQL.EventStoreMetrics
+	appends []string
+}
+
+func (m *appendMetrics) AppendToStream(streamName goengine.StreamName, eventCount int, _ time.Duration, success bool) {
+	m.appends = append(m.appends, fmt.Sprintf("%s:%d:%t", streamName, eventCount, success))
+}
+
 func createEventStore(t *testing.T, db *sql.DB, converter goengine.MessagePayloadConverter) *postgres.EventStore {
 	persistenceStrategy, err := strategyPostgres.NewSingleStreamStrategy(converter)
 	require.NoError(t, err)
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/crypto"
+)
+
+// Ensure that we satisfy the crypto.KeyStore interface
+var _ crypto.KeyStore = &KeyStore{}
+
+// KeyStore is a crypto.KeyStore that persists the key of every subject in postgres
+type KeyStore struct {
+	db *sql.DB
+
+	queryCreateTable string
+	queryLoadKey     string
+	queryInsertKey   string
+	queryDeleteKey   string
+}
+
+// NewKeyStore returns a new KeyStore using the provided table, the table name may be qualified with a schema
+func NewKeyStore(db *sql.DB, table string) (*KeyStore, error) {
+	switch {
+	case db == nil:
+		return nil, goengine.InvalidArgumentError("db")
+	case strings.TrimSpace(table) == "":
+		return nil, goengine.InvalidArgumentError("table")
+	}
+
+	tableQuoted := QuoteTableName(table)
+
+	/* #nosec G201 */
+	return &KeyStore{
+		db: db,
+
+		queryCreateTable: fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (
+				subject_id VARCHAR(255) PRIMARY KEY,
+				key BYTEA NOT NULL,
+				created_at TIMESTAMP NOT NULL DEFAULT NOW()
+			)`,
+			tableQuoted,
+		),
+		queryLoadKey: fmt.Sprintf(
+			`SELECT key FROM %s WHERE subject_id = $1`,
+			tableQuoted,
+		),
+		// The update on conflict ensures the key that was inserted by a concurrent call is returned
+		queryInsertKey: fmt.Sprintf(
+			`INSERT INTO %s (subject_id, key) VALUES ($1, $2)
+			 ON CONFLICT (subject_id) DO UPDATE SET subject_id = EXCLUDED.subject_id
+			 RETURNING key`,
+			tableQuoted,
+		),
+		queryDeleteKey: fmt.Sprintf(
+			`DELETE FROM %s WHERE subject_id = $1`,
+			tableQuoted,
+		),
+	}, nil
+}
+
+// CreateTable creates the key table when it does not exist
+func (s *KeyStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.queryCreateTable)
+
+	return err
+}
+
+// Key returns the key of the subject or crypto.ErrKeyNotFound
+func (s *KeyStore) Key(ctx context.Context, subjectID string) ([]byte, error) {
+	var key []byte
+	err := s.db.QueryRowContext(ctx, s.queryLoadKey, subjectID).Scan(&key)
+	switch err {
+	case nil:
+		return key, nil
+	case sql.ErrNoRows:
+		return nil, crypto.ErrKeyNotFound
+	default:
+		return nil, err
+	}
+}
+
+// GetOrCreateKey returns the key of the subject and generates a new key when the subject has none
+func (s *KeyStore) GetOrCreateKey(ctx context.Context, subjectID string) ([]byte, error) {
+	key, err := s.Key(ctx, subjectID)
+	if err != crypto.ErrKeyNotFound {
+		return key, err
+	}
+
+	newKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.QueryRowContext(ctx, s.queryInsertKey, subjectID, newKey).Scan(&key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// DeleteKey deletes the key of the subject making the personal data encrypted with it unreadable
+func (s *KeyStore) DeleteKey(ctx context.Context, subjectID string) error {
+	_, err := s.db.ExecContext(ctx, s.queryDeleteKey, subjectID)
+
+	return err
+}
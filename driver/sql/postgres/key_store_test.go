@@ -0,0 +1,137 @@
+// +build unit
+
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/crypto"
+	"github.com/hellofresh/goengine/driver/sql/internal/test"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKeyStore(t *testing.T) {
+	test.RunWithMockDB(t, "invalid arguments", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		_, err := postgres.NewKeyStore(nil, "personal_data_keys")
+		assert.Equal(t, goengine.InvalidArgumentError("db"), err)
+
+		_, err = postgres.NewKeyStore(db, " ")
+		assert.Equal(t, goengine.InvalidArgumentError("table"), err)
+	})
+}
+
+func TestKeyStore_CreateTable(t *testing.T) {
+	test.RunWithMockDB(t, "create table", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		store, err := postgres.NewKeyStore(db, "personal_data_keys")
+		require.NoError(t, err)
+
+		dbMock.ExpectExec(`CREATE TABLE IF NOT EXISTS "personal_data_keys"(.+)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, store.CreateTable(context.Background()))
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	test.RunWithMockDB(t, "create table in a schema", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		store, err := postgres.NewKeyStore(db, "privacy.personal_data_keys")
+		require.NoError(t, err)
+
+		dbMock.ExpectExec(`CREATE TABLE IF NOT EXISTS "privacy"."personal_data_keys"(.+)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, store.CreateTable(context.Background()))
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestKeyStore_Key(t *testing.T) {
+	query := regexp.QuoteMeta(`SELECT key FROM "personal_data_keys" WHERE subject_id = $1`)
+	key := make([]byte, crypto.KeySize)
+
+	test.RunWithMockDB(t, "known subject", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		store, err := postgres.NewKeyStore(db, "personal_data_keys")
+		require.NoError(t, err)
+
+		dbMock.ExpectQuery(query).WithArgs("user-1").WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow(key))
+
+		loaded, err := store.Key(context.Background(), "user-1")
+		assert.NoError(t, err)
+		assert.Equal(t, key, loaded)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	test.RunWithMockDB(t, "unknown subject", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		store, err := postgres.NewKeyStore(db, "personal_data_keys")
+		require.NoError(t, err)
+
+		dbMock.ExpectQuery(query).WithArgs("user-1").WillReturnRows(sqlmock.NewRows([]string{"key"}))
+
+		loaded, err := store.Key(context.Background(), "user-1")
+		assert.Nil(t, loaded)
+		assert.Equal(t, crypto.ErrKeyNotFound, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	test.RunWithMockDB(t, "query error", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		store, err := postgres.NewKeyStore(db, "personal_data_keys")
+		require.NoError(t, err)
+
+		expectedErr := errors.New("connection lost")
+		dbMock.ExpectQuery(query).WithArgs("user-1").WillReturnError(expectedErr)
+
+		loaded, err := store.Key(context.Background(), "user-1")
+		assert.Nil(t, loaded)
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestKeyStore_GetOrCreateKey(t *testing.T) {
+	queryLoad := regexp.QuoteMeta(`SELECT key FROM "personal_data_keys" WHERE subject_id = $1`)
+	queryInsert := regexp.QuoteMeta(`INSERT INTO "personal_data_keys" (subject_id, key) VALUES ($1, $2)`)
+	key := make([]byte, crypto.KeySize)
+
+	test.RunWithMockDB(t, "existing key", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		store, err := postgres.NewKeyStore(db, "personal_data_keys")
+		require.NoError(t, err)
+
+		dbMock.ExpectQuery(queryLoad).WithArgs("user-1").WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow(key))
+
+		loaded, err := store.GetOrCreateKey(context.Background(), "user-1")
+		assert.NoError(t, err)
+		assert.Equal(t, key, loaded)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	test.RunWithMockDB(t, "new key", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		store, err := postgres.NewKeyStore(db, "personal_data_keys")
+		require.NoError(t, err)
+
+		dbMock.ExpectQuery(queryLoad).WithArgs("user-1").WillReturnRows(sqlmock.NewRows([]string{"key"}))
+		dbMock.ExpectQuery(queryInsert).WithArgs("user-1", sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow(key))
+
+		loaded, err := store.GetOrCreateKey(context.Background(), "user-1")
+		assert.NoError(t, err)
+		assert.Equal(t, key, loaded)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestKeyStore_DeleteKey(t *testing.T) {
+	test.RunWithMockDB(t, "delete key", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		store, err := postgres.NewKeyStore(db, "personal_data_keys")
+		require.NoError(t, err)
+
+		dbMock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "personal_data_keys" WHERE subject_id = $1`)).
+			WithArgs("user-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, store.DeleteKey(context.Background(), "user-1"))
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/saga"
+)
+
+// Ensure that we satisfy the saga.LockingStorage interface
+var _ saga.LockingStorage = &SagaStorage{}
+
+// SagaStorage is a saga.Storage that persists the saga position, instances and deadlines in postgres.
+// A saga is locked using a postgres advisory lock so that only one saga.Manager runs it at a time.
+type SagaStorage struct {
+	db *sql.DB
+
+	logger goengine.Logger
+
+	queryAcquireLock      string
+	queryReleaseLock      string
+	queryLoadPosition     string
+	queryLoadInstance     string
+	queryLoadDueDeadlines string
+	queryPersistPosition  string
+	queryPersistInstance  string
+	queryInsertDeadline   string
+	queryDeleteDeadline   string
+	queryDeleteDeadlines  string
+}
+
+// NewSagaStorage returns a new SagaStorage using the provided saga, instance and deadline tables, the table names may
+// be qualified with a schema
+func NewSagaStorage(
+	db *sql.DB,
+	sagaTable,
+	instanceTable,
+	deadlineTable string,
+	logger goengine.Logger,
+) (*SagaStorage, error) {
+	switch {
+	case db == nil:
+		return nil, goengine.InvalidArgumentError("db")
+	case strings.TrimSpace(sagaTable) == "":
+		return nil, goengine.InvalidArgumentError("sagaTable")
+	case strings.TrimSpace(instanceTable) == "":
+		return nil, goengine.InvalidArgumentError("instanceTable")
+	case strings.TrimSpace(deadlineTable) == "":
+		return nil, goengine.InvalidArgumentError("deadlineTable")
+	}
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+
+	sagaTableQuoted := QuoteTableName(sagaTable)
+	sagaTableStr := QuoteString(sagaTable)
+	instanceTableQuoted := QuoteTableName(instanceTable)
+	deadlineTableQuoted := QuoteTableName(deadlineTable)
+
+	/* #nosec G201 */
+	return &SagaStorage{
+		db:     db,
+		logger: logger,
+
+		queryAcquireLock: fmt.Sprintf(
+			`SELECT pg_try_advisory_lock(%s::regclass::oid::int, hashtext($1))`,
+			sagaTableStr,
+		),
+		queryReleaseLock: fmt.Sprintf(
+			`SELECT pg_advisory_unlock(%s::regclass::oid::int, hashtext($1))`,
+			sagaTableStr,
+		),
+
+		queryLoadPosition: fmt.Sprintf(
+			`SELECT position FROM %s WHERE name = $1`,
+			sagaTableQuoted,
+		),
+		queryLoadInstance: fmt.Sprintf(
+			`SELECT state, completed FROM %s WHERE saga_name = $1 AND correlation_id = $2`,
+			instanceTableQuoted,
+		),
+		queryLoadDueDeadlines: fmt.Sprintf(
+			`SELECT id, name, correlation_id, due_at FROM %s WHERE saga_name = $1 AND due_at <= $2 ORDER BY due_at`,
+			deadlineTableQuoted,
+		),
+		queryPersistPosition: fmt.Sprintf(
+			`INSERT INTO %s (name, position) VALUES ($1, $2)
+			 ON CONFLICT (name) DO UPDATE SET position = EXCLUDED.position`,
+			sagaTableQuoted,
+		),
+		queryPersistInstance: fmt.Sprintf(
+			`INSERT INTO %s (saga_name, correlation_id, state, completed) VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (saga_name, correlation_id) DO UPDATE SET state = EXCLUDED.state, completed = EXCLUDED.completed`,
+			instanceTableQuoted,
+		),
+		queryInsertDeadline: fmt.Sprintf(
+			`INSERT INTO %s (id, saga_name, correlation_id, name, due_at) VALUES ($1, $2, $3, $4, $5)`,
+			deadlineTableQuoted,
+		),
+		queryDeleteDeadline: fmt.Sprintf(
+			`DELETE FROM %s WHERE id = $1`,
+			deadlineTableQuoted,
+		),
+		queryDeleteDeadlines: fmt.Sprintf(
+			`DELETE FROM %s WHERE saga_name = $1 AND correlation_id = $2`,
+			deadlineTableQuoted,
+		),
+	}, nil
+}
+
+// Lock acquires the advisory lock of the saga on a dedicated connection that is held until the lock is released.
+// saga.ErrSagaLocked is returned when the lock is held by another process.
+func (s *SagaStorage) Lock(ctx context.Context, sagaName string) (func(), error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, s.queryAcquireLock, sagaName).Scan(&acquired); err != nil {
+		s.closeConnection(conn, sagaName)
+		return nil, err
+	}
+	if !acquired {
+		s.closeConnection(conn, sagaName)
+		return nil, saga.ErrSagaLocked
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), s.queryReleaseLock, sagaName); err != nil {
+			s.logger.Warn("failed to release saga lock", func(e goengine.LoggerEntry) {
+				e.Error(err)
+				e.String("saga", sagaName)
+			})
+		}
+
+		s.closeConnection(conn, sagaName)
+	}, nil
+}
+
+func (s *SagaStorage) closeConnection(conn *sql.Conn, sagaName string) {
+	if err := conn.Close(); err != nil {
+		s.logger.Warn("failed to close saga lock connection", func(e goengine.LoggerEntry) {
+			e.Error(err)
+			e.String("saga", sagaName)
+		})
+	}
+}
+
+// LoadPosition returns the position within the event stream up to which the saga processed messages
+func (s *SagaStorage) LoadPosition(ctx context.Context, sagaName string) (int64, error) {
+	var position int64
+	err := s.db.QueryRowContext(ctx, s.queryLoadPosition, sagaName).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return position, err
+}
+
+// LoadInstance returns the raw saga instance or nil when the instance is unknown
+func (s *SagaStorage) LoadInstance(ctx context.Context, sagaName string, correlationID string) (*saga.RawInstance, error) {
+	var instance saga.RawInstance
+	err := s.db.QueryRowContext(ctx, s.queryLoadInstance, sagaName, correlationID).Scan(&instance.State, &instance.Completed)
+	switch err {
+	case nil:
+		return &instance, nil
+	case sql.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// LoadDueDeadlines returns the deadlines of the saga that are due at the provided time ordered by their due time
+func (s *SagaStorage) LoadDueDeadlines(ctx context.Context, sagaName string, now time.Time) ([]saga.Deadline, error) {
+	rows, err := s.db.QueryContext(ctx, s.queryLoadDueDeadlines, sagaName, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.Warn("failed to close due deadline rows", func(e goengine.LoggerEntry) {
+				e.Error(err)
+			})
+		}
+	}()
+
+	var deadlines []saga.Deadline
+	for rows.Next() {
+		var deadline saga.Deadline
+		if err := rows.Scan(&deadline.ID, &deadline.Name, &deadline.CorrelationID, &deadline.DueAt); err != nil {
+			return nil, err
+		}
+
+		deadlines = append(deadlines, deadline)
+	}
+
+	return deadlines, rows.Err()
+}
+
+// Commit persists the provided changes within a single transaction
+func (s *SagaStorage) Commit(ctx context.Context, sagaName string, commit saga.Commit) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := s.commit(ctx, tx, sagaName, commit); err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			s.logger.Error("could not rollback transaction", func(e goengine.LoggerEntry) {
+				e.Error(errRollback)
+				e.String("saga", sagaName)
+			})
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SagaStorage) commit(ctx context.Context, tx *sql.Tx, sagaName string, commit saga.Commit) error {
+	if !goengine.IsUUIDEmpty(commit.FiredDeadline) {
+		if _, err := tx.ExecContext(ctx, s.queryDeleteDeadline, commit.FiredDeadline); err != nil {
+			return err
+		}
+	}
+
+	if commit.CorrelationID != "" {
+		if _, err := tx.ExecContext(ctx, s.queryPersistInstance, sagaName, commit.CorrelationID, commit.State, commit.Completed); err != nil {
+			return err
+		}
+
+		if commit.Completed {
+			if _, err := tx.ExecContext(ctx, s.queryDeleteDeadlines, sagaName, commit.CorrelationID); err != nil {
+				return err
+			}
+		} else {
+			for _, deadline := range commit.Deadlines {
+				_, err := tx.ExecContext(
+					ctx,
+					s.queryInsertDeadline,
+					deadline.ID,
+					sagaName,
+					deadline.CorrelationID,
+					deadline.Name,
+					deadline.DueAt.UTC(),
+				)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if commit.Position > 0 {
+		if _, err := tx.ExecContext(ctx, s.queryPersistPosition, sagaName, commit.Position); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
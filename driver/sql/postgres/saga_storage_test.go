@@ -0,0 +1,217 @@
+// +build unit
+
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/sql/internal/test"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+	"github.com/hellofresh/goengine/saga"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSagaStorage(t *testing.T) {
+	test.RunWithMockDB(t, "invalid arguments", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		_, err := postgres.NewSagaStorage(nil, "sagas", "saga_instances", "saga_deadlines", nil)
+		assert.Equal(t, goengine.InvalidArgumentError("db"), err)
+
+		_, err = postgres.NewSagaStorage(db, " ", "saga_instances", "saga_deadlines", nil)
+		assert.Equal(t, goengine.InvalidArgumentError("sagaTable"), err)
+
+		_, err = postgres.NewSagaStorage(db, "sagas", "", "saga_deadlines", nil)
+		assert.Equal(t, goengine.InvalidArgumentError("instanceTable"), err)
+
+		_, err = postgres.NewSagaStorage(db, "sagas", "saga_instances", "", nil)
+		assert.Equal(t, goengine.InvalidArgumentError("deadlineTable"), err)
+	})
+}
+
+func TestSagaStorage_Lock(t *testing.T) {
+	acquireQuery := regexp.QuoteMeta(`SELECT pg_try_advisory_lock('sagas'::regclass::oid::int, hashtext($1))`)
+	releaseQuery := regexp.QuoteMeta(`SELECT pg_advisory_unlock('sagas'::regclass::oid::int, hashtext($1))`)
+
+	test.RunWithMockDB(t, "acquire and release the lock", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage, err := postgres.NewSagaStorage(db, "sagas", "saga_instances", "saga_deadlines", nil)
+		require.NoError(t, err)
+
+		dbMock.ExpectQuery(acquireQuery).WithArgs("order_saga").WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(true))
+		dbMock.ExpectExec(releaseQuery).WithArgs("order_saga").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		release, err := storage.Lock(context.Background(), "order_saga")
+		require.NoError(t, err)
+		release()
+
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	test.RunWithMockDB(t, "saga locked by another manager", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage, err := postgres.NewSagaStorage(db, "sagas", "saga_instances", "saga_deadlines", nil)
+		require.NoError(t, err)
+
+		dbMock.ExpectQuery(acquireQuery).WithArgs("order_saga").WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(false))
+
+		release, err := storage.Lock(context.Background(), "order_saga")
+		assert.Equal(t, saga.ErrSagaLocked, err)
+		assert.Nil(t, release)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestSagaStorage_Schema(t *testing.T) {
+	test.RunWithMockDB(t, "schema qualified tables", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage, err := postgres.NewSagaStorage(db, "billing.sagas", "billing.saga_instances", "billing.saga_deadlines", nil)
+		require.NoError(t, err)
+
+		dbMock.ExpectQuery(regexp.QuoteMeta(`SELECT position FROM "billing"."sagas" WHERE name = $1`)).
+			WithArgs("order_saga").
+			WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(3))
+
+		position, err := storage.LoadPosition(context.Background(), "order_saga")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), position)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestSagaStorage_LoadPosition(t *testing.T) {
+	query := regexp.QuoteMeta(`SELECT position FROM "sagas" WHERE name = $1`)
+
+	test.RunWithMockDB(t, "known saga", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage, err := postgres.NewSagaStorage(db, "sagas", "saga_instances", "saga_deadlines", nil)
+		require.NoError(t, err)
+
+		dbMock.ExpectQuery(query).WithArgs("order_saga").WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(12))
+
+		position, err := storage.LoadPosition(context.Background(), "order_saga")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12), position)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	test.RunWithMockDB(t, "unknown saga", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage, err := postgres.NewSagaStorage(db, "sagas", "saga_instances", "saga_deadlines", nil)
+		require.NoError(t, err)
+
+		dbMock.ExpectQuery(query).WithArgs("order_saga").WillReturnRows(sqlmock.NewRows([]string{"position"}))
+
+		position, err := storage.LoadPosition(context.Background(), "order_saga")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), position)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestSagaStorage_LoadInstance(t *testing.T) {
+	query := regexp.QuoteMeta(`SELECT state, completed FROM "saga_instances" WHERE saga_name = $1 AND correlation_id = $2`)
+
+	test.RunWithMockDB(t, "known instance", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage, err := postgres.NewSagaStorage(db, "sagas", "saga_instances", "saga_deadlines", nil)
+		require.NoError(t, err)
+
+		dbMock.ExpectQuery(query).WithArgs("order_saga", "order-1").
+			WillReturnRows(sqlmock.NewRows([]string{"state", "completed"}).AddRow([]byte(`{"paid":true}`), true))
+
+		instance, err := storage.LoadInstance(context.Background(), "order_saga", "order-1")
+		assert.NoError(t, err)
+		assert.Equal(t, &saga.RawInstance{State: []byte(`{"paid":true}`), Completed: true}, instance)
+	})
+
+	test.RunWithMockDB(t, "unknown instance", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage, err := postgres.NewSagaStorage(db, "sagas", "saga_instances", "saga_deadlines", nil)
+		require.NoError(t, err)
+
+		dbMock.ExpectQuery(query).WithArgs("order_saga", "order-1").
+			WillReturnRows(sqlmock.NewRows([]string{"state", "completed"}))
+
+		instance, err := storage.LoadInstance(context.Background(), "order_saga", "order-1")
+		assert.NoError(t, err)
+		assert.Nil(t, instance)
+	})
+}
+
+func TestSagaStorage_Commit(t *testing.T) {
+	test.RunWithMockDB(t, "persist instance, deadlines and position", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage, err := postgres.NewSagaStorage(db, "sagas", "saga_instances", "saga_deadlines", nil)
+		require.NoError(t, err)
+
+		firedID := goengine.GenerateUUID()
+		deadline := saga.Deadline{
+			ID:            goengine.GenerateUUID(),
+			Name:          "payment_timeout",
+			CorrelationID: "order-1",
+			DueAt:         time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "saga_deadlines" WHERE id = $1`)).
+			WithArgs(firedID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "saga_instances"`)).
+			WithArgs("order_saga", "order-1", []byte(`{}`), false).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "saga_deadlines"`)).
+			WithArgs(deadline.ID, "order_saga", "order-1", "payment_timeout", deadline.DueAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "sagas"`)).
+			WithArgs("order_saga", int64(5)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectCommit()
+
+		err = storage.Commit(context.Background(), "order_saga", saga.Commit{
+			Position:      5,
+			CorrelationID: "order-1",
+			State:         []byte(`{}`),
+			Deadlines:     []saga.Deadline{deadline},
+			FiredDeadline: firedID,
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	test.RunWithMockDB(t, "completed instance removes deadlines", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage, err := postgres.NewSagaStorage(db, "sagas", "saga_instances", "saga_deadlines", nil)
+		require.NoError(t, err)
+
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "saga_instances"`)).
+			WithArgs("order_saga", "order-1", []byte(`{}`), true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "saga_deadlines" WHERE saga_name = $1 AND correlation_id = $2`)).
+			WithArgs("order_saga", "order-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectCommit()
+
+		err = storage.Commit(context.Background(), "order_saga", saga.Commit{
+			CorrelationID: "order-1",
+			State:         []byte(`{}`),
+			Completed:     true,
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	test.RunWithMockDB(t, "rollback on error", func(t *testing.T, db *sql.DB, dbMock sqlmock.Sqlmock) {
+		storage, err := postgres.NewSagaStorage(db, "sagas", "saga_instances", "saga_deadlines", nil)
+		require.NoError(t, err)
+
+		expectedErr := sql.ErrConnDone
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "sagas"`)).WillReturnError(expectedErr)
+		dbMock.ExpectRollback()
+
+		err = storage.Commit(context.Background(), "order_saga", saga.Commit{Position: 1})
+
+		assert.Equal(t, expectedErr, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
@@ -12,3 +12,24 @@ func QuoteString(str string) string {
 func QuoteIdentifier(name string) string {
 	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
 }
+
+// QuoteTableName quotes a table name that is optionally qualified with a schema (e.g. `schema.table`) to be
+// used as part of an SQL statement.
+func QuoteTableName(name string) string {
+	schema, table := SplitTableName(name)
+	if schema == "" {
+		return QuoteIdentifier(table)
+	}
+
+	return QuoteIdentifier(schema) + "." + QuoteIdentifier(table)
+}
+
+// SplitTableName returns the schema and the table of a table name that is optionally qualified with a schema.
+// The schema is empty when the table name is not qualified.
+func SplitTableName(name string) (schema string, table string) {
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+
+	return "", name
+}
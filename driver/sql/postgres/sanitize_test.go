@@ -0,0 +1,38 @@
+// +build unit
+
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteTableName(t *testing.T) {
+	testCases := []struct {
+		title    string
+		name     string
+		expected string
+	}{
+		{"unqualified table", "events_orders", `"events_orders"`},
+		{"qualified table", "billing.events_orders", `"billing"."events_orders"`},
+		{"quote in table", `events"orders`, `"events""orders"`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, postgres.QuoteTableName(testCase.name))
+		})
+	}
+}
+
+func TestSplitTableName(t *testing.T) {
+	schema, table := postgres.SplitTableName("billing.events_orders")
+	assert.Equal(t, "billing", schema)
+	assert.Equal(t, "events_orders", table)
+
+	schema, table = postgres.SplitTableName("events_orders")
+	assert.Equal(t, "", schema)
+	assert.Equal(t, "events_orders", table)
+}
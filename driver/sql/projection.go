@@ -18,6 +18,9 @@ type (
 		No          int64     `json:"no"`
 		AggregateID string    `json:"aggregate_id"`
 		ValidAfter  time.Time `json:"valid_after"`
+
+		// queuedAt is the time the notification was last queued by a ProjectionNotificationProcessor
+		queuedAt time.Time
 	}
 
 	// ProjectionTrigger triggers the notification for processing
@@ -88,6 +91,23 @@ type (
 	}
 )
 
+// QueuedAt returns the time the notification was last queued.
+// The zero time is returned for a nil notification or a notification that was never queued.
+func (p *ProjectionNotification) QueuedAt() time.Time {
+	if p == nil {
+		return time.Time{}
+	}
+
+	return p.queuedAt
+}
+
+// markQueued records the current time as the time the notification was queued
+func (p *ProjectionNotification) markQueued() {
+	if p != nil {
+		p.queuedAt = time.Now()
+	}
+}
+
 // UnmarshalJSON supports json.Unmarshaler interface
 func (p *ProjectionNotification) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
@@ -118,7 +138,8 @@ func (p *ProjectionNotification) UnmarshalEasyJSON(in *jlexer.Lexer) {
 		case "no":
 			p.No = in.Int64()
 		case "aggregate_id":
-			p.AggregateID = in.String()
+			// The aggregate id is a number when the aggregate id column is a integer
+			p.AggregateID = string(in.JsonNumber())
 		default:
 			in.SkipRecursive()
 		}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/hellofresh/goengine"
 	"github.com/pkg/errors"
@@ -14,8 +15,10 @@ type (
 	ProjectionNotificationProcessor struct {
 		queueProcessors int
 
-		logger  goengine.Logger
-		metrics Metrics
+		logger            goengine.Logger
+		metrics           Metrics
+		projectionName    string
+		projectionMetrics ProjectionMetrics
 
 		notificationQueue NotificationQueuer
 	}
@@ -23,8 +26,19 @@ type (
 	// ProcessHandler is a func used to trigger a notification but with the addition of providing a Trigger func so
 	// the original notification can trigger other notifications
 	ProcessHandler func(context.Context, *ProjectionNotification, ProjectionTrigger) error
+
+	// BackgroundProcessorOption configures optional behaviour of a ProjectionNotificationProcessor
+	BackgroundProcessorOption func(*ProjectionNotificationProcessor)
 )
 
+// WithProjectionName sets the name of the projection of which the background processor processes the notifications.
+// The name is passed to the Metrics when they implement ProjectionMetrics.
+func WithProjectionName(projectionName string) BackgroundProcessorOption {
+	return func(b *ProjectionNotificationProcessor) {
+		b.projectionName = projectionName
+	}
+}
+
 // NewBackgroundProcessor create a new projectionNotificationProcessor
 func NewBackgroundProcessor(
 	queueProcessors,
@@ -32,6 +46,7 @@ func NewBackgroundProcessor(
 	logger goengine.Logger,
 	metrics Metrics,
 	notificationQueue NotificationQueuer,
+	options ...BackgroundProcessorOption,
 ) (*ProjectionNotificationProcessor, error) {
 	if queueProcessors <= 0 {
 		return nil, errors.New("queueProcessors must be greater then zero")
@@ -46,15 +61,21 @@ func NewBackgroundProcessor(
 		metrics = NopMetrics
 	}
 	if notificationQueue == nil {
-		notificationQueue = newNotificationQueue(queueBuffer, 0, metrics)
+		notificationQueue = newNotificationQueue(queueBuffer, 0)
 	}
 
-	return &ProjectionNotificationProcessor{
+	processor := &ProjectionNotificationProcessor{
 		queueProcessors:   queueProcessors,
 		logger:            logger,
 		metrics:           metrics,
 		notificationQueue: notificationQueue,
-	}, nil
+	}
+	processor.projectionMetrics, _ = metrics.(ProjectionMetrics)
+	for _, option := range options {
+		option(processor)
+	}
+
+	return processor, nil
 }
 
 // Execute starts the background worker and wait for the notification to be executed
@@ -67,7 +88,7 @@ func (b *ProjectionNotificationProcessor) Execute(ctx context.Context, handler P
 	defer stopExecutor()
 
 	// Execute a run of the internal.
-	if err := b.notificationQueue.Queue(ctx, nil); err != nil {
+	if err := b.queue(ctx, nil); err != nil {
 		return err
 	}
 
@@ -99,12 +120,50 @@ func (b *ProjectionNotificationProcessor) Start(ctx context.Context, handler Pro
 	return func() {
 		queueClose()
 		wg.Wait()
+
+		if b.projectionMetrics != nil {
+			b.projectionMetrics.StopProjectionNotificationProcessing(b.projectionName)
+		}
 	}
 }
 
 // Queue puts the notification on the queue to be processed
 func (b *ProjectionNotificationProcessor) Queue(ctx context.Context, notification *ProjectionNotification) error {
-	return b.notificationQueue.Queue(ctx, notification)
+	return b.queue(ctx, notification)
+}
+
+// queue records the time the notification is queued and sends it to the queue
+func (b *ProjectionNotificationProcessor) queue(ctx context.Context, notification *ProjectionNotification) error {
+	notification.markQueued()
+	if err := b.notificationQueue.Queue(ctx, notification); err != nil {
+		return err
+	}
+	b.queueMetrics(notification)
+
+	return nil
+}
+
+// reQueue records the time the notification is queued and sends it to the queue to be retried
+func (b *ProjectionNotificationProcessor) reQueue(ctx context.Context, notification *ProjectionNotification) error {
+	notification.markQueued()
+	if err := b.notificationQueue.ReQueue(ctx, notification); err != nil {
+		return err
+	}
+	b.queueMetrics(notification)
+
+	return nil
+}
+
+// queueMetrics records that the notification is queued.
+// This is done after the notification is queued so a notification that could not be queued is never counted, as a
+// result the processing of the notification may be recorded before it's queueing.
+func (b *ProjectionNotificationProcessor) queueMetrics(notification *ProjectionNotification) {
+	if b.projectionMetrics != nil {
+		b.projectionMetrics.QueueProjectionNotification(b.projectionName, notification)
+		return
+	}
+
+	b.metrics.QueueNotification(notification)
 }
 
 func (b *ProjectionNotificationProcessor) startProcessor(ctx context.Context, handler ProcessHandler) {
@@ -116,27 +175,47 @@ func (b *ProjectionNotificationProcessor) startProcessor(ctx context.Context, ha
 
 		var queueFunc ProjectionTrigger
 		if notification == nil {
-			queueFunc = b.notificationQueue.Queue
+			queueFunc = b.queue
 		} else {
-			queueFunc = b.notificationQueue.ReQueue
+			queueFunc = b.reQueue
 		}
 
 		// Execute the notification
-		b.metrics.StartNotificationProcessing(notification)
+		b.startMetrics(notification)
+		start := time.Now()
 		if err := handler(ctx, notification, queueFunc); err != nil {
 			b.logger.Error("the ProcessHandler produced an error", func(e goengine.LoggerEntry) {
 				e.Error(err)
 				e.Any("notification", notification)
 			})
 
-			b.metrics.FinishNotificationProcessing(notification, false)
-
+			b.finishMetrics(notification, time.Since(start), false)
 		} else {
-			b.metrics.FinishNotificationProcessing(notification, true)
+			b.finishMetrics(notification, time.Since(start), true)
 		}
 	}
 }
 
+// startMetrics records that the processing of the notification started
+func (b *ProjectionNotificationProcessor) startMetrics(notification *ProjectionNotification) {
+	if b.projectionMetrics != nil {
+		b.projectionMetrics.StartProjectionNotificationProcessing(b.projectionName, notification)
+		return
+	}
+
+	b.metrics.StartNotificationProcessing(notification)
+}
+
+// finishMetrics records that the processing of the notification finished
+func (b *ProjectionNotificationProcessor) finishMetrics(notification *ProjectionNotification, duration time.Duration, success bool) {
+	if b.projectionMetrics != nil {
+		b.projectionMetrics.FinishProjectionNotificationProcessing(b.projectionName, notification, duration, success)
+		return
+	}
+
+	b.metrics.FinishNotificationProcessing(notification, success)
+}
+
 // wrapProcessHandlerForSingleRun returns a wrapped ProcessHandler with a done channel that is closed after the
 // provided ProcessHandler it's first call and related messages are finished or when the context is done.
 func (b *ProjectionNotificationProcessor) wrapProcessHandlerForSingleRun(handler ProcessHandler) (ProcessHandler, chan struct{}) {
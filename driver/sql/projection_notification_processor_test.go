@@ -4,13 +4,16 @@ package sql_test
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/driver/sql/admin"
 	mocks "github.com/hellofresh/goengine/mocks/driver/sql"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -112,3 +115,50 @@ func TestStartProcessor(t *testing.T) {
 		})
 	}
 }
+
+func TestProjectionNotificationProcessor_Queue(t *testing.T) {
+	t.Run("Notification that could not be queued is not recorded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.Background()
+		notification := &sql.ProjectionNotification{No: 1, AggregateID: "abc"}
+		queueErr := errors.New("queue closed")
+
+		notificationQueue := mocks.NewNotificationQueuer(ctrl)
+		notificationQueue.EXPECT().Queue(ctx, notification).Return(queueErr)
+
+		monitor := admin.NewMonitor(nil)
+		processor, err := sql.NewBackgroundProcessor(1, 1, nil, monitor, notificationQueue, sql.WithProjectionName("balance"))
+		require.NoError(t, err)
+
+		assert.Equal(t, queueErr, processor.Queue(ctx, notification))
+		assert.NotContains(t, monitor.Projections(), "balance")
+	})
+
+	t.Run("Queue depth is reset when the processor stops", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		monitor := admin.NewMonitor(nil)
+		processor, err := sql.NewBackgroundProcessor(1, 2, nil, monitor, nil, sql.WithProjectionName("balance"))
+		require.NoError(t, err)
+
+		block := make(chan struct{})
+		stop := processor.Start(ctx, func(context.Context, *sql.ProjectionNotification, sql.ProjectionTrigger) error {
+			<-block
+			return nil
+		})
+
+		require.NoError(t, processor.Queue(ctx, &sql.ProjectionNotification{No: 1}))
+		require.NoError(t, processor.Queue(ctx, &sql.ProjectionNotification{No: 2}))
+
+		close(block)
+		stop()
+
+		stats := monitor.Projections()["balance"]
+		assert.Equal(t, int64(0), stats.QueueDepth)
+		assert.Equal(t, int64(0), stats.ProcessingNotifications)
+		assert.Nil(t, stats.OldestQueuedAt)
+	})
+}
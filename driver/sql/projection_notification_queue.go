@@ -26,7 +26,6 @@ type (
 	// NotificationQueue implements a smart queue
 	NotificationQueue struct {
 		retryDelay  time.Duration
-		metrics     Metrics
 		done        chan struct{}
 		queue       chan *ProjectionNotification
 		queueLock   sync.Mutex
@@ -35,14 +34,13 @@ type (
 	}
 )
 
-func newNotificationQueue(queueBuffer int, retryDelay time.Duration, metrics Metrics) *NotificationQueue {
+func newNotificationQueue(queueBuffer int, retryDelay time.Duration) *NotificationQueue {
 	if retryDelay == 0 {
 		retryDelay = time.Millisecond * 50
 	}
 
 	return &NotificationQueue{
 		retryDelay:  retryDelay,
-		metrics:     metrics,
 		queueBuffer: queueBuffer,
 	}
 }
@@ -103,8 +101,6 @@ func (nq *NotificationQueue) Queue(ctx context.Context, notification *Projection
 
 	atomic.AddInt32(&nq.queueCount, 1)
 
-	nq.metrics.QueueNotification(notification)
-
 	nq.queueNotification(notification)
 
 	return nil
@@ -0,0 +1,43 @@
+// +build unit
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectionNotification_UnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		title    string
+		json     string
+		expected ProjectionNotification
+	}{
+		{
+			"uuid aggregate id",
+			`{"no":1,"event_name":"order_placed","aggregate_id":"8150276e-34fe-49d9-aeae-a35af0040a4f"}`,
+			ProjectionNotification{No: 1, AggregateID: "8150276e-34fe-49d9-aeae-a35af0040a4f"},
+		},
+		{
+			"integer aggregate id",
+			`{"no":2,"event_name":"order_placed","aggregate_id":1042}`,
+			ProjectionNotification{No: 2, AggregateID: "1042"},
+		},
+		{
+			"no aggregate id",
+			`{"no":3,"aggregate_id":null}`,
+			ProjectionNotification{No: 3},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			var notification ProjectionNotification
+			err := notification.UnmarshalJSON([]byte(testCase.json))
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, notification)
+		})
+	}
+}
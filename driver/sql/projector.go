@@ -5,41 +5,47 @@ import (
 	"database/sql/driver"
 )
 
-type errorAction int
+// ProjectorErrorAction is the way a projector handles an error that occurred while projecting a notification
+type ProjectorErrorAction int
 
 const (
-	errorRetry errorAction = iota
-	errorFail
-	errorIgnore
-	errorFallthrough
+	// ProjectorErrorRetry indicates the notification should be projected again
+	ProjectorErrorRetry ProjectorErrorAction = iota
+	// ProjectorErrorFail indicates the projection should be marked as failed
+	ProjectorErrorFail
+	// ProjectorErrorIgnore indicates the error should be ignored
+	ProjectorErrorIgnore
+	// ProjectorErrorFallthrough indicates the error should be returned by the projector
+	ProjectorErrorFallthrough
 )
 
-// resolveErrorAction determines the way the provided error should be handled by the projector
-func resolveErrorAction(
+// ResolveProjectorErrorAction determines the way the provided error should be handled by the projector.
+// It's shared by all projector implementations so they handle errors and the ProjectionErrorCallback the same way.
+func ResolveProjectorErrorAction(
 	projectionCallback ProjectionErrorCallback,
 	notification *ProjectionNotification,
 	err error,
-) errorAction {
+) ProjectorErrorAction {
 	switch err {
 	case ErrProjectionPreviouslyLocked:
-		return errorFail
+		return ProjectorErrorFail
 	case context.Canceled, ErrNoProjectionRequired:
-		return errorIgnore
+		return ProjectorErrorIgnore
 	case driver.ErrBadConn, ErrConnFailedToAcquire, ErrProjectionFailedToLock:
-		return errorRetry
+		return ProjectorErrorRetry
 	default:
 		switch e := err.(type) {
 		case *ProjectionHandlerError:
 			switch projectionCallback(e.Cause(), notification) {
 			case ProjectionRetry:
-				return errorRetry
+				return ProjectorErrorRetry
 			case ProjectionIgnoreError:
-				return errorIgnore
+				return ProjectorErrorIgnore
 			case ProjectionFail:
-				return errorFail
+				return ProjectorErrorFail
 			}
 		}
 	}
 
-	return errorFallthrough
+	return ProjectorErrorFallthrough
 }
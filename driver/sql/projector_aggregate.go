@@ -61,18 +61,22 @@ func NewAggregateProjector(
 		e.String("projection", projection.Name())
 	})
 
-	processor, err := NewBackgroundProcessor(10, 32, logger, metrics, nil)
+	processor, err := NewBackgroundProcessor(10, 32, logger, metrics, nil, WithProjectionName(projection.Name()))
 	if err != nil {
 		return nil, err
 	}
 
+	projectionMetrics, _ := metrics.(ProjectionMetrics)
+
 	executor, err := newNotificationProjector(
 		db,
+		projection.Name(),
 		projectorStorage,
 		projection.Handlers(),
 		eventLoader,
 		resolver,
 		logger,
+		projectionMetrics,
 	)
 	if err != nil {
 		return nil, err
@@ -152,14 +156,14 @@ func (a *AggregateProjector) processNotification(
 	}
 
 	// Resolve the action to take based on the error that occurred
-	switch resolveErrorAction(a.projectionErrorHandler, notification, err) {
-	case errorFail:
+	switch ResolveProjectorErrorAction(a.projectionErrorHandler, notification, err) {
+	case ProjectorErrorFail:
 		a.logger.Debug("ProcessHandler->ErrorHandler: marking projection as failed", logFields)
 		return a.markProjectionAsFailed(notification)
-	case errorIgnore:
+	case ProjectorErrorIgnore:
 		a.logger.Debug("ProcessHandler->ErrorHandler: ignoring error", logFields)
 		return nil
-	case errorRetry:
+	case ProjectorErrorRetry:
 		a.logger.Debug("ProcessHandler->ErrorHandler: re-queueing notification", logFields)
 		return queue(ctx, notification)
 	}
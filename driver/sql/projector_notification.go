@@ -3,6 +3,7 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/hellofresh/goengine"
 	"github.com/pkg/errors"
@@ -15,22 +16,26 @@ var _ ProjectionTrigger = (&notificationProjector{}).Execute
 type notificationProjector struct {
 	db *sql.DB
 
-	storage     ProjectorStorage
-	handlers    map[string]goengine.MessageHandler
-	eventLoader EventStreamLoader
-	resolver    goengine.MessagePayloadResolver
+	projectionName string
+	storage        ProjectorStorage
+	handlers       map[string]goengine.MessageHandler
+	eventLoader    EventStreamLoader
+	resolver       goengine.MessagePayloadResolver
 
-	logger goengine.Logger
+	logger  goengine.Logger
+	metrics ProjectionMetrics
 }
 
 // newNotificationProjector returns a new notificationProjector
 func newNotificationProjector(
 	db *sql.DB,
+	projectionName string,
 	storage ProjectorStorage,
 	eventHandlers map[string]goengine.MessageHandler,
 	eventLoader EventStreamLoader,
 	resolver goengine.MessagePayloadResolver,
 	logger goengine.Logger,
+	metrics ProjectionMetrics,
 ) (*notificationProjector, error) {
 	switch {
 	case db == nil:
@@ -49,13 +54,19 @@ func newNotificationProjector(
 		logger = goengine.NopLogger
 	}
 
+	if metrics == nil {
+		metrics = &nopMetrics{}
+	}
+
 	return &notificationProjector{
-		db:          db,
-		storage:     storage,
-		handlers:    wrapProjectionHandlers(eventHandlers),
-		eventLoader: eventLoader,
-		resolver:    resolver,
-		logger:      logger,
+		db:             db,
+		projectionName: projectionName,
+		storage:        storage,
+		handlers:       WrapProjectionHandlers(eventHandlers),
+		eventLoader:    eventLoader,
+		resolver:       resolver,
+		logger:         logger,
+		metrics:        metrics,
 	}, nil
 }
 
@@ -102,10 +113,23 @@ func (s *notificationProjector) project(
 	conn *sql.Conn,
 	streamConn *sql.Conn,
 	notification *ProjectionNotification,
-) error {
+) (err error) {
+	ctx, span := goengine.TracerFromContext(ctx).StartSpan(ctx, "goengine.projection.project")
+	defer func() {
+		span.Error(err)
+		span.End()
+	}()
+	if notification != nil {
+		span.Int64("notification.no", notification.No)
+		span.String("notification.aggregate_id", notification.AggregateID)
+	}
+
 	// Acquire the projection
 	transaction, position, err := s.storage.Acquire(ctx, conn, notification)
 	if err != nil {
+		if err == ErrProjectionFailedToLock {
+			s.metrics.FailedToLockProjection(s.projectionName)
+		}
 		return err
 	}
 	defer func() {
@@ -167,14 +191,19 @@ func (s *notificationProjector) projectStream(
 		}
 
 		// Execute the handler
+		handlerStart := time.Now()
 		state.Position = stream.MessageNumber()
 		state.ProjectionState, err = stream.Project(ctx, state.ProjectionState)
+		s.metrics.ExecuteProjectionHandler(s.projectionName, stream.eventName, time.Since(handlerStart), err == nil)
 		if err != nil {
 			return err
 		}
 
 		// Persist state and position changes
-		if err = tx.CommitState(state); err != nil {
+		commitStart := time.Now()
+		err = tx.CommitState(state)
+		s.metrics.CommitProjectionState(s.projectionName, state.Position, time.Since(commitStart), err == nil)
+		if err != nil {
 			return err
 		}
 	}
@@ -182,16 +211,31 @@ func (s *notificationProjector) projectStream(
 	return stream.Err()
 }
 
-// wrapProjectionHandlers wraps the projection handlers so that any error or panic is caught and returned
-func wrapProjectionHandlers(handlers map[string]goengine.MessageHandler) map[string]goengine.MessageHandler {
+// WrapProjectionHandlers wraps the projection handlers so that any error or panic is caught and returned
+func WrapProjectionHandlers(handlers map[string]goengine.MessageHandler) map[string]goengine.MessageHandler {
 	res := make(map[string]goengine.MessageHandler, len(handlers))
 	for k, h := range handlers {
-		res[k] = wrapProjectionHandlerToTrapError(h)
+		res[k] = wrapProjectionHandlerToTrace(k, wrapProjectionHandlerToTrapError(h))
 	}
 
 	return res
 }
 
+// wrapProjectionHandlerToTrace wraps a projection handler so that it's execution is traced.
+// The span is linked to the span that appended the message to the event stream
+func wrapProjectionHandlerToTrace(eventName string, handler goengine.MessageHandler) goengine.MessageHandler {
+	return func(ctx context.Context, state interface{}, message goengine.Message) (interface{}, error) {
+		ctx, span := goengine.TracerFromContext(ctx).StartMessageSpan(ctx, "goengine.projection.handle", message)
+		defer span.End()
+		span.String("event_name", eventName)
+
+		state, err := handler(ctx, state, message)
+		span.Error(err)
+
+		return state, err
+	}
+}
+
 // wrapProjectionHandlerToTrapError wraps a projection handler with error catching code.
 // This ensures a projection handler can return a error or panic without destroying the executor
 func wrapProjectionHandlerToTrapError(handler goengine.MessageHandler) goengine.MessageHandler {
@@ -23,6 +23,21 @@ type StreamProjector struct {
 	logger goengine.Logger
 }
 
+// StreamProjectorOption configures optional behaviour of a StreamProjector
+type StreamProjectorOption func(*streamProjectorConfig)
+
+// streamProjectorConfig contains the optional configuration of a StreamProjector
+type streamProjectorConfig struct {
+	metrics ProjectionMetrics
+}
+
+// WithStreamProjectorMetrics sets the metrics used to observe the projection handlers and state commits
+func WithStreamProjectorMetrics(metrics ProjectionMetrics) StreamProjectorOption {
+	return func(c *streamProjectorConfig) {
+		c.metrics = metrics
+	}
+}
+
 // NewStreamProjector creates a new projector for a projection
 func NewStreamProjector(
 	db *sql.DB,
@@ -32,6 +47,7 @@ func NewStreamProjector(
 	projectorStorage StreamProjectorStorage,
 	projectionErrorHandler ProjectionErrorCallback,
 	logger goengine.Logger,
+	options ...StreamProjectorOption,
 ) (*StreamProjector, error) {
 	switch {
 	case db == nil:
@@ -55,13 +71,20 @@ func NewStreamProjector(
 		e.String("projection", projection.Name())
 	})
 
+	var config streamProjectorConfig
+	for _, option := range options {
+		option(&config)
+	}
+
 	executor, err := newNotificationProjector(
 		db,
+		projection.Name(),
 		projectorStorage,
 		projection.Handlers(),
 		eventLoader,
 		resolver,
 		logger,
+		config.metrics,
 	)
 	if err != nil {
 		return nil, err
@@ -136,14 +159,14 @@ func (s *StreamProjector) processNotification(
 				e.String("notification.aggregate_id", notification.AggregateID)
 			}
 		}
-		switch resolveErrorAction(s.projectionErrorHandler, notification, err) {
-		case errorRetry:
+		switch ResolveProjectorErrorAction(s.projectionErrorHandler, notification, err) {
+		case ProjectorErrorRetry:
 			s.logger.Debug("Trigger->ErrorHandler: retrying notification", logFields)
 			continue
-		case errorIgnore:
+		case ProjectorErrorIgnore:
 			s.logger.Debug("Trigger->ErrorHandler: ignoring error", logFields)
 			return nil
-		case errorFail, errorFallthrough:
+		case ProjectorErrorFail, ProjectorErrorFallthrough:
 			s.logger.Debug("Trigger->ErrorHandler: error fallthrough", logFields)
 			return err
 		}
@@ -0,0 +1,344 @@
+// Package sse provides a http.Handler streaming the events of an event stream to browsers using Server-Sent Events
+package sse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/strategy/json/ndjson"
+)
+
+// heartbeatInterval is the interval at which a comment is send to keep idle connections open
+const heartbeatInterval = 30 * time.Second
+
+// lineBreakStripper removes the line breaks that would end a field of a server-sent event
+var lineBreakStripper = strings.NewReplacer("\r", "", "\n", "")
+
+// operators are the supported constraint operators, longer operators first so that they take precedence
+var operators = []metadata.Operator{
+	metadata.NotEquals,
+	metadata.GreaterThanEquals,
+	metadata.LowerThanEquals,
+	metadata.Equals,
+	metadata.GreaterThan,
+	metadata.LowerThan,
+}
+
+// Ensure Handler implements http.Handler
+var _ http.Handler = &Handler{}
+
+// Handler is a http.Handler streaming the events of an event stream as Server-Sent Events.
+//
+// Every event is send with it's number as id, it's name as event type and the JSON encoded event as data:
+//
+//	id: 1
+//	event: account_deposited
+//	data: {"no":1,"event_id":"...","event_name":"account_deposited","payload":{...},"metadata":{...},"created_at":"..."}
+//
+// The stream is resumed after the number in the Last-Event-ID header and events are filtered using the metadata
+// constraints in the where query parameters, for example `?where=_aggregate_id=...&where=_aggregate_version>1`.
+//
+// Connected clients receive newly appended events once the Handler is notified by the listener passed to Listen.
+type Handler struct {
+	store      goengine.ReadOnlyEventStore
+	streamName goengine.StreamName
+	converter  goengine.MessagePayloadConverter
+
+	logger goengine.Logger
+
+	mu          sync.Mutex
+	subscribers map[chan struct{}]struct{}
+}
+
+// NewHandler returns a new Handler streaming the events of the provided stream
+func NewHandler(
+	store goengine.ReadOnlyEventStore,
+	streamName goengine.StreamName,
+	converter goengine.MessagePayloadConverter,
+	logger goengine.Logger,
+) (*Handler, error) {
+	switch {
+	case store == nil:
+		return nil, goengine.InvalidArgumentError("store")
+	case strings.TrimSpace(string(streamName)) == "":
+		return nil, goengine.InvalidArgumentError("streamName")
+	case converter == nil:
+		return nil, goengine.InvalidArgumentError("converter")
+	}
+
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+
+	return &Handler{
+		store:       store,
+		streamName:  streamName,
+		converter:   converter,
+		logger:      logger,
+		subscribers: map[chan struct{}]struct{}{},
+	}, nil
+}
+
+// Listen starts listening using the listener and notifies connected clients when a event was appended.
+// Listen blocks until the context is done or the listener stopped.
+func (h *Handler) Listen(ctx context.Context, listener driverSQL.Listener) error {
+	if listener == nil {
+		return goengine.InvalidArgumentError("listener")
+	}
+
+	return listener.Listen(ctx, func(context.Context, *driverSQL.ProjectionNotification) error {
+		h.notify()
+		return nil
+	})
+}
+
+// ServeHTTP streams the events of the event stream until the client disconnects
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	fromNumber := int64(1)
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		number, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || number < 0 {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		fromNumber = number + 1
+	}
+
+	matcher, err := parseMatcher(r.URL.Query()["where"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	// Subscribe before loading the events to ensure no notification is missed
+	notifications := h.subscribe()
+	defer h.unsubscribe(notifications)
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	started := false
+	for {
+		stream, err := h.store.Load(ctx, h.streamName, fromNumber, nil, matcher)
+		if err != nil {
+			if !started {
+				h.writeLoadError(ctx, w, err)
+				return
+			}
+
+			h.logLoadError(err)
+			return
+		}
+
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			w.WriteHeader(http.StatusOK)
+			flusher.Flush()
+			started = true
+		}
+
+		lastNumber, err := h.write(w, flusher, stream)
+		if lastNumber >= fromNumber {
+			fromNumber = lastNumber + 1
+		}
+		if err != nil {
+			// Writing fails once the client disconnected which is not worth logging
+			if ctx.Err() == nil {
+				h.logLoadError(err)
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-notifications:
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// write writes and flushes the events of the stream one by one as server-sent events, closes the stream and returns
+// the number of the last written event
+func (h *Handler) write(w io.Writer, flusher http.Flusher, stream goengine.EventStream) (int64, error) {
+	defer func() {
+		if err := stream.Close(); err != nil {
+			h.logger.Warn("failed to close event stream", func(e goengine.LoggerEntry) {
+				e.Error(err)
+			})
+		}
+	}()
+
+	var (
+		lastNumber int64
+		data       bytes.Buffer
+	)
+	encoder, err := ndjson.NewEncoder(&data, h.converter)
+	if err != nil {
+		return 0, err
+	}
+
+	for stream.Next() {
+		msg, number, err := stream.Message()
+		if err != nil {
+			return lastNumber, err
+		}
+
+		eventName, payload, err := h.converter.ConvertPayload(msg.Payload())
+		if err != nil {
+			return lastNumber, err
+		}
+
+		data.Reset()
+		if err := encoder.EncodeConverted(msg, number, eventName, payload); err != nil {
+			return lastNumber, err
+		}
+
+		// The JSON encoder escapes new lines so the data always fits on a single line, the event name is stripped of
+		// line breaks so it can not add fields to the event
+		_, err = fmt.Fprintf(
+			w,
+			"id: %d\nevent: %s\ndata: %s\n\n",
+			number,
+			lineBreakStripper.Replace(eventName),
+			bytes.TrimSpace(data.Bytes()),
+		)
+		if err != nil {
+			return lastNumber, err
+		}
+		flusher.Flush()
+
+		lastNumber = number
+	}
+
+	return lastNumber, stream.Err()
+}
+
+// logLoadError logs an error that occurred after the event stream started
+func (h *Handler) logLoadError(err error) {
+	h.logger.Error("failed to load events for server-sent events", func(e goengine.LoggerEntry) {
+		e.Error(err)
+		e.String("stream", string(h.streamName))
+	})
+}
+
+// writeLoadError writes the response for an error that occurred before the event stream started
+func (h *Handler) writeLoadError(ctx context.Context, w http.ResponseWriter, err error) {
+	if !h.store.HasStream(ctx, h.streamName) {
+		http.Error(w, "unknown stream", http.StatusNotFound)
+		return
+	}
+
+	h.logLoadError(err)
+	http.Error(w, "failed to load events", http.StatusInternalServerError)
+}
+
+// notify wakes up all connected clients
+func (h *Handler) notify() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for notifications := range h.subscribers {
+		// A client that did not handle the previous notification yet will still load the new events
+		select {
+		case notifications <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (h *Handler) subscribe() chan struct{} {
+	notifications := make(chan struct{}, 1)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers[notifications] = struct{}{}
+
+	return notifications
+}
+
+func (h *Handler) unsubscribe(notifications chan struct{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, notifications)
+}
+
+// parseMatcher parses the `key<operator>value` constraints into a metadata.Matcher.
+// The value is used as a integer or boolean when possible and otherwise as a string.
+func parseMatcher(constraints []string) (metadata.Matcher, error) {
+	matcher := metadata.NewMatcher()
+	for _, constraint := range constraints {
+		field, operator, value, err := parseConstraint(constraint)
+		if err != nil {
+			return nil, err
+		}
+
+		matcher = metadata.WithConstraint(matcher, field, operator, value)
+	}
+
+	return matcher, nil
+}
+
+func parseConstraint(constraint string) (string, metadata.Operator, interface{}, error) {
+	for i := 0; i < len(constraint); i++ {
+		for _, operator := range operators {
+			if !strings.HasPrefix(constraint[i:], string(operator)) {
+				continue
+			}
+
+			field := strings.TrimSpace(constraint[:i])
+			if field == "" {
+				return "", "", nil, fmt.Errorf("constraint %q has no metadata key", constraint)
+			}
+
+			return field, operator, parseConstraintValue(strings.TrimSpace(constraint[i+len(operator):])), nil
+		}
+	}
+
+	return "", "", nil, fmt.Errorf("constraint %q has no operator", constraint)
+}
+
+func parseConstraintValue(value string) interface{} {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	return value
+}
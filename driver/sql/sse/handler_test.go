@@ -0,0 +1,354 @@
+// +build unit
+
+package sse_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/driver/inmemory"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/driver/sql/sse"
+	"github.com/hellofresh/goengine/eventstoretest"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/strategy/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStream goengine.StreamName = "event_stream"
+
+type accountDeposited struct {
+	Amount int `json:"amount"`
+}
+
+func TestNewHandler(t *testing.T) {
+	store := inmemory.NewEventStore(goengine.NopLogger)
+	transformer := json.NewPayloadTransformer()
+
+	testCases := []struct {
+		title       string
+		store       goengine.ReadOnlyEventStore
+		streamName  goengine.StreamName
+		converter   goengine.MessagePayloadConverter
+		expectedErr error
+	}{
+		{"nil store", nil, testStream, transformer, goengine.InvalidArgumentError("store")},
+		{"empty stream name", store, " ", transformer, goengine.InvalidArgumentError("streamName")},
+		{"nil converter", store, testStream, nil, goengine.InvalidArgumentError("converter")},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			handler, err := sse.NewHandler(testCase.store, testCase.streamName, testCase.converter, nil)
+
+			assert.Nil(t, handler)
+			assert.Equal(t, testCase.expectedErr, err)
+		})
+	}
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	firstID := aggregate.GenerateID()
+	secondID := aggregate.GenerateID()
+
+	t.Run("Stream events", func(t *testing.T) {
+		store, handler := newHandler(t)
+		appendEvents(t, store, firstID, 1, 2)
+
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		listener := &fakeListener{triggers: make(chan driverSQL.ProjectionTrigger, 1)}
+		go func() {
+			_ = handler.Listen(ctx, listener)
+		}()
+		trigger := <-listener.triggers
+
+		res := get(ctx, t, server.URL, "")
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+		reader := bufio.NewReader(res.Body)
+		assertEvent(t, reader, "1", `"payload":{"amount":1}`)
+		assertEvent(t, reader, "2", `"payload":{"amount":2}`)
+
+		appendEvents(t, store, secondID, 1, 1)
+		require.NoError(t, trigger(ctx, nil))
+
+		assertEvent(t, reader, "3", `"_aggregate_id":"`+string(secondID)+`"`)
+	})
+
+	t.Run("Resume after Last-Event-ID", func(t *testing.T) {
+		store, handler := newHandler(t)
+		appendEvents(t, store, firstID, 1, 3)
+
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Last-Event-ID", "2")
+
+		res, err := http.DefaultClient.Do(req.WithContext(ctx))
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assertEvent(t, bufio.NewReader(res.Body), "3", `"payload":{"amount":3}`)
+	})
+
+	t.Run("Filter on metadata", func(t *testing.T) {
+		store, handler := newHandler(t)
+		appendEvents(t, store, firstID, 1, 2)
+		appendEvents(t, store, secondID, 1, 2)
+
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		res := get(ctx, t, server.URL, "?where=_aggregate_id="+string(secondID)+"&where=_aggregate_version>1")
+		defer res.Body.Close()
+
+		assertEvent(t, bufio.NewReader(res.Body), "4", `"payload":{"amount":2}`)
+	})
+
+	t.Run("Write events while the stream is read", func(t *testing.T) {
+		store, _ := newHandler(t)
+		appendEvents(t, store, firstID, 1, 2)
+
+		release := make(chan struct{})
+		handler, err := sse.NewHandler(&blockingStore{store, release}, testStream, newPayloadTransformer(t), nil)
+		require.NoError(t, err)
+
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		res := get(ctx, t, server.URL, "")
+		defer res.Body.Close()
+
+		// The first event is received while the handler is waiting for the second event
+		reader := bufio.NewReader(res.Body)
+		assertEvent(t, reader, "1", `"payload":{"amount":1}`)
+
+		close(release)
+		assertEvent(t, reader, "2", `"payload":{"amount":2}`)
+	})
+
+	t.Run("Strip line breaks from event names and convert payloads once", func(t *testing.T) {
+		store, _ := newHandler(t)
+		appendEvents(t, store, firstID, 1, 2)
+
+		converter := &injectingConverter{MessagePayloadConverter: newPayloadTransformer(t)}
+		handler, err := sse.NewHandler(store, testStream, converter, nil)
+		require.NoError(t, err)
+
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		res := get(ctx, t, server.URL, "")
+		defer res.Body.Close()
+
+		reader := bufio.NewReader(res.Body)
+		for _, expectedID := range []string{"1", "2"} {
+			fields := readEvent(t, reader)
+			assert.Equal(t, expectedID, fields["id"])
+			assert.Equal(t, "account_depositeddata: injected", fields["event"])
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&converter.calls))
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		store, handler := newHandler(t)
+		appendEvents(t, store, firstID, 1, 1)
+
+		testCases := []struct {
+			title          string
+			method         string
+			query          string
+			lastEventID    string
+			expectedStatus int
+		}{
+			{"method not allowed", http.MethodPost, "", "", http.StatusMethodNotAllowed},
+			{"invalid Last-Event-ID", http.MethodGet, "", "one", http.StatusBadRequest},
+			{"constraint without operator", http.MethodGet, "?where=_aggregate_id", "", http.StatusBadRequest},
+			{"constraint without key", http.MethodGet, "?where==1", "", http.StatusBadRequest},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				req := httptest.NewRequest(testCase.method, "/"+testCase.query, nil)
+				if testCase.lastEventID != "" {
+					req.Header.Set("Last-Event-ID", testCase.lastEventID)
+				}
+				rec := httptest.NewRecorder()
+
+				handler.ServeHTTP(rec, req)
+
+				assert.Equal(t, testCase.expectedStatus, rec.Code)
+			})
+		}
+	})
+
+	t.Run("Unknown stream", func(t *testing.T) {
+		transformer := newPayloadTransformer(t)
+		handler, err := sse.NewHandler(inmemory.NewEventStore(goengine.NopLogger), testStream, transformer, nil)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+// fakeListener is a driverSQL.Listener that hands out it's trigger
+type fakeListener struct {
+	triggers chan driverSQL.ProjectionTrigger
+}
+
+func (l *fakeListener) Listen(ctx context.Context, trigger driverSQL.ProjectionTrigger) error {
+	l.triggers <- trigger
+	<-ctx.Done()
+	return nil
+}
+
+func newHandler(t *testing.T) (*inmemory.EventStore, *sse.Handler) {
+	store := inmemory.NewEventStore(goengine.NopLogger)
+	require.NoError(t, store.Create(context.Background(), testStream))
+
+	handler, err := sse.NewHandler(store, testStream, newPayloadTransformer(t), nil)
+	require.NoError(t, err)
+
+	return store, handler
+}
+
+func newPayloadTransformer(t *testing.T) *json.PayloadTransformer {
+	transformer := json.NewPayloadTransformer()
+	require.NoError(t,
+		transformer.RegisterPayload("account_deposited", func() interface{} { return accountDeposited{} }),
+	)
+
+	return transformer
+}
+
+func appendEvents(t *testing.T, store goengine.EventStore, aggregateID aggregate.ID, fromVersion uint, count int) {
+	messages := eventstoretest.NewMessages(t, "account", aggregateID, fromVersion, count, func(version uint) interface{} {
+		return accountDeposited{Amount: int(version)}
+	})
+
+	require.NoError(t, store.AppendTo(context.Background(), testStream, messages))
+}
+
+func get(ctx context.Context, t *testing.T, url string, query string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, url+query, nil)
+	require.NoError(t, err)
+
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	require.NoError(t, err)
+
+	return res
+}
+
+// assertEvent reads the next event and asserts it's id, type and that the data contains the expected string
+func assertEvent(t *testing.T, reader *bufio.Reader, expectedID string, expectedData string) {
+	fields := readEvent(t, reader)
+
+	assert.Equal(t, expectedID, fields["id"])
+	assert.Equal(t, "account_deposited", fields["event"])
+	assert.Contains(t, fields["data"], expectedData)
+}
+
+// readEvent reads the fields of the next event
+func readEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	fields := map[string]string{}
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return fields
+		}
+
+		parts := strings.SplitN(line, ": ", 2)
+		require.Len(t, parts, 2, "invalid line %q", line)
+		require.NotContains(t, fields, parts[0], "duplicate field %q", parts[0])
+		fields[parts[0]] = parts[1]
+	}
+}
+
+// blockingStore is a goengine.ReadOnlyEventStore of which the event streams wait for release after the first event
+type blockingStore struct {
+	goengine.ReadOnlyEventStore
+
+	release chan struct{}
+}
+
+func (s *blockingStore) Load(
+	ctx context.Context,
+	streamName goengine.StreamName,
+	fromNumber int64,
+	count *uint,
+	matcher metadata.Matcher,
+) (goengine.EventStream, error) {
+	stream, err := s.ReadOnlyEventStore.Load(ctx, streamName, fromNumber, count, matcher)
+	if err != nil {
+		return nil, err
+	}
+
+	return &blockingStream{EventStream: stream, release: s.release}, nil
+}
+
+type blockingStream struct {
+	goengine.EventStream
+
+	release chan struct{}
+	read    int
+}
+
+func (s *blockingStream) Next() bool {
+	if s.read == 1 {
+		<-s.release
+	}
+	s.read++
+
+	return s.EventStream.Next()
+}
+
+// injectingConverter is a goengine.MessagePayloadConverter counting the conversions and returning event names that
+// contain a line break followed by a data field
+type injectingConverter struct {
+	goengine.MessagePayloadConverter
+
+	calls int32
+}
+
+func (c *injectingConverter) ConvertPayload(payload interface{}) (string, []byte, error) {
+	atomic.AddInt32(&c.calls, 1)
+
+	name, data, err := c.MessagePayloadConverter.ConvertPayload(payload)
+	return name + "\r\ndata: injected", data, err
+}
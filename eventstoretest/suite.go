@@ -0,0 +1,479 @@
+// Package eventstoretest provides a conformance test suite for goengine.EventStore implementations.
+//
+// The suite appends *aggregate.Changed messages containing a Payload. In order to load these messages the event store
+// must be able to reconstruct the Payload, for example by registering it under PayloadName:
+//  transformer.RegisterPayload(eventstoretest.PayloadName, func() interface{} { return eventstoretest.Payload{} })
+package eventstoretest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// PayloadName is the event name under which the Payload must be registered
+	PayloadName = "eventstoretest_payload"
+	// AggregateType is the aggregate type used for the appended messages
+	AggregateType = "eventstoretest"
+	// TagKey is a custom metadata key containing a string value
+	TagKey = "eventstoretest_tag"
+)
+
+type (
+	// Payload is the payload of the messages appended by the suite
+	Payload struct {
+		Name   string `json:"name"`
+		Number int    `json:"number"`
+	}
+
+	// EventStoreFactory returns the goengine.EventStore to test.
+	// Every test uses uniquely named streams so the same event store may be returned by each call.
+	EventStoreFactory func(t *testing.T) goengine.EventStore
+)
+
+// Run runs the conformance test suite against the event store returned by the factory
+func Run(t *testing.T, factory EventStoreFactory) {
+	require.NotNil(t, factory, "eventstoretest: a factory is required")
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, store goengine.EventStore, streamName goengine.StreamName)
+	}{
+		{"create", testCreate},
+		{"unknown stream", testUnknownStream},
+		{"append and load", testAppendAndLoad},
+		{"append nothing", testAppendNothing},
+		{"ordering", testOrdering},
+		{"streams are isolated", testStreamIsolation},
+		{"from number", testFromNumber},
+		{"count", testCount},
+		{"matcher", testMatcher},
+		{"concurrent append", testConcurrentAppend},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		streamName := goengine.StreamName(fmt.Sprintf("conformance_%d_%d", time.Now().UnixNano(), i))
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, factory(t), streamName)
+		})
+	}
+}
+
+func testCreate(t *testing.T, store goengine.EventStore, streamName goengine.StreamName) {
+	ctx := context.Background()
+
+	assert.False(t, store.HasStream(ctx, streamName), "a stream must not exist before it is created")
+	require.NoError(t, store.Create(ctx, streamName))
+	assert.True(t, store.HasStream(ctx, streamName), "a stream must exist after it is created")
+	assert.Error(t, store.Create(ctx, streamName), "a stream cannot be created twice")
+	assert.False(t, store.HasStream(ctx, streamName+"_unknown"), "only the created stream must exist")
+}
+
+func testUnknownStream(t *testing.T, store goengine.EventStore, streamName goengine.StreamName) {
+	ctx := context.Background()
+
+	stream, err := store.Load(ctx, streamName, 1, nil, metadata.NewMatcher())
+	if assert.Error(t, err, "loading an unknown stream must return an error") {
+		assert.Nil(t, stream)
+	}
+
+	assert.Error(t, store.AppendTo(ctx, streamName, newMessages(aggregate.GenerateID(), 1, 1)), "appending to an unknown stream must return an error")
+}
+
+func testAppendAndLoad(t *testing.T, store goengine.EventStore, streamName goengine.StreamName) {
+	ctx := context.Background()
+	require.NoError(t, store.Create(ctx, streamName))
+
+	messages := newMessages(aggregate.GenerateID(), 1, 3)
+	require.NoError(t, store.AppendTo(ctx, streamName, messages))
+
+	loaded, numbers := load(t, store, streamName, 1, nil, metadata.NewMatcher())
+	assert.Equal(t, []int64{1, 2, 3}, numbers)
+	assertMessages(t, messages, loaded)
+}
+
+func testAppendNothing(t *testing.T, store goengine.EventStore, streamName goengine.StreamName) {
+	ctx := context.Background()
+	require.NoError(t, store.Create(ctx, streamName))
+
+	assert.NoError(t, store.AppendTo(ctx, streamName, nil))
+	assert.NoError(t, store.AppendTo(ctx, streamName, []goengine.Message{}))
+
+	loaded, _ := load(t, store, streamName, 1, nil, metadata.NewMatcher())
+	assert.Empty(t, loaded)
+}
+
+func testOrdering(t *testing.T, store goengine.EventStore, streamName goengine.StreamName) {
+	ctx := context.Background()
+	require.NoError(t, store.Create(ctx, streamName))
+
+	firstID, secondID := aggregate.GenerateID(), aggregate.GenerateID()
+	first := newMessages(firstID, 1, 2)
+	second := newMessages(secondID, 1, 2)
+	third := newMessages(firstID, 3, 2)
+
+	require.NoError(t, store.AppendTo(ctx, streamName, first))
+	require.NoError(t, store.AppendTo(ctx, streamName, second))
+	require.NoError(t, store.AppendTo(ctx, streamName, third))
+
+	var expected []goengine.Message
+	expected = append(expected, first...)
+	expected = append(expected, second...)
+	expected = append(expected, third...)
+
+	loaded, numbers := load(t, store, streamName, 1, nil, metadata.NewMatcher())
+	assert.Equal(t, []int64{1, 2, 3, 4, 5, 6}, numbers, "messages must be numbered in the order they are appended")
+	assertMessages(t, expected, loaded)
+}
+
+func testStreamIsolation(t *testing.T, store goengine.EventStore, streamName goengine.StreamName) {
+	ctx := context.Background()
+	otherStreamName := streamName + "_other"
+	require.NoError(t, store.Create(ctx, streamName))
+	require.NoError(t, store.Create(ctx, otherStreamName))
+
+	messages := newMessages(aggregate.GenerateID(), 1, 2)
+	otherMessages := newMessages(aggregate.GenerateID(), 1, 1)
+	require.NoError(t, store.AppendTo(ctx, streamName, messages))
+	require.NoError(t, store.AppendTo(ctx, otherStreamName, otherMessages))
+
+	loaded, numbers := load(t, store, streamName, 1, nil, metadata.NewMatcher())
+	assert.Equal(t, []int64{1, 2}, numbers)
+	assertMessages(t, messages, loaded)
+
+	loaded, numbers = load(t, store, otherStreamName, 1, nil, metadata.NewMatcher())
+	assert.Equal(t, []int64{1}, numbers)
+	assertMessages(t, otherMessages, loaded)
+}
+
+func testFromNumber(t *testing.T, store goengine.EventStore, streamName goengine.StreamName) {
+	ctx := context.Background()
+	require.NoError(t, store.Create(ctx, streamName))
+
+	messages := newMessages(aggregate.GenerateID(), 1, 5)
+	require.NoError(t, store.AppendTo(ctx, streamName, messages))
+
+	loaded, numbers := load(t, store, streamName, 0, nil, metadata.NewMatcher())
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, numbers)
+	assertMessages(t, messages, loaded)
+
+	loaded, numbers = load(t, store, streamName, 3, nil, metadata.NewMatcher())
+	assert.Equal(t, []int64{3, 4, 5}, numbers)
+	assertMessages(t, messages[2:], loaded)
+
+	loaded, numbers = load(t, store, streamName, 6, nil, metadata.NewMatcher())
+	assert.Empty(t, numbers)
+	assert.Empty(t, loaded)
+}
+
+func testCount(t *testing.T, store goengine.EventStore, streamName goengine.StreamName) {
+	ctx := context.Background()
+	require.NoError(t, store.Create(ctx, streamName))
+
+	aggregateID := aggregate.GenerateID()
+	messages := newMessages(aggregateID, 1, 5)
+	require.NoError(t, store.AppendTo(ctx, streamName, messages))
+
+	count := func(c uint) *uint {
+		return &c
+	}
+
+	_, numbers := load(t, store, streamName, 1, count(2), metadata.NewMatcher())
+	assert.Equal(t, []int64{1, 2}, numbers)
+
+	_, numbers = load(t, store, streamName, 3, count(2), metadata.NewMatcher())
+	assert.Equal(t, []int64{3, 4}, numbers)
+
+	_, numbers = load(t, store, streamName, 1, count(10), metadata.NewMatcher())
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, numbers)
+
+	_, numbers = load(t, store, streamName, 1, count(0), metadata.NewMatcher())
+	assert.Empty(t, numbers, "a count of zero must not return any messages")
+
+	matcher := metadata.WithConstraint(metadata.NewMatcher(), aggregate.VersionKey, metadata.GreaterThan, 2)
+	_, numbers = load(t, store, streamName, 1, count(2), matcher)
+	assert.Equal(t, []int64{3, 4}, numbers, "the count must be applied after matching the metadata")
+}
+
+func testMatcher(t *testing.T, store goengine.EventStore, streamName goengine.StreamName) {
+	ctx := context.Background()
+	require.NoError(t, store.Create(ctx, streamName))
+
+	firstID, secondID := aggregate.GenerateID(), aggregate.GenerateID()
+	require.NoError(t, store.AppendTo(ctx, streamName, newMessages(firstID, 1, 4)))
+	require.NoError(t, store.AppendTo(ctx, streamName, newMessages(secondID, 1, 2)))
+
+	testCases := []struct {
+		title    string
+		matcher  metadata.Matcher
+		expected []int64
+	}{
+		{
+			"equals aggregate id",
+			metadata.WithConstraint(metadata.NewMatcher(), aggregate.IDKey, metadata.Equals, string(secondID)),
+			[]int64{5, 6},
+		},
+		{
+			"equals aggregate type and id",
+			metadata.WithConstraint(
+				metadata.WithConstraint(metadata.NewMatcher(), aggregate.TypeKey, metadata.Equals, AggregateType),
+				aggregate.IDKey, metadata.Equals, string(firstID),
+			),
+			[]int64{1, 2, 3, 4},
+		},
+		{
+			"equals version",
+			metadata.WithConstraint(metadata.NewMatcher(), aggregate.VersionKey, metadata.Equals, 2),
+			[]int64{2, 6},
+		},
+		{
+			"not equals version",
+			metadata.WithConstraint(metadata.NewMatcher(), aggregate.VersionKey, metadata.NotEquals, 1),
+			[]int64{2, 3, 4, 6},
+		},
+		{
+			"greater than version",
+			metadata.WithConstraint(metadata.NewMatcher(), aggregate.VersionKey, metadata.GreaterThan, 2),
+			[]int64{3, 4},
+		},
+		{
+			"greater than equals version",
+			metadata.WithConstraint(metadata.NewMatcher(), aggregate.VersionKey, metadata.GreaterThanEquals, 2),
+			[]int64{2, 3, 4, 6},
+		},
+		{
+			"lower than version",
+			metadata.WithConstraint(metadata.NewMatcher(), aggregate.VersionKey, metadata.LowerThan, 2),
+			[]int64{1, 5},
+		},
+		{
+			"lower than equals version",
+			metadata.WithConstraint(metadata.NewMatcher(), aggregate.VersionKey, metadata.LowerThanEquals, 2),
+			[]int64{1, 2, 5, 6},
+		},
+		{
+			"version range for an aggregate",
+			metadata.WithConstraint(
+				metadata.WithConstraint(
+					metadata.WithConstraint(metadata.NewMatcher(), aggregate.IDKey, metadata.Equals, string(firstID)),
+					aggregate.VersionKey, metadata.GreaterThan, 1,
+				),
+				aggregate.VersionKey, metadata.LowerThan, 4,
+			),
+			[]int64{2, 3},
+		},
+		{
+			"equals custom metadata",
+			metadata.WithConstraint(metadata.NewMatcher(), TagKey, metadata.Equals, "even"),
+			[]int64{2, 4, 6},
+		},
+		{
+			"not equals custom metadata",
+			metadata.WithConstraint(metadata.NewMatcher(), TagKey, metadata.NotEquals, "even"),
+			[]int64{1, 3, 5},
+		},
+		{
+			"unknown metadata key",
+			metadata.WithConstraint(metadata.NewMatcher(), "eventstoretest_unknown", metadata.Equals, "value"),
+			nil,
+		},
+		{
+			"unknown aggregate id",
+			metadata.WithConstraint(metadata.NewMatcher(), aggregate.IDKey, metadata.Equals, string(aggregate.GenerateID())),
+			nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.title, func(t *testing.T) {
+			_, numbers := load(t, store, streamName, 1, nil, testCase.matcher)
+			assert.Equal(t, testCase.expected, numbers)
+		})
+	}
+}
+
+func testConcurrentAppend(t *testing.T, store goengine.EventStore, streamName goengine.StreamName) {
+	const (
+		writers  = 10
+		messages = 5
+	)
+
+	ctx := context.Background()
+	require.NoError(t, store.Create(ctx, streamName))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	wg.Add(writers)
+	for i := 0; i < writers; i++ {
+		go func() {
+			defer wg.Done()
+			errs <- store.AppendTo(ctx, streamName, newMessages(aggregate.GenerateID(), 1, messages))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	loaded, numbers := load(t, store, streamName, 1, nil, metadata.NewMatcher())
+	require.Len(t, loaded, writers*messages)
+
+	// The messages of every append must be stored in order
+	versions := map[string]uint{}
+	for i, msg := range loaded {
+		assert.Equal(t, int64(i+1), numbers[i], "message numbers must be sequential")
+
+		aggregateID := fmt.Sprint(msg.Metadata().Value(aggregate.IDKey))
+		version := versions[aggregateID] + 1
+		assert.Equal(t, fmt.Sprint(version), fmt.Sprint(msg.Metadata().Value(aggregate.VersionKey)))
+		versions[aggregateID] = version
+	}
+	assert.Len(t, versions, writers)
+}
+
+// NewMessage returns an aggregate.Changed message of the aggregate at the provided version.
+// The aggregate id, type and version are added to the metadata of the message.
+func NewMessage(t *testing.T, aggregateType string, aggregateID aggregate.ID, version uint, payload interface{}) goengine.Message {
+	msg, err := newMessage(aggregateType, aggregateID, version, payload, metadata.New())
+	require.NoError(t, err)
+
+	return msg
+}
+
+// NewMessages returns count aggregate.Changed messages of the aggregate starting at the provided version.
+// The payload of every message is created by calling payload with the version of the message.
+func NewMessages(
+	t *testing.T,
+	aggregateType string,
+	aggregateID aggregate.ID,
+	fromVersion uint,
+	count int,
+	payload func(version uint) interface{},
+) []goengine.Message {
+	messages := make([]goengine.Message, count)
+	for i := range messages {
+		version := fromVersion + uint(i)
+		messages[i] = NewMessage(t, aggregateType, aggregateID, version, payload(version))
+	}
+
+	return messages
+}
+
+// newMessages returns count aggregate.Changed messages for the aggregate starting at the provided version
+func newMessages(aggregateID aggregate.ID, fromVersion uint, count int) []goengine.Message {
+	messages := make([]goengine.Message, count)
+	for i := 0; i < count; i++ {
+		version := fromVersion + uint(i)
+		tag := "odd"
+		if version%2 == 0 {
+			tag = "even"
+		}
+
+		msg, err := newMessage(
+			AggregateType,
+			aggregateID,
+			version,
+			Payload{Name: string(aggregateID), Number: int(version)},
+			metadata.WithValue(metadata.New(), TagKey, tag),
+		)
+		if err != nil {
+			panic(err)
+		}
+
+		messages[i] = msg
+	}
+
+	return messages
+}
+
+// newMessage returns an aggregate.Changed message with the aggregate information added to the provided metadata
+func newMessage(
+	aggregateType string,
+	aggregateID aggregate.ID,
+	version uint,
+	payload interface{},
+	meta metadata.Metadata,
+) (goengine.Message, error) {
+	meta = metadata.WithValue(meta, aggregate.IDKey, string(aggregateID))
+	meta = metadata.WithValue(meta, aggregate.TypeKey, aggregateType)
+	meta = metadata.WithValue(meta, aggregate.VersionKey, version)
+
+	return aggregate.ReconstituteChange(
+		aggregateID,
+		goengine.GenerateUUID(),
+		payload,
+		meta,
+		time.Now().UTC().Truncate(time.Microsecond),
+		version,
+	)
+}
+
+func load(
+	t *testing.T,
+	store goengine.EventStore,
+	streamName goengine.StreamName,
+	fromNumber int64,
+	count *uint,
+	matcher metadata.Matcher,
+) ([]goengine.Message, []int64) {
+	stream, err := store.Load(context.Background(), streamName, fromNumber, count, matcher)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, stream.Close())
+	}()
+
+	messages, numbers, err := goengine.ReadEventStream(stream)
+	require.NoError(t, err)
+
+	return messages, numbers
+}
+
+// assertMessages asserts that the loaded messages contain the same information as the expected messages
+func assertMessages(t *testing.T, expected []goengine.Message, loaded []goengine.Message) {
+	if !assert.Len(t, loaded, len(expected)) {
+		return
+	}
+
+	for i, msg := range loaded {
+		expectedMsg := expected[i]
+
+		assert.Equal(t, expectedMsg.UUID(), msg.UUID(), "message %d uuid", i)
+		assert.Equal(t, expectedMsg.Payload(), dereference(msg.Payload()), "message %d payload", i)
+		assert.True(t, expectedMsg.CreatedAt().Equal(msg.CreatedAt()), "message %d created at %s != %s", i, expectedMsg.CreatedAt(), msg.CreatedAt())
+
+		expectedMeta := expectedMsg.Metadata().AsMap()
+		loadedMeta := msg.Metadata().AsMap()
+		for key, value := range expectedMeta {
+			// Metadata values may be decoded into a different type (e.g. uint into float64) so compare their representation
+			assert.Equal(t, fmt.Sprint(value), fmt.Sprint(loadedMeta[key]), "message %d metadata %s", i, key)
+		}
+
+		for key := range loadedMeta {
+			if _, found := expectedMeta[key]; !found && !strings.HasPrefix(key, "_") {
+				t.Errorf("message %d contains unexpected metadata %s", i, key)
+			}
+		}
+	}
+}
+
+func dereference(payload interface{}) interface{} {
+	if p, ok := payload.(*Payload); ok && p != nil {
+		return *p
+	}
+
+	return payload
+}
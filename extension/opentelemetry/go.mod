@@ -0,0 +1,14 @@
+module github.com/hellofresh/goengine/extension/opentelemetry
+
+go 1.15
+
+require (
+	github.com/hellofresh/goengine v1.4.0
+	github.com/stretchr/testify v1.7.0
+	go.opentelemetry.io/otel v1.0.1
+	go.opentelemetry.io/otel/sdk v1.0.1
+	go.opentelemetry.io/otel/trace v1.0.1
+)
+
+// The replace is only used for local development, a release requires the root module version it is tagged with
+replace github.com/hellofresh/goengine => ../..
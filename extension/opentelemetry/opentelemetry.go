@@ -0,0 +1,149 @@
+package opentelemetry
+
+import (
+	"context"
+
+	"github.com/hellofresh/goengine"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceContextKey is the metadata key used to store the trace context of the span that produced a message
+const TraceContextKey = "_trace_context"
+
+// Ensure that we satisfy the goengine.Tracer interface
+var (
+	_ goengine.Tracer            = &tracer{}
+	_ propagation.TextMapCarrier = metadataCarrier{}
+)
+
+type (
+	tracer struct {
+		tracer     trace.Tracer
+		propagator propagation.TextMapPropagator
+	}
+
+	span struct {
+		span trace.Span
+	}
+
+	// metadataCarrier is the propagation.TextMapCarrier stored within the message metadata
+	metadataCarrier map[string]string
+)
+
+// NewTracer wraps a trace.Tracer.
+// The propagator is used to add the trace context to the message metadata, when nil the W3C trace context is used.
+func NewTracer(t trace.Tracer, propagator propagation.TextMapPropagator) (goengine.Tracer, error) {
+	if t == nil {
+		return nil, goengine.InvalidArgumentError("tracer")
+	}
+
+	if propagator == nil {
+		propagator = propagation.TraceContext{}
+	}
+
+	return &tracer{
+		tracer:     t,
+		propagator: propagator,
+	}, nil
+}
+
+func (t *tracer) StartSpan(ctx context.Context, operation string) (context.Context, goengine.Span) {
+	ctx, s := t.tracer.Start(ctx, operation)
+
+	return ctx, &span{span: s}
+}
+
+func (t *tracer) StartMessageSpan(ctx context.Context, operation string, message goengine.Message) (context.Context, goengine.Span) {
+	var opts []trace.SpanStartOption
+	if carrier := messageCarrier(message); carrier != nil {
+		linked := trace.SpanContextFromContext(t.propagator.Extract(context.Background(), carrier))
+		if linked.IsValid() {
+			opts = append(opts, trace.WithLinks(trace.Link{SpanContext: linked}))
+		}
+	}
+
+	ctx, s := t.tracer.Start(ctx, operation, opts...)
+	if message != nil {
+		s.SetAttributes(attribute.String("message.uuid", message.UUID().String()))
+	}
+
+	return ctx, &span{span: s}
+}
+
+func (t *tracer) Inject(ctx context.Context, message goengine.Message) goengine.Message {
+	if message == nil {
+		return message
+	}
+
+	carrier := metadataCarrier{}
+	t.propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return message
+	}
+
+	return message.WithMetadata(TraceContextKey, map[string]string(carrier))
+}
+
+func (s *span) Int64(k string, v int64) {
+	s.span.SetAttributes(attribute.Int64(k, v))
+}
+
+func (s *span) String(k, v string) {
+	s.span.SetAttributes(attribute.String(k, v))
+}
+
+func (s *span) Error(err error) {
+	if err == nil {
+		return
+	}
+
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *span) End() {
+	s.span.End()
+}
+
+func (c metadataCarrier) Get(key string) string {
+	return c[key]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// messageCarrier returns the trace context stored within the message metadata.
+// Once the metadata is persisted and loaded the trace context is a map[string]interface{} instead of a map[string]string.
+func messageCarrier(message goengine.Message) metadataCarrier {
+	if message == nil || message.Metadata() == nil {
+		return nil
+	}
+
+	switch value := message.Metadata().Value(TraceContextKey).(type) {
+	case map[string]string:
+		return value
+	case map[string]interface{}:
+		carrier := make(metadataCarrier, len(value))
+		for k, v := range value {
+			if str, ok := v.(string); ok {
+				carrier[k] = str
+			}
+		}
+		return carrier
+	}
+
+	return nil
+}
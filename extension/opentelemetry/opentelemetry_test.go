@@ -0,0 +1,148 @@
+// +build unit
+
+package opentelemetry_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/extension/opentelemetry"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewTracer(t *testing.T) {
+	t.Run("Invalid arguments", func(t *testing.T) {
+		tracer, err := opentelemetry.NewTracer(nil, nil)
+
+		assert.Equal(t, goengine.InvalidArgumentError("tracer"), err)
+		assert.Nil(t, tracer)
+	})
+}
+
+func TestTracer_StartSpan(t *testing.T) {
+	recorder, tracer := createTracer(t)
+
+	ctx, span := tracer.StartSpan(context.Background(), "parent")
+	_, child := tracer.StartSpan(ctx, "child")
+	child.String("stream", "event_stream")
+	child.Int64("event_count", 2)
+	child.Error(nil)
+	child.Error(errors.New("failure"))
+	child.End()
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "child", spans[0].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("stream", "event_stream"),
+		attribute.Int64("event_count", 2),
+	}, spans[0].Attributes())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "failure", spans[0].Status().Description)
+
+	assert.Equal(t, "parent", spans[1].Name())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
+
+func TestTracer_StartMessageSpan(t *testing.T) {
+	t.Run("Link to the span that injected the trace context", func(t *testing.T) {
+		recorder, tracer := createTracer(t)
+
+		ctx, commandSpan := tracer.StartSpan(context.Background(), "command")
+		msg := tracer.Inject(ctx, createMessage(t))
+		commandSpan.End()
+
+		_, handlerSpan := tracer.StartMessageSpan(context.Background(), "handler", msg)
+		handlerSpan.End()
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 2)
+		require.Len(t, spans[1].Links(), 1)
+		assert.Equal(t, spans[0].SpanContext().TraceID(), spans[1].Links()[0].SpanContext.TraceID())
+		assert.Equal(t, spans[0].SpanContext().SpanID(), spans[1].Links()[0].SpanContext.SpanID())
+		assert.NotEqual(t, spans[0].SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+	})
+
+	t.Run("Link after the metadata is persisted", func(t *testing.T) {
+		recorder, tracer := createTracer(t)
+
+		ctx, commandSpan := tracer.StartSpan(context.Background(), "command")
+		msg := tracer.Inject(ctx, createMessage(t))
+		commandSpan.End()
+
+		// Simulate the metadata being stored and loaded by a event store
+		data, err := json.Marshal(msg.Metadata())
+		require.NoError(t, err)
+		meta, err := metadata.UnmarshalJSON(data)
+		require.NoError(t, err)
+
+		loaded, err := aggregate.ReconstituteChange(
+			aggregate.GenerateID(),
+			msg.UUID(),
+			struct{}{},
+			meta,
+			msg.CreatedAt(),
+			1,
+		)
+		require.NoError(t, err)
+
+		_, handlerSpan := tracer.StartMessageSpan(context.Background(), "handler", loaded)
+		handlerSpan.End()
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 2)
+		require.Len(t, spans[1].Links(), 1)
+		assert.Equal(t, spans[0].SpanContext().SpanID(), spans[1].Links()[0].SpanContext.SpanID())
+	})
+
+	t.Run("No link without a trace context", func(t *testing.T) {
+		recorder, tracer := createTracer(t)
+
+		msg := tracer.Inject(context.Background(), createMessage(t))
+		_, handlerSpan := tracer.StartMessageSpan(context.Background(), "handler", msg)
+		handlerSpan.End()
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Empty(t, spans[0].Links())
+		assert.Nil(t, msg.Metadata().Value(opentelemetry.TraceContextKey))
+	})
+}
+
+func createTracer(t *testing.T) (*tracetest.SpanRecorder, goengine.Tracer) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	tracer, err := opentelemetry.NewTracer(provider.Tracer("goengine"), nil)
+	require.NoError(t, err)
+
+	return recorder, tracer
+}
+
+func createMessage(t *testing.T) goengine.Message {
+	msg, err := aggregate.ReconstituteChange(
+		aggregate.GenerateID(),
+		goengine.GenerateUUID(),
+		struct{}{},
+		metadata.New(),
+		time.Now().UTC(),
+		1,
+	)
+	require.NoError(t, err)
+
+	return msg
+}
@@ -3,6 +3,7 @@ package pq
 import (
 	"context"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/hellofresh/goengine"
@@ -24,6 +25,9 @@ type Listener struct {
 
 	logger  goengine.Logger
 	metrics sql.Metrics
+
+	// connected is 1 while the database listener is connected
+	connected int32
 }
 
 // NewListener returns a new notification listener
@@ -76,6 +80,7 @@ func (s *Listener) Listen(ctx context.Context, exec sql.ProjectionTrigger) error
 	// Create the postgres listener
 	listener := pq.NewListener(s.dbDSN, s.minReconnectInterval, s.maxReconnectInterval, s.listenerStateCallback)
 	defer func() {
+		atomic.StoreInt32(&s.connected, 0)
 		if err := listener.Close(); err != nil {
 			s.logger.Warn("failed to close database Listener", func(e goengine.LoggerEntry) {
 				e.Error(err)
@@ -103,7 +108,7 @@ func (s *Listener) Listen(ctx context.Context, exec sql.ProjectionTrigger) error
 			notification := s.unmarshalNotification(n)
 
 			// Execute the notification to be projected
-			if err := exec(ctx, notification); err != nil {
+			if err := s.execute(ctx, exec, notification); err != nil {
 				return err
 			}
 		case <-ctx.Done():
@@ -113,6 +118,26 @@ func (s *Listener) Listen(ctx context.Context, exec sql.ProjectionTrigger) error
 	}
 }
 
+// Connected returns true when the Listener is connected to the database
+func (s *Listener) Connected() bool {
+	return atomic.LoadInt32(&s.connected) == 1
+}
+
+// execute calls the trigger for the received notification within a span
+func (s *Listener) execute(ctx context.Context, exec sql.ProjectionTrigger, notification *sql.ProjectionNotification) error {
+	ctx, span := goengine.TracerFromContext(ctx).StartSpan(ctx, "goengine.pq.notification")
+	defer span.End()
+	if notification != nil {
+		span.Int64("notification.no", notification.No)
+		span.String("notification.aggregate_id", notification.AggregateID)
+	}
+
+	err := exec(ctx, notification)
+	span.Error(err)
+
+	return err
+}
+
 // listenerStateCallback a callback used for getting state changes from a pq.Listener
 // This callback will also close the related db connection pool used to query/persist projection data
 func (s *Listener) listenerStateCallback(event pq.ListenerEventType, err error) {
@@ -125,12 +150,16 @@ func (s *Listener) listenerStateCallback(event pq.ListenerEventType, err error)
 
 	switch event {
 	case pq.ListenerEventConnected:
+		atomic.StoreInt32(&s.connected, 1)
 		s.logger.Debug("connection Listener: connected", logFields)
 	case pq.ListenerEventConnectionAttemptFailed:
+		atomic.StoreInt32(&s.connected, 0)
 		s.logger.Debug("connection Listener: failed to connect", logFields)
 	case pq.ListenerEventDisconnected:
+		atomic.StoreInt32(&s.connected, 0)
 		s.logger.Debug("connection Listener: disconnected", logFields)
 	case pq.ListenerEventReconnected:
+		atomic.StoreInt32(&s.connected, 1)
 		s.logger.Debug("connection Listener: reconnected", logFields)
 	default:
 		s.logger.Warn("connection Listener: unknown event", logFields)
@@ -1,9 +1,7 @@
 package prometheus
 
 import (
-	"fmt"
 	"strconv"
-	"sync"
 	"time"
 
 	"github.com/hellofresh/goengine"
@@ -12,19 +10,34 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-const (
-	namespace                       = "goengine"
-	notificationQueueKeyPrefix      = "q"
-	notificationProcessingKeyPrefix = "p"
+const namespace = "goengine"
+
+var (
+	// Ensure that we satisfy the sql.Metrics interface
+	_ sql.Metrics = &Metrics{}
+	// Ensure that we satisfy the sql.ProjectionMetrics interface
+	_ sql.ProjectionMetrics = &Metrics{}
+	// Ensure that we satisfy the sql.EventStoreMetrics interface
+	_ sql.EventStoreMetrics = &Metrics{}
 )
 
-// Metrics is an object for exposing prometheus metrics
+// Metrics is an object for exposing prometheus metrics.
+// No state is kept for a notification, the time it was queued is provided by the notification itself.
 type Metrics struct {
 	notificationCounter            *prometheus.CounterVec
+	notificationQueuedCounter      *prometheus.CounterVec
 	notificationQueueDuration      *prometheus.HistogramVec
 	notificationProcessingDuration *prometheus.HistogramVec
-	notificationStartTimes         sync.Map
-	logger                         goengine.Logger
+
+	appendDuration       *prometheus.HistogramVec
+	appendedEventCounter *prometheus.CounterVec
+	loadDuration         *prometheus.HistogramVec
+	handlerDuration      *prometheus.HistogramVec
+	handlerErrorCounter  *prometheus.CounterVec
+	stateCommitDuration  *prometheus.HistogramVec
+	lockFailureCounter   *prometheus.CounterVec
+
+	logger goengine.Logger
 }
 
 // NewMetrics instantiate and return an object of Metrics
@@ -42,6 +55,15 @@ func NewMetrics(logger goengine.Logger) *Metrics {
 			},
 			[]string{"is_notification"},
 		),
+		// notificationQueuedCounter is used to expose 'notification_queued_count' metric
+		notificationQueuedCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "notification_queued_count",
+				Help:      "counter for number of notifications queued by a projection",
+			},
+			[]string{"projection"},
+		),
 		// queueDuration is used to expose 'queue_duration_seconds' metrics
 		notificationQueueDuration: prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
@@ -50,7 +72,7 @@ func NewMetrics(logger goengine.Logger) *Metrics {
 				Help:      "histogram of queue latencies",
 				Buckets:   []float64{0.1, 0.5, 0.9, 0.99}, //buckets for histogram
 			},
-			[]string{"success"},
+			[]string{"projection"},
 		),
 
 		// notificationProcessingDuration is used to expose 'notification_handle_duration_seconds' metrics
@@ -61,25 +83,104 @@ func NewMetrics(logger goengine.Logger) *Metrics {
 				Help:      "histogram of notifications handled latencies",
 				Buckets:   []float64{0.1, 0.5, 0.9, 0.99}, //buckets for histogram
 			},
-			[]string{"success"},
+			[]string{"projection", "success"},
+		),
+
+		// appendDuration is used to expose 'eventstore_append_duration_seconds' metrics
+		appendDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "eventstore_append_duration_seconds",
+				Help:      "histogram of event stream append latencies",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"stream", "success"},
+		),
+		// appendedEventCounter is used to expose 'eventstore_appended_events_total' metric
+		appendedEventCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "eventstore_appended_events_total",
+				Help:      "counter for number of events appended to a event stream",
+			},
+			[]string{"stream"},
+		),
+		// loadDuration is used to expose 'eventstore_load_duration_seconds' metrics
+		loadDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "eventstore_load_duration_seconds",
+				Help:      "histogram of event stream load latencies",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"stream", "success"},
+		),
+		// handlerDuration is used to expose 'projection_handler_duration_seconds' metrics
+		handlerDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "projection_handler_duration_seconds",
+				Help:      "histogram of projection handler latencies",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"projection", "event_name", "success"},
+		),
+		// handlerErrorCounter is used to expose 'projection_handler_errors_total' metric
+		handlerErrorCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "projection_handler_errors_total",
+				Help:      "counter for number of projection handler errors",
+			},
+			[]string{"projection", "event_name"},
+		),
+		// stateCommitDuration is used to expose 'projection_state_commit_duration_seconds' metrics
+		stateCommitDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "projection_state_commit_duration_seconds",
+				Help:      "histogram of projection state commit latencies",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"projection", "success"},
 		),
+		// lockFailureCounter is used to expose 'projection_lock_failures_total' metric
+		lockFailureCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "projection_lock_failures_total",
+				Help:      "counter for number of times a projection lock could not be acquired",
+			},
+			[]string{"projection"},
+		),
+
 		logger: logger,
 	}
 }
 
 // RegisterMetrics returns http handler for prometheus
 func (m *Metrics) RegisterMetrics(registry *prometheus.Registry) error {
-	err := registry.Register(m.notificationCounter)
-	if err != nil {
-		return err
+	collectors := []prometheus.Collector{
+		m.notificationCounter,
+		m.notificationQueuedCounter,
+		m.notificationQueueDuration,
+		m.notificationProcessingDuration,
+		m.appendDuration,
+		m.appendedEventCounter,
+		m.loadDuration,
+		m.handlerDuration,
+		m.handlerErrorCounter,
+		m.stateCommitDuration,
+		m.lockFailureCounter,
 	}
 
-	err = registry.Register(m.notificationQueueDuration)
-	if err != nil {
-		return err
+	for _, c := range collectors {
+		if err := registry.Register(c); err != nil {
+			return err
+		}
 	}
 
-	return registry.Register(m.notificationProcessingDuration)
+	return nil
 }
 
 // ReceivedNotification counts received notifications
@@ -88,55 +189,96 @@ func (m *Metrics) ReceivedNotification(isNotification bool) {
 	m.notificationCounter.With(labels).Inc()
 }
 
-// QueueNotification returns http handler for prometheus
+// QueueNotification counts the queued notifications of an unnamed projection
 func (m *Metrics) QueueNotification(notification *sql.ProjectionNotification) {
-	if !m.storeStartTime(notificationQueueKeyPrefix, notification) {
-		m.logger.Warn("notification already queued", func(e goengine.LoggerEntry) {
-			e.Any("notification", notification)
-		})
-	}
-
+	m.QueueProjectionNotification("", notification)
 }
 
-// StartNotificationProcessing is used to record start time of notification processing
+// StartNotificationProcessing observes the time the notification of an unnamed projection spend in the queue
 func (m *Metrics) StartNotificationProcessing(notification *sql.ProjectionNotification) {
-	if !m.storeStartTime(notificationProcessingKeyPrefix, notification) {
-		m.logger.Warn("notification processing already started", func(e goengine.LoggerEntry) {
-			e.Any("notification", notification)
-		})
-	}
+	m.StartProjectionNotificationProcessing("", notification)
 }
 
-// FinishNotificationProcessing is used to observe end time of notification queue and processing time
+// FinishNotificationProcessing is not observed since the processing duration is unknown
 func (m *Metrics) FinishNotificationProcessing(notification *sql.ProjectionNotification, success bool) {
-	memAddress := fmt.Sprintf("%p", notification)
-	labels := prometheus.Labels{"success": strconv.FormatBool(success)}
+}
 
-	if queueStartTime, ok := m.notificationStartTimes.Load(notificationQueueKeyPrefix + memAddress); ok {
-		m.notificationQueueDuration.With(labels).Observe(time.Since(queueStartTime.(time.Time)).Seconds())
-		m.notificationStartTimes.Delete(notificationQueueKeyPrefix + memAddress)
+// QueueProjectionNotification counts the notifications queued by a projection
+func (m *Metrics) QueueProjectionNotification(projectionName string, notification *sql.ProjectionNotification) {
+	m.notificationQueuedCounter.With(prometheus.Labels{"projection": projectionName}).Inc()
+}
 
-	} else {
+// StartProjectionNotificationProcessing observes the time the notification spend in the queue.
+// The queue duration of a nil notification is unknown and thus not observed.
+func (m *Metrics) StartProjectionNotificationProcessing(projectionName string, notification *sql.ProjectionNotification) {
+	if notification == nil {
+		return
+	}
 
-		m.logger.Warn("notification queue start time not found", func(e goengine.LoggerEntry) {
+	queuedAt := notification.QueuedAt()
+	if queuedAt.IsZero() {
+		m.logger.Warn("notification queue time not found", func(e goengine.LoggerEntry) {
+			e.String("projection", projectionName)
 			e.Any("notification", notification)
 		})
+		return
 	}
 
-	if processingStartTime, ok := m.notificationStartTimes.Load(notificationProcessingKeyPrefix + memAddress); ok {
-		m.notificationProcessingDuration.With(labels).Observe(time.Since(processingStartTime.(time.Time)).Seconds())
-		m.notificationStartTimes.Delete(notificationProcessingKeyPrefix + memAddress)
-	} else {
-		m.logger.Warn("notification processing start time not found", func(e goengine.LoggerEntry) {
-			e.Any("notification", notification)
-		})
+	m.notificationQueueDuration.With(prometheus.Labels{"projection": projectionName}).Observe(time.Since(queuedAt).Seconds())
+}
+
+// FinishProjectionNotificationProcessing observes the processing duration of a notification
+func (m *Metrics) FinishProjectionNotificationProcessing(
+	projectionName string,
+	notification *sql.ProjectionNotification,
+	duration time.Duration,
+	success bool,
+) {
+	labels := prometheus.Labels{"projection": projectionName, "success": strconv.FormatBool(success)}
+	m.notificationProcessingDuration.With(labels).Observe(duration.Seconds())
+}
+
+// StopProjectionNotificationProcessing is a no-op since no state is kept for a projection
+func (m *Metrics) StopProjectionNotificationProcessing(projectionName string) {
+}
+
+// AppendToStream observes the duration of appending events to an event stream and counts the appended events
+func (m *Metrics) AppendToStream(streamName goengine.StreamName, eventCount int, duration time.Duration, success bool) {
+	labels := prometheus.Labels{"stream": string(streamName), "success": strconv.FormatBool(success)}
+	m.appendDuration.With(labels).Observe(duration.Seconds())
+
+	if success {
+		m.appendedEventCounter.With(prometheus.Labels{"stream": string(streamName)}).Add(float64(eventCount))
 	}
 }
 
-// storeStartTime stores the start time against each notification only if it's not already existent
-func (m *Metrics) storeStartTime(prefix string, notification *sql.ProjectionNotification) bool {
-	key := prefix + fmt.Sprintf("%p", notification)
+// LoadStream observes the duration of loading an event stream
+func (m *Metrics) LoadStream(streamName goengine.StreamName, duration time.Duration, success bool) {
+	labels := prometheus.Labels{"stream": string(streamName), "success": strconv.FormatBool(success)}
+	m.loadDuration.With(labels).Observe(duration.Seconds())
+}
+
+// ExecuteProjectionHandler observes the duration of a projection handler and counts the handler errors
+func (m *Metrics) ExecuteProjectionHandler(projectionName string, eventName string, duration time.Duration, success bool) {
+	labels := prometheus.Labels{
+		"projection": projectionName,
+		"event_name": eventName,
+		"success":    strconv.FormatBool(success),
+	}
+	m.handlerDuration.With(labels).Observe(duration.Seconds())
+
+	if !success {
+		m.handlerErrorCounter.With(prometheus.Labels{"projection": projectionName, "event_name": eventName}).Inc()
+	}
+}
+
+// CommitProjectionState observes the duration of persisting the state of a projection
+func (m *Metrics) CommitProjectionState(projectionName string, position int64, duration time.Duration, success bool) {
+	labels := prometheus.Labels{"projection": projectionName, "success": strconv.FormatBool(success)}
+	m.stateCommitDuration.With(labels).Observe(duration.Seconds())
+}
 
-	_, alreadyExists := m.notificationStartTimes.LoadOrStore(key, time.Now())
-	return !alreadyExists
+// FailedToLockProjection counts the failures to acquire a projection lock
+func (m *Metrics) FailedToLockProjection(projectionName string) {
+	m.lockFailureCounter.With(prometheus.Labels{"projection": projectionName}).Inc()
 }
@@ -3,134 +3,166 @@
 package prometheus_test
 
 import (
+	"context"
+	"sync/atomic"
 	"testing"
-
-	"github.com/stretchr/testify/assert"
-
-	"github.com/stretchr/testify/require"
+	"time"
 
 	"github.com/hellofresh/goengine/driver/sql"
+	goengineLogger "github.com/hellofresh/goengine/extension/logrus"
 	goenginePrometheus "github.com/hellofresh/goengine/extension/prometheus"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestMetrics_QueueAndFinishNotification(t *testing.T) {
-	notification := &sql.ProjectionNotification{
-		No:          1,
-		AggregateID: "C56A4180-65AA-42EC-A945-5FD21DEC0538",
-	}
-
+func TestMetrics_ProcessNotification(t *testing.T) {
 	registry := prometheus.NewPedanticRegistry()
 
 	metrics := goenginePrometheus.NewMetrics(nil)
 	require.NoError(t, metrics.RegisterMetrics(registry))
 
-	metrics.QueueNotification(notification)
-	metrics.FinishNotificationProcessing(notification, true)
+	processNotifications(t, metrics, func(ctx context.Context, notification *sql.ProjectionNotification, queue sql.ProjectionTrigger) error {
+		return nil
+	})
 
 	assertMetricsWhereCalled(t, registry, map[string]uint64{
+		"goengine_notification_queued_count":                2,
 		"goengine_queue_duration_seconds":                   1,
-		"goengine_notification_processing_duration_seconds": 0,
+		"goengine_notification_processing_duration_seconds": 2,
 	})
-
 }
 
-func TestMetrics_ProcessAndFinishNotification(t *testing.T) {
-	notification := &sql.ProjectionNotification{
-		No:          1,
-		AggregateID: "C56A4180-65AA-42EC-A945-5FD21DEC0538",
-	}
-
+func TestMetrics_ReQueueNotification(t *testing.T) {
 	registry := prometheus.NewPedanticRegistry()
 
-	metrics := goenginePrometheus.NewMetrics(nil)
+	logger, loggerHook := test.NewNullLogger()
+	metrics := goenginePrometheus.NewMetrics(goengineLogger.Wrap(logger))
 	require.NoError(t, metrics.RegisterMetrics(registry))
 
-	metrics.StartNotificationProcessing(notification)
-	metrics.FinishNotificationProcessing(notification, true)
+	var retried int32
+	processNotifications(t, metrics, func(ctx context.Context, notification *sql.ProjectionNotification, queue sql.ProjectionTrigger) error {
+		if atomic.AddInt32(&retried, 1) == 1 {
+			return queue(ctx, notification)
+		}
+		return nil
+	})
 
 	assertMetricsWhereCalled(t, registry, map[string]uint64{
-		"goengine_notification_processing_duration_seconds": 1,
-		"goengine_queue_duration_seconds":                   0,
+		"goengine_notification_queued_count":                3,
+		"goengine_queue_duration_seconds":                   2,
+		"goengine_notification_processing_duration_seconds": 3,
 	})
+	assert.Empty(t, loggerHook.AllEntries())
 }
 
-func TestMetrics_QueueProcessAndFinishNotification(t *testing.T) {
-	notification := &sql.ProjectionNotification{
-		No:          1,
-		AggregateID: "C56A4180-65AA-42EC-A945-5FD21DEC0538",
-	}
+func TestMetrics_StartNotificationProcessing(t *testing.T) {
+	t.Run("Nil notification", func(t *testing.T) {
+		registry := prometheus.NewPedanticRegistry()
 
-	registry := prometheus.NewPedanticRegistry()
+		metrics := goenginePrometheus.NewMetrics(nil)
+		require.NoError(t, metrics.RegisterMetrics(registry))
 
-	metrics := goenginePrometheus.NewMetrics(nil)
-	require.NoError(t, metrics.RegisterMetrics(registry))
+		metrics.StartProjectionNotificationProcessing("projection", nil)
+		metrics.FinishProjectionNotificationProcessing("projection", nil, time.Millisecond, true)
+
+		assertMetricsWhereCalled(t, registry, map[string]uint64{
+			"goengine_notification_processing_duration_seconds": 1,
+		})
+	})
 
-	metrics.QueueNotification(notification)
-	metrics.StartNotificationProcessing(notification)
-	metrics.FinishNotificationProcessing(notification, true)
+	t.Run("Notification that was never queued", func(t *testing.T) {
+		registry := prometheus.NewPedanticRegistry()
 
-	assertMetricsWhereCalled(t, registry, map[string]uint64{
-		"goengine_notification_processing_duration_seconds": 1,
-		"goengine_queue_duration_seconds":                   1,
+		logger, loggerHook := test.NewNullLogger()
+		metrics := goenginePrometheus.NewMetrics(goengineLogger.Wrap(logger))
+		require.NoError(t, metrics.RegisterMetrics(registry))
+
+		metrics.StartProjectionNotificationProcessing("projection", &sql.ProjectionNotification{
+			No:          1,
+			AggregateID: "C56A4180-65AA-42EC-A945-5FD21DEC0538",
+		})
+
+		assertMetricsWhereCalled(t, registry, map[string]uint64{})
+		if assert.Len(t, loggerHook.AllEntries(), 1) {
+			assert.Equal(t, "notification queue time not found", loggerHook.LastEntry().Message)
+		}
 	})
 }
 
-func TestMetrics_FinishNotificationWithoutStart(t *testing.T) {
-	notification := &sql.ProjectionNotification{
-		No:          1,
-		AggregateID: "C56A4180-65AA-42EC-A945-5FD21DEC0538",
-	}
-
+func TestMetrics_ReceivedNotification(t *testing.T) {
 	registry := prometheus.NewPedanticRegistry()
 
 	metrics := goenginePrometheus.NewMetrics(nil)
 	require.NoError(t, metrics.RegisterMetrics(registry))
 
-	metrics.FinishNotificationProcessing(notification, true)
+	metrics.ReceivedNotification(true)
+	metrics.ReceivedNotification(false)
 
 	assertMetricsWhereCalled(t, registry, map[string]uint64{
-		"goengine_notification_processing_duration_seconds": 0,
-		"goengine_queue_duration_seconds":                   0,
+		"goengine_notification_count": 2,
 	})
 }
 
-func TestMetrics_QueueProcessAndFinishNotificationTwice(t *testing.T) {
-	notification := &sql.ProjectionNotification{
-		No:          1,
-		AggregateID: "C56A4180-65AA-42EC-A945-5FD21DEC0538",
-	}
-
+func TestMetrics_EventStore(t *testing.T) {
 	registry := prometheus.NewPedanticRegistry()
 
 	metrics := goenginePrometheus.NewMetrics(nil)
 	require.NoError(t, metrics.RegisterMetrics(registry))
 
-	metrics.QueueNotification(notification)
-	metrics.StartNotificationProcessing(notification)
-	metrics.FinishNotificationProcessing(notification, true)
-	metrics.FinishNotificationProcessing(notification, true)
+	metrics.AppendToStream("event_stream", 3, time.Millisecond, true)
+	metrics.AppendToStream("event_stream", 2, time.Millisecond, false)
+	metrics.LoadStream("event_stream", time.Millisecond, true)
 
 	assertMetricsWhereCalled(t, registry, map[string]uint64{
-		"goengine_notification_processing_duration_seconds": 1,
-		"goengine_queue_duration_seconds":                   1,
+		"goengine_eventstore_append_duration_seconds": 2,
+		"goengine_eventstore_appended_events_total":   3,
+		"goengine_eventstore_load_duration_seconds":   1,
 	})
 }
 
-func TestMetrics_ReceivedNotification(t *testing.T) {
+func TestMetrics_Projection(t *testing.T) {
 	registry := prometheus.NewPedanticRegistry()
 
 	metrics := goenginePrometheus.NewMetrics(nil)
 	require.NoError(t, metrics.RegisterMetrics(registry))
 
-	metrics.ReceivedNotification(true)
-	metrics.ReceivedNotification(false)
+	metrics.ExecuteProjectionHandler("balance", "account_debited", time.Millisecond, true)
+	metrics.ExecuteProjectionHandler("balance", "account_credited", time.Millisecond, false)
+	metrics.CommitProjectionState("balance", 12, time.Millisecond, true)
+	metrics.FailedToLockProjection("balance")
+	metrics.FailedToLockProjection("balance")
 
 	assertMetricsWhereCalled(t, registry, map[string]uint64{
-		"goengine_notification_count": 2,
+		"goengine_projection_handler_duration_seconds":      2,
+		"goengine_projection_handler_errors_total":          1,
+		"goengine_projection_state_commit_duration_seconds": 1,
+		"goengine_projection_lock_failures_total":           2,
 	})
 }
 
+// processNotifications queues a notification using a background processor and waits for it to be processed
+func processNotifications(t *testing.T, metrics sql.Metrics, handler sql.ProcessHandler) {
+	processor, err := sql.NewBackgroundProcessor(1, 2, nil, metrics, nil, sql.WithProjectionName("projection"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	notification := &sql.ProjectionNotification{
+		No:          1,
+		AggregateID: "C56A4180-65AA-42EC-A945-5FD21DEC0538",
+	}
+
+	require.NoError(t, processor.Execute(ctx, func(ctx context.Context, n *sql.ProjectionNotification, queue sql.ProjectionTrigger) error {
+		if n == nil {
+			return queue(ctx, notification)
+		}
+		return handler(ctx, n, queue)
+	}, nil))
+}
+
 func assertMetricsWhereCalled(t *testing.T, g prometheus.Gatherer, metricsCounts map[string]uint64) {
 	got, err := g.Gather()
 	require.NoError(t, err)
@@ -1,5 +1,7 @@
 package goengine
 
+import "context"
+
 type (
 	// MessagePayloadConverter an interface describing converting payload data
 	MessagePayloadConverter interface {
@@ -7,6 +9,14 @@ type (
 		ConvertPayload(payload interface{}) (name string, data []byte, err error)
 	}
 
+	// MessagePayloadContextConverter is a MessagePayloadConverter that is able to convert a payload using a context
+	MessagePayloadContextConverter interface {
+		MessagePayloadConverter
+
+		// ConvertPayloadContext generates unique name for the event_name using the context for any lookups
+		ConvertPayloadContext(ctx context.Context, payload interface{}) (name string, data []byte, err error)
+	}
+
 	// MessagePayloadFactory is used to reconstruct message payloads
 	MessagePayloadFactory interface {
 		// CreatePayload returns a reconstructed payload or a error
@@ -0,0 +1,229 @@
+// Package projectiontest provides a test harness that runs the handlers of a goengine.Projection against fixtures.
+//
+// Between every handled message the state is encoded and decoded using the goengine.ProjectionSaga
+// EncodeState and DecodeState in order to catch serialization bugs.
+//
+// Example testing a projection:
+//  projectiontest.NewScenario(t, &TotalDepositProjection{}, payloadTransformer).
+//  	GivenPayloads(AccountCredited{Amount: 100}, AccountCredited{Amount: 50}).
+//  	Then(TotalDepositState{Deposited: 150, Times: 2})
+package projectiontest
+
+import (
+	"context"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// TestingT is the subset of testing.TB used by the Scenario
+	TestingT interface {
+		Errorf(format string, args ...interface{})
+		FailNow()
+		Helper()
+	}
+
+	// Scenario runs a goengine.Projection against a set of messages
+	Scenario struct {
+		t                  TestingT
+		projection         goengine.Projection
+		resolver           goengine.MessagePayloadResolver
+		stateSerialization driverSQL.ProjectionStateSerialization
+
+		executed bool
+		state    interface{}
+		handled  int
+		err      error
+	}
+)
+
+// NewScenario returns a new Scenario for the projection using the resolver to resolve the message event names
+func NewScenario(t TestingT, projection goengine.Projection, resolver goengine.MessagePayloadResolver) *Scenario {
+	t.Helper()
+
+	switch {
+	case projection == nil:
+		t.Errorf("projectiontest: a projection is required")
+		t.FailNow()
+	case resolver == nil:
+		t.Errorf("projectiontest: a resolver is required")
+		t.FailNow()
+	}
+
+	return &Scenario{
+		t:                  t,
+		projection:         projection,
+		resolver:           resolver,
+		stateSerialization: driverSQL.GetProjectionStateSerialization(projection),
+	}
+}
+
+// GivenPayloads wraps the payloads into messages and projects them
+func (s *Scenario) GivenPayloads(payloads ...interface{}) *Scenario {
+	s.t.Helper()
+
+	messages := make([]goengine.Message, len(payloads))
+	for i, payload := range payloads {
+		messages[i] = NewMessage(payload, nil)
+	}
+
+	return s.Given(messages...)
+}
+
+// Given initializes the projection state and projects the messages in order.
+// Projecting stops at the first error which can be asserted using ThenError.
+func (s *Scenario) Given(messages ...goengine.Message) *Scenario {
+	s.t.Helper()
+
+	ctx := context.Background()
+	s.executed = true
+	s.handled = 0
+
+	handlers := s.projection.Handlers()
+	s.state, s.err = s.stateSerialization.Init(ctx)
+	if s.err != nil {
+		return s
+	}
+
+	for _, msg := range messages {
+		eventName, err := s.resolver.ResolveName(msg.Payload())
+		if err != nil {
+			s.t.Errorf("projectiontest: failed to resolve the event name of %T: %s", msg.Payload(), err)
+			s.t.FailNow()
+		}
+
+		handler, found := handlers[eventName]
+		if !found {
+			continue
+		}
+
+		state, err := handler(ctx, s.state, msg)
+		if err != nil {
+			s.err = err
+			return s
+		}
+		s.handled++
+
+		// Round trip the state as the projector would when committing and acquiring it
+		data, err := s.stateSerialization.EncodeState(state)
+		if err != nil {
+			s.t.Errorf("projectiontest: failed to encode the state after %s: %s", eventName, err)
+			s.t.FailNow()
+		}
+
+		if _, isSaga := s.projection.(goengine.ProjectionSaga); !isSaga {
+			s.state = state
+			continue
+		}
+
+		s.state, err = s.stateSerialization.DecodeState(data)
+		if err != nil {
+			s.t.Errorf("projectiontest: failed to decode the state after %s: %s", eventName, err)
+			s.t.FailNow()
+		}
+	}
+
+	return s
+}
+
+// Then asserts that all messages were projected without an error and that the final state equals the expected state
+func (s *Scenario) Then(expected interface{}) {
+	s.t.Helper()
+	s.requireExecuted()
+
+	if s.err != nil {
+		s.t.Errorf("projectiontest: expected the projection to succeed but it returned an error: %s", s.err)
+		return
+	}
+
+	assert.Equal(s.t, expected, s.state, "projectiontest: the projection state does not match the expected state")
+}
+
+// ThenError asserts that projecting the messages returned the expected error
+func (s *Scenario) ThenError(expected error) {
+	s.t.Helper()
+	s.requireExecuted()
+
+	if s.err == nil {
+		s.t.Errorf("projectiontest: expected the projection to return error %q but it succeeded", expected)
+		return
+	}
+
+	assert.Equal(s.t, expected, s.err, "projectiontest: the returned error does not match the expected error")
+}
+
+// State returns the projection state after the messages were projected
+func (s *Scenario) State() interface{} {
+	s.t.Helper()
+	s.requireExecuted()
+
+	return s.state
+}
+
+// Handled returns the number of messages for which a projection handler was called
+func (s *Scenario) Handled() int {
+	s.t.Helper()
+	s.requireExecuted()
+
+	return s.handled
+}
+
+func (s *Scenario) requireExecuted() {
+	s.t.Helper()
+
+	if !s.executed {
+		s.t.Errorf("projectiontest: no messages were projected, call Given or GivenPayloads first")
+		s.t.FailNow()
+	}
+}
+
+// Ensure that message satisfies the goengine.Message interface
+var _ goengine.Message = &message{}
+
+// message is a simple goengine.Message used as a fixture
+type message struct {
+	uuid      goengine.UUID
+	payload   interface{}
+	metadata  metadata.Metadata
+	createdAt time.Time
+}
+
+// NewMessage returns a new goengine.Message fixture with the provided payload and metadata
+func NewMessage(payload interface{}, meta metadata.Metadata) goengine.Message {
+	if meta == nil {
+		meta = metadata.New()
+	}
+
+	return &message{
+		uuid:      goengine.GenerateUUID(),
+		payload:   payload,
+		metadata:  meta,
+		createdAt: time.Now().UTC(),
+	}
+}
+
+func (m *message) UUID() goengine.UUID {
+	return m.uuid
+}
+
+func (m *message) CreatedAt() time.Time {
+	return m.createdAt
+}
+
+func (m *message) Payload() interface{} {
+	return m.payload
+}
+
+func (m *message) Metadata() metadata.Metadata {
+	return m.metadata
+}
+
+func (m message) WithMetadata(key string, value interface{}) goengine.Message {
+	m.metadata = metadata.WithValue(m.metadata, key, value)
+
+	return &m
+}
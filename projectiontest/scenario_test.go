@@ -0,0 +1,174 @@
+// +build unit
+
+package projectiontest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/inmemory"
+	"github.com/hellofresh/goengine/projectiontest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNegativeDeposit = errors.New("negative deposit")
+
+type (
+	deposited struct {
+		Amount int
+	}
+
+	ignored struct{}
+
+	depositState struct {
+		Deposited int `json:"deposited"`
+		Times     int `json:"times"`
+	}
+
+	// depositProjection is a goengine.ProjectionSaga that tracks the total deposits
+	depositProjection struct {
+		forgetTimes bool
+	}
+
+	// counterProjection is a goengine.Projection that counts messages without state
+	counterProjection struct {
+		count *int
+	}
+
+	// recordingT is a projectiontest.TestingT that records the reported errors
+	recordingT struct {
+		errors []string
+	}
+)
+
+func (*depositProjection) Name() string {
+	return "deposits"
+}
+
+func (*depositProjection) FromStream() goengine.StreamName {
+	return "event_stream"
+}
+
+func (*depositProjection) Init(ctx context.Context) (interface{}, error) {
+	return depositState{}, nil
+}
+
+func (*depositProjection) Handlers() map[string]goengine.MessageHandler {
+	return map[string]goengine.MessageHandler{
+		"deposited": func(ctx context.Context, state interface{}, message goengine.Message) (interface{}, error) {
+			event := message.Payload().(deposited)
+			if event.Amount < 0 {
+				return nil, errNegativeDeposit
+			}
+
+			s := state.(depositState)
+			s.Deposited += event.Amount
+			s.Times++
+
+			return s, nil
+		},
+	}
+}
+
+func (p *depositProjection) DecodeState(data []byte) (interface{}, error) {
+	var state depositState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	if p.forgetTimes {
+		state.Times = 0
+	}
+
+	return state, nil
+}
+
+func (*depositProjection) EncodeState(obj interface{}) ([]byte, error) {
+	return json.Marshal(obj)
+}
+
+func (*counterProjection) Name() string {
+	return "counter"
+}
+
+func (*counterProjection) FromStream() goengine.StreamName {
+	return "event_stream"
+}
+
+func (*counterProjection) Init(ctx context.Context) (interface{}, error) {
+	return nil, nil
+}
+
+func (p *counterProjection) Handlers() map[string]goengine.MessageHandler {
+	return map[string]goengine.MessageHandler{
+		"deposited": func(ctx context.Context, state interface{}, message goengine.Message) (interface{}, error) {
+			*p.count++
+			return nil, nil
+		},
+	}
+}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingT) FailNow() {}
+
+func (r *recordingT) Helper() {}
+
+func TestScenario(t *testing.T) {
+	resolver := &inmemory.PayloadRegistry{}
+	require.NoError(t, resolver.RegisterPayload("deposited", deposited{}))
+	require.NoError(t, resolver.RegisterPayload("ignored", ignored{}))
+
+	t.Run("project payloads", func(t *testing.T) {
+		scenario := projectiontest.NewScenario(t, &depositProjection{}, resolver).
+			GivenPayloads(deposited{Amount: 100}, ignored{}, deposited{Amount: 50})
+
+		scenario.Then(depositState{Deposited: 150, Times: 2})
+		assert.Equal(t, 2, scenario.Handled())
+	})
+
+	t.Run("project without state", func(t *testing.T) {
+		var count int
+		projectiontest.NewScenario(t, &counterProjection{count: &count}, resolver).
+			GivenPayloads(deposited{Amount: 100}, deposited{Amount: 50}).
+			Then(nil)
+
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		projectiontest.NewScenario(t, &depositProjection{}, resolver).
+			GivenPayloads(deposited{Amount: 100}, deposited{Amount: -1}).
+			ThenError(errNegativeDeposit)
+	})
+
+	t.Run("report state lost during serialization", func(t *testing.T) {
+		recorder := &recordingT{}
+
+		projectiontest.NewScenario(recorder, &depositProjection{forgetTimes: true}, resolver).
+			GivenPayloads(deposited{Amount: 100}, deposited{Amount: 50}).
+			Then(depositState{Deposited: 150, Times: 2})
+
+		if assert.Len(t, recorder.errors, 1) {
+			assert.Contains(t, recorder.errors[0], "the projection state does not match the expected state")
+		}
+	})
+
+	t.Run("report unresolvable payload", func(t *testing.T) {
+		recorder := &recordingT{}
+
+		projectiontest.NewScenario(recorder, &depositProjection{}, resolver).
+			GivenPayloads(struct{}{})
+
+		if assert.Len(t, recorder.errors, 1) {
+			assert.Contains(t, recorder.errors[0], "failed to resolve the event name")
+		}
+	})
+}
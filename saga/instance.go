@@ -0,0 +1,69 @@
+package saga
+
+import (
+	"time"
+
+	"github.com/hellofresh/goengine"
+)
+
+// Instance is a single saga instance identified by it's correlation ID
+type Instance struct {
+	correlationID string
+	state         interface{}
+	isNew         bool
+	completed     bool
+
+	now       time.Time
+	commands  []interface{}
+	deadlines []Deadline
+}
+
+// CorrelationID returns the correlation ID of the saga instance
+func (i *Instance) CorrelationID() string {
+	return i.correlationID
+}
+
+// IsNew returns true when the saga instance was started by the current message
+func (i *Instance) IsNew() bool {
+	return i.isNew
+}
+
+// State returns the state of the saga instance
+func (i *Instance) State() interface{} {
+	return i.state
+}
+
+// SetState replaces the state of the saga instance
+func (i *Instance) SetState(state interface{}) {
+	i.state = state
+}
+
+// Dispatch queues a command that is dispatched after the handler returned successfully
+func (i *Instance) Dispatch(command interface{}) {
+	i.commands = append(i.commands, command)
+}
+
+// ScheduleDeadline schedules a deadline with the given name that is due after the provided delay.
+// The deadline is persisted and will be handled even when the process restarts.
+func (i *Instance) ScheduleDeadline(name string, delay time.Duration) goengine.UUID {
+	deadline := Deadline{
+		ID:            goengine.GenerateUUID(),
+		Name:          name,
+		CorrelationID: i.correlationID,
+		DueAt:         i.now.Add(delay),
+	}
+	i.deadlines = append(i.deadlines, deadline)
+
+	return deadline.ID
+}
+
+// Complete marks the saga instance as completed.
+// A completed instance ignores any further messages and it's pending deadlines are removed.
+func (i *Instance) Complete() {
+	i.completed = true
+}
+
+// Completed returns true when the saga instance is completed
+func (i *Instance) Completed() bool {
+	return i.completed
+}
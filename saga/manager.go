@@ -0,0 +1,360 @@
+package saga
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/pkg/errors"
+)
+
+// Manager runs a Saga against an event stream.
+// It correlates messages to saga instances, persists their state, dispatches the issued commands and handles due deadlines.
+//
+// Commands are dispatched before the saga state is committed, which means that a command can be dispatched more than
+// once when committing fails. Only one Manager should be running for a saga at any point in time, when the Storage is a
+// LockingStorage a run is skipped while another Manager holds the lock of the saga.
+type Manager struct {
+	sync.Mutex
+
+	eventStore goengine.ReadOnlyEventStore
+	resolver   goengine.MessagePayloadResolver
+	storage    Storage
+	dispatcher Dispatcher
+
+	saga             Saga
+	handlers         map[string]Handler
+	deadlineHandlers map[string]DeadlineHandler
+
+	logger goengine.Logger
+}
+
+// NewManager returns a new Manager for the saga
+func NewManager(
+	eventStore goengine.ReadOnlyEventStore,
+	resolver goengine.MessagePayloadResolver,
+	saga Saga,
+	storage Storage,
+	dispatcher Dispatcher,
+	logger goengine.Logger,
+) (*Manager, error) {
+	switch {
+	case eventStore == nil:
+		return nil, goengine.InvalidArgumentError("eventStore")
+	case resolver == nil:
+		return nil, goengine.InvalidArgumentError("resolver")
+	case saga == nil:
+		return nil, goengine.InvalidArgumentError("saga")
+	case saga.CorrelationKey() == "":
+		return nil, goengine.InvalidArgumentError("saga")
+	case storage == nil:
+		return nil, goengine.InvalidArgumentError("storage")
+	case dispatcher == nil:
+		return nil, goengine.InvalidArgumentError("dispatcher")
+	}
+
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+	logger = logger.WithFields(func(e goengine.LoggerEntry) {
+		e.String("saga", saga.Name())
+	})
+
+	return &Manager{
+		eventStore:       eventStore,
+		resolver:         resolver,
+		storage:          storage,
+		dispatcher:       dispatcher,
+		saga:             saga,
+		handlers:         saga.Handlers(),
+		deadlineHandlers: saga.DeadlineHandlers(),
+		logger:           logger,
+	}, nil
+}
+
+// Run processes all messages that were appended to the event stream since the last run and handles any due deadlines
+func (m *Manager) Run(ctx context.Context) error {
+	m.Lock()
+	defer m.Unlock()
+
+	// Check if the context is expired
+	select {
+	default:
+	case <-ctx.Done():
+		return nil
+	}
+
+	if lockingStorage, ok := m.storage.(LockingStorage); ok {
+		release, err := lockingStorage.Lock(ctx, m.saga.Name())
+		if err == ErrSagaLocked {
+			m.logger.Debug("skipping run since the saga is locked by another manager", nil)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	if err := m.processMessages(ctx); err != nil {
+		return err
+	}
+
+	return m.processDeadlines(ctx, time.Now().UTC())
+}
+
+// RunAndPoll runs the saga every interval until the context is done.
+// Errors are logged and the failed message or deadline is retried during the next run.
+func (m *Manager) RunAndPoll(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return goengine.InvalidArgumentError("interval")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Run(ctx); err != nil {
+			m.logger.Error("saga run failed", func(e goengine.LoggerEntry) {
+				e.Error(err)
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) processMessages(ctx context.Context) error {
+	sagaName := m.saga.Name()
+	position, err := m.storage.LoadPosition(ctx, sagaName)
+	if err != nil {
+		return err
+	}
+
+	stream, err := m.eventStore.Load(ctx, m.saga.FromStream(), position+1, nil, metadata.NewMatcher())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			m.logger.Warn("failed to close the event stream", func(e goengine.LoggerEntry) {
+				e.Error(err)
+			})
+		}
+	}()
+
+	committedPosition := position
+	for stream.Next() {
+		// Check if the context is expired
+		select {
+		default:
+		case <-ctx.Done():
+			return nil
+		}
+
+		msg, msgNumber, err := stream.Message()
+		if err != nil {
+			return err
+		}
+		position = msgNumber
+
+		eventName, err := m.resolver.ResolveName(msg.Payload())
+		if err != nil {
+			return err
+		}
+
+		handler, found := m.handlers[eventName]
+		if !found {
+			continue
+		}
+
+		correlationID := correlationIDFromMetadata(msg, m.saga.CorrelationKey())
+		if correlationID == "" {
+			m.logger.Debug("skipping message without correlation id", func(e goengine.LoggerEntry) {
+				e.Int64("message.no", msgNumber)
+				e.String("message.name", eventName)
+			})
+			continue
+		}
+
+		instance, err := m.loadInstance(ctx, correlationID)
+		if err != nil {
+			return err
+		}
+		if instance.completed {
+			continue
+		}
+
+		if err := trapHandlerError(func() error { return handler(ctx, instance, msg) }); err != nil {
+			return err
+		}
+
+		if err := m.commit(ctx, instance, Commit{Position: position}); err != nil {
+			return err
+		}
+		committedPosition = position
+	}
+
+	if err := stream.Err(); err != nil {
+		return err
+	}
+
+	// Persist the position of any trailing messages that did not concern the saga
+	if position > committedPosition {
+		return m.storage.Commit(ctx, sagaName, Commit{Position: position})
+	}
+
+	return nil
+}
+
+func (m *Manager) processDeadlines(ctx context.Context, now time.Time) error {
+	sagaName := m.saga.Name()
+	deadlines, err := m.storage.LoadDueDeadlines(ctx, sagaName, now)
+	if err != nil {
+		return err
+	}
+
+	for _, deadline := range deadlines {
+		// Check if the context is expired
+		select {
+		default:
+		case <-ctx.Done():
+			return nil
+		}
+
+		handler, found := m.deadlineHandlers[deadline.Name]
+		if !found {
+			return errors.Wrap(ErrUnknownDeadline, deadline.Name)
+		}
+
+		instance, err := m.loadInstance(ctx, deadline.CorrelationID)
+		if err != nil {
+			return err
+		}
+		if instance.completed {
+			if err := m.storage.Commit(ctx, sagaName, Commit{FiredDeadline: deadline.ID}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		deadline := deadline
+		if err := trapHandlerError(func() error { return handler(ctx, instance, deadline) }); err != nil {
+			return err
+		}
+
+		if err := m.commit(ctx, instance, Commit{FiredDeadline: deadline.ID}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadInstance loads the saga instance from the storage or initializes a new instance when it is unknown
+func (m *Manager) loadInstance(ctx context.Context, correlationID string) (*Instance, error) {
+	raw, err := m.storage.LoadInstance(ctx, m.saga.Name(), correlationID)
+	if err != nil {
+		return nil, err
+	}
+
+	instance := &Instance{
+		correlationID: correlationID,
+		now:           time.Now().UTC(),
+	}
+
+	if raw == nil {
+		instance.isNew = true
+		instance.state, err = m.saga.Init(ctx)
+	} else {
+		instance.completed = raw.Completed
+		instance.state, err = m.saga.DecodeState(raw.State)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return instance, nil
+}
+
+// commit dispatches the commands issued by the instance and persists the instance changes
+func (m *Manager) commit(ctx context.Context, instance *Instance, commit Commit) error {
+	for _, command := range instance.commands {
+		if err := m.dispatcher.Dispatch(ctx, command); err != nil {
+			return err
+		}
+	}
+
+	state, err := m.saga.EncodeState(instance.state)
+	if err != nil {
+		return err
+	}
+
+	commit.CorrelationID = instance.correlationID
+	commit.State = state
+	commit.Completed = instance.completed
+	commit.Deadlines = instance.deadlines
+
+	if err := m.storage.Commit(ctx, m.saga.Name(), commit); err != nil {
+		return err
+	}
+
+	m.logger.Debug("committed saga instance", func(e goengine.LoggerEntry) {
+		e.String("correlation_id", instance.correlationID)
+		e.Int64("position", commit.Position)
+		e.Int("commands", len(instance.commands))
+		e.Int("deadlines", len(instance.deadlines))
+	})
+
+	return nil
+}
+
+// correlationIDFromMetadata returns the correlation ID of the message or an empty string when there is none
+func correlationIDFromMetadata(msg goengine.Message, key string) string {
+	switch v := msg.Metadata().Value(key).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// trapHandlerError calls the handler and ensures that a returned error or panic is wrapped in a HandlerError
+func trapHandlerError(handler func() error) (handlerErr error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		// find out exactly what the error was and set err
+		var err error
+		switch x := r.(type) {
+		case string:
+			err = errors.New(x)
+		case error:
+			err = x
+		default:
+			err = errors.Errorf("unknown panic: (%T) %v", x, x)
+		}
+
+		handlerErr = NewHandlerError(err)
+	}()
+
+	if err := handler(); err != nil {
+		return NewHandlerError(err)
+	}
+
+	return nil
+}
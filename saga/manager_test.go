@@ -0,0 +1,290 @@
+// +build unit
+
+package saga_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/inmemory"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/mocks"
+	"github.com/hellofresh/goengine/saga"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	orderPlaced struct{}
+	orderPaid   struct{}
+	orderOther  struct{}
+
+	cancelOrder struct {
+		OrderID string
+	}
+	shipOrder struct {
+		OrderID string
+	}
+
+	orderState struct {
+		Paid bool `json:"paid"`
+	}
+
+	orderSaga struct {
+		handlerErr error
+	}
+
+	recordingDispatcher struct {
+		commands []interface{}
+	}
+
+	lockingStorage struct {
+		saga.Storage
+
+		locked bool
+	}
+)
+
+func (*orderSaga) Name() string {
+	return "order_saga"
+}
+
+func (*orderSaga) FromStream() goengine.StreamName {
+	return "orders"
+}
+
+func (*orderSaga) CorrelationKey() string {
+	return "order_id"
+}
+
+func (*orderSaga) Init(ctx context.Context) (interface{}, error) {
+	return orderState{}, nil
+}
+
+func (s *orderSaga) Handlers() map[string]saga.Handler {
+	return map[string]saga.Handler{
+		"order_placed": func(ctx context.Context, instance *saga.Instance, message goengine.Message) error {
+			if s.handlerErr != nil {
+				return s.handlerErr
+			}
+
+			instance.ScheduleDeadline("payment_timeout", time.Hour)
+			return nil
+		},
+		"order_paid": func(ctx context.Context, instance *saga.Instance, message goengine.Message) error {
+			instance.SetState(orderState{Paid: true})
+			instance.Dispatch(shipOrder{OrderID: instance.CorrelationID()})
+			instance.Complete()
+			return nil
+		},
+	}
+}
+
+func (*orderSaga) DeadlineHandlers() map[string]saga.DeadlineHandler {
+	return map[string]saga.DeadlineHandler{
+		"payment_timeout": func(ctx context.Context, instance *saga.Instance, deadline saga.Deadline) error {
+			instance.Dispatch(cancelOrder{OrderID: instance.CorrelationID()})
+			instance.Complete()
+			return nil
+		},
+	}
+}
+
+func (*orderSaga) DecodeState(data []byte) (interface{}, error) {
+	var state orderState
+	err := json.Unmarshal(data, &state)
+	return state, err
+}
+
+func (*orderSaga) EncodeState(obj interface{}) ([]byte, error) {
+	return json.Marshal(obj)
+}
+
+func (d *recordingDispatcher) Dispatch(ctx context.Context, command interface{}) error {
+	d.commands = append(d.commands, command)
+	return nil
+}
+
+func (s *lockingStorage) Lock(ctx context.Context, sagaName string) (func(), error) {
+	if s.locked {
+		return nil, saga.ErrSagaLocked
+	}
+
+	s.locked = true
+	return func() { s.locked = false }, nil
+}
+
+func TestNewManager(t *testing.T) {
+	store := inmemory.NewEventStore(nil)
+	resolver := &inmemory.PayloadRegistry{}
+	storage := inmemory.NewSagaStorage()
+	dispatcher := &recordingDispatcher{}
+
+	t.Run("create a manager", func(t *testing.T) {
+		manager, err := saga.NewManager(store, resolver, &orderSaga{}, storage, dispatcher, nil)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, manager)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := saga.NewManager(nil, resolver, &orderSaga{}, storage, dispatcher, nil)
+		assert.Equal(t, goengine.InvalidArgumentError("eventStore"), err)
+
+		_, err = saga.NewManager(store, nil, &orderSaga{}, storage, dispatcher, nil)
+		assert.Equal(t, goengine.InvalidArgumentError("resolver"), err)
+
+		_, err = saga.NewManager(store, resolver, nil, storage, dispatcher, nil)
+		assert.Equal(t, goengine.InvalidArgumentError("saga"), err)
+
+		_, err = saga.NewManager(store, resolver, &orderSaga{}, nil, dispatcher, nil)
+		assert.Equal(t, goengine.InvalidArgumentError("storage"), err)
+
+		_, err = saga.NewManager(store, resolver, &orderSaga{}, storage, nil, nil)
+		assert.Equal(t, goengine.InvalidArgumentError("dispatcher"), err)
+	})
+}
+
+func TestManager_Run(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("complete a saga based on events", func(t *testing.T) {
+		store, resolver := newOrderEventStore(t)
+		storage := inmemory.NewSagaStorage()
+		dispatcher := &recordingDispatcher{}
+
+		manager, err := saga.NewManager(store, resolver, &orderSaga{}, storage, dispatcher, nil)
+		require.NoError(t, err)
+
+		appendOrderEvents(t, store, orderPlaced{}, "order-1", orderOther{}, "order-1", orderPlaced{}, "")
+		require.NoError(t, manager.Run(ctx))
+
+		position, err := storage.LoadPosition(ctx, "order_saga")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), position)
+		assert.Empty(t, dispatcher.commands)
+
+		deadlines, err := storage.LoadDueDeadlines(ctx, "order_saga", time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+		if assert.Len(t, deadlines, 1) {
+			assert.Equal(t, "payment_timeout", deadlines[0].Name)
+			assert.Equal(t, "order-1", deadlines[0].CorrelationID)
+		}
+
+		appendOrderEvents(t, store, orderPaid{}, "order-1", orderPaid{}, "order-1")
+		require.NoError(t, manager.Run(ctx))
+
+		assert.Equal(t, []interface{}{shipOrder{OrderID: "order-1"}}, dispatcher.commands)
+
+		instance, err := storage.LoadInstance(ctx, "order_saga", "order-1")
+		require.NoError(t, err)
+		if assert.NotNil(t, instance) {
+			assert.True(t, instance.Completed)
+			assert.JSONEq(t, `{"paid":true}`, string(instance.State))
+		}
+
+		deadlines, err = storage.LoadDueDeadlines(ctx, "order_saga", time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, deadlines)
+	})
+
+	t.Run("handle a due deadline", func(t *testing.T) {
+		store, resolver := newOrderEventStore(t)
+		storage := inmemory.NewSagaStorage()
+		dispatcher := &recordingDispatcher{}
+
+		manager, err := saga.NewManager(store, resolver, &orderSaga{}, storage, dispatcher, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, storage.Commit(ctx, "order_saga", saga.Commit{
+			CorrelationID: "order-2",
+			State:         []byte(`{}`),
+			Deadlines: []saga.Deadline{
+				{ID: goengine.GenerateUUID(), Name: "payment_timeout", CorrelationID: "order-2", DueAt: time.Now().Add(-time.Second)},
+			},
+		}))
+
+		require.NoError(t, manager.Run(ctx))
+		assert.Equal(t, []interface{}{cancelOrder{OrderID: "order-2"}}, dispatcher.commands)
+
+		// The deadline must only fire once
+		require.NoError(t, manager.Run(ctx))
+		assert.Len(t, dispatcher.commands, 1)
+	})
+
+	t.Run("skip the run while the saga is locked by another manager", func(t *testing.T) {
+		store, resolver := newOrderEventStore(t)
+		storage := &lockingStorage{Storage: inmemory.NewSagaStorage(), locked: true}
+		dispatcher := &recordingDispatcher{}
+
+		manager, err := saga.NewManager(store, resolver, &orderSaga{}, storage, dispatcher, nil)
+		require.NoError(t, err)
+
+		appendOrderEvents(t, store, orderPlaced{}, "order-4")
+		require.NoError(t, manager.Run(ctx))
+
+		position, err := storage.LoadPosition(ctx, "order_saga")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), position)
+
+		// Once the lock is released by the other manager the saga is run and the lock is released after the run
+		storage.locked = false
+		require.NoError(t, manager.Run(ctx))
+		assert.False(t, storage.locked)
+
+		position, err = storage.LoadPosition(ctx, "order_saga")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), position)
+	})
+
+	t.Run("a handler error stops the run without moving the position", func(t *testing.T) {
+		store, resolver := newOrderEventStore(t)
+		storage := inmemory.NewSagaStorage()
+		handlerErr := errors.New("failed")
+
+		manager, err := saga.NewManager(store, resolver, &orderSaga{handlerErr: handlerErr}, storage, &recordingDispatcher{}, nil)
+		require.NoError(t, err)
+
+		appendOrderEvents(t, store, orderPlaced{}, "order-3")
+
+		err = manager.Run(ctx)
+		if assert.IsType(t, &saga.HandlerError{}, err) {
+			assert.Equal(t, handlerErr, err.(*saga.HandlerError).Cause())
+		}
+
+		position, err := storage.LoadPosition(ctx, "order_saga")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), position)
+	})
+}
+
+func newOrderEventStore(t *testing.T) (*inmemory.EventStore, *inmemory.PayloadRegistry) {
+	store := inmemory.NewEventStore(nil)
+	require.NoError(t, store.Create(context.Background(), "orders"))
+
+	resolver := &inmemory.PayloadRegistry{}
+	require.NoError(t, resolver.RegisterPayload("order_placed", orderPlaced{}))
+	require.NoError(t, resolver.RegisterPayload("order_paid", orderPaid{}))
+	require.NoError(t, resolver.RegisterPayload("order_other", orderOther{}))
+
+	return store, resolver
+}
+
+// appendOrderEvents appends pairs of payload and order id to the orders stream
+func appendOrderEvents(t *testing.T, store *inmemory.EventStore, payloadsAndIDs ...interface{}) {
+	var messages []goengine.Message
+	for i := 0; i < len(payloadsAndIDs); i += 2 {
+		meta := metadata.New()
+		if orderID := payloadsAndIDs[i+1].(string); orderID != "" {
+			meta = metadata.WithValue(meta, "order_id", orderID)
+		}
+
+		messages = append(messages, mocks.NewDummyMessage(goengine.GenerateUUID(), payloadsAndIDs[i], meta, time.Now()))
+	}
+
+	require.NoError(t, store.AppendTo(context.Background(), "orders", messages))
+}
@@ -0,0 +1,135 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hellofresh/goengine"
+)
+
+var (
+	// ErrUnknownDeadline occurs when a deadline is due but the saga has no handler for it
+	ErrUnknownDeadline = errors.New("goengine: no deadline handler is registered for the deadline")
+	// ErrSagaLocked occurs when the saga is locked by another Manager
+	ErrSagaLocked = errors.New("goengine: the saga is locked by another manager")
+)
+
+type (
+	// Handler is a func that reacts to a message for a saga instance.
+	// Any state changes, commands and deadlines are only persisted when the handler returns without an error.
+	Handler func(ctx context.Context, instance *Instance, message goengine.Message) error
+
+	// DeadlineHandler is a func that reacts to a deadline of a saga instance that is due.
+	DeadlineHandler func(ctx context.Context, instance *Instance, deadline Deadline) error
+
+	// Saga contains the information of a process manager that reacts to the messages of an event stream.
+	// Messages are correlated to a saga instance using the metadata value of the CorrelationKey.
+	Saga interface {
+		// Name returns the name of the saga
+		Name() string
+
+		// FromStream returns the stream this saga is based on
+		FromStream() goengine.StreamName
+
+		// CorrelationKey returns the metadata key used to correlate a message to a saga instance
+		CorrelationKey() string
+
+		// Init initializes the state of a new saga instance
+		Init(ctx context.Context) (interface{}, error)
+
+		// Handlers return the handlers for a set of messages
+		Handlers() map[string]Handler
+
+		// DeadlineHandlers return the handlers for a set of deadlines
+		DeadlineHandlers() map[string]DeadlineHandler
+
+		// DecodeState reconstitute the saga instance state based on the provided state data
+		DecodeState(data []byte) (interface{}, error)
+
+		// EncodeState encode the given object for storage
+		EncodeState(obj interface{}) ([]byte, error)
+	}
+
+	// Dispatcher dispatches the commands issued by a saga instance.
+	// A command.Bus is a Dispatcher.
+	Dispatcher interface {
+		// Dispatch handles the command
+		Dispatch(ctx context.Context, command interface{}) error
+	}
+
+	// Deadline is a point in time at which a saga instance wants to be notified
+	Deadline struct {
+		ID            goengine.UUID
+		Name          string
+		CorrelationID string
+		DueAt         time.Time
+	}
+
+	// RawInstance is the raw saga instance returned by the Storage
+	RawInstance struct {
+		State     []byte
+		Completed bool
+	}
+
+	// Commit contains the changes that a Storage must persist atomically
+	Commit struct {
+		// Position is the new position of the saga within the event stream or zero when it did not change
+		Position int64
+		// CorrelationID identifies the saga instance that changed or is empty when no instance changed
+		CorrelationID string
+		// State is the encoded state of the saga instance
+		State []byte
+		// Completed indicates that the saga instance is completed and any pending deadlines must be removed
+		Completed bool
+		// Deadlines contains the newly scheduled deadlines
+		Deadlines []Deadline
+		// FiredDeadline is the ID of the deadline that was handled and must be removed
+		FiredDeadline goengine.UUID
+	}
+
+	// Storage is an interface for persisting the saga position, the state of saga instances and their deadlines
+	Storage interface {
+		// LoadPosition returns the position within the event stream up to which the saga processed messages
+		LoadPosition(ctx context.Context, sagaName string) (int64, error)
+
+		// LoadInstance returns the raw saga instance or nil when the instance is unknown
+		LoadInstance(ctx context.Context, sagaName string, correlationID string) (*RawInstance, error)
+
+		// LoadDueDeadlines returns the deadlines of the saga that are due at the provided time ordered by their due time
+		LoadDueDeadlines(ctx context.Context, sagaName string, now time.Time) ([]Deadline, error)
+
+		// Commit persists the provided changes
+		Commit(ctx context.Context, sagaName string, commit Commit) error
+	}
+
+	// LockingStorage is a Storage that is able to lock a saga so that only one Manager runs the saga at a time
+	LockingStorage interface {
+		Storage
+
+		// Lock locks the saga and returns a func releasing the lock or ErrSagaLocked when the saga is already locked
+		Lock(ctx context.Context, sagaName string) (release func(), err error)
+	}
+)
+
+// HandlerError an error indicating that a saga handler failed
+type HandlerError struct {
+	error
+}
+
+// NewHandlerError return a HandlerError with the cause being the provided error
+func NewHandlerError(err error) *HandlerError {
+	return &HandlerError{err}
+}
+
+// Error return the error message
+func (e *HandlerError) Error() string {
+	return fmt.Sprintf("goengine: the saga handler returned with an error. (%s)", e.error.Error())
+}
+
+// Cause returns the actual saga handler error.
+// This also adds support for github.com/pkg/errors.Cause
+func (e *HandlerError) Cause() error {
+	return e.error
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+)
+
+// gzipMagic are the first bytes of gzip compressed data, JSON never starts with these bytes
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// CompressPayload returns the gzip compressed data when it's size is at least the threshold
+// and otherwise the data as is
+func CompressPayload(data []byte, threshold int) ([]byte, error) {
+	if len(data) < threshold {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DecompressPayload returns the decompressed data when the data is gzip compressed and otherwise the data as is
+func DecompressPayload(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
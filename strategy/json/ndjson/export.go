@@ -0,0 +1,77 @@
+package ndjson
+
+import (
+	"context"
+	"io"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/metadata"
+)
+
+// Exporter writes event streams as newline delimited JSON
+type Exporter struct {
+	store     goengine.ReadOnlyEventStore
+	converter goengine.MessagePayloadConverter
+}
+
+// NewExporter returns a new Exporter
+func NewExporter(store goengine.ReadOnlyEventStore, converter goengine.MessagePayloadConverter) (*Exporter, error) {
+	switch {
+	case store == nil:
+		return nil, goengine.InvalidArgumentError("store")
+	case converter == nil:
+		return nil, goengine.InvalidArgumentError("converter")
+	}
+
+	return &Exporter{
+		store:     store,
+		converter: converter,
+	}, nil
+}
+
+// Export writes the events of the stream matching the matcher with a number between fromNumber and toNumber to w.
+// When toNumber is 0 all events starting at fromNumber are exported.
+// The amount of exported events is returned.
+func (e *Exporter) Export(
+	ctx context.Context,
+	w io.Writer,
+	streamName goengine.StreamName,
+	fromNumber int64,
+	toNumber int64,
+	matcher metadata.Matcher,
+) (int, error) {
+	encoder, err := NewEncoder(w, e.converter)
+	if err != nil {
+		return 0, err
+	}
+
+	if matcher == nil {
+		matcher = metadata.NewMatcher()
+	}
+
+	stream, err := e.store.Load(ctx, streamName, fromNumber, nil, matcher)
+	if err != nil {
+		return 0, err
+	}
+	defer stream.Close()
+
+	var exported int
+	for stream.Next() {
+		msg, number, err := stream.Message()
+		if err != nil {
+			return exported, err
+		}
+
+		// The stream is ordered by number so all remaining events are out of range
+		if toNumber > 0 && number > toNumber {
+			break
+		}
+
+		if err := encoder.Encode(msg, number); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+
+	return exported, stream.Err()
+}
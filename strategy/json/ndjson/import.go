@@ -0,0 +1,145 @@
+package ndjson
+
+import (
+	"context"
+	"io"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/strategy/json/record"
+	"github.com/pkg/errors"
+)
+
+// Importer appends newline delimited JSON events to an event stream
+type Importer struct {
+	store     goengine.EventStore
+	factory   record.MessageFactory
+	batchSize int
+
+	logger goengine.Logger
+}
+
+// NewImporter returns a new Importer that appends at most batchSize events at a time
+func NewImporter(store goengine.EventStore, factory record.MessageFactory, batchSize int, logger goengine.Logger) (*Importer, error) {
+	switch {
+	case store == nil:
+		return nil, goengine.InvalidArgumentError("store")
+	case factory == nil:
+		return nil, goengine.InvalidArgumentError("factory")
+	case batchSize <= 0:
+		return nil, goengine.InvalidArgumentError("batchSize")
+	}
+
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+
+	return &Importer{
+		store:     store,
+		factory:   factory,
+		batchSize: batchSize,
+		logger:    logger,
+	}, nil
+}
+
+// Import appends the events read from r to the stream in the order they are read and returns the amount of appended events.
+// The stream is created when it does not exist.
+//
+// Events with an event id that already exists in the stream are skipped, this allows an interrupted import to be
+// resumed by importing the same input again. The event numbers are assigned by the event store.
+func (i *Importer) Import(ctx context.Context, r io.Reader, streamName goengine.StreamName) (int, error) {
+	decoder, err := NewDecoder(r)
+	if err != nil {
+		return 0, err
+	}
+
+	existing, err := i.loadEventIDs(ctx, streamName)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		imported, skipped int
+		line              int
+	)
+	batch := make([]goengine.Message, 0, i.batchSize)
+	appendBatch := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := i.store.AppendTo(ctx, streamName, batch); err != nil {
+			return err
+		}
+
+		imported += len(batch)
+		batch = batch[:0]
+
+		return nil
+	}
+
+	for {
+		line++
+		record, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return imported, errors.Wrapf(err, "failed to decode record %d", line)
+		}
+
+		if _, found := existing[record.UUID]; found {
+			skipped++
+			continue
+		}
+
+		msg, err := i.factory.CreateMessage(record)
+		if err != nil {
+			return imported, errors.Wrapf(err, "failed to create message for record %d", line)
+		}
+
+		batch = append(batch, msg)
+		if len(batch) == i.batchSize {
+			if err := appendBatch(); err != nil {
+				return imported, err
+			}
+		}
+	}
+
+	if err := appendBatch(); err != nil {
+		return imported, err
+	}
+
+	i.logger.Debug("imported event stream", func(e goengine.LoggerEntry) {
+		e.String("stream", string(streamName))
+		e.Int("imported", imported)
+		e.Int("skipped", skipped)
+	})
+
+	return imported, nil
+}
+
+// loadEventIDs returns the event id's of the stream and creates the stream when it does not exist
+func (i *Importer) loadEventIDs(ctx context.Context, streamName goengine.StreamName) (map[goengine.UUID]struct{}, error) {
+	eventIDs := map[goengine.UUID]struct{}{}
+	if !i.store.HasStream(ctx, streamName) {
+		return eventIDs, i.store.Create(ctx, streamName)
+	}
+
+	stream, err := i.store.Load(ctx, streamName, 1, nil, metadata.NewMatcher())
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	for stream.Next() {
+		msg, _, err := stream.Message()
+		if err != nil {
+			return nil, err
+		}
+
+		eventIDs[msg.UUID()] = struct{}{}
+	}
+
+	return eventIDs, stream.Err()
+}
@@ -0,0 +1,137 @@
+// Package ndjson exports and imports event streams as newline delimited JSON.
+//
+// Every line contains a single event:
+//  {"no":1,"event_id":"...","event_name":"...","payload":{...},"metadata":{...},"created_at":"..."}
+// The payload is stored as the JSON produced by the goengine.MessagePayloadConverter.
+package ndjson
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/strategy/json/record"
+)
+
+var (
+	// ErrRecordOutOfOrder occurs when the number of a record is not greater then the number of the previous record
+	ErrRecordOutOfOrder = errors.New("goengine: record number is not greater then the previous record number")
+	// ErrInvalidRecord occurs when a record is missing the event id or event name
+	ErrInvalidRecord = errors.New("goengine: record is missing the event id or event name")
+)
+
+type (
+	// recordJSON is the JSON representation of a record.Record as it is written on a line
+	recordJSON struct {
+		Number    int64           `json:"no"`
+		UUID      goengine.UUID   `json:"event_id"`
+		EventName string          `json:"event_name"`
+		Payload   json.RawMessage `json:"payload"`
+		Metadata  json.RawMessage `json:"metadata"`
+		CreatedAt time.Time       `json:"created_at"`
+	}
+
+	// Encoder writes messages as records to an output stream
+	Encoder struct {
+		enc       *json.Encoder
+		converter goengine.MessagePayloadConverter
+	}
+
+	// Decoder reads records from an input stream
+	Decoder struct {
+		dec        *json.Decoder
+		lastNumber int64
+	}
+)
+
+// NewEncoder returns a new Encoder that writes to w
+func NewEncoder(w io.Writer, converter goengine.MessagePayloadConverter) (*Encoder, error) {
+	switch {
+	case w == nil:
+		return nil, goengine.InvalidArgumentError("w")
+	case converter == nil:
+		return nil, goengine.InvalidArgumentError("converter")
+	}
+
+	return &Encoder{
+		enc:       json.NewEncoder(w),
+		converter: converter,
+	}, nil
+}
+
+// Encode writes the message with it's number within the event stream as a single line
+func (e *Encoder) Encode(msg goengine.Message, number int64) error {
+	eventName, payload, err := e.converter.ConvertPayload(msg.Payload())
+	if err != nil {
+		return err
+	}
+
+	return e.EncodeConverted(msg, number, eventName, payload)
+}
+
+// EncodeConverted writes the message with it's number within the event stream as a single line using the event name
+// and payload data that were already returned by the goengine.MessagePayloadConverter
+func (e *Encoder) EncodeConverted(msg goengine.Message, number int64, eventName string, payload []byte) error {
+	meta, err := json.Marshal(msg.Metadata())
+	if err != nil {
+		return err
+	}
+
+	return e.enc.Encode(recordJSON{
+		Number:    number,
+		UUID:      msg.UUID(),
+		EventName: eventName,
+		Payload:   payload,
+		Metadata:  meta,
+		CreatedAt: msg.CreatedAt(),
+	})
+}
+
+// NewDecoder returns a new Decoder that reads from r
+func NewDecoder(r io.Reader) (*Decoder, error) {
+	if r == nil {
+		return nil, goengine.InvalidArgumentError("r")
+	}
+
+	return &Decoder{
+		dec: json.NewDecoder(r),
+	}, nil
+}
+
+// Decode reads the next record.
+// io.EOF is returned when there are no more records.
+func (d *Decoder) Decode() (*record.Record, error) {
+	var data recordJSON
+	if err := d.dec.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	if goengine.IsUUIDEmpty(data.UUID) || data.EventName == "" {
+		return nil, ErrInvalidRecord
+	}
+
+	if data.Number <= d.lastNumber {
+		return nil, ErrRecordOutOfOrder
+	}
+	d.lastNumber = data.Number
+
+	meta := metadata.New()
+	if len(data.Metadata) > 0 {
+		var err error
+		if meta, err = metadata.UnmarshalJSON(data.Metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	return &record.Record{
+		Number:    data.Number,
+		UUID:      data.UUID,
+		EventName: data.EventName,
+		Payload:   data.Payload,
+		Metadata:  meta,
+		CreatedAt: data.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,199 @@
+// +build unit
+
+package ndjson_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/driver/inmemory"
+	"github.com/hellofresh/goengine/eventstoretest"
+	"github.com/hellofresh/goengine/metadata"
+	strategyJSON "github.com/hellofresh/goengine/strategy/json"
+	"github.com/hellofresh/goengine/strategy/json/ndjson"
+	"github.com/hellofresh/goengine/strategy/json/record"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStream goengine.StreamName = "orders"
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	transformer := strategyJSON.NewRawPayloadTransformer()
+	firstID, secondID := aggregate.GenerateID(), aggregate.GenerateID()
+
+	source := inmemory.NewEventStore(goengine.NopLogger)
+	require.NoError(t, source.Create(ctx, testStream))
+	require.NoError(t, source.AppendTo(ctx, testStream, []goengine.Message{
+		createMessage(t, firstID, 1),
+		createMessage(t, secondID, 1),
+		createMessage(t, firstID, 2),
+		createMessage(t, firstID, 3),
+		createMessage(t, firstID, 4),
+	}))
+
+	exporter, err := ndjson.NewExporter(source, transformer)
+	require.NoError(t, err)
+
+	t.Run("export a filtered range", func(t *testing.T) {
+		var out bytes.Buffer
+		matcher := metadata.WithConstraint(metadata.NewMatcher(), aggregate.IDKey, metadata.Equals, string(firstID))
+
+		exported, err := exporter.Export(ctx, &out, testStream, 2, 4, matcher)
+		require.NoError(t, err)
+		assert.Equal(t, 2, exported)
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 2)
+
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+		assert.Equal(t, float64(3), record["no"])
+		assert.Equal(t, "order_placed", record["event_name"])
+		assert.Equal(t, map[string]interface{}{"version": float64(2)}, record["payload"])
+		assert.Equal(t, string(firstID), record["metadata"].(map[string]interface{})[aggregate.IDKey])
+	})
+
+	t.Run("import preserves event ids and order", func(t *testing.T) {
+		var out bytes.Buffer
+		_, err := exporter.Export(ctx, &out, testStream, 1, 0, nil)
+		require.NoError(t, err)
+
+		target := inmemory.NewEventStore(goengine.NopLogger)
+		importer := newImporter(t, target, 2)
+
+		imported, err := importer.Import(ctx, bytes.NewReader(out.Bytes()), testStream)
+		require.NoError(t, err)
+		assert.Equal(t, 5, imported)
+
+		assertSameEvents(t, source, target)
+	})
+
+	t.Run("resume an interrupted import", func(t *testing.T) {
+		var out bytes.Buffer
+		_, err := exporter.Export(ctx, &out, testStream, 1, 0, nil)
+		require.NoError(t, err)
+		lines := strings.SplitAfter(out.String(), "\n")
+
+		target := inmemory.NewEventStore(goengine.NopLogger)
+		importer := newImporter(t, target, 10)
+
+		imported, err := importer.Import(ctx, strings.NewReader(strings.Join(lines[:2], "")), testStream)
+		require.NoError(t, err)
+		assert.Equal(t, 2, imported)
+
+		imported, err = importer.Import(ctx, bytes.NewReader(out.Bytes()), testStream)
+		require.NoError(t, err)
+		assert.Equal(t, 3, imported)
+
+		imported, err = importer.Import(ctx, bytes.NewReader(out.Bytes()), testStream)
+		require.NoError(t, err)
+		assert.Equal(t, 0, imported)
+
+		assertSameEvents(t, source, target)
+	})
+}
+
+func TestImporter_Import(t *testing.T) {
+	ctx := context.Background()
+	line := func(no int, eventID goengine.UUID, name string) string {
+		return `{"no":` + strconv.Itoa(no) + `,"event_id":"` + eventID.String() + `","event_name":"` + name + `",` +
+			`"payload":{},"metadata":{"_aggregate_id":"` + string(aggregate.GenerateID()) + `","_aggregate_version":1},` +
+			`"created_at":"2020-01-01T00:00:00Z"}` + "\n"
+	}
+
+	testCases := []struct {
+		title         string
+		input         string
+		expectedError error
+	}{
+		{
+			"out of order",
+			line(2, goengine.GenerateUUID(), "order_placed") + line(1, goengine.GenerateUUID(), "order_placed"),
+			ndjson.ErrRecordOutOfOrder,
+		},
+		{
+			"missing event name",
+			line(1, goengine.GenerateUUID(), ""),
+			ndjson.ErrInvalidRecord,
+		},
+		{
+			"missing aggregate version",
+			`{"no":1,"event_id":"` + goengine.GenerateUUID().String() + `","event_name":"order_placed","payload":{},"metadata":{"_aggregate_id":"` + string(aggregate.GenerateID()) + `"}}`,
+			record.MissingMetadataError(aggregate.VersionKey),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			importer := newImporter(t, inmemory.NewEventStore(goengine.NopLogger), 10)
+
+			imported, err := importer.Import(ctx, strings.NewReader(testCase.input), testStream)
+
+			assert.Equal(t, testCase.expectedError, errors.Cause(err))
+			assert.Equal(t, 0, imported)
+		})
+	}
+}
+
+func TestNewImporter(t *testing.T) {
+	factory, err := record.NewAggregateChangedFactory(strategyJSON.NewRawPayloadTransformer())
+	require.NoError(t, err)
+	store := inmemory.NewEventStore(goengine.NopLogger)
+
+	_, err = ndjson.NewImporter(nil, factory, 1, nil)
+	assert.Equal(t, goengine.InvalidArgumentError("store"), err)
+
+	_, err = ndjson.NewImporter(store, nil, 1, nil)
+	assert.Equal(t, goengine.InvalidArgumentError("factory"), err)
+
+	_, err = ndjson.NewImporter(store, factory, 0, nil)
+	assert.Equal(t, goengine.InvalidArgumentError("batchSize"), err)
+}
+
+func newImporter(t *testing.T, store goengine.EventStore, batchSize int) *ndjson.Importer {
+	factory, err := record.NewAggregateChangedFactory(strategyJSON.NewRawPayloadTransformer())
+	require.NoError(t, err)
+
+	importer, err := ndjson.NewImporter(store, factory, batchSize, nil)
+	require.NoError(t, err)
+
+	return importer
+}
+
+func assertSameEvents(t *testing.T, expected, actual goengine.EventStore) {
+	load := func(store goengine.EventStore) []goengine.Message {
+		stream, err := store.Load(context.Background(), testStream, 1, nil, metadata.NewMatcher())
+		require.NoError(t, err)
+		defer stream.Close()
+
+		messages, _, err := goengine.ReadEventStream(stream)
+		require.NoError(t, err)
+
+		return messages
+	}
+
+	expectedMessages, actualMessages := load(expected), load(actual)
+	require.Len(t, actualMessages, len(expectedMessages))
+	for i, msg := range expectedMessages {
+		assert.Equal(t, msg.UUID(), actualMessages[i].UUID())
+		assert.Equal(t, msg.Payload(), actualMessages[i].Payload())
+		assert.True(t, msg.CreatedAt().Equal(actualMessages[i].CreatedAt()))
+		assert.Equal(t, msg.Metadata().Value(aggregate.IDKey), actualMessages[i].Metadata().Value(aggregate.IDKey))
+	}
+}
+
+func createMessage(t *testing.T, aggregateID aggregate.ID, version uint) goengine.Message {
+	data, err := json.Marshal(map[string]uint{"version": version})
+	require.NoError(t, err)
+
+	return eventstoretest.NewMessage(t, "order", aggregateID, version, strategyJSON.RawPayload{Name: "order_placed", Data: data})
+}
@@ -2,11 +2,16 @@ package json
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/crypto"
 	reflectUtil "github.com/hellofresh/goengine/internal/reflect"
 	"github.com/hellofresh/goengine/strategy/json/internal"
 )
@@ -29,6 +34,8 @@ var (
 	_ goengine.MessagePayloadFactory = &PayloadTransformer{}
 	// Ensure that PayloadTransformer satisfies the MessagePayloadConverter interface
 	_ goengine.MessagePayloadConverter = &PayloadTransformer{}
+	// Ensure that PayloadTransformer satisfies the MessagePayloadContextConverter interface
+	_ goengine.MessagePayloadContextConverter = &PayloadTransformer{}
 	// Ensure that PayloadTransformer satisfies the MessagePayloadResolver interface
 	_ goengine.MessagePayloadResolver = &PayloadTransformer{}
 )
@@ -40,15 +47,21 @@ type (
 
 	// PayloadTransformer is a payload factory that can reconstruct payload from and to JSON
 	PayloadTransformer struct {
-		types map[string]PayloadType
-		names map[string]string
+		types            map[string]PayloadType
+		names            map[string]string
+		keyStore         crypto.KeyStore
+		keyLookupTimeout time.Duration
 	}
 
+	// PersonalDataOption configures optional behaviour of a PayloadTransformer encrypting personal data
+	PersonalDataOption func(*PayloadTransformer)
+
 	// PayloadType represents a payload and the way to create it
 	PayloadType struct {
 		initiator      PayloadInitiator
 		isPtr          bool
 		reflectionType reflect.Type
+		personal       *personalFields
 	}
 )
 
@@ -60,8 +73,49 @@ func NewPayloadTransformer() *PayloadTransformer {
 	}
 }
 
+// WithKeyLookupTimeout sets the timeout of the key lookups done by ConvertPayload and CreatePayload.
+// A timeout of zero disables the timeout.
+func WithKeyLookupTimeout(timeout time.Duration) PersonalDataOption {
+	return func(p *PayloadTransformer) {
+		p.keyLookupTimeout = timeout
+	}
+}
+
+// NewPersonalDataPayloadTransformer returns a new instance of the PayloadTransformer that encrypts personal data.
+// The fields of a payload tagged `personal:"data"` are encrypted using the key of the subject
+// in the field tagged `personal:"subject"`, once the key is deleted from the key store the fields are left empty
+// when the payload is reconstructed.
+func NewPersonalDataPayloadTransformer(keyStore crypto.KeyStore, options ...PersonalDataOption) (*PayloadTransformer, error) {
+	if keyStore == nil {
+		return nil, goengine.InvalidArgumentError("keyStore")
+	}
+
+	transformer := NewPayloadTransformer()
+	transformer.keyStore = keyStore
+	transformer.keyLookupTimeout = DefaultKeyLookupTimeout
+	for _, option := range options {
+		option(transformer)
+	}
+
+	if transformer.keyLookupTimeout < 0 {
+		return nil, goengine.InvalidArgumentError("keyLookupTimeout")
+	}
+
+	return transformer, nil
+}
+
 // ConvertPayload marshall the payload into JSON returning the payload fullpkgPath and the serialized data.
+// Keys are looked up using the key lookup timeout, use ConvertPayloadContext to provide a context instead.
 func (p *PayloadTransformer) ConvertPayload(payload interface{}) (string, []byte, error) {
+	ctx, cancel := p.keyLookupContext()
+	defer cancel()
+
+	return p.ConvertPayloadContext(ctx, payload)
+}
+
+// ConvertPayloadContext marshall the payload into JSON returning the payload fullpkgPath and the serialized data.
+// The context is used to look up the key of the subject of the personal data.
+func (p *PayloadTransformer) ConvertPayloadContext(ctx context.Context, payload interface{}) (string, []byte, error) {
 	payloadName, err := p.ResolveName(payload)
 	if err != nil {
 		return "", nil, err
@@ -72,6 +126,12 @@ func (p *PayloadTransformer) ConvertPayload(payload interface{}) (string, []byte
 		return "", nil, ErrPayloadCannotBeSerialized
 	}
 
+	if personal := p.types[payloadName].personal; p.keyStore != nil && personal != nil {
+		if data, err = encryptPersonalData(ctx, p.keyStore, personal, data); err != nil {
+			return "", nil, err
+		}
+	}
+
 	return payloadName, data, nil
 }
 
@@ -91,25 +151,49 @@ func (p *PayloadTransformer) RegisterPayload(payloadType string, initiator Paylo
 		return ErrDuplicatePayloadType
 	}
 
-	checkPayload := initiator()
-	if checkPayload == nil {
-		return ErrInitiatorInvalidResult
+	t, err := newPayloadType(initiator)
+	if err != nil {
+		return err
 	}
 
-	rv := reflect.ValueOf(checkPayload)
-	isPtr := rv.Kind() == reflect.Ptr
-	if isPtr && rv.IsNil() {
-		return ErrInitiatorInvalidResult
+	p.names[reflectUtil.FullTypeName(t.reflectionType)] = payloadType
+	p.types[payloadType] = t
+
+	return nil
+}
+
+// RegisterAlias registers a alias of a payload type.
+// The alias is used to reconstruct payloads stored with an old name of the payload type,
+// payloads are always converted using the name they are registered with.
+func (p *PayloadTransformer) RegisterAlias(alias string, payloadType string) error {
+	if _, known := p.types[alias]; known {
+		return ErrDuplicatePayloadType
 	}
 
-	p.names[reflectUtil.FullTypeName(rv.Type())] = payloadType
+	t, found := p.types[payloadType]
+	if !found {
+		return ErrUnknownPayloadType
+	}
 
-	p.types[payloadType] = PayloadType{
-		initiator:      initiator,
-		isPtr:          isPtr,
-		reflectionType: rv.Type(),
+	p.types[alias] = t
+
+	return nil
+}
+
+// RegisterDeprecatedPayload registers a payload type that is only used to reconstruct payloads.
+// This allows to keep reading events of a type that is no longer used to append new events.
+func (p *PayloadTransformer) RegisterDeprecatedPayload(payloadType string, initiator PayloadInitiator) error {
+	if _, known := p.types[payloadType]; known {
+		return ErrDuplicatePayloadType
 	}
 
+	t, err := newPayloadType(initiator)
+	if err != nil {
+		return err
+	}
+
+	p.types[payloadType] = t
+
 	return nil
 }
 
@@ -124,8 +208,18 @@ func (p *PayloadTransformer) RegisterPayloads(payloads map[string]PayloadInitiat
 	return nil
 }
 
-// CreatePayload reconstructs a payload based on it's type and the json data
+// CreatePayload reconstructs a payload based on it's type and the json data.
+// Keys are looked up using the key lookup timeout, use CreatePayloadContext to provide a context instead.
 func (p *PayloadTransformer) CreatePayload(typeName string, data interface{}) (interface{}, error) {
+	ctx, cancel := p.keyLookupContext()
+	defer cancel()
+
+	return p.CreatePayloadContext(ctx, typeName, data)
+}
+
+// CreatePayloadContext reconstructs a payload based on it's type and the json data.
+// The context is used to look up the key of the subject of the personal data.
+func (p *PayloadTransformer) CreatePayloadContext(ctx context.Context, typeName string, data interface{}) (interface{}, error) {
 	var dataBytes []byte
 	switch d := data.(type) {
 	case []byte:
@@ -144,6 +238,13 @@ func (p *PayloadTransformer) CreatePayload(typeName string, data interface{}) (i
 	}
 	payload := payloadType.initiator()
 
+	if p.keyStore != nil && payloadType.personal != nil {
+		var err error
+		if dataBytes, err = decryptPersonalData(ctx, p.keyStore, payloadType.personal, dataBytes); err != nil {
+			return nil, err
+		}
+	}
+
 	// Pointer we can handle nicely
 	if payloadType.isPtr {
 		if err := internal.UnmarshalJSON(dataBytes, payload); err != nil {
@@ -160,3 +261,53 @@ func (p *PayloadTransformer) CreatePayload(typeName string, data interface{}) (i
 
 	return vp.Elem().Interface(), nil
 }
+
+// CheckEventNames returns a UnknownEventNamesError when any of the event names is not registered
+func (p *PayloadTransformer) CheckEventNames(eventNames []string) error {
+	var unknown UnknownEventNamesError
+	for _, name := range eventNames {
+		if _, found := p.types[name]; !found {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	return unknown
+}
+
+// UnknownEventNamesError occurs when a event stream contains events with names that are not registered
+type UnknownEventNamesError []string
+
+func (u UnknownEventNamesError) Error() string {
+	return "goengine: unknown event names: " + strings.Join(u, ", ")
+}
+
+// newPayloadType returns the PayloadType of the payloads created by the initiator
+func newPayloadType(initiator PayloadInitiator) (PayloadType, error) {
+	checkPayload := initiator()
+	if checkPayload == nil {
+		return PayloadType{}, ErrInitiatorInvalidResult
+	}
+
+	rv := reflect.ValueOf(checkPayload)
+	isPtr := rv.Kind() == reflect.Ptr
+	if isPtr && rv.IsNil() {
+		return PayloadType{}, ErrInitiatorInvalidResult
+	}
+
+	personal, err := personalFieldsOf(rv.Type())
+	if err != nil {
+		return PayloadType{}, err
+	}
+
+	return PayloadType{
+		initiator:      initiator,
+		isPtr:          isPtr,
+		reflectionType: rv.Type(),
+		personal:       personal,
+	}, nil
+}
@@ -331,3 +331,100 @@ func TestPayloadTransformer_RegisterMultiplePayloads(t *testing.T) {
 		})
 	})
 }
+
+func TestPayloadTransformer_RegisterAlias(t *testing.T) {
+	newTransformer := func(t *testing.T) *strategyJSON.PayloadTransformer {
+		transformer := strategyJSON.NewPayloadTransformer()
+		require.NoError(t,
+			transformer.RegisterPayload("simple_type", func() interface{} {
+				return simpleType{}
+			}),
+		)
+
+		return transformer
+	}
+
+	t.Run("create payloads using the alias", func(t *testing.T) {
+		transformer := newTransformer(t)
+		require.NoError(t, transformer.RegisterAlias("old_simple_type", "simple_type"))
+
+		res, err := transformer.CreatePayload("old_simple_type", `{"Test":"mine","Order":1}`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, simpleType{Test: "mine", Order: 1}, res)
+
+		name, _, err := transformer.ConvertPayload(simpleType{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "simple_type", name)
+	})
+
+	t.Run("invalid aliases", func(t *testing.T) {
+		testCases := []struct {
+			title         string
+			alias         string
+			payloadType   string
+			expectedError error
+		}{
+			{"alias is registered", "simple_type", "simple_type", strategyJSON.ErrDuplicatePayloadType},
+			{"unknown payload type", "old_simple_type", "unknown", strategyJSON.ErrUnknownPayloadType},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				err := newTransformer(t).RegisterAlias(testCase.alias, testCase.payloadType)
+
+				assert.Equal(t, testCase.expectedError, err)
+			})
+		}
+	})
+}
+
+func TestPayloadTransformer_RegisterDeprecatedPayload(t *testing.T) {
+	transformer := strategyJSON.NewPayloadTransformer()
+	require.NoError(t,
+		transformer.RegisterDeprecatedPayload("simple_type", func() interface{} {
+			return &simpleType{}
+		}),
+	)
+
+	t.Run("create payload", func(t *testing.T) {
+		res, err := transformer.CreatePayload("simple_type", `{"Test":"mine","Order":1}`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &simpleType{Test: "mine", Order: 1}, res)
+	})
+
+	t.Run("convert payload", func(t *testing.T) {
+		name, data, err := transformer.ConvertPayload(&simpleType{})
+
+		assert.Equal(t, strategyJSON.ErrPayloadNotRegistered, err)
+		assert.Equal(t, "", name)
+		assert.Nil(t, data)
+	})
+
+	t.Run("duplicate registration", func(t *testing.T) {
+		err := transformer.RegisterDeprecatedPayload("simple_type", func() interface{} {
+			return &simpleType{}
+		})
+
+		assert.Equal(t, strategyJSON.ErrDuplicatePayloadType, err)
+	})
+}
+
+func TestPayloadTransformer_CheckEventNames(t *testing.T) {
+	transformer := strategyJSON.NewPayloadTransformer()
+	require.NoError(t,
+		transformer.RegisterPayload("simple_type", func() interface{} {
+			return simpleType{}
+		}),
+	)
+	require.NoError(t, transformer.RegisterAlias("old_simple_type", "simple_type"))
+
+	assert.NoError(t, transformer.CheckEventNames([]string{"simple_type", "old_simple_type"}))
+
+	err := transformer.CheckEventNames([]string{"simple_type", "renamed", "deleted"})
+
+	assert.Equal(t, strategyJSON.UnknownEventNamesError{"deleted", "renamed"}, err)
+	assert.EqualError(t, err, "goengine: unknown event names: deleted, renamed")
+}
@@ -0,0 +1,221 @@
+package json
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hellofresh/goengine/crypto"
+)
+
+const (
+	// PersonalTag is the struct tag used to mark the personal data fields of a payload.
+	// The field containing the id of the subject the personal data belongs to is tagged `personal:"subject"`
+	// and the fields containing personal data are tagged `personal:"data"`.
+	PersonalTag = "personal"
+
+	personalSubject = "subject"
+	personalData    = "data"
+
+	// encryptedPrefix is the prefix of the JSON string replacing the value of an encrypted field
+	encryptedPrefix = "goengine-encrypted:"
+
+	// DefaultKeyLookupTimeout is the timeout of a key lookup when no context is provided
+	DefaultKeyLookupTimeout = 5 * time.Second
+)
+
+var (
+	// ErrInvalidPersonalTag occurs when the personal tag of a payload field is not subject or data
+	ErrInvalidPersonalTag = errors.New("goengine: personal tag must be either subject or data")
+	// ErrPersonalDataWithoutSubject occurs when a payload with personal data does not have exactly one subject field
+	ErrPersonalDataWithoutSubject = errors.New("goengine: payload with personal data must have a single subject field")
+	// ErrMissingPersonalDataSubject occurs when the subject field of a payload with personal data is empty
+	ErrMissingPersonalDataSubject = errors.New("goengine: payload with personal data has no subject")
+)
+
+// personalFields are the JSON names of the subject and personal data fields of a payload
+type personalFields struct {
+	subject string
+	data    []string
+}
+
+// personalFieldsOf returns the personal fields of the payload type or nil when it has no personal data
+func personalFieldsOf(payloadType reflect.Type) (*personalFields, error) {
+	if payloadType.Kind() == reflect.Ptr {
+		payloadType = payloadType.Elem()
+	}
+	if payloadType.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var (
+		fields   personalFields
+		subjects int
+	)
+	for i := 0; i < payloadType.NumField(); i++ {
+		field := payloadType.Field(i)
+		tag, ok := field.Tag.Lookup(PersonalTag)
+		if !ok {
+			continue
+		}
+
+		name := jsonFieldName(field)
+		if name == "" {
+			continue
+		}
+
+		switch tag {
+		case personalSubject:
+			fields.subject = name
+			subjects++
+		case personalData:
+			fields.data = append(fields.data, name)
+		default:
+			return nil, ErrInvalidPersonalTag
+		}
+	}
+
+	if len(fields.data) == 0 {
+		return nil, nil
+	}
+
+	if subjects != 1 {
+		return nil, ErrPersonalDataWithoutSubject
+	}
+
+	return &fields, nil
+}
+
+// jsonFieldName returns the name of the field in JSON or an empty string when the field is not serialized
+func jsonFieldName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+
+	return name
+}
+
+// encryptPersonalData replaces the personal data in the JSON data with it's encrypted value
+func encryptPersonalData(ctx context.Context, keyStore crypto.KeyStore, fields *personalFields, data []byte) ([]byte, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, ErrPayloadCannotBeSerialized
+	}
+
+	subjectID := personalSubjectID(object[fields.subject])
+	if subjectID == "" {
+		return nil, ErrMissingPersonalDataSubject
+	}
+
+	key, err := keyStore.GetOrCreateKey(ctx, subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range fields.data {
+		value, found := object[name]
+		if !found || string(value) == "null" {
+			continue
+		}
+
+		ciphertext, err := crypto.Encrypt(key, value, []byte(subjectID))
+		if err != nil {
+			return nil, err
+		}
+
+		if object[name], err = json.Marshal(encryptedPrefix + base64.StdEncoding.EncodeToString(ciphertext)); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(object)
+}
+
+// decryptPersonalData replaces the encrypted personal data in the JSON data with it's decrypted value.
+// When the key of the subject was deleted the personal data is removed.
+func decryptPersonalData(ctx context.Context, keyStore crypto.KeyStore, fields *personalFields, data []byte) ([]byte, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+
+	ciphertexts := map[string][]byte{}
+	for _, name := range fields.data {
+		var value string
+		if err := json.Unmarshal(object[name], &value); err != nil || !strings.HasPrefix(value, encryptedPrefix) {
+			// The value was not encrypted, for example because it was stored before encryption was used
+			continue
+		}
+
+		ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+		if err != nil {
+			return nil, crypto.ErrInvalidCiphertext
+		}
+		ciphertexts[name] = ciphertext
+	}
+
+	if len(ciphertexts) == 0 {
+		return data, nil
+	}
+
+	subjectID := personalSubjectID(object[fields.subject])
+	if subjectID == "" {
+		return nil, ErrMissingPersonalDataSubject
+	}
+
+	key, err := keyStore.Key(ctx, subjectID)
+	if err == crypto.ErrKeyNotFound {
+		for name := range ciphertexts {
+			delete(object, name)
+		}
+
+		return json.Marshal(object)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for name, ciphertext := range ciphertexts {
+		if object[name], err = crypto.Decrypt(key, ciphertext, []byte(subjectID)); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(object)
+}
+
+// keyLookupContext returns the context used to look up keys when no context is provided
+func (p *PayloadTransformer) keyLookupContext() (context.Context, context.CancelFunc) {
+	if p.keyStore == nil || p.keyLookupTimeout == 0 {
+		return context.Background(), func() {}
+	}
+
+	return context.WithTimeout(context.Background(), p.keyLookupTimeout)
+}
+
+// personalSubjectID returns the subject id from the JSON value of the subject field
+func personalSubjectID(value json.RawMessage) string {
+	var subjectID string
+	if err := json.Unmarshal(value, &subjectID); err == nil {
+		return subjectID
+	}
+
+	if len(value) == 0 || string(value) == "null" {
+		return ""
+	}
+
+	// Use the JSON representation of a numeric subject id
+	return string(value)
+}
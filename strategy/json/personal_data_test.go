@@ -0,0 +1,221 @@
+// +build unit
+
+package json_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/crypto"
+	strategyJSON "github.com/hellofresh/goengine/strategy/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type userRegistered struct {
+	UserID  string `json:"user_id" personal:"subject"`
+	Email   string `json:"email" personal:"data"`
+	Name    string `personal:"data"`
+	Country string `json:"country"`
+}
+
+func TestNewPersonalDataPayloadTransformer(t *testing.T) {
+	transformer, err := strategyJSON.NewPersonalDataPayloadTransformer(nil)
+
+	assert.Nil(t, transformer)
+	assert.Equal(t, goengine.InvalidArgumentError("keyStore"), err)
+
+	transformer, err = strategyJSON.NewPersonalDataPayloadTransformer(
+		crypto.NewInMemoryKeyStore(),
+		strategyJSON.WithKeyLookupTimeout(-time.Second),
+	)
+
+	assert.Nil(t, transformer)
+	assert.Equal(t, goengine.InvalidArgumentError("keyLookupTimeout"), err)
+}
+
+func TestPayloadTransformer_PersonalData(t *testing.T) {
+	payload := userRegistered{UserID: "user-1", Email: "jane@example.com", Name: "Jane", Country: "NL"}
+
+	t.Run("encrypt and decrypt personal data", func(t *testing.T) {
+		transformer := newPersonalDataTransformer(t, crypto.NewInMemoryKeyStore())
+
+		name, data, err := transformer.ConvertPayload(payload)
+		require.NoError(t, err)
+		assert.Equal(t, "user_registered", name)
+		assert.NotContains(t, string(data), "jane@example.com")
+		assert.NotContains(t, string(data), "Jane")
+		assert.Contains(t, string(data), `"user_id":"user-1"`)
+		assert.Contains(t, string(data), `"country":"NL"`)
+
+		result, err := transformer.CreatePayload(name, data)
+		assert.NoError(t, err)
+		assert.Equal(t, payload, result)
+	})
+
+	t.Run("deleted key leaves personal data empty", func(t *testing.T) {
+		keyStore := crypto.NewInMemoryKeyStore()
+		transformer := newPersonalDataTransformer(t, keyStore)
+
+		name, data, err := transformer.ConvertPayload(payload)
+		require.NoError(t, err)
+
+		require.NoError(t, keyStore.DeleteKey(context.Background(), "user-1"))
+
+		result, err := transformer.CreatePayload(name, data)
+		assert.NoError(t, err)
+		assert.Equal(t, userRegistered{UserID: "user-1", Country: "NL"}, result)
+	})
+
+	t.Run("other subjects are not affected", func(t *testing.T) {
+		keyStore := crypto.NewInMemoryKeyStore()
+		transformer := newPersonalDataTransformer(t, keyStore)
+
+		other := userRegistered{UserID: "user-2", Email: "john@example.com", Name: "John", Country: "DE"}
+		name, data, err := transformer.ConvertPayload(other)
+		require.NoError(t, err)
+
+		require.NoError(t, keyStore.DeleteKey(context.Background(), "user-1"))
+
+		result, err := transformer.CreatePayload(name, data)
+		assert.NoError(t, err)
+		assert.Equal(t, other, result)
+	})
+
+	t.Run("unencrypted personal data", func(t *testing.T) {
+		transformer := newPersonalDataTransformer(t, crypto.NewInMemoryKeyStore())
+
+		result, err := transformer.CreatePayload("user_registered", []byte(`{"user_id":"user-1","email":"jane@example.com"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, userRegistered{UserID: "user-1", Email: "jane@example.com"}, result)
+	})
+
+	t.Run("missing subject", func(t *testing.T) {
+		transformer := newPersonalDataTransformer(t, crypto.NewInMemoryKeyStore())
+
+		name, data, err := transformer.ConvertPayload(userRegistered{Email: "jane@example.com"})
+		assert.Equal(t, strategyJSON.ErrMissingPersonalDataSubject, err)
+		assert.Empty(t, name)
+		assert.Nil(t, data)
+	})
+
+	t.Run("without key store personal data is not encrypted", func(t *testing.T) {
+		transformer := strategyJSON.NewPayloadTransformer()
+		require.NoError(t, transformer.RegisterPayload("user_registered", func() interface{} { return userRegistered{} }))
+
+		_, data, err := transformer.ConvertPayload(payload)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "jane@example.com")
+	})
+}
+
+func TestPayloadTransformer_PersonalDataContext(t *testing.T) {
+	payload := userRegistered{UserID: "user-1", Email: "jane@example.com", Name: "Jane", Country: "NL"}
+
+	t.Run("keys are looked up using the context", func(t *testing.T) {
+		keyStore := &contextKeyStore{KeyStore: crypto.NewInMemoryKeyStore()}
+		transformer := newPersonalDataTransformer(t, keyStore)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		name, data, err := transformer.ConvertPayloadContext(ctx, payload)
+		require.NoError(t, err)
+
+		result, err := transformer.CreatePayloadContext(ctx, name, data)
+		assert.NoError(t, err)
+		assert.Equal(t, payload, result)
+
+		cancel()
+
+		_, _, err = transformer.ConvertPayloadContext(ctx, payload)
+		assert.Equal(t, context.Canceled, err)
+
+		_, err = transformer.CreatePayloadContext(ctx, name, data)
+		assert.Equal(t, context.Canceled, err)
+	})
+
+	t.Run("keys are looked up using the key lookup timeout", func(t *testing.T) {
+		keyStore := &contextKeyStore{KeyStore: crypto.NewInMemoryKeyStore()}
+		transformer, err := strategyJSON.NewPersonalDataPayloadTransformer(
+			keyStore,
+			strategyJSON.WithKeyLookupTimeout(time.Minute),
+		)
+		require.NoError(t, err)
+		require.NoError(t, transformer.RegisterPayload("user_registered", func() interface{} { return userRegistered{} }))
+
+		_, _, err = transformer.ConvertPayload(payload)
+		require.NoError(t, err)
+
+		deadline, ok := keyStore.ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+	})
+}
+
+// contextKeyStore records the context of the last key lookup and fails when the context is done
+type contextKeyStore struct {
+	crypto.KeyStore
+	ctx context.Context
+}
+
+func (s *contextKeyStore) Key(ctx context.Context, subjectID string) ([]byte, error) {
+	s.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.KeyStore.Key(ctx, subjectID)
+}
+
+func (s *contextKeyStore) GetOrCreateKey(ctx context.Context, subjectID string) ([]byte, error) {
+	s.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.KeyStore.GetOrCreateKey(ctx, subjectID)
+}
+
+func TestPayloadTransformer_RegisterPayload_PersonalData(t *testing.T) {
+	type withoutSubject struct {
+		Email string `personal:"data"`
+	}
+	type twoSubjects struct {
+		UserID    string `personal:"subject"`
+		AccountID string `personal:"subject"`
+		Email     string `personal:"data"`
+	}
+	type invalidTag struct {
+		UserID string `personal:"subject"`
+		Email  string `personal:"yes"`
+	}
+
+	testCases := []struct {
+		title       string
+		initiator   strategyJSON.PayloadInitiator
+		expectedErr error
+	}{
+		{"without subject", func() interface{} { return withoutSubject{} }, strategyJSON.ErrPersonalDataWithoutSubject},
+		{"multiple subjects", func() interface{} { return &twoSubjects{} }, strategyJSON.ErrPersonalDataWithoutSubject},
+		{"invalid tag", func() interface{} { return invalidTag{} }, strategyJSON.ErrInvalidPersonalTag},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			transformer := strategyJSON.NewPayloadTransformer()
+
+			err := transformer.RegisterPayload("payload", testCase.initiator)
+
+			assert.Equal(t, testCase.expectedErr, err)
+		})
+	}
+}
+
+func newPersonalDataTransformer(t *testing.T, keyStore crypto.KeyStore) *strategyJSON.PayloadTransformer {
+	transformer, err := strategyJSON.NewPersonalDataPayloadTransformer(keyStore)
+	require.NoError(t, err)
+	require.NoError(t, transformer.RegisterPayload("user_registered", func() interface{} { return userRegistered{} }))
+
+	return transformer
+}
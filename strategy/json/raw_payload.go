@@ -0,0 +1,94 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/hellofresh/goengine"
+)
+
+var (
+	// ErrUnsupportedRawPayload occurs when a payload other then a RawPayload is provided to the RawPayloadTransformer
+	ErrUnsupportedRawPayload = errors.New("goengine: payload was expected to be a json.RawPayload")
+
+	// Ensure that RawPayloadTransformer satisfies the MessagePayloadFactory interface
+	_ goengine.MessagePayloadFactory = &RawPayloadTransformer{}
+	// Ensure that RawPayloadTransformer satisfies the MessagePayloadConverter interface
+	_ goengine.MessagePayloadConverter = &RawPayloadTransformer{}
+	// Ensure that RawPayloadTransformer satisfies the MessagePayloadResolver interface
+	_ goengine.MessagePayloadResolver = &RawPayloadTransformer{}
+)
+
+type (
+	// RawPayload is a payload that is kept in it's serialized JSON form together with it's payload type
+	RawPayload struct {
+		Name string
+		Data json.RawMessage
+	}
+
+	// RawPayloadTransformer is a payload factory and converter for RawPayload's.
+	// It allows reading and writing event streams without registering the payload types.
+	RawPayloadTransformer struct{}
+)
+
+// NewRawPayloadTransformer returns a new instance of the RawPayloadTransformer
+func NewRawPayloadTransformer() *RawPayloadTransformer {
+	return &RawPayloadTransformer{}
+}
+
+// ConvertPayload returns the payload type name and the JSON data of the RawPayload
+func (p *RawPayloadTransformer) ConvertPayload(payload interface{}) (string, []byte, error) {
+	raw, err := asRawPayload(payload)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !json.Valid(raw.Data) {
+		return "", nil, ErrPayloadCannotBeSerialized
+	}
+
+	return raw.Name, raw.Data, nil
+}
+
+// ResolveName returns the payload type name of the RawPayload
+func (p *RawPayloadTransformer) ResolveName(payload interface{}) (string, error) {
+	raw, err := asRawPayload(payload)
+	if err != nil {
+		return "", err
+	}
+
+	return raw.Name, nil
+}
+
+// CreatePayload returns a RawPayload containing a copy of the JSON data
+func (p *RawPayloadTransformer) CreatePayload(typeName string, data interface{}) (interface{}, error) {
+	var dataBytes []byte
+	switch d := data.(type) {
+	case []byte:
+		dataBytes = d
+	case json.RawMessage:
+		dataBytes = d
+	case string:
+		dataBytes = []byte(d)
+	default:
+		return nil, ErrUnsupportedJSONPayloadData
+	}
+
+	raw := make(json.RawMessage, len(dataBytes))
+	copy(raw, dataBytes)
+
+	return RawPayload{Name: typeName, Data: raw}, nil
+}
+
+func asRawPayload(payload interface{}) (RawPayload, error) {
+	switch p := payload.(type) {
+	case RawPayload:
+		return p, nil
+	case *RawPayload:
+		if p != nil {
+			return *p, nil
+		}
+	}
+
+	return RawPayload{}, ErrUnsupportedRawPayload
+}
@@ -0,0 +1,55 @@
+// +build unit
+
+package json_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	strategyJSON "github.com/hellofresh/goengine/strategy/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawPayloadTransformer(t *testing.T) {
+	transformer := strategyJSON.NewRawPayloadTransformer()
+
+	t.Run("Create and convert payload", func(t *testing.T) {
+		data := []byte(`{"amount":10}`)
+
+		payload, err := transformer.CreatePayload("account_debited", data)
+		require.NoError(t, err)
+
+		// The payload must not share the data
+		data[2] = 'x'
+
+		assert.Equal(t, strategyJSON.RawPayload{Name: "account_debited", Data: json.RawMessage(`{"amount":10}`)}, payload)
+
+		name, err := transformer.ResolveName(payload)
+		require.NoError(t, err)
+		assert.Equal(t, "account_debited", name)
+
+		raw := payload.(strategyJSON.RawPayload)
+		name, convertedData, err := transformer.ConvertPayload(&raw)
+		require.NoError(t, err)
+		assert.Equal(t, "account_debited", name)
+		assert.JSONEq(t, `{"amount":10}`, string(convertedData))
+	})
+
+	t.Run("Unsupported payloads", func(t *testing.T) {
+		var nilPayload *strategyJSON.RawPayload
+		for _, payload := range []interface{}{nil, nilPayload, "account_debited", struct{}{}} {
+			_, err := transformer.ResolveName(payload)
+			assert.Equal(t, strategyJSON.ErrUnsupportedRawPayload, err)
+
+			_, _, err = transformer.ConvertPayload(payload)
+			assert.Equal(t, strategyJSON.ErrUnsupportedRawPayload, err)
+		}
+
+		_, err := transformer.CreatePayload("account_debited", 1)
+		assert.Equal(t, strategyJSON.ErrUnsupportedJSONPayloadData, err)
+
+		_, _, err = transformer.ConvertPayload(strategyJSON.RawPayload{Name: "account_debited", Data: json.RawMessage(`{`)})
+		assert.Equal(t, strategyJSON.ErrPayloadCannotBeSerialized, err)
+	})
+}
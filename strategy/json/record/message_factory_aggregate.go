@@ -0,0 +1,109 @@
+package record
+
+import (
+	"fmt"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/metadata"
+)
+
+// Ensure that AggregateChangedFactory satisfies the MessageFactory interface
+var _ MessageFactory = &AggregateChangedFactory{}
+
+// AggregateChangedFactory reconstructs aggregate.Changed messages
+type AggregateChangedFactory struct {
+	payloadFactory goengine.MessagePayloadFactory
+}
+
+// NewAggregateChangedFactory returns a new instance of an AggregateChangedFactory
+func NewAggregateChangedFactory(factory goengine.MessagePayloadFactory) (*AggregateChangedFactory, error) {
+	if factory == nil {
+		return nil, goengine.InvalidArgumentError("factory")
+	}
+
+	return &AggregateChangedFactory{
+		payloadFactory: factory,
+	}, nil
+}
+
+// CreateMessage reconstruct the aggregate.Changed message from the record
+func (f *AggregateChangedFactory) CreateMessage(record *Record) (goengine.Message, error) {
+	if record == nil {
+		return nil, goengine.InvalidArgumentError("record")
+	}
+
+	payload, err := f.payloadFactory.CreatePayload(record.EventName, record.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregateID, err := aggregateIDFromMetadata(record.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregateVersion, err := aggregateVersionFromMetadata(record.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return aggregate.ReconstituteChange(
+		aggregateID,
+		record.UUID,
+		payload,
+		record.Metadata,
+		record.CreatedAt,
+		aggregateVersion,
+	)
+}
+
+func aggregateIDFromMetadata(meta metadata.Metadata) (aggregate.ID, error) {
+	val := meta.Value(aggregate.IDKey)
+	if val == nil {
+		return "", MissingMetadataError(aggregate.IDKey)
+	}
+
+	str, ok := val.(string)
+	if !ok {
+		return "", &InvalidMetadataValueTypeError{key: aggregate.IDKey, value: val, expected: "string"}
+	}
+
+	return aggregate.ID(str), nil
+}
+
+func aggregateVersionFromMetadata(meta metadata.Metadata) (uint, error) {
+	val := meta.Value(aggregate.VersionKey)
+	if val == nil {
+		return 0, MissingMetadataError(aggregate.VersionKey)
+	}
+
+	float, ok := val.(float64)
+	if !ok {
+		return 0, &InvalidMetadataValueTypeError{key: aggregate.VersionKey, value: val, expected: "float64"}
+	}
+
+	if float <= 0 {
+		return 0, aggregate.ErrInvalidChangeVersion
+	}
+
+	return uint(float), nil
+}
+
+// MissingMetadataError is an error indicating the requested metadata was nil.
+type MissingMetadataError string
+
+func (e MissingMetadataError) Error() string {
+	return "goengine: metadata key " + string(e) + " is not set or nil"
+}
+
+// InvalidMetadataValueTypeError is an error indicating the value metadata key was an unexpected type.
+type InvalidMetadataValueTypeError struct {
+	key      string
+	value    interface{}
+	expected string
+}
+
+func (e *InvalidMetadataValueTypeError) Error() string {
+	return fmt.Sprintf("goengine: metadata key %s with value %v was expected to be of type %s", e.key, e.value, e.expected)
+}
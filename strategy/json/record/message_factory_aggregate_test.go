@@ -0,0 +1,124 @@
+// +build unit
+
+package record_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/mocks"
+	"github.com/hellofresh/goengine/strategy/json/record"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAggregateChangedFactory(t *testing.T) {
+	factory, err := record.NewAggregateChangedFactory(nil)
+
+	assert.Equal(t, goengine.InvalidArgumentError("factory"), err)
+	assert.Nil(t, factory)
+}
+
+func TestAggregateChangedFactory_CreateMessage(t *testing.T) {
+	aggregateID := aggregate.GenerateID()
+	createdAt := time.Now().UTC()
+
+	t.Run("reconstruct the message", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		meta := metadata.WithValue(metadata.New(), aggregate.IDKey, string(aggregateID))
+		meta = metadata.WithValue(meta, aggregate.VersionKey, float64(3))
+
+		payloadFactory := mocks.NewMessagePayloadFactory(ctrl)
+		payloadFactory.EXPECT().CreatePayload("name_changed", []byte(`{}`)).Return("payload", nil)
+
+		factory, err := record.NewAggregateChangedFactory(payloadFactory)
+		require.NoError(t, err)
+
+		rec := &record.Record{
+			Number:    1,
+			UUID:      goengine.GenerateUUID(),
+			EventName: "name_changed",
+			Payload:   []byte(`{}`),
+			Metadata:  meta,
+			CreatedAt: createdAt,
+		}
+		msg, err := factory.CreateMessage(rec)
+
+		require.NoError(t, err)
+		require.IsType(t, &aggregate.Changed{}, msg)
+		changed := msg.(*aggregate.Changed)
+		assert.Equal(t, rec.UUID, changed.UUID())
+		assert.Equal(t, aggregateID, changed.AggregateID())
+		assert.Equal(t, uint(3), changed.Version())
+		assert.Equal(t, "payload", changed.Payload())
+		assert.Equal(t, createdAt, changed.CreatedAt())
+	})
+
+	t.Run("invalid records", func(t *testing.T) {
+		testCases := []struct {
+			title         string
+			meta          map[string]interface{}
+			expectedError string
+		}{
+			{
+				"missing aggregate id",
+				map[string]interface{}{aggregate.VersionKey: float64(1)},
+				"goengine: metadata key _aggregate_id is not set or nil",
+			},
+			{
+				"invalid aggregate id",
+				map[string]interface{}{aggregate.IDKey: 1, aggregate.VersionKey: float64(1)},
+				"goengine: metadata key _aggregate_id with value 1 was expected to be of type string",
+			},
+			{
+				"missing aggregate version",
+				map[string]interface{}{aggregate.IDKey: string(aggregateID)},
+				"goengine: metadata key _aggregate_version is not set or nil",
+			},
+			{
+				"invalid aggregate version",
+				map[string]interface{}{aggregate.IDKey: string(aggregateID), aggregate.VersionKey: "1"},
+				"goengine: metadata key _aggregate_version with value 1 was expected to be of type float64",
+			},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				payloadFactory := mocks.NewMessagePayloadFactory(ctrl)
+				payloadFactory.EXPECT().CreatePayload(gomock.Any(), gomock.Any()).Return("payload", nil)
+
+				factory, err := record.NewAggregateChangedFactory(payloadFactory)
+				require.NoError(t, err)
+
+				msg, err := factory.CreateMessage(&record.Record{
+					UUID:      goengine.GenerateUUID(),
+					EventName: "name_changed",
+					Metadata:  metadata.FromMap(testCase.meta),
+					CreatedAt: createdAt,
+				})
+
+				assert.EqualError(t, err, testCase.expectedError)
+				assert.Nil(t, msg)
+			})
+		}
+	})
+
+	t.Run("nil record", func(t *testing.T) {
+		factory, err := record.NewAggregateChangedFactory(&mocks.MessagePayloadFactory{})
+		require.NoError(t, err)
+
+		msg, err := factory.CreateMessage(nil)
+
+		assert.Equal(t, goengine.InvalidArgumentError("record"), err)
+		assert.Nil(t, msg)
+	})
+}
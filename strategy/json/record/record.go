@@ -0,0 +1,28 @@
+// Package record reconstructs messages from the records in which event stores keep and transfer them.
+// A record contains the JSON payload and metadata of a message as they are produced by a json.PayloadTransformer.
+package record
+
+import (
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/metadata"
+)
+
+type (
+	// Record is a message as it is stored or transferred by an event store
+	Record struct {
+		Number    int64
+		UUID      goengine.UUID
+		EventName string
+		Payload   []byte
+		Metadata  metadata.Metadata
+		CreatedAt time.Time
+	}
+
+	// MessageFactory reconstruct messages from records
+	MessageFactory interface {
+		// CreateMessage reconstructs the message from the provided record
+		CreateMessage(record *Record) (goengine.Message, error)
+	}
+)
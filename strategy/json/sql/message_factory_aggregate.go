@@ -2,31 +2,40 @@ package sql
 
 import (
 	"database/sql"
-	"fmt"
 	"time"
 
 	"github.com/hellofresh/goengine"
-	"github.com/hellofresh/goengine/aggregate"
 	driverSQL "github.com/hellofresh/goengine/driver/sql"
 	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/strategy/json/internal"
+	"github.com/hellofresh/goengine/strategy/json/record"
 )
 
 // Ensure that AggregateChangedFactory satisfies the MessageFactory interface
 var _ driverSQL.MessageFactory = &AggregateChangedFactory{}
 
-// AggregateChangedFactory reconstructs aggregate.Changed messages
-type AggregateChangedFactory struct {
-	payloadFactory goengine.MessagePayloadFactory
-}
+type (
+	// AggregateChangedFactory reconstructs aggregate.Changed messages
+	AggregateChangedFactory struct {
+		messageFactory *record.AggregateChangedFactory
+	}
+
+	// MissingMetadataError is an error indicating the requested metadata was nil.
+	MissingMetadataError = record.MissingMetadataError
+
+	// InvalidMetadataValueTypeError is an error indicating the value metadata key was an unexpected type.
+	InvalidMetadataValueTypeError = record.InvalidMetadataValueTypeError
+)
 
 // NewAggregateChangedFactory returns a new instance of an AggregateChangedFactory
 func NewAggregateChangedFactory(factory goengine.MessagePayloadFactory) (*AggregateChangedFactory, error) {
-	if factory == nil {
-		return nil, goengine.InvalidArgumentError("factory")
+	messageFactory, err := record.NewAggregateChangedFactory(factory)
+	if err != nil {
+		return nil, err
 	}
 
 	return &AggregateChangedFactory{
-		factory,
+		messageFactory,
 	}, nil
 }
 
@@ -37,7 +46,7 @@ func (f *AggregateChangedFactory) CreateEventStream(rows *sql.Rows) (goengine.Ev
 	}
 
 	return &aggregateChangedEventStream{
-		payloadFactory: f.payloadFactory,
+		messageFactory: f.messageFactory,
 		rows:           rows,
 	}, nil
 }
@@ -46,7 +55,7 @@ func (f *AggregateChangedFactory) CreateEventStream(rows *sql.Rows) (goengine.Ev
 var _ goengine.EventStream = &aggregateChangedEventStream{}
 
 type aggregateChangedEventStream struct {
-	payloadFactory goengine.MessagePayloadFactory
+	messageFactory *record.AggregateChangedFactory
 	rows           *sql.Rows
 }
 
@@ -82,79 +91,20 @@ func (a *aggregateChangedEventStream) Message() (goengine.Message, int64, error)
 		return nil, 0, err
 	}
 
-	payload, err := a.payloadFactory.CreatePayload(eventName, jsonPayload)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	aggregateID, err := aggregateIDFromMetadata(meta)
+	// Payloads stored by a compressing persistence strategy are gzip compressed
+	jsonPayload, err = internal.DecompressPayload(jsonPayload)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	aggregateVersion, err := aggregateVersionFromMetadata(meta)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	aggr, err := aggregate.ReconstituteChange(
-		aggregateID,
-		eventID,
-		payload,
-		meta,
-		createdAt,
-		aggregateVersion,
-	)
+	aggr, err := a.messageFactory.CreateMessage(&record.Record{
+		Number:    eventNumber,
+		UUID:      eventID,
+		EventName: eventName,
+		Payload:   jsonPayload,
+		Metadata:  meta,
+		CreatedAt: createdAt,
+	})
 
 	return aggr, eventNumber, err
 }
-
-func aggregateIDFromMetadata(meta metadata.Metadata) (aggregate.ID, error) {
-	val := meta.Value(aggregate.IDKey)
-	if val == nil {
-		return "", MissingMetadataError(aggregate.IDKey)
-	}
-
-	str, ok := val.(string)
-	if !ok {
-		return "", &InvalidMetadataValueTypeError{key: aggregate.IDKey, value: val, expected: "string"}
-	}
-
-	return aggregate.ID(str), nil
-}
-
-func aggregateVersionFromMetadata(meta metadata.Metadata) (uint, error) {
-	val := meta.Value(aggregate.VersionKey)
-	if val == nil {
-		return 0, MissingMetadataError(aggregate.VersionKey)
-	}
-
-	float, ok := val.(float64)
-	if !ok {
-		return 0, &InvalidMetadataValueTypeError{key: aggregate.VersionKey, value: val, expected: "float64"}
-	}
-
-	if float <= 0 {
-		return 0, aggregate.ErrInvalidChangeVersion
-	}
-
-	return uint(float), nil
-}
-
-// MissingMetadataError is an error indicating the requested metadata was nil.
-type MissingMetadataError string
-
-func (e MissingMetadataError) Error() string {
-	return "goengine: metadata key " + string(e) + " is not set or nil"
-}
-
-// InvalidMetadataValueTypeError is an error indicating the value metadata key was an unexpected type.
-type InvalidMetadataValueTypeError struct {
-	key      string
-	value    interface{}
-	expected string
-}
-
-func (e *InvalidMetadataValueTypeError) Error() string {
-	return fmt.Sprintf("goengine: metadata key %s with value %v was expected to be of type %s", e.key, e.value, e.expected)
-}
@@ -116,6 +116,50 @@ func TestAggregateChangedFactory_CreateFromRows(t *testing.T) {
 		}
 	})
 
+	t.Run("decompress payloads", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expectedMessage, err := createAggregateChangedMessage(nameChanged{"bob"}, 1)
+		require.NoError(t, err)
+
+		rowPayload := []byte(`{"name":"bob"}`)
+		compressedPayload, err := internal.CompressPayload(rowPayload, 0)
+		require.NoError(t, err)
+		require.NotEqual(t, rowPayload, compressedPayload)
+
+		rowMetadata, err := internal.MarshalJSON(expectedMessage.Metadata())
+		require.NoError(t, err)
+
+		payloadFactory := mocks.NewMessagePayloadFactory(ctrl)
+		payloadFactory.EXPECT().CreatePayload("name_changed", rowPayload).Return(expectedMessage.Payload(), nil).Times(1)
+
+		uuid, _ := expectedMessage.UUID().MarshalBinary()
+		mockRows := sqlmock.NewRows(rowColumns).
+			AddRow(1, uuid, "name_changed", compressedPayload, rowMetadata, expectedMessage.CreatedAt())
+
+		db, dbMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		dbMock.ExpectQuery("SELECT").WillReturnRows(mockRows)
+		rows, err := db.Query("SELECT")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		messageFactory, err := sql.NewAggregateChangedFactory(payloadFactory)
+		require.NoError(t, err)
+
+		stream, err := messageFactory.CreateEventStream(rows)
+		require.NoError(t, err)
+		defer stream.Close()
+
+		messages, _, err := goengine.ReadEventStream(stream)
+		require.NoError(t, err)
+
+		assertEqualMessages(t, []*aggregate.Changed{expectedMessage}, messages)
+	})
+
 	t.Run("no rows", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
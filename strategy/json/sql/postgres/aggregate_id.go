@@ -0,0 +1,37 @@
+package postgres
+
+import "github.com/hellofresh/goengine"
+
+// AggregateIDType is the postgres column type used to store the aggregate id
+type AggregateIDType string
+
+const (
+	// AggregateIDUUID stores aggregate ids as a UUID, this is the default
+	AggregateIDUUID AggregateIDType = "UUID"
+	// AggregateIDText stores aggregate ids as text which allows natural keys or ULIDs to be used as aggregate id
+	AggregateIDText AggregateIDType = "TEXT"
+	// AggregateIDBigInt stores aggregate ids as a 64-bit integer
+	AggregateIDBigInt AggregateIDType = "BIGINT"
+)
+
+func (t AggregateIDType) valid() bool {
+	switch t {
+	case AggregateIDUUID, AggregateIDText, AggregateIDBigInt:
+		return true
+	}
+
+	return false
+}
+
+// WithAggregateIDType stores the aggregate id in a column of the provided type, this allows the use of aggregate ids
+// that are not a UUID
+func WithAggregateIDType(aggregateIDType AggregateIDType) Option {
+	return func(s *SingleStreamStrategy) error {
+		if !aggregateIDType.valid() {
+			return goengine.InvalidArgumentError("aggregateIDType")
+		}
+
+		s.aggregateIDType = aggregateIDType
+		return nil
+	}
+}
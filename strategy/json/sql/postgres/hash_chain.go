@@ -0,0 +1,229 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+)
+
+// HashChainScope is the scope in which the hash of a event is chained to the hash of the previous event
+type HashChainScope int
+
+const (
+	// HashChainPerStream chains the hash of every event to the hash of the previous event in the stream
+	HashChainPerStream HashChainScope = iota + 1
+	// HashChainPerAggregate chains the hash of every event to the hash of the previous event of the same aggregate
+	HashChainPerAggregate
+)
+
+// createdAtHashLayout is the layout used to hash the created at time of a event
+const createdAtHashLayout = "2006-01-02T15:04:05.000000"
+
+// ErrHashChainNotEnabled occurs when a hash chain is verified using a strategy that does not hash chain events
+var ErrHashChainNotEnabled = errors.New("goengine: the persistence strategy does not hash chain events")
+
+// HashChainBrokenError occurs when a event was modified, removed or reordered after it was appended
+type HashChainBrokenError struct {
+	// Number is the number of the first event of which the hash does not match
+	Number int64
+	// EventID is the id of the first event of which the hash does not match
+	EventID goengine.UUID
+}
+
+func (e *HashChainBrokenError) Error() string {
+	return fmt.Sprintf("goengine: hash chain is broken at event %d (%s)", e.Number, e.EventID)
+}
+
+// WithHashChain stores a hash of every event chained to the hash of the previous event in the stream or of the
+// aggregate depending on the scope.
+// The hash chain of a stream can be verified using VerifyHashChain.
+func WithHashChain(scope HashChainScope) Option {
+	return func(s *SingleStreamStrategy) error {
+		if scope != HashChainPerStream && scope != HashChainPerAggregate {
+			return goengine.InvalidArgumentError("scope")
+		}
+
+		s.hashChain = scope
+		return nil
+	}
+}
+
+// VerifyHashChain walks the event stream and returns a HashChainBrokenError for the first event of which the content
+// or the link to the previous event does not match it's hash.
+// Removing the most recent events of a stream or aggregate can not be detected by verifying the hash chain.
+func (s *SingleStreamStrategy) VerifyHashChain(ctx context.Context, db sql.Queryer, streamName goengine.StreamName) error {
+	if s.hashChain == 0 {
+		return ErrHashChainNotEnabled
+	}
+
+	tableName, err := s.GenerateTableName(streamName)
+	if err != nil {
+		return err
+	}
+
+	/* #nosec G201 */
+	query := fmt.Sprintf(
+		`SELECT no, event_id, event_name, payload, metadata, aggregate_type, aggregate_id, created_at, content_hash, hash
+FROM %s ORDER BY no`,
+		postgres.QuoteTableName(tableName),
+	)
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		previousStreamHash    []byte
+		previousAggregateHash = map[string][]byte{}
+	)
+	for rows.Next() {
+		var (
+			number        int64
+			eventID       goengine.UUID
+			eventName     string
+			payload       []byte
+			meta          []byte
+			aggregateType string
+			aggregateID   string
+			createdAt     time.Time
+			storedContent []byte
+			storedHash    []byte
+		)
+		if err := rows.Scan(
+			&number, &eventID, &eventName, &payload, &meta, &aggregateType, &aggregateID, &createdAt, &storedContent, &storedHash,
+		); err != nil {
+			return err
+		}
+
+		content, err := contentHash(eventID.String(), eventName, payload, meta, createdAt)
+		if err != nil {
+			return err
+		}
+
+		previous := previousStreamHash
+		aggregateKey := aggregateType + "/" + aggregateID
+		if s.hashChain == HashChainPerAggregate {
+			previous = previousAggregateHash[aggregateKey]
+		}
+
+		hash := chainHash(previous, content)
+		if !bytes.Equal(content, storedContent) || !bytes.Equal(hash, storedHash) {
+			return &HashChainBrokenError{Number: number, EventID: eventID}
+		}
+
+		previousStreamHash = hash
+		previousAggregateHash[aggregateKey] = hash
+	}
+
+	return rows.Err()
+}
+
+// contentHash returns the sha256 hash of the content of a event.
+// The metadata is hashed in it's canonical form since postgres normalizes JSONB.
+func contentHash(eventID string, eventName string, payload []byte, meta []byte, createdAt time.Time) ([]byte, error) {
+	var metaValue interface{}
+	if err := json.Unmarshal(meta, &metaValue); err != nil {
+		return nil, err
+	}
+	canonicalMeta, err := json.Marshal(metaValue)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	for _, field := range [][]byte{
+		[]byte(eventID),
+		[]byte(eventName),
+		payload,
+		canonicalMeta,
+		[]byte(createdAt.Format(createdAtHashLayout)),
+	} {
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(field)))
+		_, _ = h.Write(length[:])
+		_, _ = h.Write(field)
+	}
+
+	return h.Sum(nil), nil
+}
+
+// chainHash returns the hash of a event chained to the hash of the previous event, this is the same as the hash
+// calculated by the trigger created by sqlHashChainTrigger
+func chainHash(previous []byte, content []byte) []byte {
+	h := sha256.New()
+	_, _ = h.Write(previous)
+	_, _ = h.Write(content)
+
+	return h.Sum(nil)
+}
+
+// sqlHashChainTrigger returns the statements creating the trigger that chains the content hash of a appended event
+// to the hash of the previous event
+func sqlHashChainTrigger(tableName string, scope HashChainScope) []string {
+	funcName := postgres.QuoteTableName(tableName + "_hash_chain")
+	quotedTableName := postgres.QuoteTableName(tableName)
+
+	// A trigger is created in the schema of it's table so the trigger name can not be qualified
+	_, table := postgres.SplitTableName(tableName)
+	triggerName := postgres.QuoteIdentifier(table + "_hash_chain")
+
+	var statements []string
+
+	// The previous event of a aggregate is appended before the next version can be appended so only the previous event
+	// of the stream requires a lock, the event number is assigned after locking to ensure the hashes are chained in
+	// the order of the event numbers.
+	// The number column of a stream has no default, so a number is only taken from the sequence once.
+	previous := fmt.Sprintf(
+		`SELECT hash INTO previous FROM %[1]s
+        WHERE aggregate_type = NEW.aggregate_type AND aggregate_id = NEW.aggregate_id AND aggregate_version = NEW.aggregate_version - 1;`,
+		quotedTableName,
+	)
+	if scope == HashChainPerStream {
+		sequenceName := postgres.QuoteTableName(tableName + "_no_seq")
+		statements = append(statements, fmt.Sprintf(`CREATE SEQUENCE %[1]s OWNED BY %[2]s.no;`, sequenceName, quotedTableName))
+
+		previous = fmt.Sprintf(
+			`PERFORM pg_advisory_xact_lock(TG_RELID::int, 0);
+    NEW.no := nextval(%[2]s);
+    SELECT hash INTO previous FROM %[1]s ORDER BY no DESC LIMIT 1;`,
+			quotedTableName,
+			postgres.QuoteString(sequenceName),
+		)
+	}
+
+	/* #nosec G201 */
+	return append(statements,
+		fmt.Sprintf(
+			`CREATE FUNCTION %[1]s()
+  RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+DECLARE
+  previous BYTEA;
+BEGIN
+    %[2]s
+    NEW.hash := sha256(COALESCE(previous, ''::BYTEA) || NEW.content_hash);
+    RETURN NEW;
+END;
+$$;`,
+			funcName,
+			previous,
+		),
+		fmt.Sprintf(
+			`CREATE TRIGGER %[1]s BEFORE INSERT ON %[2]s FOR EACH ROW EXECUTE PROCEDURE %[3]s();`,
+			triggerName,
+			quotedTableName,
+			funcName,
+		),
+	)
+}
@@ -0,0 +1,306 @@
+// +build unit
+
+package postgres_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/aggregate"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/mocks"
+	strategyJSON "github.com/hellofresh/goengine/strategy/json"
+	"github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type balanceChanged struct {
+	Balance int `json:"balance"`
+}
+
+func TestWithHashChain(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		testCases := []struct {
+			title       string
+			converter   goengine.MessagePayloadConverter
+			scope       postgres.HashChainScope
+			expectedErr error
+		}{
+			{"nil converter", nil, postgres.HashChainPerStream, goengine.InvalidArgumentError("converter")},
+			{"no scope", &mocks.MessagePayloadConverter{}, 0, goengine.InvalidArgumentError("scope")},
+			{"unknown scope", &mocks.MessagePayloadConverter{}, 3, goengine.InvalidArgumentError("scope")},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				strategy, err := postgres.NewSingleStreamStrategy(testCase.converter, postgres.WithHashChain(testCase.scope))
+
+				assert.Equal(t, testCase.expectedErr, err)
+				assert.Nil(t, strategy)
+			})
+		}
+	})
+
+	t.Run("schema", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithHashChain(postgres.HashChainPerStream))
+		require.NoError(t, err)
+
+		cs := strategy.CreateSchema("abc")
+
+		require.Len(t, cs, 6)
+		assert.Contains(t, cs[0], "no BIGINT NOT NULL,")
+		assert.Contains(t, cs[0], "content_hash BYTEA NOT NULL")
+		assert.Contains(t, cs[0], "hash BYTEA NOT NULL")
+		assert.Equal(t, `CREATE SEQUENCE "abc_no_seq" OWNED BY "abc".no;`, cs[3])
+		assert.Contains(t, cs[4], `CREATE FUNCTION "abc_hash_chain"()`)
+		assert.Contains(t, cs[4], "pg_advisory_xact_lock")
+		assert.Contains(t, cs[4], `NEW.no := nextval('"abc_no_seq"');`)
+		assert.Equal(t, 1, strings.Count(cs[4], "nextval"))
+		assert.Contains(t, cs[5], `CREATE TRIGGER "abc_hash_chain" BEFORE INSERT ON "abc"`)
+	})
+
+	t.Run("schema qualified table", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithHashChain(postgres.HashChainPerStream))
+		require.NoError(t, err)
+
+		cs := strategy.CreateSchema("billing.abc")
+
+		require.Len(t, cs, 6)
+		assert.Equal(t, `CREATE SEQUENCE "billing"."abc_no_seq" OWNED BY "billing"."abc".no;`, cs[3])
+		assert.Contains(t, cs[4], `CREATE FUNCTION "billing"."abc_hash_chain"()`)
+		assert.Contains(t, cs[4], `nextval('"billing"."abc_no_seq"')`)
+		assert.Contains(t, cs[5], `CREATE TRIGGER "abc_hash_chain" BEFORE INSERT ON "billing"."abc"`)
+		assert.Contains(t, cs[5], `EXECUTE PROCEDURE "billing"."abc_hash_chain"()`)
+	})
+
+	t.Run("schema per aggregate", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithHashChain(postgres.HashChainPerAggregate))
+		require.NoError(t, err)
+
+		cs := strategy.CreateSchema("abc")
+
+		require.Len(t, cs, 5)
+		assert.Contains(t, cs[0], "no BIGSERIAL,")
+		assert.Contains(t, cs[3], "aggregate_version = NEW.aggregate_version - 1")
+		assert.NotContains(t, cs[3], "pg_advisory_xact_lock")
+		assert.NotContains(t, cs[3], "nextval")
+	})
+
+	t.Run("insert columns", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithHashChain(postgres.HashChainPerStream))
+		require.NoError(t, err)
+
+		assert.Equal(t, "content_hash", strategy.InsertColumnNames()[8])
+	})
+}
+
+func TestSingleStreamStrategy_VerifyHashChain(t *testing.T) {
+	firstID := aggregate.GenerateID()
+	secondID := aggregate.GenerateID()
+
+	testCases := []struct {
+		title string
+		scope postgres.HashChainScope
+	}{
+		{"per stream", postgres.HashChainPerStream},
+		{"per aggregate", postgres.HashChainPerAggregate},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			strategy := newHashChainedStrategy(t, testCase.scope)
+			messages := []goengine.Message{
+				newBalanceChanged(t, firstID, 1),
+				newBalanceChanged(t, secondID, 1),
+				newBalanceChanged(t, firstID, 2),
+			}
+
+			t.Run("valid chain", func(t *testing.T) {
+				rows := hashChainRows(t, strategy, testCase.scope, messages, nil)
+
+				assert.NoError(t, verifyHashChain(t, strategy, rows))
+			})
+
+			t.Run("metadata normalized by postgres", func(t *testing.T) {
+				rows := hashChainRows(t, strategy, testCase.scope, messages, func(i int, row []interface{}) {
+					if i == 0 {
+						row[4] = []byte(fmt.Sprintf(`{"_aggregate_id": "%s", "_aggregate_type": "account", "_aggregate_version": 1}`, firstID))
+					}
+				})
+
+				assert.NoError(t, verifyHashChain(t, strategy, rows))
+			})
+
+			t.Run("modified event", func(t *testing.T) {
+				rows := hashChainRows(t, strategy, testCase.scope, messages, func(i int, row []interface{}) {
+					if i == 1 {
+						row[3] = []byte(`{"balance":1000000}`)
+					}
+				})
+
+				err := verifyHashChain(t, strategy, rows)
+
+				assert.Equal(t, &postgres.HashChainBrokenError{Number: 2, EventID: messages[1].UUID()}, err)
+			})
+
+			t.Run("modified metadata", func(t *testing.T) {
+				rows := hashChainRows(t, strategy, testCase.scope, messages, func(i int, row []interface{}) {
+					if i == 0 {
+						row[4] = []byte(fmt.Sprintf(`{"_aggregate_id":"%s","_aggregate_type":"account","_aggregate_version":2}`, firstID))
+					}
+				})
+
+				err := verifyHashChain(t, strategy, rows)
+
+				assert.Equal(t, &postgres.HashChainBrokenError{Number: 1, EventID: messages[0].UUID()}, err)
+			})
+		})
+	}
+
+	t.Run("removed event", func(t *testing.T) {
+		strategy := newHashChainedStrategy(t, postgres.HashChainPerStream)
+		messages := []goengine.Message{
+			newBalanceChanged(t, firstID, 1),
+			newBalanceChanged(t, firstID, 2),
+			newBalanceChanged(t, firstID, 3),
+		}
+
+		rows := sqlmock.NewRows(hashChainColumns)
+		hashChainRows(t, strategy, postgres.HashChainPerStream, messages, func(i int, row []interface{}) {
+			if i != 1 {
+				rows.AddRow(toDriverValues(row)...)
+			}
+		})
+
+		err := verifyHashChain(t, strategy, rows)
+
+		assert.Equal(t, &postgres.HashChainBrokenError{Number: 3, EventID: messages[2].UUID()}, err)
+	})
+
+	t.Run("strategy without hash chain", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{})
+		require.NoError(t, err)
+
+		err = strategy.(*postgres.SingleStreamStrategy).VerifyHashChain(context.Background(), nil, "event_stream")
+
+		assert.Equal(t, postgres.ErrHashChainNotEnabled, err)
+	})
+}
+
+var hashChainColumns = []string{
+	"no", "event_id", "event_name", "payload", "metadata", "aggregate_type", "aggregate_id", "created_at", "content_hash", "hash",
+}
+
+func newHashChainedStrategy(t *testing.T, scope postgres.HashChainScope) *postgres.SingleStreamStrategy {
+	transformer := strategyJSON.NewPayloadTransformer()
+	require.NoError(t,
+		transformer.RegisterPayload("balance_changed", func() interface{} { return balanceChanged{} }),
+	)
+
+	strategy, err := postgres.NewSingleStreamStrategy(transformer, postgres.WithHashChain(scope))
+	require.NoError(t, err)
+
+	return strategy.(*postgres.SingleStreamStrategy)
+}
+
+func newBalanceChanged(t *testing.T, aggregateID aggregate.ID, version uint) goengine.Message {
+	meta := metadata.New()
+	meta = metadata.WithValue(meta, aggregate.IDKey, aggregateID)
+	meta = metadata.WithValue(meta, aggregate.TypeKey, "account")
+	meta = metadata.WithValue(meta, aggregate.VersionKey, version)
+
+	msg, err := aggregate.ReconstituteChange(
+		aggregateID,
+		goengine.GenerateUUID(),
+		balanceChanged{Balance: int(version)},
+		meta,
+		time.Date(2021, 1, 2, 3, 4, 5, 6789, time.UTC),
+		version,
+	)
+	require.NoError(t, err)
+
+	return msg
+}
+
+// hashChainRows returns the rows as they would be stored by the hash chain trigger of the strategy.
+// The modify func is called with every row after it's hash was calculated.
+func hashChainRows(
+	t *testing.T,
+	strategy *postgres.SingleStreamStrategy,
+	scope postgres.HashChainScope,
+	messages []goengine.Message,
+	modify func(i int, row []interface{}),
+) *sqlmock.Rows {
+	data, err := strategy.PrepareData(messages)
+	require.NoError(t, err)
+
+	columnCount := len(strategy.InsertColumnNames())
+	rows := sqlmock.NewRows(hashChainColumns)
+	previous := map[string][]byte{}
+	for i := range messages {
+		values := data[i*columnCount : (i+1)*columnCount]
+
+		key := ""
+		if scope == postgres.HashChainPerAggregate {
+			key = fmt.Sprint(values[5])
+		}
+
+		contentHash := values[8].([]byte)
+		hash := sha256.Sum256(append(append([]byte{}, previous[key]...), contentHash...))
+		previous[key] = hash[:]
+
+		row := []interface{}{
+			int64(i + 1), // no
+			values[0],    // event_id
+			values[1],    // event_name
+			values[2],    // payload
+			values[3],    // metadata
+			values[4],    // aggregate_type
+			values[5],    // aggregate_id
+			values[7],    // created_at
+			contentHash,  // content_hash
+			hash[:],      // hash
+		}
+		if modify != nil {
+			modify(i, row)
+		}
+
+		rows.AddRow(toDriverValues(row)...)
+	}
+
+	return rows
+}
+
+func toDriverValues(row []interface{}) []driver.Value {
+	values := make([]driver.Value, len(row))
+	for i, v := range row {
+		switch v := v.(type) {
+		case goengine.UUID:
+			values[i] = v.String()
+		case aggregate.ID:
+			values[i] = string(v)
+		default:
+			values[i] = v
+		}
+	}
+
+	return values
+}
+
+func verifyHashChain(t *testing.T, strategy *postgres.SingleStreamStrategy, rows *sqlmock.Rows) error {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	dbMock.ExpectQuery(`SELECT no, (.+) FROM "events_event_stream" ORDER BY no`).WillReturnRows(rows)
+
+	return strategy.VerifyHashChain(context.Background(), db, "event_stream")
+}
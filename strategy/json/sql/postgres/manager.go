@@ -1,12 +1,14 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
 	"github.com/hellofresh/goengine"
 	driverSQL "github.com/hellofresh/goengine/driver/sql"
 	"github.com/hellofresh/goengine/driver/sql/postgres"
+	"github.com/hellofresh/goengine/saga"
 	"github.com/hellofresh/goengine/strategy/json"
 	strategySQL "github.com/hellofresh/goengine/strategy/json/sql"
 )
@@ -17,6 +19,7 @@ type SingleStreamManager struct {
 	payloadTransformer  *json.PayloadTransformer
 	persistenceStrategy driverSQL.PersistenceStrategy
 	messageFactory      driverSQL.MessageFactory
+	migrator            *Migrator
 
 	logger  goengine.Logger
 	metrics driverSQL.Metrics
@@ -24,6 +27,39 @@ type SingleStreamManager struct {
 
 // NewSingleStreamManager return a new instance of the SingleStreamManager
 func NewSingleStreamManager(db *sql.DB, logger goengine.Logger, metrics driverSQL.Metrics) (*SingleStreamManager, error) {
+	payloadTransformer := json.NewPayloadTransformer()
+
+	// Setting up the postgres strategy
+	persistenceStrategy, err := NewSingleStreamStrategy(payloadTransformer)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSingleStreamManager(db, payloadTransformer, persistenceStrategy, DefaultMigrationTable, logger, metrics)
+}
+
+// NewSingleStreamManagerInSchema return a new instance of the SingleStreamManager that stores the event streams and
+// the applied migrations in the provided postgres schema
+func NewSingleStreamManagerInSchema(db *sql.DB, schema string, logger goengine.Logger, metrics driverSQL.Metrics) (*SingleStreamManager, error) {
+	payloadTransformer := json.NewPayloadTransformer()
+
+	// Setting up the postgres strategy
+	persistenceStrategy, err := NewSingleStreamStrategy(payloadTransformer, WithSchema(schema))
+	if err != nil {
+		return nil, err
+	}
+
+	return newSingleStreamManager(db, payloadTransformer, persistenceStrategy, schema+"."+DefaultMigrationTable, logger, metrics)
+}
+
+func newSingleStreamManager(
+	db *sql.DB,
+	payloadTransformer *json.PayloadTransformer,
+	persistenceStrategy driverSQL.PersistenceStrategy,
+	migrationTable string,
+	logger goengine.Logger,
+	metrics driverSQL.Metrics,
+) (*SingleStreamManager, error) {
 	if db == nil {
 		return nil, goengine.InvalidArgumentError("db")
 	}
@@ -34,16 +70,14 @@ func NewSingleStreamManager(db *sql.DB, logger goengine.Logger, metrics driverSQ
 		metrics = driverSQL.NopMetrics
 	}
 
-	payloadTransformer := json.NewPayloadTransformer()
-
-	// Setting up the postgres strategy
-	persistenceStrategy, err := NewSingleStreamStrategy(payloadTransformer)
+	// Setting up the message factory
+	messageFactory, err := strategySQL.NewAggregateChangedFactory(payloadTransformer)
 	if err != nil {
 		return nil, err
 	}
 
-	// Setting up the message factory
-	messageFactory, err := strategySQL.NewAggregateChangedFactory(payloadTransformer)
+	// Setting up the migrator
+	migrator, err := NewMigrator(db, migrationTable, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -53,6 +87,7 @@ func NewSingleStreamManager(db *sql.DB, logger goengine.Logger, metrics driverSQ
 		payloadTransformer:  payloadTransformer,
 		persistenceStrategy: persistenceStrategy,
 		messageFactory:      messageFactory,
+		migrator:            migrator,
 		logger:              logger,
 		metrics:             metrics,
 	}, nil
@@ -60,12 +95,18 @@ func NewSingleStreamManager(db *sql.DB, logger goengine.Logger, metrics driverSQ
 
 // NewEventStore returns a new event store instance
 func (m *SingleStreamManager) NewEventStore() (*postgres.EventStore, error) {
+	var options []postgres.EventStoreOption
+	if metrics, ok := m.metrics.(driverSQL.EventStoreMetrics); ok {
+		options = append(options, postgres.WithEventStoreMetrics(metrics))
+	}
+
 	// Setting up the event store
 	return postgres.NewEventStore(
 		m.persistenceStrategy,
 		m.db,
 		m.messageFactory,
 		m.logger,
+		options...,
 	)
 }
 
@@ -79,6 +120,53 @@ func (m *SingleStreamManager) PersistenceStrategy() driverSQL.PersistenceStrateg
 	return m.persistenceStrategy
 }
 
+// MigrateEventStream applies the EventStreamMigrations to the table of the event stream
+func (m *SingleStreamManager) MigrateEventStream(ctx context.Context, streamName goengine.StreamName) ([]Migration, error) {
+	tableName, err := m.persistenceStrategy.GenerateTableName(streamName)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.migrator.Migrate(ctx, tableName, EventStreamMigrations)
+}
+
+// MigrateStreamProjection applies the StreamProjectionMigrations to the stream projection table
+func (m *SingleStreamManager) MigrateStreamProjection(ctx context.Context, projectionTable string) ([]Migration, error) {
+	return m.migrator.Migrate(ctx, projectionTable, StreamProjectionMigrations)
+}
+
+// MigrateAggregateProjection applies the AggregateProjectionMigrations to the aggregate projection table
+func (m *SingleStreamManager) MigrateAggregateProjection(ctx context.Context, projectionTable string) ([]Migration, error) {
+	return m.migrator.Migrate(ctx, projectionTable, AggregateProjectionMigrations)
+}
+
+// ChangeAggregateIDType changes the type of the aggregate_id column of the event stream table and the provided
+// aggregate projection tables, see Migrator.ChangeAggregateIDType
+func (m *SingleStreamManager) ChangeAggregateIDType(
+	ctx context.Context,
+	streamName goengine.StreamName,
+	aggregateIDType AggregateIDType,
+	aggregateProjectionTables ...string,
+) ([]string, error) {
+	tableName, err := m.persistenceStrategy.GenerateTableName(streamName)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.migrator.ChangeAggregateIDType(ctx, aggregateIDType, append([]string{tableName}, aggregateProjectionTables...)...)
+}
+
+// NotificationChannel returns the channel on which the notifications of the event stream are sent, listeners used to
+// run the projectors of the event stream must listen on this channel
+func (m *SingleStreamManager) NotificationChannel(streamName goengine.StreamName) (string, error) {
+	tableName, err := m.persistenceStrategy.GenerateTableName(streamName)
+	if err != nil {
+		return "", err
+	}
+
+	return NotificationChannel(streamName, tableName), nil
+}
+
 // NewStreamProjector returns a new stream projector instance
 func (m *SingleStreamManager) NewStreamProjector(
 	projectionTable string,
@@ -102,6 +190,11 @@ func (m *SingleStreamManager) NewStreamProjector(
 		return nil, err
 	}
 
+	var options []driverSQL.StreamProjectorOption
+	if metrics, ok := m.metrics.(driverSQL.ProjectionMetrics); ok {
+		options = append(options, driverSQL.WithStreamProjectorMetrics(metrics))
+	}
+
 	return driverSQL.NewStreamProjector(
 		m.db,
 		driverSQL.StreamProjectionEventStreamLoader(eventStore, projection.FromStream()),
@@ -110,6 +203,7 @@ func (m *SingleStreamManager) NewStreamProjector(
 		projectorStorage,
 		projectionErrorHandler,
 		m.logger,
+		options...,
 	)
 }
 
@@ -156,3 +250,24 @@ func (m *SingleStreamManager) NewAggregateProjector(
 		retryDelay,
 	)
 }
+
+// NewSagaManager returns a new saga manager instance that persists the saga data in the provided tables
+func (m *SingleStreamManager) NewSagaManager(
+	sagaTable,
+	instanceTable,
+	deadlineTable string,
+	s saga.Saga,
+	dispatcher saga.Dispatcher,
+) (*saga.Manager, error) {
+	eventStore, err := m.NewEventStore()
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := postgres.NewSagaStorage(m.db, sagaTable, instanceTable, deadlineTable, m.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return saga.NewManager(eventStore, m.payloadTransformer, s, storage, dispatcher, m.logger)
+}
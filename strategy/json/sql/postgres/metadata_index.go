@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+)
+
+// MetadataIndexKind is the way in which a metadata key is made searchable
+type MetadataIndexKind int
+
+const (
+	// MetadataExpressionIndex creates a btree index on the text value of the metadata key
+	MetadataExpressionIndex MetadataIndexKind = iota + 1
+	// MetadataPromotedColumn stores the value of the metadata key in a indexed and typed column
+	MetadataPromotedColumn
+	// MetadataGINIndex creates a GIN index on the metadata which is used to search the metadata key by equality to a
+	// string value
+	MetadataGINIndex
+)
+
+// MetadataColumnType is the postgres column type of a promoted metadata column
+type MetadataColumnType string
+
+const (
+	// MetadataText stores the metadata value as text
+	MetadataText MetadataColumnType = "TEXT"
+	// MetadataUUID stores the metadata value as a UUID
+	MetadataUUID MetadataColumnType = "UUID"
+	// MetadataBigInt stores the metadata value as a 64-bit integer
+	MetadataBigInt MetadataColumnType = "BIGINT"
+	// MetadataBoolean stores the metadata value as a boolean
+	MetadataBoolean MetadataColumnType = "BOOLEAN"
+)
+
+// metadataColumnPrefix is the prefix of the name of promoted metadata columns
+const metadataColumnPrefix = "metadata_"
+
+// MetadataIndex configures how a metadata key of the events is indexed by the SingleStreamStrategy
+type MetadataIndex struct {
+	// Key is the metadata key, for example tenant_id
+	Key string
+	// Kind is the way in which the metadata key is indexed
+	Kind MetadataIndexKind
+	// ColumnType is the type of the column when the key is promoted to a column
+	ColumnType MetadataColumnType
+}
+
+// Column returns the name of the column a promoted metadata key is stored in
+func (i MetadataIndex) Column() string {
+	return metadataColumnPrefix + tableNameInvalidCharRegex.ReplaceAllString(strings.ToLower(i.Key), "")
+}
+
+func (i MetadataIndex) valid() bool {
+	// Aggregate metadata is always stored in it's own column
+	if strings.TrimSpace(i.Key) == "" || strings.HasPrefix(i.Key, "_aggregate_") {
+		return false
+	}
+
+	switch i.Kind {
+	case MetadataExpressionIndex, MetadataGINIndex:
+		return i.ColumnType == ""
+	case MetadataPromotedColumn:
+		switch i.ColumnType {
+		case MetadataText, MetadataUUID, MetadataBigInt, MetadataBoolean:
+			return i.Column() != metadataColumnPrefix
+		}
+	}
+
+	return false
+}
+
+// WithMetadataIndexes indexes the provided metadata keys or promotes them to typed columns, this allows events to be
+// searched efficiently by their metadata
+func WithMetadataIndexes(indexes ...MetadataIndex) Option {
+	return func(s *SingleStreamStrategy) error {
+		if len(indexes) == 0 || !validMetadataIndexes(indexes) {
+			return goengine.InvalidArgumentError("indexes")
+		}
+
+		s.metadataIndexes = append([]MetadataIndex(nil), indexes...)
+		return nil
+	}
+}
+
+// validMetadataIndexes returns true if all indexes are valid and no key or promoted column is indexed twice
+func validMetadataIndexes(indexes []MetadataIndex) bool {
+	keys := make(map[string]bool, len(indexes))
+	columns := make(map[string]bool, len(indexes))
+	for _, index := range indexes {
+		if !index.valid() || keys[index.Key] {
+			return false
+		}
+		keys[index.Key] = true
+
+		if index.Kind != MetadataPromotedColumn {
+			continue
+		}
+		if columns[index.Column()] {
+			return false
+		}
+		columns[index.Column()] = true
+	}
+
+	return true
+}
+
+// metadataIndex returns the index of the metadata key or false when the key is not indexed
+func (s *SingleStreamStrategy) metadataIndex(key string) (MetadataIndex, bool) {
+	for _, index := range s.metadataIndexes {
+		if index.Key == key {
+			return index, true
+		}
+	}
+
+	return MetadataIndex{}, false
+}
+
+// promotedColumns returns the metadata indexes that are promoted to a column
+func (s *SingleStreamStrategy) promotedColumns() []MetadataIndex {
+	var promoted []MetadataIndex
+	for _, index := range s.metadataIndexes {
+		if index.Kind == MetadataPromotedColumn {
+			promoted = append(promoted, index)
+		}
+	}
+
+	return promoted
+}
+
+// sqlMetadataColumns returns the column definitions of the promoted metadata columns
+func (s *SingleStreamStrategy) sqlMetadataColumns() string {
+	columns := ""
+	for _, index := range s.promotedColumns() {
+		columns += fmt.Sprintf("    %s %s,\n", postgres.QuoteIdentifier(index.Column()), index.ColumnType)
+	}
+
+	return columns
+}
+
+// sqlMetadataIndexes returns the statements creating the indexes of the indexed metadata keys
+func (s *SingleStreamStrategy) sqlMetadataIndexes(tableName string) []string {
+	var (
+		statements []string
+		gin        bool
+	)
+
+	/* #nosec G201 */
+	for _, index := range s.metadataIndexes {
+		switch index.Kind {
+		case MetadataExpressionIndex:
+			statements = append(statements, fmt.Sprintf(
+				`CREATE INDEX ON %s ((metadata ->> %s));`,
+				tableName,
+				postgres.QuoteString(index.Key),
+			))
+		case MetadataPromotedColumn:
+			statements = append(statements, fmt.Sprintf(
+				`CREATE INDEX ON %s (%s);`,
+				tableName,
+				postgres.QuoteIdentifier(index.Column()),
+			))
+		case MetadataGINIndex:
+			gin = true
+		}
+	}
+
+	// A single GIN index is used for all keys since it indexes the metadata as a whole
+	if gin {
+		statements = append(statements, fmt.Sprintf(`CREATE INDEX ON %s USING GIN (metadata jsonb_path_ops);`, tableName))
+	}
+
+	return statements
+}
+
+// metadataContains returns the JSON document used to search a GIN indexed metadata key by containment.
+// Containment compares JSON types so only values marshalled to a JSON string are searched by containment, other values
+// are compared as text like keys that are not GIN indexed.
+func metadataContains(key string, value interface{}) (string, bool) {
+	jsonValue, err := json.Marshal(value)
+	if err != nil || len(jsonValue) == 0 || jsonValue[0] != '"' {
+		return "", false
+	}
+
+	contains, err := json.Marshal(map[string]json.RawMessage{key: jsonValue})
+	if err != nil {
+		return "", false
+	}
+
+	return string(contains), true
+}
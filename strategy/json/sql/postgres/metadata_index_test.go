@@ -0,0 +1,242 @@
+// +build unit
+
+package postgres_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/mocks"
+	"github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMetadataIndexes = []postgres.MetadataIndex{
+	{Key: "tenant_id", Kind: postgres.MetadataPromotedColumn, ColumnType: postgres.MetadataUUID},
+	{Key: "correlation_id", Kind: postgres.MetadataExpressionIndex},
+	{Key: "channel", Kind: postgres.MetadataGINIndex},
+}
+
+func TestWithMetadataIndexes(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		testCases := []struct {
+			title       string
+			converter   goengine.MessagePayloadConverter
+			indexes     []postgres.MetadataIndex
+			expectedErr error
+		}{
+			{"nil converter", nil, testMetadataIndexes, goengine.InvalidArgumentError("converter")},
+			{"no indexes", &mocks.MessagePayloadConverter{}, nil, goengine.InvalidArgumentError("indexes")},
+			{
+				"empty key",
+				&mocks.MessagePayloadConverter{},
+				[]postgres.MetadataIndex{{Key: " ", Kind: postgres.MetadataExpressionIndex}},
+				goengine.InvalidArgumentError("indexes"),
+			},
+			{
+				"aggregate key",
+				&mocks.MessagePayloadConverter{},
+				[]postgres.MetadataIndex{{Key: "_aggregate_id", Kind: postgres.MetadataExpressionIndex}},
+				goengine.InvalidArgumentError("indexes"),
+			},
+			{
+				"unknown kind",
+				&mocks.MessagePayloadConverter{},
+				[]postgres.MetadataIndex{{Key: "tenant_id"}},
+				goengine.InvalidArgumentError("indexes"),
+			},
+			{
+				"promoted without column type",
+				&mocks.MessagePayloadConverter{},
+				[]postgres.MetadataIndex{{Key: "tenant_id", Kind: postgres.MetadataPromotedColumn}},
+				goengine.InvalidArgumentError("indexes"),
+			},
+			{
+				"column type without promotion",
+				&mocks.MessagePayloadConverter{},
+				[]postgres.MetadataIndex{{Key: "tenant_id", Kind: postgres.MetadataExpressionIndex, ColumnType: postgres.MetadataText}},
+				goengine.InvalidArgumentError("indexes"),
+			},
+			{
+				"duplicate key",
+				&mocks.MessagePayloadConverter{},
+				[]postgres.MetadataIndex{
+					{Key: "tenant_id", Kind: postgres.MetadataExpressionIndex},
+					{Key: "tenant_id", Kind: postgres.MetadataGINIndex},
+				},
+				goengine.InvalidArgumentError("indexes"),
+			},
+			{
+				"duplicate column",
+				&mocks.MessagePayloadConverter{},
+				[]postgres.MetadataIndex{
+					{Key: "tenant_id", Kind: postgres.MetadataPromotedColumn, ColumnType: postgres.MetadataText},
+					{Key: "Tenant_ID", Kind: postgres.MetadataPromotedColumn, ColumnType: postgres.MetadataText},
+				},
+				goengine.InvalidArgumentError("indexes"),
+			},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				strategy, err := postgres.NewSingleStreamStrategy(testCase.converter, postgres.WithMetadataIndexes(testCase.indexes...))
+
+				assert.Equal(t, testCase.expectedErr, err)
+				assert.Nil(t, strategy)
+			})
+		}
+	})
+
+	t.Run("schema", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithMetadataIndexes(testMetadataIndexes...))
+		require.NoError(t, err)
+
+		cs := strategy.CreateSchema("abc")
+
+		require.Len(t, cs, 6)
+		assert.Contains(t, cs[0], `"metadata_tenant_id" UUID,`)
+		assert.Equal(t, `CREATE INDEX ON "abc" ("metadata_tenant_id");`, cs[3])
+		assert.Equal(t, `CREATE INDEX ON "abc" ((metadata ->> 'correlation_id'));`, cs[4])
+		assert.Equal(t, `CREATE INDEX ON "abc" USING GIN (metadata jsonb_path_ops);`, cs[5])
+	})
+
+	t.Run("insert columns", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithMetadataIndexes(testMetadataIndexes...))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			"event_id", "event_name", "payload", "metadata", "aggregate_type", "aggregate_id", "aggregate_version", "created_at",
+			"metadata_tenant_id",
+		}, strategy.InsertColumnNames())
+	})
+}
+
+func TestSingleStreamStrategy_PrepareDataWithMetadataIndexes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tenantID := goengine.GenerateUUID()
+	withTenant := mocks.NewDummyMessage(
+		goengine.GenerateUUID(),
+		[]byte(`{}`),
+		metadata.FromMap(map[string]interface{}{"tenant_id": tenantID}),
+		time.Now(),
+	)
+	withoutTenant := mocks.NewDummyMessage(goengine.GenerateUUID(), []byte(`{}`), metadata.New(), time.Now())
+
+	pc := mocks.NewMessagePayloadConverter(ctrl)
+	pc.EXPECT().ConvertPayload(gomock.Any()).Return("payload", []byte(`{}`), nil).Times(2)
+
+	strategy, err := postgres.NewSingleStreamStrategy(pc, postgres.WithMetadataIndexes(testMetadataIndexes...))
+	require.NoError(t, err)
+
+	data, err := strategy.PrepareData([]goengine.Message{withTenant, withoutTenant})
+
+	require.NoError(t, err)
+	require.Len(t, data, 18)
+	assert.Equal(t, tenantID, data[8])
+	assert.Nil(t, data[17])
+}
+
+func TestSingleStreamStrategy_PrepareSearchWithMetadataIndexes(t *testing.T) {
+	strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithMetadataIndexes(testMetadataIndexes...))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		title          string
+		field          string
+		operator       metadata.Operator
+		value          interface{}
+		expectedQuery  string
+		expectedParams []interface{}
+	}{
+		{
+			"aggregate column",
+			"_aggregate_type",
+			metadata.Equals,
+			"order",
+			" AND aggregate_type = $2",
+			[]interface{}{"order"},
+		},
+		{
+			"promoted column",
+			"tenant_id",
+			metadata.Equals,
+			"b2f5ff47-4361-4e2c-a6a9-5f7e5a2b8a1c",
+			` AND "metadata_tenant_id" = $2`,
+			[]interface{}{"b2f5ff47-4361-4e2c-a6a9-5f7e5a2b8a1c"},
+		},
+		{
+			"expression index",
+			"correlation_id",
+			metadata.Equals,
+			"abc",
+			" AND metadata ->> 'correlation_id' = $2",
+			[]interface{}{"abc"},
+		},
+		{
+			"gin index equality",
+			"channel",
+			metadata.Equals,
+			"web",
+			" AND metadata @> $2::JSONB",
+			[]interface{}{`{"channel":"web"}`},
+		},
+		{
+			"gin index equality of a uuid",
+			"channel",
+			metadata.Equals,
+			uuid.Must(uuid.Parse("b2f5ff47-4361-4e2c-a6a9-5f7e5a2b8a1c")),
+			" AND metadata @> $2::JSONB",
+			[]interface{}{`{"channel":"b2f5ff47-4361-4e2c-a6a9-5f7e5a2b8a1c"}`},
+		},
+		{
+			"gin index equality of a number is compared as text",
+			"channel",
+			metadata.Equals,
+			5,
+			" AND metadata ->> 'channel' = $2",
+			[]interface{}{5},
+		},
+		{
+			"gin index equality of a boolean is compared as text",
+			"channel",
+			metadata.Equals,
+			true,
+			" AND metadata ->> 'channel' = $2",
+			[]interface{}{true},
+		},
+		{
+			"gin index inequality",
+			"channel",
+			metadata.NotEquals,
+			"web",
+			" AND metadata ->> 'channel' != $2",
+			[]interface{}{"web"},
+		},
+		{
+			"not indexed",
+			"user_id",
+			metadata.GreaterThan,
+			1,
+			" AND metadata ->> 'user_id' > $2",
+			[]interface{}{1},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			matcher := metadata.WithConstraint(metadata.NewMatcher(), testCase.field, testCase.operator, testCase.value)
+
+			query, params := strategy.PrepareSearch(matcher)
+
+			assert.Equal(t, testCase.expectedQuery, string(query))
+			assert.Equal(t, testCase.expectedParams, params)
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+)
+
+// DefaultMigrationTable is the table used by the SingleStreamManager to record the applied migrations
+const DefaultMigrationTable = "goengine_migrations"
+
+// ErrInvalidMigrations occurs when the migrations are not ordered by a unique positive version
+var ErrInvalidMigrations = errors.New("goengine: migrations must be ordered by a unique positive version")
+
+// Migration is a versioned change to the schema of a existing table
+type Migration struct {
+	// Version is the version of the table after the migration is applied
+	Version int
+	// Description describes the migration and is recorded when the migration is applied
+	Description string
+	// Statements returns the SQL statements migrating the table
+	Statements func(tableName string) []string
+}
+
+// EventStreamMigrations are the migrations of the event stream tables created by the SingleStreamStrategy
+var EventStreamMigrations = []Migration{
+	{
+		Version:     1,
+		Description: "widen aggregate_version to INTEGER",
+		Statements: func(tableName string) []string {
+			/* #nosec G201 */
+			return []string{
+				fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN aggregate_version TYPE INTEGER`, postgres.QuoteTableName(tableName)),
+			}
+		},
+	},
+}
+
+// StreamProjectionMigrations are the migrations of the tables created by StreamProjectorCreateSchema
+var StreamProjectionMigrations = []Migration{
+	{
+		Version:     1,
+		Description: "add the locked column",
+		Statements: func(tableName string) []string {
+			/* #nosec G201 */
+			return []string{
+				fmt.Sprintf(
+					`ALTER TABLE %s ADD COLUMN IF NOT EXISTS locked BOOLEAN NOT NULL DEFAULT (FALSE)`,
+					postgres.QuoteTableName(tableName),
+				),
+			}
+		},
+	},
+}
+
+// AggregateProjectionMigrations are the migrations of the tables created by AggregateProjectorCreateSchema
+var AggregateProjectionMigrations = []Migration{
+	{
+		Version:     1,
+		Description: "add the locked and failed columns",
+		Statements: func(tableName string) []string {
+			/* #nosec G201 */
+			return []string{
+				fmt.Sprintf(
+					`ALTER TABLE %s
+    ADD COLUMN IF NOT EXISTS locked BOOLEAN NOT NULL DEFAULT (FALSE),
+    ADD COLUMN IF NOT EXISTS failed BOOLEAN NOT NULL DEFAULT (FALSE)`,
+					postgres.QuoteTableName(tableName),
+				),
+			}
+		},
+	},
+}
+
+// Migrator applies migrations to existing tables and records the applied migrations in the migration table
+type Migrator struct {
+	db *sql.DB
+
+	queryCreateTable     string
+	queryLock            string
+	queryAppliedVersion  string
+	queryRecord          string
+	queryAggregateIDType string
+
+	logger goengine.Logger
+}
+
+// NewMigrator returns a new Migrator recording the applied migrations in the migration table
+func NewMigrator(db *sql.DB, migrationTable string, logger goengine.Logger) (*Migrator, error) {
+	switch {
+	case db == nil:
+		return nil, goengine.InvalidArgumentError("db")
+	case strings.TrimSpace(migrationTable) == "":
+		return nil, goengine.InvalidArgumentError("migrationTable")
+	}
+
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+
+	migrationTableQuoted := postgres.QuoteTableName(migrationTable)
+
+	/* #nosec G201 */
+	return &Migrator{
+		db: db,
+
+		queryCreateTable: MigrationCreateSchema(migrationTable)[0],
+		queryLock:        fmt.Sprintf(`LOCK TABLE %s IN SHARE ROW EXCLUSIVE MODE`, migrationTableQuoted),
+		queryAppliedVersion: fmt.Sprintf(
+			`SELECT COALESCE(MAX(version), 0) FROM %s WHERE table_name = $1`,
+			migrationTableQuoted,
+		),
+		queryRecord: fmt.Sprintf(
+			`INSERT INTO %s (table_name, version, description) VALUES ($1, $2, $3)`,
+			migrationTableQuoted,
+		),
+		queryAggregateIDType: `SELECT UPPER(format_type(atttypid, atttypmod)) FROM pg_attribute
+WHERE attrelid = $1::regclass AND attname = 'aggregate_id' AND NOT attisdropped`,
+
+		logger: logger,
+	}, nil
+}
+
+// Migrate applies the migrations that where not yet applied to the table and returns the applied migrations.
+// The migrations are applied within a single transaction so either all or none of the migrations are applied.
+func (m *Migrator) Migrate(ctx context.Context, tableName string, migrations []Migration) ([]Migration, error) {
+	if strings.TrimSpace(tableName) == "" {
+		return nil, goengine.InvalidArgumentError("tableName")
+	}
+
+	for i, migration := range migrations {
+		if migration.Version <= 0 || (i > 0 && migration.Version <= migrations[i-1].Version) {
+			return nil, ErrInvalidMigrations
+		}
+	}
+
+	if _, err := m.db.ExecContext(ctx, m.queryCreateTable); err != nil {
+		return nil, err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.migrate(ctx, tx, tableName, migrations)
+	if err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			m.logger.Error("could not rollback transaction", func(e goengine.LoggerEntry) {
+				e.Error(errRollback)
+				e.String("table", tableName)
+			})
+		}
+
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) migrate(ctx context.Context, tx *sql.Tx, tableName string, migrations []Migration) ([]Migration, error) {
+	// Lock the migration table to ensure a migration is only applied once
+	if _, err := tx.ExecContext(ctx, m.queryLock); err != nil {
+		return nil, err
+	}
+
+	var version int
+	if err := tx.QueryRowContext(ctx, m.queryAppliedVersion, tableName).Scan(&version); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			continue
+		}
+
+		for _, statement := range migration.Statements(tableName) {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, m.queryRecord, tableName, migration.Version, migration.Description); err != nil {
+			return nil, err
+		}
+
+		m.logger.Info("applied migration", func(e goengine.LoggerEntry) {
+			e.String("table", tableName)
+			e.Int("version", migration.Version)
+			e.String("description", migration.Description)
+		})
+
+		applied = append(applied, migration)
+	}
+
+	return applied, nil
+}
+
+// ChangeAggregateIDType changes the type of the aggregate_id column of the event stream and aggregate projection
+// tables within a single transaction and returns the tables that were changed.
+// The existing aggregate ids are converted using their text representation, so changing the type to BIGINT requires
+// all aggregate ids to be integers. Tables of which the aggregate id already has the type are left as is.
+func (m *Migrator) ChangeAggregateIDType(ctx context.Context, aggregateIDType AggregateIDType, tableNames ...string) ([]string, error) {
+	if !aggregateIDType.valid() {
+		return nil, goengine.InvalidArgumentError("aggregateIDType")
+	}
+	for _, tableName := range tableNames {
+		if strings.TrimSpace(tableName) == "" {
+			return nil, goengine.InvalidArgumentError("tableNames")
+		}
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := m.changeAggregateIDType(ctx, tx, aggregateIDType, tableNames)
+	if err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			m.logger.Error("could not rollback transaction", func(e goengine.LoggerEntry) {
+				e.Error(errRollback)
+			})
+		}
+
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+func (m *Migrator) changeAggregateIDType(ctx context.Context, tx *sql.Tx, aggregateIDType AggregateIDType, tableNames []string) ([]string, error) {
+	var changed []string
+	for _, tableName := range tableNames {
+		quotedTableName := postgres.QuoteTableName(tableName)
+
+		var currentType string
+		if err := tx.QueryRowContext(ctx, m.queryAggregateIDType, quotedTableName).Scan(&currentType); err != nil {
+			return nil, err
+		}
+
+		if AggregateIDType(currentType) == aggregateIDType {
+			continue
+		}
+
+		/* #nosec G201 */
+		statement := fmt.Sprintf(
+			`ALTER TABLE %[1]s ALTER COLUMN aggregate_id TYPE %[2]s USING aggregate_id::TEXT::%[2]s`,
+			quotedTableName,
+			aggregateIDType,
+		)
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return nil, err
+		}
+
+		m.logger.Info("changed aggregate id type", func(e goengine.LoggerEntry) {
+			e.String("table", tableName)
+			e.String("from", currentType)
+			e.String("to", string(aggregateIDType))
+		})
+
+		changed = append(changed, tableName)
+	}
+
+	return changed, nil
+}
+
+// MigrationCreateSchema return the sql statements needed for the postgres database in order to record the applied
+// migrations
+func MigrationCreateSchema(migrationTable string) []string {
+	/* #nosec G201 */
+	return []string{
+		fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (
+				table_name VARCHAR(150) NOT NULL,
+				version INTEGER NOT NULL,
+				description TEXT NOT NULL,
+				applied_at TIMESTAMP(6) NOT NULL DEFAULT (now() AT TIME ZONE 'UTC'),
+				PRIMARY KEY (table_name, version)
+			)`,
+			postgres.QuoteTableName(migrationTable),
+		),
+	}
+}
@@ -0,0 +1,223 @@
+// +build unit
+
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMigrations = []postgres.Migration{
+	{
+		Version:     1,
+		Description: "first",
+		Statements: func(tableName string) []string {
+			return []string{"ALTER TABLE " + tableName + " ADD COLUMN first INTEGER"}
+		},
+	},
+	{
+		Version:     2,
+		Description: "second",
+		Statements: func(tableName string) []string {
+			return []string{"ALTER TABLE " + tableName + " ADD COLUMN second INTEGER"}
+		},
+	},
+}
+
+func TestNewMigrator(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	testCases := []struct {
+		title          string
+		db             *sql.DB
+		migrationTable string
+		expectedErr    error
+	}{
+		{"nil db", nil, "migrations", goengine.InvalidArgumentError("db")},
+		{"empty migration table", db, " ", goengine.InvalidArgumentError("migrationTable")},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			migrator, err := postgres.NewMigrator(testCase.db, testCase.migrationTable, nil)
+
+			assert.Equal(t, testCase.expectedErr, err)
+			assert.Nil(t, migrator)
+		})
+	}
+}
+
+func TestMigrator_Migrate(t *testing.T) {
+	t.Run("apply pending migrations", func(t *testing.T) {
+		migrator, dbMock, stop := newMigrator(t)
+		defer stop()
+
+		dbMock.ExpectExec(`CREATE TABLE IF NOT EXISTS "migrations"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec(`LOCK TABLE "migrations" IN SHARE ROW EXCLUSIVE MODE`).WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectQuery(`SELECT COALESCE\(MAX\(version\), 0\) FROM "migrations" WHERE table_name = \$1`).
+			WithArgs("events_orders").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+		dbMock.ExpectExec(`ALTER TABLE events_orders ADD COLUMN second INTEGER`).WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec(`INSERT INTO "migrations" \(table_name, version, description\) VALUES \(\$1, \$2, \$3\)`).
+			WithArgs("events_orders", 2, "second").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectCommit()
+
+		applied, err := migrator.Migrate(context.Background(), "events_orders", testMigrations)
+
+		assert.NoError(t, err)
+		assert.Len(t, applied, 1)
+		assert.Equal(t, 2, applied[0].Version)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("rollback on failure", func(t *testing.T) {
+		migrator, dbMock, stop := newMigrator(t)
+		defer stop()
+
+		expectedErr := errors.New("column already exists")
+
+		dbMock.ExpectExec(`CREATE TABLE IF NOT EXISTS "migrations"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec(`LOCK TABLE`).WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectQuery(`SELECT COALESCE`).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(0))
+		dbMock.ExpectExec(`ALTER TABLE events_orders ADD COLUMN first INTEGER`).WillReturnError(expectedErr)
+		dbMock.ExpectRollback()
+
+		applied, err := migrator.Migrate(context.Background(), "events_orders", testMigrations)
+
+		assert.Equal(t, expectedErr, err)
+		assert.Nil(t, applied)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		migrator, _, stop := newMigrator(t)
+		defer stop()
+
+		testCases := []struct {
+			title       string
+			tableName   string
+			migrations  []postgres.Migration
+			expectedErr error
+		}{
+			{"empty table name", "", testMigrations, goengine.InvalidArgumentError("tableName")},
+			{"unordered migrations", "events_orders", []postgres.Migration{testMigrations[1], testMigrations[0]}, postgres.ErrInvalidMigrations},
+			{"duplicate version", "events_orders", []postgres.Migration{testMigrations[0], testMigrations[0]}, postgres.ErrInvalidMigrations},
+			{"zero version", "events_orders", []postgres.Migration{{Description: "zero"}}, postgres.ErrInvalidMigrations},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				applied, err := migrator.Migrate(context.Background(), testCase.tableName, testCase.migrations)
+
+				assert.Equal(t, testCase.expectedErr, err)
+				assert.Nil(t, applied)
+			})
+		}
+	})
+}
+
+func TestMigrator_ChangeAggregateIDType(t *testing.T) {
+	t.Run("change the tables with another type", func(t *testing.T) {
+		migrator, dbMock, stop := newMigrator(t)
+		defer stop()
+
+		dbMock.ExpectBegin()
+		dbMock.ExpectQuery(`SELECT UPPER\(format_type\(atttypid, atttypmod\)\) FROM pg_attribute`).
+			WithArgs(`"billing"."events_orders"`).
+			WillReturnRows(sqlmock.NewRows([]string{"type"}).AddRow("UUID"))
+		dbMock.ExpectExec(`ALTER TABLE "billing"."events_orders" ALTER COLUMN aggregate_id TYPE TEXT USING aggregate_id::TEXT::TEXT`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectQuery(`SELECT UPPER`).
+			WithArgs(`"order_projection"`).
+			WillReturnRows(sqlmock.NewRows([]string{"type"}).AddRow("TEXT"))
+		dbMock.ExpectCommit()
+
+		changed, err := migrator.ChangeAggregateIDType(context.Background(), postgres.AggregateIDText, "billing.events_orders", "order_projection")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"billing.events_orders"}, changed)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("rollback on failure", func(t *testing.T) {
+		migrator, dbMock, stop := newMigrator(t)
+		defer stop()
+
+		expectedErr := errors.New("invalid input syntax for type bigint")
+
+		dbMock.ExpectBegin()
+		dbMock.ExpectQuery(`SELECT UPPER`).WillReturnRows(sqlmock.NewRows([]string{"type"}).AddRow("UUID"))
+		dbMock.ExpectExec(`ALTER TABLE "events_orders" ALTER COLUMN aggregate_id TYPE BIGINT`).WillReturnError(expectedErr)
+		dbMock.ExpectRollback()
+
+		changed, err := migrator.ChangeAggregateIDType(context.Background(), postgres.AggregateIDBigInt, "events_orders")
+
+		assert.Equal(t, expectedErr, err)
+		assert.Nil(t, changed)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		migrator, _, stop := newMigrator(t)
+		defer stop()
+
+		changed, err := migrator.ChangeAggregateIDType(context.Background(), "VARCHAR", "events_orders")
+		assert.Equal(t, goengine.InvalidArgumentError("aggregateIDType"), err)
+		assert.Nil(t, changed)
+
+		changed, err = migrator.ChangeAggregateIDType(context.Background(), postgres.AggregateIDText, "events_orders", " ")
+		assert.Equal(t, goengine.InvalidArgumentError("tableNames"), err)
+		assert.Nil(t, changed)
+	})
+}
+
+func TestMigrations(t *testing.T) {
+	testCases := []struct {
+		title      string
+		migrations []postgres.Migration
+	}{
+		{"event stream", postgres.EventStreamMigrations},
+		{"stream projection", postgres.StreamProjectionMigrations},
+		{"aggregate projection", postgres.AggregateProjectionMigrations},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			require.NotEmpty(t, testCase.migrations)
+
+			for i, migration := range testCase.migrations {
+				assert.Equal(t, i+1, migration.Version)
+				assert.NotEmpty(t, migration.Description)
+				assert.NotEmpty(t, migration.Statements("billing.orders"))
+			}
+		})
+	}
+
+	assert.Contains(t, postgres.StreamProjectionMigrations[0].Statements("billing.orders")[0], `ALTER TABLE "billing"."orders" ADD COLUMN IF NOT EXISTS locked`)
+	assert.Contains(t, postgres.AggregateProjectionMigrations[0].Statements("orders")[0], "ADD COLUMN IF NOT EXISTS failed BOOLEAN NOT NULL DEFAULT (FALSE)")
+}
+
+func newMigrator(t *testing.T) (*postgres.Migrator, sqlmock.Sqlmock, func()) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	migrator, err := postgres.NewMigrator(db, "migrations", nil)
+	require.NoError(t, err)
+
+	return migrator, dbMock, func() {
+		_ = db.Close()
+	}
+}
@@ -0,0 +1,262 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/sql"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+)
+
+// PartitionKey is the column by which the event stream table is partitioned
+type PartitionKey int
+
+const (
+	// PartitionByNumber partitions the event stream table by ranges of the event number
+	PartitionByNumber PartitionKey = iota + 1
+	// PartitionByMonth partitions the event stream table by the month in which the events where created
+	PartitionByMonth
+)
+
+// ErrPartitioningNotEnabled occurs when partitions are created using a strategy that does not partition the event stream
+var ErrPartitioningNotEnabled = errors.New("goengine: the persistence strategy does not partition event streams")
+
+// Partitioning configures how the event stream tables are partitioned
+type Partitioning struct {
+	// Key is the column by which the table is partitioned
+	Key PartitionKey
+	// Size is the number of events per partition when partitioning by number
+	Size int64
+	// Premake is the number of upcoming partitions that are created ahead of the current partition
+	Premake int
+}
+
+func (p *Partitioning) valid() bool {
+	switch p.Key {
+	case PartitionByNumber:
+		return p.Size > 0 && p.Premake >= 0
+	case PartitionByMonth:
+		return p.Size == 0 && p.Premake >= 0
+	}
+
+	return false
+}
+
+// WithPartitioning creates the event stream tables as partitioned tables, the upcoming partitions can be created using
+// CreatePartitions or MaintainPartitions.
+// Partitioned tables require postgres 13 or later.
+func WithPartitioning(partitioning Partitioning) Option {
+	return func(s *SingleStreamStrategy) error {
+		if !partitioning.valid() {
+			return goengine.InvalidArgumentError("partitioning")
+		}
+
+		s.partitioning = &partitioning
+		return nil
+	}
+}
+
+// CreatePartitions creates the current and upcoming partitions of the event stream table when they do not exist.
+// This needs to be done regularly to ensure appended events are not stored in the default partition.
+// A partition can not be created while the default partition contains events in it's range, the names of these
+// partitions are returned so the events can be moved out of the default partition.
+func (s *SingleStreamStrategy) CreatePartitions(ctx context.Context, db sql.Queryer, streamName goengine.StreamName) ([]string, error) {
+	if s.partitioning == nil {
+		return nil, ErrPartitioningNotEnabled
+	}
+
+	tableName, err := s.GenerateTableName(streamName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, sqlCreatePartitions(tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var skipped []string
+	for rows.Next() {
+		var partition string
+		if err := rows.Scan(&partition); err != nil {
+			return nil, err
+		}
+
+		skipped = append(skipped, partition)
+	}
+
+	return skipped, rows.Err()
+}
+
+// MaintainPartitions creates the current and upcoming partitions of the event stream table every interval until the
+// context is done.
+// Errors and partitions that could not be created because of events in the default partition are logged and the
+// partitions are created during the next run.
+func (s *SingleStreamStrategy) MaintainPartitions(
+	ctx context.Context,
+	db sql.Queryer,
+	streamName goengine.StreamName,
+	interval time.Duration,
+	logger goengine.Logger,
+) error {
+	switch {
+	case s.partitioning == nil:
+		return ErrPartitioningNotEnabled
+	case interval <= 0:
+		return goengine.InvalidArgumentError("interval")
+	}
+	if logger == nil {
+		logger = goengine.NopLogger
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		skipped, err := s.CreatePartitions(ctx, db, streamName)
+		if err != nil {
+			logger.Error("failed to create partitions", func(e goengine.LoggerEntry) {
+				e.Error(err)
+				e.String("stream", string(streamName))
+			})
+		}
+		for _, partition := range skipped {
+			logger.Warn("partition not created, the default partition contains events in it's range", func(e goengine.LoggerEntry) {
+				e.String("stream", string(streamName))
+				e.String("partition", partition)
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sqlCreatePartitions returns the statement calling the function created by sqlPartitionFunctions that creates the
+// current and upcoming partitions
+func sqlCreatePartitions(tableName string) string {
+	return fmt.Sprintf(`SELECT %s();`, postgres.QuoteTableName(tableName+"_create_partitions"))
+}
+
+// sqlPartitionFunctions returns the statements creating the default partition, the trigger enforcing the uniqueness
+// of events and the function creating partitions of a partitioned event stream table.
+// The function returns the names of the partitions that could not be created because the default partition contains
+// events in their range, the other partitions are still created.
+func sqlPartitionFunctions(tableName string, partitioning *Partitioning) []string {
+	schema, table := postgres.SplitTableName(tableName)
+	quotedTableName := postgres.QuoteTableName(tableName)
+	uniqueFuncName := postgres.QuoteTableName(tableName + "_unique")
+	partitionsFuncName := postgres.QuoteTableName(tableName + "_create_partitions")
+
+	// Partitions are created in the schema of the partitioned table
+	partitionPrefix := ""
+	if schema != "" {
+		partitionPrefix = postgres.QuoteString(postgres.QuoteIdentifier(schema)+".") + " || "
+	}
+
+	// The partition, it's name and the range it contains
+	partition := fmt.Sprintf(
+		`partition_from := ((current_no - 1) / %[1]d + i) * %[1]d + 1;
+        partition_to := partition_from + %[1]d;
+        partition_name := %[2]s || '_p' || ((current_no - 1) / %[1]d + i);`,
+		partitioning.Size,
+		postgres.QuoteString(table),
+	)
+	current := fmt.Sprintf(
+		`EXECUTE 'SELECT CASE WHEN is_called THEN last_value ELSE last_value - 1 END FROM ' || pg_get_serial_sequence(%s, 'no') INTO current_no;
+    current_no := GREATEST(current_no, 1);`,
+		postgres.QuoteString(quotedTableName),
+	)
+	rangeType, currentVar := "BIGINT", "current_no"
+	if partitioning.Key == PartitionByMonth {
+		rangeType, currentVar = "TIMESTAMP", "current_month"
+		partition = fmt.Sprintf(
+			`partition_from := current_month + make_interval(months => i);
+        partition_to := partition_from + INTERVAL '1 month';
+        partition_name := %s || to_char(partition_from, '"_y"YYYY"m"MM');`,
+			postgres.QuoteString(table),
+		)
+		current = `current_month := date_trunc('month', now() AT TIME ZONE 'UTC');`
+	}
+
+	/* #nosec G201 */
+	return []string{
+		fmt.Sprintf(
+			`CREATE TABLE %s PARTITION OF %s DEFAULT;`,
+			postgres.QuoteTableName(tableName+"_default"),
+			quotedTableName,
+		),
+		// Unique constraints of a partitioned table must include the partition key so the uniqueness of the event id
+		// and the aggregate version is enforced by locking the aggregate before checking for existing events
+		fmt.Sprintf(
+			`CREATE FUNCTION %[1]s()
+  RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(%[2]s::regclass::int, hashtext(NEW.aggregate_type || '/' || NEW.aggregate_id::text));
+    IF EXISTS(
+        SELECT 1 FROM %[3]s
+        WHERE aggregate_type = NEW.aggregate_type AND aggregate_id = NEW.aggregate_id AND aggregate_version = NEW.aggregate_version
+    ) THEN
+        RAISE EXCEPTION 'duplicate aggregate version %% of %% %%', NEW.aggregate_version, NEW.aggregate_type, NEW.aggregate_id
+            USING ERRCODE = 'unique_violation';
+    END IF;
+    IF EXISTS(SELECT 1 FROM %[3]s WHERE event_id = NEW.event_id) THEN
+        RAISE EXCEPTION 'duplicate event id %%', NEW.event_id USING ERRCODE = 'unique_violation';
+    END IF;
+    RETURN NEW;
+END;
+$$;`,
+			uniqueFuncName,
+			postgres.QuoteString(quotedTableName),
+			quotedTableName,
+		),
+		fmt.Sprintf(
+			`CREATE TRIGGER %s BEFORE INSERT ON %s FOR EACH ROW EXECUTE PROCEDURE %s();`,
+			postgres.QuoteIdentifier(table+"_unique"),
+			quotedTableName,
+			uniqueFuncName,
+		),
+		fmt.Sprintf(
+			`CREATE FUNCTION %[1]s()
+  RETURNS SETOF TEXT
+LANGUAGE plpgsql AS $$
+DECLARE
+  %[8]s %[2]s;
+  partition_from %[2]s;
+  partition_to %[2]s;
+  partition_name TEXT;
+BEGIN
+    PERFORM pg_advisory_xact_lock(%[3]s::regclass::int, 1);
+    %[4]s
+    FOR i IN 0..%[5]d LOOP
+        %[6]s
+        BEGIN
+            EXECUTE 'CREATE TABLE IF NOT EXISTS ' || %[7]squote_ident(partition_name) || ' PARTITION OF ' || %[3]s ||
+                ' FOR VALUES FROM (' || quote_literal(partition_from) || ') TO (' || quote_literal(partition_to) || ')';
+        EXCEPTION WHEN check_violation THEN
+            RETURN NEXT partition_name;
+        END;
+    END LOOP;
+    RETURN;
+END;
+$$;`,
+			partitionsFuncName,
+			rangeType,
+			postgres.QuoteString(quotedTableName),
+			current,
+			partitioning.Premake,
+			partition,
+			partitionPrefix,
+			currentVar,
+		),
+		sqlCreatePartitions(tableName),
+	}
+}
@@ -0,0 +1,204 @@
+// +build unit
+
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/hellofresh/goengine"
+	logrusExtension "github.com/hellofresh/goengine/extension/logrus"
+	"github.com/hellofresh/goengine/mocks"
+	"github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPartitioning(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		testCases := []struct {
+			title        string
+			converter    goengine.MessagePayloadConverter
+			partitioning postgres.Partitioning
+			expectedErr  error
+		}{
+			{"nil converter", nil, postgres.Partitioning{Key: postgres.PartitionByMonth}, goengine.InvalidArgumentError("converter")},
+			{"no key", &mocks.MessagePayloadConverter{}, postgres.Partitioning{}, goengine.InvalidArgumentError("partitioning")},
+			{"no size", &mocks.MessagePayloadConverter{}, postgres.Partitioning{Key: postgres.PartitionByNumber}, goengine.InvalidArgumentError("partitioning")},
+			{"size by month", &mocks.MessagePayloadConverter{}, postgres.Partitioning{Key: postgres.PartitionByMonth, Size: 10}, goengine.InvalidArgumentError("partitioning")},
+			{"negative premake", &mocks.MessagePayloadConverter{}, postgres.Partitioning{Key: postgres.PartitionByMonth, Premake: -1}, goengine.InvalidArgumentError("partitioning")},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				strategy, err := postgres.NewSingleStreamStrategy(testCase.converter, postgres.WithPartitioning(testCase.partitioning))
+
+				assert.Equal(t, testCase.expectedErr, err)
+				assert.Nil(t, strategy)
+			})
+		}
+	})
+
+	t.Run("schema partitioned by number", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(
+			&mocks.MessagePayloadConverter{},
+			postgres.WithPartitioning(postgres.Partitioning{Key: postgres.PartitionByNumber, Size: 1000000, Premake: 2}),
+		)
+		require.NoError(t, err)
+
+		cs := strategy.CreateSchema("events_orders")
+
+		require.Len(t, cs, 9)
+		assert.Contains(t, cs[0], "PRIMARY KEY (no)\n) PARTITION BY RANGE (no);")
+		assert.NotContains(t, cs[0], "UNIQUE (event_id)")
+		assert.Equal(t, `CREATE INDEX ON "events_orders" (aggregate_type, aggregate_id, aggregate_version);`, cs[1])
+		assert.Equal(t, `CREATE INDEX ON "events_orders" (event_id);`, cs[3])
+		assert.Equal(t, `CREATE TABLE "events_orders_default" PARTITION OF "events_orders" DEFAULT;`, cs[4])
+		assert.Contains(t, cs[5], `CREATE FUNCTION "events_orders_unique"()`)
+		assert.Contains(t, cs[5], "USING ERRCODE = 'unique_violation'")
+		assert.Equal(t, `CREATE TRIGGER "events_orders_unique" BEFORE INSERT ON "events_orders" FOR EACH ROW EXECUTE PROCEDURE "events_orders_unique"();`, cs[6])
+		assert.Contains(t, cs[7], `CREATE FUNCTION "events_orders_create_partitions"()`)
+		assert.Contains(t, cs[7], "FOR i IN 0..2 LOOP")
+		assert.Contains(t, cs[7], "pg_get_serial_sequence('\"events_orders\"', 'no')")
+		assert.Contains(t, cs[7], "'events_orders' || '_p' ||")
+		assert.Contains(t, cs[7], "RETURNS SETOF TEXT")
+		assert.Contains(t, cs[7], "EXCEPTION WHEN check_violation THEN\n            RETURN NEXT partition_name;")
+		assert.Equal(t, `SELECT "events_orders_create_partitions"();`, cs[8])
+	})
+
+	t.Run("schema partitioned by month", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(
+			&mocks.MessagePayloadConverter{},
+			postgres.WithPartitioning(postgres.Partitioning{Key: postgres.PartitionByMonth, Premake: 3}),
+		)
+		require.NoError(t, err)
+
+		cs := strategy.CreateSchema("billing.events_orders")
+
+		require.Len(t, cs, 9)
+		assert.Contains(t, cs[0], "PRIMARY KEY (no, created_at)\n) PARTITION BY RANGE (created_at);")
+		assert.Equal(t, `CREATE TABLE "billing"."events_orders_default" PARTITION OF "billing"."events_orders" DEFAULT;`, cs[4])
+		assert.Contains(t, cs[6], `CREATE TRIGGER "events_orders_unique" BEFORE INSERT ON "billing"."events_orders"`)
+		assert.Contains(t, cs[7], "current_month TIMESTAMP;")
+		assert.Contains(t, cs[7], "date_trunc('month', now() AT TIME ZONE 'UTC')")
+		assert.Contains(t, cs[7], `'"billing".' || quote_ident(partition_name)`)
+	})
+}
+
+func TestSingleStreamStrategy_CreatePartitions(t *testing.T) {
+	t.Run("create partitions", func(t *testing.T) {
+		db, dbMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		strategy := newPartitionedStrategy(t)
+
+		dbMock.ExpectQuery(`SELECT "events_orders_create_partitions"\(\);`).
+			WillReturnRows(sqlmock.NewRows([]string{"events_orders_create_partitions"}))
+
+		skipped, err := strategy.CreatePartitions(context.Background(), db, "orders")
+
+		assert.NoError(t, err)
+		assert.Empty(t, skipped)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("partitions overlapping the default partition", func(t *testing.T) {
+		db, dbMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		strategy := newPartitionedStrategy(t)
+
+		dbMock.ExpectQuery(`SELECT "events_orders_create_partitions"\(\);`).
+			WillReturnRows(sqlmock.NewRows([]string{"events_orders_create_partitions"}).AddRow("events_orders_p1"))
+
+		skipped, err := strategy.CreatePartitions(context.Background(), db, "orders")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"events_orders_p1"}, skipped)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("strategy without partitioning", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{})
+		require.NoError(t, err)
+
+		skipped, err := strategy.(*postgres.SingleStreamStrategy).CreatePartitions(context.Background(), nil, "orders")
+
+		assert.Equal(t, postgres.ErrPartitioningNotEnabled, err)
+		assert.Nil(t, skipped)
+	})
+}
+
+func TestSingleStreamStrategy_MaintainPartitions(t *testing.T) {
+	t.Run("create partitions until the context is done", func(t *testing.T) {
+		strategy := newPartitionedStrategy(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sqlDB, dbMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer sqlDB.Close()
+
+		dbMock.ExpectQuery(`SELECT "events_orders_create_partitions"\(\);`).WillReturnError(errors.New("lock timeout"))
+		dbMock.ExpectQuery(`SELECT "events_orders_create_partitions"\(\);`).
+			WillReturnRows(sqlmock.NewRows([]string{"events_orders_create_partitions"}).AddRow("events_orders_p1"))
+
+		logrusLogger, logObserver := test.NewNullLogger()
+		db := &partitionQueryer{db: sqlDB, remaining: 2, done: cancel}
+
+		err = strategy.MaintainPartitions(ctx, db, "orders", time.Millisecond, logrusExtension.Wrap(logrusLogger))
+
+		assert.NoError(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+
+		entries := logObserver.AllEntries()
+		require.Len(t, entries, 2)
+		assert.Equal(t, "failed to create partitions", entries[0].Message)
+		assert.Equal(t, logrus.WarnLevel, entries[1].Level)
+		assert.Equal(t, "events_orders_p1", entries[1].Data["partition"])
+	})
+
+	t.Run("invalid interval", func(t *testing.T) {
+		strategy := newPartitionedStrategy(t)
+
+		err := strategy.MaintainPartitions(context.Background(), nil, "orders", 0, nil)
+
+		assert.Equal(t, goengine.InvalidArgumentError("interval"), err)
+	})
+}
+
+// partitionQueryer queries the db and calls done after the remaining number of queries.
+// The queries do not use the provided context so the last result can be read after done is called.
+type partitionQueryer struct {
+	db        *sql.DB
+	remaining int
+	done      func()
+}
+
+func (q *partitionQueryer) QueryContext(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	q.remaining--
+	if q.remaining == 0 {
+		q.done()
+	}
+
+	return q.db.QueryContext(context.Background(), query, args...)
+}
+
+func newPartitionedStrategy(t *testing.T) *postgres.SingleStreamStrategy {
+	strategy, err := postgres.NewSingleStreamStrategy(
+		&mocks.MessagePayloadConverter{},
+		postgres.WithPartitioning(postgres.Partitioning{Key: postgres.PartitionByNumber, Size: 1000}),
+	)
+	require.NoError(t, err)
+
+	return strategy.(*postgres.SingleStreamStrategy)
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import "github.com/hellofresh/goengine"
+
+// PayloadColumnType is the postgres column type used to store the payload
+type PayloadColumnType string
+
+const (
+	// PayloadJSON stores payloads as JSON, this is the default and requires the converter to encode payloads as JSON
+	PayloadJSON PayloadColumnType = "JSON"
+	// PayloadBytea stores payloads as BYTEA which allows payloads of any encoding, for example protocol buffers
+	PayloadBytea PayloadColumnType = "BYTEA"
+)
+
+func (t PayloadColumnType) valid() bool {
+	switch t {
+	case PayloadJSON, PayloadBytea:
+		return true
+	}
+
+	return false
+}
+
+// WithPayloadColumnType stores the payload in a column of the provided type.
+// The payloads are stored as encoded by the converter so a converter that does not encode payloads as JSON, like the
+// protobuf.PayloadTransformer, requires PayloadBytea.
+func WithPayloadColumnType(payloadColumnType PayloadColumnType) Option {
+	return func(s *SingleStreamStrategy) error {
+		if !payloadColumnType.valid() {
+			return goengine.InvalidArgumentError("payloadColumnType")
+		}
+
+		s.payloadColumnType = payloadColumnType
+		return nil
+	}
+}
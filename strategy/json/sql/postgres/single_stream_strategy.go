@@ -1,10 +1,12 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hellofresh/goengine"
 	"github.com/hellofresh/goengine/driver/sql"
@@ -16,48 +18,174 @@ import (
 var (
 	// Ensure SingleStreamStrategy implements strategy.PersistenceStrategy
 	_ sql.PersistenceStrategy = &SingleStreamStrategy{}
+	// Ensure SingleStreamStrategy implements strategy.ContextPersistenceStrategy
+	_ sql.ContextPersistenceStrategy = &SingleStreamStrategy{}
 
 	tableNameInvalidCharRegex = regexp.MustCompile("[^a-z0-9_]+")
+	schemaNameRegex           = regexp.MustCompile("^[a-z_][a-z0-9_]*$")
 )
 
 // SingleStreamStrategy struct represents eventstore with single stream
 type SingleStreamStrategy struct {
 	converter goengine.MessagePayloadConverter
+
+	// payloadColumnType is the column type of the payload or empty to use JSON, compressed payloads are always BYTEA
+	payloadColumnType PayloadColumnType
+
+	// compress indicates that payloads of at least the compressionThreshold size are stored gzip compressed
+	compress             bool
+	compressionThreshold int
+
+	// hashChain is the scope in which events are hash chained or zero when hash chaining is disabled
+	hashChain HashChainScope
+
+	// aggregateIDType is the column type of the aggregate id or empty to use a UUID
+	aggregateIDType AggregateIDType
+
+	// schema is the postgres schema of the event stream tables or empty to use the current schema
+	schema string
+
+	// metadataIndexes are the metadata keys that are indexed or promoted to a column
+	metadataIndexes []MetadataIndex
+
+	// partitioning is the partitioning of the event stream tables or nil when the tables are not partitioned
+	partitioning *Partitioning
 }
 
+// Option configures optional behaviour of a SingleStreamStrategy
+type Option func(*SingleStreamStrategy) error
+
 // NewSingleStreamStrategy is the constructor postgres for PersistenceStrategy interface
-func NewSingleStreamStrategy(converter goengine.MessagePayloadConverter) (sql.PersistenceStrategy, error) {
+func NewSingleStreamStrategy(converter goengine.MessagePayloadConverter, options ...Option) (sql.PersistenceStrategy, error) {
 	if converter == nil {
 		return nil, goengine.InvalidArgumentError("converter")
 	}
 
-	return &SingleStreamStrategy{converter: converter}, nil
+	strategy := &SingleStreamStrategy{converter: converter}
+	for _, option := range options {
+		if err := option(strategy); err != nil {
+			return nil, err
+		}
+	}
+
+	// Compressed payloads are binary and can not be stored in a JSON column
+	if strategy.compress && strategy.payloadColumnType == PayloadJSON {
+		return nil, goengine.InvalidArgumentError("payloadColumnType")
+	}
+
+	// The per stream hash chain assigns the event number in a trigger which can not move a row to another partition
+	if strategy.hashChain == HashChainPerStream && strategy.partitioning != nil && strategy.partitioning.Key == PartitionByNumber {
+		return nil, goengine.InvalidArgumentError("partitioning")
+	}
+
+	return strategy, nil
+}
+
+// WithSchema stores the event streams in tables of the provided postgres schema.
+// The schema must exist and consist of lowercase letters, digits and underscores.
+func WithSchema(schema string) Option {
+	return func(s *SingleStreamStrategy) error {
+		if !schemaNameRegex.MatchString(schema) {
+			return goengine.InvalidArgumentError("schema")
+		}
+
+		s.schema = schema
+		return nil
+	}
+}
+
+// WithCompression stores the payloads in a BYTEA column with payloads of at least the threshold size in bytes being
+// gzip compressed.
+// The compressed payloads are decompressed by the strategySQL.AggregateChangedFactory.
+func WithCompression(threshold int) Option {
+	return func(s *SingleStreamStrategy) error {
+		if threshold < 0 {
+			return goengine.InvalidArgumentError("threshold")
+		}
+
+		s.compress = true
+		s.compressionThreshold = threshold
+		return nil
+	}
 }
 
 // CreateSchema returns a valid set of SQL statements to create the event store tables and indexes
 func (s *SingleStreamStrategy) CreateSchema(tableName string) []string {
-	tableName = postgres.QuoteIdentifier(tableName)
+	rawTableName := tableName
+	tableName = postgres.QuoteTableName(tableName)
+
+	payloadType := s.payloadColumnType
+	switch {
+	case s.compress:
+		payloadType = PayloadBytea
+	case payloadType == "":
+		payloadType = PayloadJSON
+	}
+
+	aggregateIDType := s.aggregateIDType
+	if aggregateIDType == "" {
+		aggregateIDType = AggregateIDUUID
+	}
+
+	// The per stream hash chain assigns the event number in it's trigger so the number must not have a default
+	numberType := "BIGSERIAL"
+	if s.hashChain == HashChainPerStream {
+		numberType = "BIGINT NOT NULL"
+	}
 
-	statements := make([]string, 3)
+	extraColumns := ""
+	if s.hashChain != 0 {
+		extraColumns = "    content_hash BYTEA NOT NULL,\n    hash BYTEA NOT NULL,\n"
+	}
+	extraColumns += s.sqlMetadataColumns()
+
+	// Unique constraints of a partitioned table must include the partition key
+	constraints := "PRIMARY KEY (no),\n    UNIQUE (event_id)\n)"
+	uniqueIndex := "UNIQUE INDEX"
+	if s.partitioning != nil {
+		uniqueIndex = "INDEX"
+		switch s.partitioning.Key {
+		case PartitionByNumber:
+			constraints = "PRIMARY KEY (no)\n) PARTITION BY RANGE (no)"
+		case PartitionByMonth:
+			constraints = "PRIMARY KEY (no, created_at)\n) PARTITION BY RANGE (created_at)"
+		}
+	}
+
+	statements := make([]string, 3, 5+len(s.metadataIndexes))
 	statements[0] = fmt.Sprintf(
 		`CREATE TABLE %s (
-    no BIGSERIAL,
+    no %s,
     event_id UUID NOT NULL,
     event_name VARCHAR(100) NOT NULL,
-    payload JSON NOT NULL,
+    payload %s NOT NULL,
     metadata JSONB NOT NULL, 
     aggregate_type VARCHAR(50) NOT NULL,
-	aggregate_id UUID NOT NULL,
-	aggregate_version SMALLINT NOT NULL,
+	aggregate_id %s NOT NULL,
+	aggregate_version INTEGER NOT NULL,
     created_at TIMESTAMP(6) NOT NULL,
-    PRIMARY KEY (no),
-    UNIQUE (event_id)
-);`,
+%s    %s;`,
 		tableName,
+		numberType,
+		payloadType,
+		aggregateIDType,
+		extraColumns,
+		constraints,
 	)
-	statements[1] = fmt.Sprintf(`CREATE UNIQUE INDEX ON %s (aggregate_type, aggregate_id, aggregate_version);`, tableName)
+	statements[1] = fmt.Sprintf(`CREATE %s ON %s (aggregate_type, aggregate_id, aggregate_version);`, uniqueIndex, tableName)
 	statements[2] = fmt.Sprintf(`CREATE INDEX ON %s (aggregate_type, aggregate_id, no);`, tableName)
 
+	statements = append(statements, s.sqlMetadataIndexes(tableName)...)
+
+	if s.partitioning != nil {
+		statements = append(statements, fmt.Sprintf(`CREATE INDEX ON %s (event_id);`, tableName))
+		statements = append(statements, sqlPartitionFunctions(rawTableName, s.partitioning)...)
+	}
+
+	if s.hashChain != 0 {
+		statements = append(statements, sqlHashChainTrigger(rawTableName, s.hashChain)...)
+	}
+
 	return statements
 }
 
@@ -68,24 +196,64 @@ func (s *SingleStreamStrategy) EventColumnNames() []string {
 
 // InsertColumnNames returns the columns that need to be inserted into the table in the correct order
 func (s *SingleStreamStrategy) InsertColumnNames() []string {
-	return []string{"event_id", "event_name", "payload", "metadata", "aggregate_type", "aggregate_id", "aggregate_version", "created_at"}
+	columns := []string{"event_id", "event_name", "payload", "metadata", "aggregate_type", "aggregate_id", "aggregate_version", "created_at"}
+	if s.hashChain != 0 {
+		columns = append(columns, "content_hash")
+	}
+	for _, index := range s.promotedColumns() {
+		columns = append(columns, index.Column())
+	}
+
+	return columns
 }
 
 // PrepareData transforms a slice of messaging into a flat interface slice with the correct column order
 func (s *SingleStreamStrategy) PrepareData(messages []goengine.Message) ([]interface{}, error) {
+	return s.prepareData(messages, s.converter.ConvertPayload)
+}
+
+// PrepareDataContext transforms a slice of messaging into a flat interface slice with the correct column order.
+// The context is used to convert the payloads when the converter is a goengine.MessagePayloadContextConverter.
+func (s *SingleStreamStrategy) PrepareDataContext(ctx context.Context, messages []goengine.Message) ([]interface{}, error) {
+	converter, ok := s.converter.(goengine.MessagePayloadContextConverter)
+	if !ok {
+		return s.PrepareData(messages)
+	}
+
+	return s.prepareData(messages, func(payload interface{}) (string, []byte, error) {
+		return converter.ConvertPayloadContext(ctx, payload)
+	})
+}
+
+func (s *SingleStreamStrategy) prepareData(
+	messages []goengine.Message,
+	convertPayload func(payload interface{}) (string, []byte, error),
+) ([]interface{}, error) {
 	var out = make([]interface{}, 0, len(messages)*5) // optimization for the number of columns
 	for _, msg := range messages {
-		payloadType, payloadData, err := s.converter.ConvertPayload(msg.Payload())
+		payloadType, payloadData, err := convertPayload(msg.Payload())
 		if err != nil {
 			return nil, err
 		}
 
+		if s.compress {
+			if payloadData, err = internal.CompressPayload(payloadData, s.compressionThreshold); err != nil {
+				return nil, err
+			}
+		}
+
 		msgMetadata := msg.Metadata()
 		meta, err := internal.MarshalJSON(msgMetadata)
 		if err != nil {
 			return nil, err
 		}
 
+		createdAt := msg.CreatedAt()
+		if s.hashChain != 0 {
+			// Postgres stores microseconds so round before hashing to ensure the stored event has the same hash
+			createdAt = createdAt.Round(time.Microsecond)
+		}
+
 		out = append(out,
 			msg.UUID(),
 			payloadType,
@@ -94,8 +262,21 @@ func (s *SingleStreamStrategy) PrepareData(messages []goengine.Message) ([]inter
 			msgMetadata.Value("_aggregate_type"),
 			msgMetadata.Value("_aggregate_id"),
 			msgMetadata.Value("_aggregate_version"),
-			msg.CreatedAt(),
+			createdAt,
 		)
+
+		if s.hashChain != 0 {
+			hash, err := contentHash(msg.UUID().String(), payloadType, payloadData, meta, createdAt)
+			if err != nil {
+				return nil, err
+			}
+
+			out = append(out, hash)
+		}
+
+		for _, index := range s.promotedColumns() {
+			out = append(out, msgMetadata.Value(index.Key))
+		}
 	}
 	return out, nil
 }
@@ -108,16 +289,33 @@ func (s *SingleStreamStrategy) PrepareSearch(matcher metadata.Matcher) ([]byte,
 	paramCount := 1
 	matcher.Iterate(func(c metadata.Constraint) {
 		paramCount++
+		query = append(query, " AND "...)
+
+		index, indexed := s.metadataIndex(c.Field())
+
+		// Equality of a GIN indexed key to a string is searched by containment so the index can be used
+		if indexed && index.Kind == MetadataGINIndex && c.Operator() == metadata.Equals {
+			if contains, ok := metadataContains(c.Field(), c.Value()); ok {
+				params = append(params, contains)
+
+				query = append(query, "metadata @> $"...)
+				query = append(query, strconv.Itoa(paramCount)...)
+				query = append(query, "::JSONB"...)
+				return
+			}
+		}
+
 		params = append(params, c.Value())
 
-		query = append(query, " AND "...)
-		switch c.Field() {
-		case "_aggregate_type":
+		switch {
+		case c.Field() == "_aggregate_type":
 			query = append(query, "aggregate_type"...)
-		case "_aggregate_id":
+		case c.Field() == "_aggregate_id":
 			query = append(query, "aggregate_id"...)
-		case "_aggregate_version":
+		case c.Field() == "_aggregate_version":
 			query = append(query, "aggregate_version"...)
+		case indexed && index.Kind == MetadataPromotedColumn:
+			query = append(query, postgres.QuoteIdentifier(index.Column())...)
 		default:
 			query = append(query, "metadata ->> "...)
 			query = append(query, postgres.QuoteString(c.Field())...)
@@ -144,5 +342,11 @@ func (s *SingleStreamStrategy) GenerateTableName(streamName goengine.StreamName)
 	// remove underscore at the end
 	name = strings.TrimRight(name, "_")
 	// prefix with events_
-	return fmt.Sprintf("events_%s", name), nil
+	name = fmt.Sprintf("events_%s", name)
+
+	if s.schema != "" {
+		return s.schema + "." + name, nil
+	}
+
+	return name, nil
 }
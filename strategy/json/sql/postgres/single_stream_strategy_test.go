@@ -3,15 +3,20 @@
 package postgres_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/crypto"
+	driverSQL "github.com/hellofresh/goengine/driver/sql"
 	"github.com/hellofresh/goengine/metadata"
 	"github.com/hellofresh/goengine/mocks"
+	strategyJSON "github.com/hellofresh/goengine/strategy/json"
 	"github.com/hellofresh/goengine/strategy/json/internal"
 	"github.com/hellofresh/goengine/strategy/json/sql/postgres"
 	"github.com/stretchr/testify/assert"
@@ -37,6 +42,183 @@ func TestNewPostgresStrategy(t *testing.T) {
 	})
 }
 
+func TestWithCompression(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(nil, postgres.WithCompression(1024))
+		assert.Equal(t, goengine.InvalidArgumentError("converter"), err)
+		assert.Nil(t, strategy)
+
+		strategy, err = postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithCompression(-1))
+		assert.Equal(t, goengine.InvalidArgumentError("threshold"), err)
+		assert.Nil(t, strategy)
+	})
+
+	t.Run("payload column", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithCompression(1024))
+		require.NoError(t, err)
+
+		cs := strategy.CreateSchema("abc")
+
+		assert.Contains(t, cs[0], "payload BYTEA NOT NULL")
+		assert.Contains(t, cs[0], "metadata JSONB NOT NULL")
+	})
+
+	t.Run("compress payloads of at least the threshold size", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		smallPayload := []byte(`{"name":"alice"}`)
+		largePayload := []byte(fmt.Sprintf(`{"document":"%s"}`, strings.Repeat("a", 100)))
+
+		pc := mocks.NewMessagePayloadConverter(ctrl)
+		pc.EXPECT().ConvertPayload(smallPayload).Return("small", smallPayload, nil)
+		pc.EXPECT().ConvertPayload(largePayload).Return("large", largePayload, nil)
+
+		meta := metadata.FromMap(map[string]interface{}{"type": "m"})
+		messages := []goengine.Message{
+			mocks.NewDummyMessage(goengine.GenerateUUID(), smallPayload, meta, time.Now()),
+			mocks.NewDummyMessage(goengine.GenerateUUID(), largePayload, meta, time.Now()),
+		}
+
+		strategy, err := postgres.NewSingleStreamStrategy(pc, postgres.WithCompression(64))
+		require.NoError(t, err)
+
+		data, err := strategy.PrepareData(messages)
+		require.NoError(t, err)
+
+		assert.Equal(t, smallPayload, data[2])
+
+		compressed := data[10].([]byte)
+		assert.True(t, len(compressed) < len(largePayload))
+
+		decompressed, err := internal.DecompressPayload(compressed)
+		assert.NoError(t, err)
+		assert.Equal(t, largePayload, decompressed)
+	})
+}
+
+func TestWithPayloadColumnType(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithPayloadColumnType("TEXT"))
+		assert.Equal(t, goengine.InvalidArgumentError("payloadColumnType"), err)
+		assert.Nil(t, strategy)
+
+		strategy, err = postgres.NewSingleStreamStrategy(
+			&mocks.MessagePayloadConverter{},
+			postgres.WithPayloadColumnType(postgres.PayloadJSON),
+			postgres.WithCompression(1024),
+		)
+		assert.Equal(t, goengine.InvalidArgumentError("payloadColumnType"), err)
+		assert.Nil(t, strategy)
+	})
+
+	t.Run("payload column", func(t *testing.T) {
+		testCases := []struct {
+			payloadColumnType postgres.PayloadColumnType
+			expectedColumn    string
+		}{
+			{postgres.PayloadJSON, "payload JSON NOT NULL"},
+			{postgres.PayloadBytea, "payload BYTEA NOT NULL"},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(string(testCase.payloadColumnType), func(t *testing.T) {
+				strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithPayloadColumnType(testCase.payloadColumnType))
+				require.NoError(t, err)
+
+				assert.Contains(t, strategy.CreateSchema("abc")[0], testCase.expectedColumn)
+			})
+		}
+	})
+}
+
+func TestWithAggregateIDType(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(nil, postgres.WithAggregateIDType(postgres.AggregateIDText))
+		assert.Equal(t, goengine.InvalidArgumentError("converter"), err)
+		assert.Nil(t, strategy)
+
+		strategy, err = postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithAggregateIDType("VARCHAR"))
+		assert.Equal(t, goengine.InvalidArgumentError("aggregateIDType"), err)
+		assert.Nil(t, strategy)
+	})
+
+	t.Run("aggregate id column", func(t *testing.T) {
+		testCases := []struct {
+			aggregateIDType postgres.AggregateIDType
+			expectedColumn  string
+		}{
+			{postgres.AggregateIDUUID, "aggregate_id UUID NOT NULL"},
+			{postgres.AggregateIDText, "aggregate_id TEXT NOT NULL"},
+			{postgres.AggregateIDBigInt, "aggregate_id BIGINT NOT NULL"},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(string(testCase.aggregateIDType), func(t *testing.T) {
+				strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithAggregateIDType(testCase.aggregateIDType))
+				require.NoError(t, err)
+
+				assert.Contains(t, strategy.CreateSchema("abc")[0], testCase.expectedColumn)
+			})
+		}
+	})
+}
+
+func TestWithSchema(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		testCases := []struct {
+			title       string
+			converter   goengine.MessagePayloadConverter
+			schema      string
+			expectedErr error
+		}{
+			{"nil converter", nil, "billing", goengine.InvalidArgumentError("converter")},
+			{"empty schema", &mocks.MessagePayloadConverter{}, "", goengine.InvalidArgumentError("schema")},
+			{"qualified schema", &mocks.MessagePayloadConverter{}, "billing.orders", goengine.InvalidArgumentError("schema")},
+			{"uppercase schema", &mocks.MessagePayloadConverter{}, "Billing", goengine.InvalidArgumentError("schema")},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				strategy, err := postgres.NewSingleStreamStrategy(testCase.converter, postgres.WithSchema(testCase.schema))
+
+				assert.Equal(t, testCase.expectedErr, err)
+				assert.Nil(t, strategy)
+			})
+		}
+	})
+
+	t.Run("schema qualified table", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{}, postgres.WithSchema("billing"))
+		require.NoError(t, err)
+
+		tableName, err := strategy.GenerateTableName("orders")
+		require.NoError(t, err)
+		assert.Equal(t, "billing.events_orders", tableName)
+
+		cs := strategy.CreateSchema(tableName)
+		assert.Contains(t, cs[0], `CREATE TABLE "billing"."events_orders"`)
+		assert.Contains(t, cs[1], `CREATE UNIQUE INDEX ON "billing"."events_orders"`)
+	})
+}
+
+func TestStreamProjectorCreateSchemaInSchema(t *testing.T) {
+	statements := postgres.StreamProjectorCreateSchema("billing.projections", "orders", "billing.events_orders")
+
+	assert.Contains(t, statements[1], `tgrelid = 'billing.events_orders'::regclass`)
+	assert.Contains(t, statements[1], `CREATE TRIGGER "events_orders_notify"`)
+	assert.Contains(t, statements[1], `ON "billing"."events_orders"`)
+	assert.Contains(t, statements[2], `CREATE TABLE IF NOT EXISTS "billing"."projections"`)
+}
+
+func TestAggregateProjectorCreateSchemaWithAggregateIDType(t *testing.T) {
+	statements := postgres.AggregateProjectorCreateSchemaWithAggregateIDType("projections", "orders", "events_orders", postgres.AggregateIDText)
+	assert.Contains(t, statements[2], "aggregate_id TEXT UNIQUE NOT NULL")
+
+	statements = postgres.AggregateProjectorCreateSchema("projections", "orders", "events_orders")
+	assert.Contains(t, statements[2], "aggregate_id UUID UNIQUE NOT NULL")
+}
+
 func TestGenerateTableName(t *testing.T) {
 	strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{})
 	require.NoError(t, err)
@@ -159,6 +341,7 @@ func TestCreateSchema(t *testing.T) {
 
 		assert.Equal(t, 3, len(cs))
 		assert.Contains(t, cs[0], `CREATE TABLE "abc"`)
+		assert.Contains(t, cs[0], "aggregate_id UUID NOT NULL")
 	})
 }
 
@@ -235,3 +418,146 @@ func TestPrepareData(t *testing.T) {
 		assert.Nil(t, data)
 	})
 }
+
+func TestPrepareDataContext(t *testing.T) {
+	type userRegistered struct {
+		UserID string `json:"user_id" personal:"subject"`
+		Email  string `json:"email" personal:"data"`
+	}
+
+	keyStore := &contextKeyStore{KeyStore: crypto.NewInMemoryKeyStore()}
+	transformer, err := strategyJSON.NewPersonalDataPayloadTransformer(keyStore)
+	require.NoError(t, err)
+	require.NoError(t, transformer.RegisterPayload("user_registered", func() interface{} {
+		return userRegistered{}
+	}))
+
+	persistenceStrategy, err := postgres.NewSingleStreamStrategy(transformer)
+	require.NoError(t, err)
+	strategy, ok := persistenceStrategy.(driverSQL.ContextPersistenceStrategy)
+	require.True(t, ok)
+
+	messages := []goengine.Message{
+		mocks.NewDummyMessage(
+			goengine.GenerateUUID(),
+			userRegistered{UserID: "user-1", Email: "alice@example.com"},
+			metadata.New(),
+			time.Now(),
+		),
+	}
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "append")
+
+	data, err := strategy.PrepareDataContext(ctx, messages)
+	require.NoError(t, err)
+	assert.Len(t, data, len(strategy.InsertColumnNames()))
+	assert.NotContains(t, string(data[2].([]byte)), "alice@example.com")
+
+	// The key is looked up using the context of the append
+	require.Len(t, keyStore.contexts, 1)
+	assert.Equal(t, "append", keyStore.contexts[0].Value(ctxKey{}))
+}
+
+func TestNewSingleStreamStrategy_CombinedOptions(t *testing.T) {
+	promoted := postgres.MetadataIndex{Key: "tenant_id", Kind: postgres.MetadataPromotedColumn, ColumnType: postgres.MetadataUUID}
+
+	testCases := []struct {
+		title   string
+		options []postgres.Option
+	}{
+		{
+			"hash chain and metadata indexes",
+			[]postgres.Option{postgres.WithHashChain(postgres.HashChainPerStream), postgres.WithMetadataIndexes(promoted)},
+		},
+		{
+			"hash chain per aggregate, partitioning and metadata indexes",
+			[]postgres.Option{
+				postgres.WithHashChain(postgres.HashChainPerAggregate),
+				postgres.WithPartitioning(postgres.Partitioning{Key: postgres.PartitionByNumber, Size: 1000}),
+				postgres.WithMetadataIndexes(promoted),
+			},
+		},
+		{
+			"compression, aggregate id type and metadata indexes",
+			[]postgres.Option{
+				postgres.WithCompression(64),
+				postgres.WithAggregateIDType(postgres.AggregateIDText),
+				postgres.WithMetadataIndexes(promoted),
+			},
+		},
+		{
+			"all options",
+			[]postgres.Option{
+				postgres.WithSchema("billing"),
+				postgres.WithCompression(64),
+				postgres.WithAggregateIDType(postgres.AggregateIDText),
+				postgres.WithHashChain(postgres.HashChainPerStream),
+				postgres.WithPartitioning(postgres.Partitioning{Key: postgres.PartitionByMonth}),
+				postgres.WithMetadataIndexes(promoted),
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tenantID := goengine.GenerateUUID()
+			messages := []goengine.Message{
+				mocks.NewDummyMessage(
+					goengine.GenerateUUID(),
+					[]byte(`{}`),
+					metadata.FromMap(map[string]interface{}{"tenant_id": tenantID}),
+					time.Now(),
+				),
+				mocks.NewDummyMessage(goengine.GenerateUUID(), []byte(`{}`), metadata.New(), time.Now()),
+			}
+
+			pc := mocks.NewMessagePayloadConverter(ctrl)
+			pc.EXPECT().ConvertPayload(gomock.Any()).Return("payload", []byte(`{}`), nil).Times(2)
+
+			strategy, err := postgres.NewSingleStreamStrategy(pc, testCase.options...)
+			require.NoError(t, err)
+
+			columns := strategy.InsertColumnNames()
+			cs := strategy.CreateSchema("events_orders")
+			for _, column := range columns {
+				assert.Contains(t, cs[0], column)
+			}
+
+			data, err := strategy.PrepareData(messages)
+			require.NoError(t, err)
+			require.Len(t, data, len(columns)*len(messages))
+
+			assert.Equal(t, promoted.Column(), columns[len(columns)-1])
+			assert.Equal(t, tenantID, data[len(columns)-1])
+			assert.Nil(t, data[len(data)-1])
+		})
+	}
+
+	t.Run("hash chain per stream with partitioning by number", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(
+			&mocks.MessagePayloadConverter{},
+			postgres.WithHashChain(postgres.HashChainPerStream),
+			postgres.WithPartitioning(postgres.Partitioning{Key: postgres.PartitionByNumber, Size: 1000}),
+		)
+
+		assert.Equal(t, goengine.InvalidArgumentError("partitioning"), err)
+		assert.Nil(t, strategy)
+	})
+}
+
+// contextKeyStore is a crypto.KeyStore recording the contexts used to get or create keys
+type contextKeyStore struct {
+	crypto.KeyStore
+
+	contexts []context.Context
+}
+
+func (s *contextKeyStore) GetOrCreateKey(ctx context.Context, subjectID string) ([]byte, error) {
+	s.contexts = append(s.contexts, ctx)
+
+	return s.KeyStore.GetOrCreateKey(ctx, subjectID)
+}
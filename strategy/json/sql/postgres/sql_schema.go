@@ -49,18 +49,32 @@ BEGIN
 END;
 $EXIST$;`
 
-	triggerName := fmt.Sprintf("%s_notify", eventStreamTable)
+	// A trigger is created in the schema of it's table so the trigger name can not be qualified
+	_, table := postgres.SplitTableName(eventStreamTable)
+	triggerName := fmt.Sprintf("%s_notify", table)
 	/* #nosec G201 */
 	return fmt.Sprintf(
 		query,
 		postgres.QuoteString(eventStreamTable),
 		postgres.QuoteString(triggerName),
 		postgres.QuoteIdentifier(triggerName),
-		postgres.QuoteIdentifier(eventStreamTable),
-		postgres.QuoteString(string(eventStreamName)),
+		postgres.QuoteTableName(eventStreamTable),
+		postgres.QuoteString(NotificationChannel(eventStreamName, eventStreamTable)),
 	)
 }
 
+// NotificationChannel returns the channel on which the notifications of the event stream stored in the provided table
+// are sent. The stream name is prefixed with the schema of a schema qualified table so that streams with the same name
+// in different schemas do not share a channel.
+func NotificationChannel(eventStreamName goengine.StreamName, eventStreamTable string) string {
+	schema, _ := postgres.SplitTableName(eventStreamTable)
+	if schema == "" {
+		return string(eventStreamName)
+	}
+
+	return schema + "." + string(eventStreamName)
+}
+
 // StreamProjectorCreateSchema return the sql statement needed for the postgres database in order to use the StreamProjector
 func StreamProjectorCreateSchema(projectionTable string, streamName goengine.StreamName, streamTable string) []string {
 	const query = `CREATE TABLE IF NOT EXISTS %s (
@@ -76,12 +90,23 @@ func StreamProjectorCreateSchema(projectionTable string, streamName goengine.Str
 	return []string{
 		sqlFuncEventStreamNotify,
 		sqlTriggerEventStreamNotifyTemplate(streamName, streamTable),
-		fmt.Sprintf(query, postgres.QuoteIdentifier(projectionTable)),
+		fmt.Sprintf(query, postgres.QuoteTableName(projectionTable)),
 	}
 }
 
 // AggregateProjectorCreateSchema return the sql statement needed for the postgres database in order to use the AggregateProjector
 func AggregateProjectorCreateSchema(projectionTable string, streamName goengine.StreamName, streamTable string) []string {
+	return AggregateProjectorCreateSchemaWithAggregateIDType(projectionTable, streamName, streamTable, AggregateIDUUID)
+}
+
+// AggregateProjectorCreateSchemaWithAggregateIDType return the sql statement needed for the postgres database in order
+// to use the AggregateProjector for a event stream storing the aggregate id in a column of the provided type
+func AggregateProjectorCreateSchemaWithAggregateIDType(
+	projectionTable string,
+	streamName goengine.StreamName,
+	streamTable string,
+	aggregateIDType AggregateIDType,
+) []string {
 	/* #nosec G201 */
 	return []string{
 		sqlFuncEventStreamNotify,
@@ -90,14 +115,56 @@ func AggregateProjectorCreateSchema(projectionTable string, streamName goengine.
 		fmt.Sprintf(
 			`CREATE TABLE IF NOT EXISTS %s (
 				no SERIAL,
-				aggregate_id UUID UNIQUE NOT NULL,
+				aggregate_id %s UNIQUE NOT NULL,
 				position BIGINT NOT NULL DEFAULT 0,
   				state JSONB,
 				locked BOOLEAN NOT NULL DEFAULT (FALSE),
 				failed BOOLEAN NOT NULL DEFAULT (FALSE),
   				PRIMARY KEY (no)
 			)`,
-			postgres.QuoteIdentifier(projectionTable),
+			postgres.QuoteTableName(projectionTable),
+			aggregateIDType,
+		),
+	}
+}
+
+// SagaStorageCreateSchema return the sql statements needed for the postgres database in order to use the SagaStorage
+func SagaStorageCreateSchema(sagaTable, instanceTable, deadlineTable string) []string {
+	deadlineTableQuoted := postgres.QuoteTableName(deadlineTable)
+	// An index is created in the schema of it's table so the index name can not be qualified
+	_, deadlineTableName := postgres.SplitTableName(deadlineTable)
+
+	/* #nosec G201 */
+	return []string{
+		fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (
+				name VARCHAR(150) NOT NULL,
+				position BIGINT NOT NULL DEFAULT 0,
+				PRIMARY KEY (name)
+			)`,
+			postgres.QuoteTableName(sagaTable),
+		),
+		fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (
+				saga_name VARCHAR(150) NOT NULL,
+				correlation_id VARCHAR(150) NOT NULL,
+				state JSONB NOT NULL DEFAULT ('{}'),
+				completed BOOLEAN NOT NULL DEFAULT (FALSE),
+				PRIMARY KEY (saga_name, correlation_id)
+			)`,
+			postgres.QuoteTableName(instanceTable),
+		),
+		fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (
+				id UUID NOT NULL,
+				saga_name VARCHAR(150) NOT NULL,
+				correlation_id VARCHAR(150) NOT NULL,
+				name VARCHAR(150) NOT NULL,
+				due_at TIMESTAMP(6) NOT NULL,
+				PRIMARY KEY (id)
+			)`,
+			deadlineTableQuoted,
 		),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (saga_name, due_at)`, postgres.QuoteIdentifier(deadlineTableName+"_due_at_idx"), deadlineTableQuoted),
 	}
 }
@@ -0,0 +1,59 @@
+// +build unit
+
+package postgres_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationChannel(t *testing.T) {
+	testCases := []struct {
+		title           string
+		streamName      goengine.StreamName
+		streamTable     string
+		expectedChannel string
+	}{
+		{
+			"unqualified table",
+			"orders",
+			"events_orders",
+			"orders",
+		},
+		{
+			"schema qualified table",
+			"orders",
+			"billing.events_orders",
+			"billing.orders",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			channel := postgres.NotificationChannel(testCase.streamName, testCase.streamTable)
+			assert.Equal(t, testCase.expectedChannel, channel)
+
+			queries := postgres.StreamProjectorCreateSchema("projections", testCase.streamName, testCase.streamTable)
+			assert.True(t, strings.Contains(queries[1], "event_stream_notify('"+testCase.expectedChannel+"')"))
+		})
+	}
+}
+
+func TestSagaStorageCreateSchema(t *testing.T) {
+	queries := postgres.SagaStorageCreateSchema("billing.sagas", "billing.saga_instances", "billing.saga_deadlines")
+
+	if assert.Len(t, queries, 4) {
+		assert.Contains(t, queries[0], `CREATE TABLE IF NOT EXISTS "billing"."sagas"`)
+		assert.Contains(t, queries[1], `CREATE TABLE IF NOT EXISTS "billing"."saga_instances"`)
+		assert.Contains(t, queries[2], `CREATE TABLE IF NOT EXISTS "billing"."saga_deadlines"`)
+		assert.Equal(
+			t,
+			`CREATE INDEX IF NOT EXISTS "saga_deadlines_due_at_idx" ON "billing"."saga_deadlines" (saga_name, due_at)`,
+			queries[3],
+		)
+	}
+}
@@ -0,0 +1,13 @@
+module github.com/hellofresh/goengine/strategy/protobuf
+
+go 1.15
+
+require (
+	github.com/golang/mock v1.2.0
+	github.com/hellofresh/goengine v1.4.0
+	github.com/stretchr/testify v1.7.0
+	google.golang.org/protobuf v1.27.1
+)
+
+// The replace is only used for local development, a release requires the root module version it is tagged with
+replace github.com/hellofresh/goengine => ../..
@@ -0,0 +1,144 @@
+// Package protobuf provides a payload transformer that stores payloads as protocol buffer messages
+package protobuf
+
+import (
+	"errors"
+
+	"github.com/hellofresh/goengine"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrUnsupportedProtobufPayloadData occurs when the data type is not supported by the PayloadTransformer
+	ErrUnsupportedProtobufPayloadData = errors.New("goengine: payload data was expected to be a []byte or string")
+	// ErrPayloadCannotBeSerialized occurs when the payload cannot be serialized
+	ErrPayloadCannotBeSerialized = errors.New("goengine: payload cannot be serialized")
+	// ErrPayloadNotRegistered occurs when the payload is not registered
+	ErrPayloadNotRegistered = errors.New("goengine: payload is not registered")
+	// ErrUnknownPayloadType occurs when a payload type is unknown
+	ErrUnknownPayloadType = errors.New("goengine: unknown payload type provided")
+	// ErrInitiatorInvalidResult occurs when a PayloadInitiator returns nil
+	ErrInitiatorInvalidResult = errors.New("goengine: initializer must return a protocol buffer message that is not nil")
+	// ErrDuplicatePayloadType occurs when a payload type or protocol buffer message is already registered
+	ErrDuplicatePayloadType = errors.New("goengine: payload type is already registered")
+
+	// Ensure that PayloadTransformer satisfies the MessagePayloadFactory interface
+	_ goengine.MessagePayloadFactory = &PayloadTransformer{}
+	// Ensure that PayloadTransformer satisfies the MessagePayloadConverter interface
+	_ goengine.MessagePayloadConverter = &PayloadTransformer{}
+	// Ensure that PayloadTransformer satisfies the MessagePayloadResolver interface
+	_ goengine.MessagePayloadResolver = &PayloadTransformer{}
+)
+
+type (
+	// PayloadInitiator creates a new empty instance of a protocol buffer message
+	PayloadInitiator func() proto.Message
+
+	// PayloadTransformer is a payload factory that can reconstruct payloads from and to the protocol buffer wire format
+	PayloadTransformer struct {
+		types map[string]PayloadInitiator
+		// names maps the full name of the protocol buffer messages to their payload type
+		names map[string]string
+	}
+)
+
+// NewPayloadTransformer returns a new instance of the PayloadTransformer
+func NewPayloadTransformer() *PayloadTransformer {
+	return &PayloadTransformer{
+		types: map[string]PayloadInitiator{},
+		names: map[string]string{},
+	}
+}
+
+// ConvertPayload marshals the payload returning the payload type and the serialized data
+func (p *PayloadTransformer) ConvertPayload(payload interface{}) (string, []byte, error) {
+	payloadName, err := p.ResolveName(payload)
+	if err != nil {
+		return "", nil, err
+	}
+
+	data, err := proto.Marshal(payload.(proto.Message))
+	if err != nil {
+		return "", nil, ErrPayloadCannotBeSerialized
+	}
+
+	return payloadName, data, nil
+}
+
+// ResolveName returns the payload type of the provided protocol buffer message
+func (p *PayloadTransformer) ResolveName(payload interface{}) (string, error) {
+	msg, ok := payload.(proto.Message)
+	if !ok || msg == nil {
+		return "", ErrPayloadNotRegistered
+	}
+
+	payloadName, found := p.names[messageName(msg)]
+	if !found {
+		return "", ErrPayloadNotRegistered
+	}
+
+	return payloadName, nil
+}
+
+// RegisterPayload registers a payload type and the way to initialize it's protocol buffer message
+func (p *PayloadTransformer) RegisterPayload(payloadType string, initiator PayloadInitiator) error {
+	if _, known := p.types[payloadType]; known {
+		return ErrDuplicatePayloadType
+	}
+
+	msg := initiator()
+	if msg == nil || !msg.ProtoReflect().IsValid() {
+		return ErrInitiatorInvalidResult
+	}
+
+	name := messageName(msg)
+	if _, known := p.names[name]; known {
+		return ErrDuplicatePayloadType
+	}
+
+	p.names[name] = payloadType
+	p.types[payloadType] = initiator
+
+	return nil
+}
+
+// RegisterPayloads registers multiple payload types
+func (p *PayloadTransformer) RegisterPayloads(payloads map[string]PayloadInitiator) error {
+	for name, initiator := range payloads {
+		if err := p.RegisterPayload(name, initiator); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreatePayload reconstructs the protocol buffer message of the payload type from the data
+func (p *PayloadTransformer) CreatePayload(typeName string, data interface{}) (interface{}, error) {
+	var dataBytes []byte
+	switch d := data.(type) {
+	case []byte:
+		dataBytes = d
+	case string:
+		dataBytes = []byte(d)
+	default:
+		return nil, ErrUnsupportedProtobufPayloadData
+	}
+
+	initiator, found := p.types[typeName]
+	if !found {
+		return nil, ErrUnknownPayloadType
+	}
+
+	payload := initiator()
+	if err := proto.Unmarshal(dataBytes, payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// messageName returns the full name of the protocol buffer message
+func messageName(msg proto.Message) string {
+	return string(msg.ProtoReflect().Descriptor().FullName())
+}
@@ -0,0 +1,154 @@
+// +build unit
+
+package protobuf_test
+
+import (
+	"testing"
+
+	"github.com/hellofresh/goengine/strategy/protobuf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestPayloadTransformer_ConvertPayload(t *testing.T) {
+	t.Run("convert payload", func(t *testing.T) {
+		transformer := newPayloadTransformer(t)
+
+		name, data, err := transformer.ConvertPayload(wrapperspb.String("alice"))
+		require.NoError(t, err)
+
+		expectedData, err := proto.Marshal(wrapperspb.String("alice"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "name_changed", name)
+		assert.Equal(t, expectedData, data)
+	})
+
+	t.Run("payload not registered", func(t *testing.T) {
+		transformer := newPayloadTransformer(t)
+
+		testCases := []struct {
+			title   string
+			payload interface{}
+		}{
+			{"unregistered message", structpb.NewNullValue()},
+			{"not a message", "alice"},
+			{"nil", nil},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				name, data, err := transformer.ConvertPayload(testCase.payload)
+
+				assert.Equal(t, protobuf.ErrPayloadNotRegistered, err)
+				assert.Equal(t, "", name)
+				assert.Nil(t, data)
+			})
+		}
+	})
+}
+
+func TestPayloadTransformer_CreatePayload(t *testing.T) {
+	data, err := proto.Marshal(wrapperspb.String("alice"))
+	require.NoError(t, err)
+
+	t.Run("create payload", func(t *testing.T) {
+		transformer := newPayloadTransformer(t)
+
+		for _, input := range []interface{}{data, string(data)} {
+			payload, err := transformer.CreatePayload("name_changed", input)
+			require.NoError(t, err)
+
+			assert.True(t, proto.Equal(wrapperspb.String("alice"), payload.(proto.Message)))
+		}
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		transformer := newPayloadTransformer(t)
+
+		testCases := []struct {
+			title       string
+			payloadType string
+			data        interface{}
+			expectedErr error
+		}{
+			{"unsupported data", "name_changed", 1, protobuf.ErrUnsupportedProtobufPayloadData},
+			{"unknown payload type", "unknown", data, protobuf.ErrUnknownPayloadType},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				payload, err := transformer.CreatePayload(testCase.payloadType, testCase.data)
+
+				assert.Equal(t, testCase.expectedErr, err)
+				assert.Nil(t, payload)
+			})
+		}
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		transformer := newPayloadTransformer(t)
+
+		payload, err := transformer.CreatePayload("name_changed", []byte{0xff})
+
+		assert.Error(t, err)
+		assert.Nil(t, payload)
+	})
+}
+
+func TestPayloadTransformer_RegisterPayload(t *testing.T) {
+	t.Run("duplicate payload", func(t *testing.T) {
+		transformer := newPayloadTransformer(t)
+
+		err := transformer.RegisterPayload("name_changed", func() proto.Message { return &structpb.Value{} })
+		assert.Equal(t, protobuf.ErrDuplicatePayloadType, err)
+
+		err = transformer.RegisterPayload("name_renamed", func() proto.Message { return &wrapperspb.StringValue{} })
+		assert.Equal(t, protobuf.ErrDuplicatePayloadType, err)
+	})
+
+	t.Run("invalid initiator", func(t *testing.T) {
+		transformer := protobuf.NewPayloadTransformer()
+
+		testCases := []struct {
+			title     string
+			initiator protobuf.PayloadInitiator
+		}{
+			{"nil", func() proto.Message { return nil }},
+			{"nil pointer", func() proto.Message { return (*wrapperspb.StringValue)(nil) }},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.title, func(t *testing.T) {
+				err := transformer.RegisterPayload("name_changed", testCase.initiator)
+
+				assert.Equal(t, protobuf.ErrInitiatorInvalidResult, err)
+			})
+		}
+	})
+
+	t.Run("register payloads", func(t *testing.T) {
+		transformer := protobuf.NewPayloadTransformer()
+		require.NoError(t, transformer.RegisterPayloads(map[string]protobuf.PayloadInitiator{
+			"name_changed":    func() proto.Message { return &wrapperspb.StringValue{} },
+			"balance_changed": func() proto.Message { return &wrapperspb.Int64Value{} },
+		}))
+
+		name, err := transformer.ResolveName(wrapperspb.Int64(10))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "balance_changed", name)
+	})
+}
+
+func newPayloadTransformer(t *testing.T) *protobuf.PayloadTransformer {
+	transformer := protobuf.NewPayloadTransformer()
+	require.NoError(t,
+		transformer.RegisterPayload("name_changed", func() proto.Message { return &wrapperspb.StringValue{} }),
+	)
+
+	return transformer
+}
@@ -0,0 +1,21 @@
+// Package postgres provides a postgres persistence strategy for protocol buffer payloads.
+//
+// The strategy is the JSON single stream strategy storing the payloads in a BYTEA column, the metadata is stored as
+// JSONB so that metadata constraints keep working.
+// Messages are reconstructed using a strategySQL.AggregateChangedFactory with a protobuf.PayloadTransformer.
+package postgres
+
+import (
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/sql"
+	jsonPostgres "github.com/hellofresh/goengine/strategy/json/sql/postgres"
+)
+
+// NewSingleStreamStrategy returns a single stream strategy storing the protocol buffer encoded payloads as BYTEA.
+// The options of the JSON single stream strategy, like schemas, hash chains and partitioning, can be used as well.
+func NewSingleStreamStrategy(converter goengine.MessagePayloadConverter, options ...jsonPostgres.Option) (sql.PersistenceStrategy, error) {
+	return jsonPostgres.NewSingleStreamStrategy(
+		converter,
+		append([]jsonPostgres.Option{jsonPostgres.WithPayloadColumnType(jsonPostgres.PayloadBytea)}, options...)...,
+	)
+}
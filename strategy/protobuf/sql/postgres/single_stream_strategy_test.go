@@ -0,0 +1,151 @@
+// +build unit
+
+package postgres_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/mocks"
+	jsonPostgres "github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	"github.com/hellofresh/goengine/strategy/protobuf/sql/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSingleStreamStrategy(t *testing.T) {
+	t.Run("error on no converter provided", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(nil)
+
+		assert.Equal(t, goengine.InvalidArgumentError("converter"), err)
+		assert.Nil(t, strategy)
+	})
+
+	t.Run("create strategy", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{})
+
+		assert.IsType(t, &jsonPostgres.SingleStreamStrategy{}, strategy)
+		assert.NoError(t, err)
+	})
+
+	t.Run("json payload column with compression", func(t *testing.T) {
+		strategy, err := postgres.NewSingleStreamStrategy(
+			&mocks.MessagePayloadConverter{},
+			jsonPostgres.WithPayloadColumnType(jsonPostgres.PayloadJSON),
+			jsonPostgres.WithCompression(1024),
+		)
+
+		assert.Equal(t, goengine.InvalidArgumentError("payloadColumnType"), err)
+		assert.Nil(t, strategy)
+	})
+}
+
+func TestSingleStreamStrategy_CreateSchema(t *testing.T) {
+	strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{})
+	require.NoError(t, err)
+
+	cs := strategy.CreateSchema("abc")
+
+	assert.Len(t, cs, 3)
+	assert.Contains(t, cs[0], `CREATE TABLE "abc"`)
+	assert.Contains(t, cs[0], "payload BYTEA NOT NULL")
+	assert.Contains(t, cs[0], "metadata JSONB NOT NULL")
+}
+
+func TestSingleStreamStrategy_CreateSchemaWithOptions(t *testing.T) {
+	strategy, err := postgres.NewSingleStreamStrategy(
+		&mocks.MessagePayloadConverter{},
+		jsonPostgres.WithSchema("billing"),
+		jsonPostgres.WithHashChain(jsonPostgres.HashChainPerAggregate),
+	)
+	require.NoError(t, err)
+
+	tableName, err := strategy.GenerateTableName("orders")
+	require.NoError(t, err)
+
+	cs := strategy.CreateSchema(tableName)
+
+	assert.Contains(t, cs[0], `CREATE TABLE "billing"."events_orders"`)
+	assert.Contains(t, cs[0], "payload BYTEA NOT NULL")
+	assert.Contains(t, cs[0], "content_hash BYTEA NOT NULL")
+}
+
+func TestSingleStreamStrategy_GenerateTableName(t *testing.T) {
+	strategy, err := postgres.NewSingleStreamStrategy(&mocks.MessagePayloadConverter{})
+	require.NoError(t, err)
+
+	tableName, err := strategy.GenerateTableName("order'1\"_")
+	assert.NoError(t, err)
+	assert.Equal(t, "events_order1", tableName)
+
+	tableName, err = strategy.GenerateTableName("")
+	assert.Equal(t, goengine.InvalidArgumentError("streamName"), err)
+	assert.Empty(t, tableName)
+}
+
+func TestSingleStreamStrategy_PrepareData(t *testing.T) {
+	t.Run("get expected columns", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := goengine.GenerateUUID()
+		payload := []byte{0x0a, 0x05, 'a', 'l', 'i', 'c', 'e'}
+		meta := metadata.FromMap(map[string]interface{}{
+			"_aggregate_type":    "user",
+			"_aggregate_id":      "ecb0b4a4-f4be-4e9a-9a2b-1f9a4e4b1b0d",
+			"_aggregate_version": 1,
+		})
+		createdAt := time.Now()
+
+		pc := mocks.NewMessagePayloadConverter(ctrl)
+		pc.EXPECT().ConvertPayload(payload).Return("name_changed", payload, nil)
+
+		strategy, err := postgres.NewSingleStreamStrategy(pc)
+		require.NoError(t, err)
+
+		data, err := strategy.PrepareData([]goengine.Message{
+			mocks.NewDummyMessage(id, payload, meta, createdAt),
+		})
+		require.NoError(t, err)
+
+		metaJSON, err := json.Marshal(meta)
+		require.NoError(t, err)
+
+		assert.Equal(t, []interface{}{
+			id,
+			"name_changed",
+			payload,
+			metaJSON,
+			"user",
+			"ecb0b4a4-f4be-4e9a-9a2b-1f9a4e4b1b0d",
+			1,
+			createdAt,
+		}, data)
+	})
+
+	t.Run("converter error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expectedErr := errors.New("converter error")
+		payload := []byte{0x0a}
+
+		pc := mocks.NewMessagePayloadConverter(ctrl)
+		pc.EXPECT().ConvertPayload(payload).Return("", nil, expectedErr)
+
+		strategy, err := postgres.NewSingleStreamStrategy(pc)
+		require.NoError(t, err)
+
+		data, err := strategy.PrepareData([]goengine.Message{
+			mocks.NewDummyMessage(goengine.GenerateUUID(), payload, metadata.New(), time.Now()),
+		})
+
+		assert.Equal(t, expectedErr, err)
+		assert.Nil(t, data)
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/hellofresh/goengine"
 	"github.com/hellofresh/goengine/driver/sql/postgres"
+	"github.com/hellofresh/goengine/eventstoretest"
 	"github.com/hellofresh/goengine/metadata"
 	"github.com/hellofresh/goengine/mocks"
 	"github.com/hellofresh/goengine/strategy/json"
@@ -314,6 +315,26 @@ func (s *eventStoreTestSuite) TestLoad() {
 	}
 }
 
+func (s *eventStoreTestSuite) TestConformance() {
+	transformer := json.NewPayloadTransformer()
+	s.Require().NoError(
+		transformer.RegisterPayload(eventstoretest.PayloadName, func() interface{} { return eventstoretest.Payload{} }),
+	)
+
+	persistenceStrategy, err := strategyPostgres.NewSingleStreamStrategy(transformer)
+	s.Require().NoError(err, "failed initializing persistent strategy")
+
+	messageFactory, err := strategySQL.NewAggregateChangedFactory(transformer)
+	s.Require().NoError(err, "failed on dependencies load")
+
+	eventStore, err := postgres.NewEventStore(persistenceStrategy, s.DB(), messageFactory, nil)
+	s.Require().NoError(err, "failed on dependencies load")
+
+	eventstoretest.Run(s.T(), func(t *testing.T) goengine.EventStore {
+		return eventStore
+	})
+}
+
 func (s *eventStoreTestSuite) createEventStore() goengine.EventStore {
 	transformer := json.NewPayloadTransformer()
 	s.Require().NoError(
@@ -0,0 +1,139 @@
+// +build integration
+
+package test_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+	"github.com/hellofresh/goengine/strategy/json"
+	strategySQL "github.com/hellofresh/goengine/strategy/json/sql"
+	strategyPostgres "github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	"github.com/hellofresh/goengine/test/internal"
+	"github.com/stretchr/testify/suite"
+)
+
+type hashChainTestSuite struct {
+	internal.PostgresSuite
+}
+
+func TestHashChainSuite(t *testing.T) {
+	suite.Run(t, new(hashChainTestSuite))
+}
+
+func (s *hashChainTestSuite) SetupTest() {
+	s.PostgresSuite.SetupTest()
+
+	// The hash chain trigger uses the sha256 function
+	s.SkipIfPostgresOlderThan(110000)
+}
+
+func (s *hashChainTestSuite) TestVerifyHashChain() {
+	ctx := context.Background()
+
+	testCases := []struct {
+		title      string
+		streamName goengine.StreamName
+		scope      strategyPostgres.HashChainScope
+	}{
+		{"per stream", "orders_per_stream", strategyPostgres.HashChainPerStream},
+		{"per aggregate", "orders_per_aggregate", strategyPostgres.HashChainPerAggregate},
+	}
+
+	for _, testCase := range testCases {
+		s.Run(testCase.title, func() {
+			strategy, eventStore := s.createEventStore(testCase.scope)
+			s.Require().NoError(eventStore.Create(ctx, testCase.streamName))
+
+			for i := 0; i < 2; i++ {
+				messages := newIndexedMessages(goengine.GenerateUUID(), goengine.GenerateUUID(), 3)
+				s.Require().NoError(eventStore.AppendTo(ctx, testCase.streamName, messages))
+			}
+
+			s.assertNumbered(strategy, testCase.streamName, 6)
+			s.NoError(strategy.VerifyHashChain(ctx, s.DB(), testCase.streamName))
+
+			// Modifying a event breaks the chain at the modified event
+			tableName, err := strategy.GenerateTableName(testCase.streamName)
+			s.Require().NoError(err)
+
+			_, err = s.DB().ExecContext(
+				ctx,
+				fmt.Sprintf(`UPDATE %s SET payload = '{"Name":"mallory","Balance":1000}' WHERE no = 3`, postgres.QuoteTableName(tableName)),
+			)
+			s.Require().NoError(err)
+
+			err = strategy.VerifyHashChain(ctx, s.DB(), testCase.streamName)
+			if s.IsType(&strategyPostgres.HashChainBrokenError{}, err) {
+				s.Equal(int64(3), err.(*strategyPostgres.HashChainBrokenError).Number)
+			}
+		})
+	}
+}
+
+func (s *hashChainTestSuite) TestConcurrentAppendsPerStream() {
+	ctx := context.Background()
+	streamName := goengine.StreamName("orders_concurrent")
+
+	strategy, eventStore := s.createEventStore(strategyPostgres.HashChainPerStream)
+	s.Require().NoError(eventStore.Create(ctx, streamName))
+
+	const appends = 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, appends)
+	for i := 0; i < appends; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			errs <- eventStore.AppendTo(ctx, streamName, newIndexedMessages(goengine.GenerateUUID(), goengine.GenerateUUID(), 2))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		s.Require().NoError(err)
+	}
+
+	s.assertNumbered(strategy, streamName, appends*2)
+	s.NoError(strategy.VerifyHashChain(ctx, s.DB(), streamName))
+}
+
+// assertNumbered asserts that the events of the stream are numbered 1 to count, so every event took a single number
+// from the sequence
+func (s *hashChainTestSuite) assertNumbered(strategy *strategyPostgres.SingleStreamStrategy, streamName goengine.StreamName, count int64) {
+	tableName, err := strategy.GenerateTableName(streamName)
+	s.Require().NoError(err)
+
+	var numbers, maxNumber int64
+	err = s.DB().QueryRowContext(
+		context.Background(),
+		fmt.Sprintf(`SELECT COUNT(*), COALESCE(MAX(no), 0) FROM %s`, postgres.QuoteTableName(tableName)),
+	).Scan(&numbers, &maxNumber)
+	s.Require().NoError(err)
+
+	s.Equal(count, numbers)
+	s.Equal(count, maxNumber)
+}
+
+func (s *hashChainTestSuite) createEventStore(scope strategyPostgres.HashChainScope) (*strategyPostgres.SingleStreamStrategy, goengine.EventStore) {
+	transformer := json.NewPayloadTransformer()
+	s.Require().NoError(transformer.RegisterPayload("tests", func() interface{} { return &payloadData{} }))
+
+	persistenceStrategy, err := strategyPostgres.NewSingleStreamStrategy(transformer, strategyPostgres.WithHashChain(scope))
+	s.Require().NoError(err)
+
+	messageFactory, err := strategySQL.NewAggregateChangedFactory(transformer)
+	s.Require().NoError(err)
+
+	eventStore, err := postgres.NewEventStore(persistenceStrategy, s.DB(), messageFactory, s.GetLogger())
+	s.Require().NoError(err)
+
+	return persistenceStrategy.(*strategyPostgres.SingleStreamStrategy), eventStore
+}
@@ -147,6 +147,18 @@ func (s *PostgresSuite) DBTableExists(tableName string) bool {
 	return exists
 }
 
+// SkipIfPostgresOlderThan skips the test when the server_version_num of the postgres server is lower than the provided
+// version, for example 130000 for postgres 13
+func (s *PostgresSuite) SkipIfPostgresOlderThan(version int) {
+	var serverVersion int
+	err := s.DB().QueryRow(`SELECT current_setting('server_version_num')::INTEGER`).Scan(&serverVersion)
+	s.Require().NoError(err, "test.postgres: failed to load the server version")
+
+	if serverVersion < version {
+		s.T().Skipf("test.postgres: requires server version %d but the server has version %d", version, serverVersion)
+	}
+}
+
 // DBQueryIsRunningWithTimeout Check if a query matching the regex is currently running
 func (s *PostgresSuite) DBQueryIsRunningWithTimeout(queryRegex *regexp.Regexp, timeout time.Duration) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
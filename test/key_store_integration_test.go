@@ -0,0 +1,170 @@
+// +build integration
+
+package test_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/crypto"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/mocks"
+	"github.com/hellofresh/goengine/strategy/json"
+	strategySQL "github.com/hellofresh/goengine/strategy/json/sql"
+	strategyPostgres "github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	"github.com/hellofresh/goengine/test/internal"
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	keyStoreTestSuite struct {
+		internal.PostgresSuite
+
+		keyStore *postgres.KeyStore
+	}
+
+	userRegistered struct {
+		UserID  string `json:"user_id" personal:"subject"`
+		Email   string `json:"email" personal:"data"`
+		Country string `json:"country"`
+	}
+)
+
+func TestKeyStoreSuite(t *testing.T) {
+	suite.Run(t, new(keyStoreTestSuite))
+}
+
+func (s *keyStoreTestSuite) SetupTest() {
+	s.PostgresSuite.SetupTest()
+
+	var err error
+	s.keyStore, err = postgres.NewKeyStore(s.DB(), "personal_data_keys")
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.keyStore.CreateTable(context.Background()))
+}
+
+func (s *keyStoreTestSuite) TearDownTest() {
+	s.keyStore = nil
+
+	s.PostgresSuite.TearDownTest()
+}
+
+func (s *keyStoreTestSuite) TestKeys() {
+	ctx := context.Background()
+
+	// Creating the table again is a no-op
+	s.Require().NoError(s.keyStore.CreateTable(ctx))
+
+	key, err := s.keyStore.Key(ctx, "alice")
+	s.Equal(crypto.ErrKeyNotFound, err)
+	s.Nil(key)
+
+	key, err = s.keyStore.GetOrCreateKey(ctx, "alice")
+	s.Require().NoError(err)
+	s.Len(key, crypto.KeySize)
+
+	existingKey, err := s.keyStore.GetOrCreateKey(ctx, "alice")
+	s.Require().NoError(err)
+	s.Equal(key, existingKey)
+
+	loadedKey, err := s.keyStore.Key(ctx, "alice")
+	s.Require().NoError(err)
+	s.Equal(key, loadedKey)
+
+	s.Require().NoError(s.keyStore.DeleteKey(ctx, "alice"))
+	s.Require().NoError(s.keyStore.DeleteKey(ctx, "alice"))
+
+	key, err = s.keyStore.Key(ctx, "alice")
+	s.Equal(crypto.ErrKeyNotFound, err)
+	s.Nil(key)
+}
+
+func (s *keyStoreTestSuite) TestConcurrentGetOrCreateKey() {
+	const calls = 10
+
+	var wg sync.WaitGroup
+	keys := make([][]byte, calls)
+	errs := make([]error, calls)
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			keys[i], errs[i] = s.keyStore.GetOrCreateKey(context.Background(), "bob")
+		}(i)
+	}
+	wg.Wait()
+
+	// Every call returns the key that was stored
+	for i := 0; i < calls; i++ {
+		s.Require().NoError(errs[i])
+		s.Equal(keys[0], keys[i])
+	}
+}
+
+func (s *keyStoreTestSuite) TestForgetPersonalData() {
+	ctx := context.Background()
+	streamName := goengine.StreamName("users")
+
+	keyStore, err := crypto.NewCachedKeyStore(s.keyStore, time.Minute)
+	s.Require().NoError(err)
+
+	transformer, err := json.NewPersonalDataPayloadTransformer(keyStore)
+	s.Require().NoError(err)
+	s.Require().NoError(transformer.RegisterPayload("user_registered", func() interface{} { return userRegistered{} }))
+
+	persistenceStrategy, err := strategyPostgres.NewSingleStreamStrategy(transformer)
+	s.Require().NoError(err)
+
+	messageFactory, err := strategySQL.NewAggregateChangedFactory(transformer)
+	s.Require().NoError(err)
+
+	eventStore, err := postgres.NewEventStore(persistenceStrategy, s.DB(), messageFactory, s.GetLogger())
+	s.Require().NoError(err)
+	s.Require().NoError(eventStore.Create(ctx, streamName))
+
+	userID := goengine.GenerateUUID()
+	registered := userRegistered{UserID: userID.String(), Email: "alice@example.com", Country: "NL"}
+	s.Require().NoError(eventStore.AppendTo(ctx, streamName, []goengine.Message{
+		mocks.NewDummyMessage(
+			goengine.GenerateUUID(),
+			registered,
+			metadata.FromMap(map[string]interface{}{
+				"_aggregate_type":    "user",
+				"_aggregate_id":      userID.String(),
+				"_aggregate_version": 1,
+			}),
+			time.Now().UTC(),
+		),
+	}))
+
+	// The personal data is not stored in plain text
+	var storedEmails int
+	err = s.DB().QueryRowContext(ctx, `SELECT COUNT(*) FROM events_users WHERE payload::TEXT LIKE '%alice@example.com%'`).Scan(&storedEmails)
+	s.Require().NoError(err)
+	s.Equal(0, storedEmails)
+
+	s.Equal(registered, s.loadPayload(eventStore, streamName))
+
+	// Once the key is deleted the personal data can no longer be read
+	s.Require().NoError(keyStore.DeleteKey(ctx, userID.String()))
+
+	s.Equal(userRegistered{UserID: userID.String(), Country: "NL"}, s.loadPayload(eventStore, streamName))
+}
+
+func (s *keyStoreTestSuite) loadPayload(eventStore goengine.EventStore, streamName goengine.StreamName) interface{} {
+	stream, err := eventStore.Load(context.Background(), streamName, 0, nil, metadata.NewMatcher())
+	s.Require().NoError(err)
+
+	messages, _, err := goengine.ReadEventStream(stream)
+	s.Require().NoError(err)
+	s.NoError(stream.Close())
+	s.Require().Len(messages, 1)
+
+	return messages[0].Payload()
+}
@@ -0,0 +1,236 @@
+// +build integration
+
+package test_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/mocks"
+	"github.com/hellofresh/goengine/strategy/json"
+	strategySQL "github.com/hellofresh/goengine/strategy/json/sql"
+	strategyPostgres "github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	"github.com/hellofresh/goengine/test/internal"
+	"github.com/stretchr/testify/suite"
+)
+
+type metadataIndexTestSuite struct {
+	internal.PostgresSuite
+
+	eventStore goengine.EventStore
+}
+
+func TestMetadataIndexSuite(t *testing.T) {
+	suite.Run(t, new(metadataIndexTestSuite))
+}
+
+func (s *metadataIndexTestSuite) SetupTest() {
+	s.PostgresSuite.SetupTest()
+
+	transformer := json.NewPayloadTransformer()
+	s.Require().NoError(transformer.RegisterPayload("tests", func() interface{} { return &payloadData{} }))
+
+	persistenceStrategy, err := strategyPostgres.NewSingleStreamStrategy(
+		transformer,
+		strategyPostgres.WithMetadataIndexes(
+			strategyPostgres.MetadataIndex{Key: "tenant_id", Kind: strategyPostgres.MetadataPromotedColumn, ColumnType: strategyPostgres.MetadataUUID},
+			strategyPostgres.MetadataIndex{Key: "priority", Kind: strategyPostgres.MetadataPromotedColumn, ColumnType: strategyPostgres.MetadataBigInt},
+			strategyPostgres.MetadataIndex{Key: "urgent", Kind: strategyPostgres.MetadataPromotedColumn, ColumnType: strategyPostgres.MetadataBoolean},
+			strategyPostgres.MetadataIndex{Key: "correlation_id", Kind: strategyPostgres.MetadataExpressionIndex},
+			strategyPostgres.MetadataIndex{Key: "channel", Kind: strategyPostgres.MetadataGINIndex},
+		),
+	)
+	s.Require().NoError(err)
+
+	messageFactory, err := strategySQL.NewAggregateChangedFactory(transformer)
+	s.Require().NoError(err)
+
+	s.eventStore, err = postgres.NewEventStore(persistenceStrategy, s.DB(), messageFactory, s.GetLogger())
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.eventStore.Create(context.Background(), "orders"))
+}
+
+func (s *metadataIndexTestSuite) TearDownTest() {
+	s.eventStore = nil
+
+	s.PostgresSuite.TearDownTest()
+}
+
+func (s *metadataIndexTestSuite) TestCreate() {
+	rows, err := s.DB().QueryContext(
+		context.Background(),
+		`SELECT indexdef FROM pg_indexes WHERE schemaname = 'public' AND tablename = 'events_orders'`,
+	)
+	s.Require().NoError(err)
+	defer func() {
+		s.NoError(rows.Close())
+	}()
+
+	var indexes []string
+	for rows.Next() {
+		var index string
+		s.Require().NoError(rows.Scan(&index))
+
+		indexes = append(indexes, index)
+	}
+	s.Require().NoError(rows.Err())
+
+	for _, expected := range []string{
+		"USING btree (metadata_tenant_id)",
+		"USING btree (metadata_priority)",
+		"USING btree (metadata_urgent)",
+		"USING btree (((metadata ->> 'correlation_id'::text)))",
+		"USING gin (metadata jsonb_path_ops)",
+	} {
+		found := false
+		for _, index := range indexes {
+			if strings.HasSuffix(index, expected) {
+				found = true
+			}
+		}
+		s.True(found, "expected a index %s in %v", expected, indexes)
+	}
+}
+
+func (s *metadataIndexTestSuite) TestLoad() {
+	ctx := context.Background()
+
+	aggregateID := goengine.GenerateUUID()
+	tenantID := goengine.GenerateUUID()
+	correlationID := goengine.GenerateUUID()
+
+	meta := []map[string]interface{}{
+		{"tenant_id": tenantID.String(), "priority": 1, "urgent": false, "correlation_id": correlationID.String(), "channel": "web"},
+		{"tenant_id": tenantID.String(), "priority": 5, "urgent": true, "channel": "app"},
+		{"tenant_id": goengine.GenerateUUID().String(), "priority": 10, "urgent": true, "correlation_id": correlationID.String()},
+		{},
+	}
+
+	messages := make([]goengine.Message, len(meta))
+	for i, values := range meta {
+		values["_aggregate_type"] = "basic"
+		values["_aggregate_id"] = aggregateID.String()
+		values["_aggregate_version"] = i + 1
+
+		messages[i] = mocks.NewDummyMessage(
+			goengine.GenerateUUID(),
+			&payloadData{Name: "alice", Balance: i},
+			metadata.FromMap(values),
+			time.Now().UTC(),
+		)
+	}
+	s.Require().NoError(s.eventStore.AppendTo(ctx, "orders", messages))
+
+	testCases := []struct {
+		title    string
+		matcher  metadata.Matcher
+		expected []goengine.Message
+	}{
+		{
+			"promoted uuid",
+			metadata.WithConstraint(metadata.NewMatcher(), "tenant_id", metadata.Equals, tenantID.String()),
+			messages[:2],
+		},
+		{
+			"promoted integer",
+			metadata.WithConstraint(metadata.NewMatcher(), "priority", metadata.GreaterThanEquals, 5),
+			messages[1:3],
+		},
+		{
+			"promoted boolean",
+			metadata.WithConstraint(metadata.NewMatcher(), "urgent", metadata.Equals, true),
+			messages[1:3],
+		},
+		{
+			"expression index",
+			metadata.WithConstraint(metadata.NewMatcher(), "correlation_id", metadata.Equals, correlationID.String()),
+			[]goengine.Message{messages[0], messages[2]},
+		},
+		{
+			"gin index",
+			metadata.WithConstraint(metadata.NewMatcher(), "channel", metadata.Equals, "app"),
+			messages[1:2],
+		},
+		{
+			"combined",
+			metadata.WithConstraint(
+				metadata.WithConstraint(metadata.NewMatcher(), "tenant_id", metadata.Equals, tenantID.String()),
+				"channel",
+				metadata.Equals,
+				"web",
+			),
+			messages[:1],
+		},
+	}
+
+	for _, testCase := range testCases {
+		s.Run(testCase.title, func() {
+			stream, err := s.eventStore.Load(ctx, "orders", 0, nil, testCase.matcher)
+			s.Require().NoError(err)
+
+			loaded, _, err := goengine.ReadEventStream(stream)
+			s.Require().NoError(err)
+			s.NoError(stream.Close())
+
+			s.Require().Len(loaded, len(testCase.expected))
+			for i, msg := range loaded {
+				s.Equal(testCase.expected[i].UUID(), msg.UUID())
+			}
+		})
+	}
+}
+
+func (s *metadataIndexTestSuite) TestLoadGINIndexValueTypes() {
+	ctx := context.Background()
+
+	aggregateID := goengine.GenerateUUID()
+	messages := make([]goengine.Message, 2)
+	for i, channel := range []interface{}{"5", 7} {
+		messages[i] = mocks.NewDummyMessage(
+			goengine.GenerateUUID(),
+			&payloadData{Name: "alice", Balance: i},
+			metadata.FromMap(map[string]interface{}{
+				"_aggregate_type":    "basic",
+				"_aggregate_id":      aggregateID.String(),
+				"_aggregate_version": i + 1,
+				"channel":            channel,
+			}),
+			time.Now().UTC(),
+		)
+	}
+	s.Require().NoError(s.eventStore.AppendTo(ctx, "orders", messages))
+
+	testCases := []struct {
+		title    string
+		value    interface{}
+		expected []goengine.Message
+	}{
+		{"string matches a stored string", "5", messages[:1]},
+		{"number is compared as text", 5, messages[:1]},
+		{"string does not match a stored number", "7", nil},
+		{"number matches a stored number", 7, messages[1:]},
+	}
+
+	for _, testCase := range testCases {
+		s.Run(testCase.title, func() {
+			matcher := metadata.WithConstraint(metadata.NewMatcher(), "channel", metadata.Equals, testCase.value)
+			stream, err := s.eventStore.Load(ctx, "orders", 0, nil, matcher)
+			s.Require().NoError(err)
+
+			loaded, _, err := goengine.ReadEventStream(stream)
+			s.Require().NoError(err)
+			s.NoError(stream.Close())
+
+			s.Require().Len(loaded, len(testCase.expected))
+			for i, msg := range loaded {
+				s.Equal(testCase.expected[i].UUID(), msg.UUID())
+			}
+		})
+	}
+}
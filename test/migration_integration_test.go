@@ -0,0 +1,170 @@
+// +build integration
+
+package test_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/strategy/json"
+	strategyPostgres "github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	"github.com/hellofresh/goengine/test/internal"
+	"github.com/stretchr/testify/suite"
+)
+
+type migrationTestSuite struct {
+	internal.PostgresSuite
+
+	manager *strategyPostgres.SingleStreamManager
+}
+
+func TestMigrationSuite(t *testing.T) {
+	suite.Run(t, new(migrationTestSuite))
+}
+
+func (s *migrationTestSuite) SetupTest() {
+	s.PostgresSuite.SetupTest()
+
+	var err error
+	s.manager, err = strategyPostgres.NewSingleStreamManager(s.DB(), s.GetLogger(), nil)
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.manager.RegisterPayloads(map[string]json.PayloadInitiator{
+		"tests": func() interface{} { return &payloadData{} },
+	}))
+}
+
+func (s *migrationTestSuite) TearDownTest() {
+	s.manager = nil
+
+	s.PostgresSuite.TearDownTest()
+}
+
+func (s *migrationTestSuite) TestMigrateEventStream() {
+	ctx := context.Background()
+
+	eventStore, err := s.manager.NewEventStore()
+	s.Require().NoError(err)
+	s.Require().NoError(eventStore.Create(ctx, "orders"))
+
+	// Tables created by older versions stored the aggregate version as SMALLINT
+	s.exec(`ALTER TABLE events_orders ALTER COLUMN aggregate_version TYPE SMALLINT`)
+
+	applied, err := s.manager.MigrateEventStream(ctx, "orders")
+	s.Require().NoError(err)
+	s.Len(applied, len(strategyPostgres.EventStreamMigrations))
+	s.Equal("integer", s.columnType("events_orders", "aggregate_version"))
+
+	// Applied migrations are recorded and not applied again
+	applied, err = s.manager.MigrateEventStream(ctx, "orders")
+	s.Require().NoError(err)
+	s.Empty(applied)
+}
+
+func (s *migrationTestSuite) TestMigrateProjections() {
+	ctx := context.Background()
+
+	eventStore, err := s.manager.NewEventStore()
+	s.Require().NoError(err)
+	s.Require().NoError(eventStore.Create(ctx, "orders"))
+
+	for _, query := range strategyPostgres.StreamProjectorCreateSchema("projections", "orders", "events_orders") {
+		s.exec(query)
+	}
+	for _, query := range strategyPostgres.AggregateProjectorCreateSchema("order_projection", "orders", "events_orders") {
+		s.exec(query)
+	}
+	for _, query := range strategyPostgres.AggregateProjectorCreateSchema("invoice_projection", "orders", "events_orders") {
+		s.exec(query)
+	}
+
+	// Tables created by older versions do not have the locked and failed columns
+	s.exec(`ALTER TABLE projections DROP COLUMN locked`)
+	s.exec(`ALTER TABLE order_projection DROP COLUMN locked, DROP COLUMN failed`)
+
+	applied, err := s.manager.MigrateStreamProjection(ctx, "projections")
+	s.Require().NoError(err)
+	s.Len(applied, len(strategyPostgres.StreamProjectionMigrations))
+	s.Equal("boolean", s.columnType("projections", "locked"))
+
+	applied, err = s.manager.MigrateAggregateProjection(ctx, "order_projection")
+	s.Require().NoError(err)
+	s.Len(applied, len(strategyPostgres.AggregateProjectionMigrations))
+	s.Equal("boolean", s.columnType("order_projection", "locked"))
+	s.Equal("boolean", s.columnType("order_projection", "failed"))
+
+	// The migrations can be applied to tables created by the current version
+	applied, err = s.manager.MigrateAggregateProjection(ctx, "invoice_projection")
+	s.Require().NoError(err)
+	s.Len(applied, len(strategyPostgres.AggregateProjectionMigrations))
+}
+
+func (s *migrationTestSuite) TestChangeAggregateIDType() {
+	ctx := context.Background()
+	streamName := goengine.StreamName("orders")
+
+	eventStore, err := s.manager.NewEventStore()
+	s.Require().NoError(err)
+	s.Require().NoError(eventStore.Create(ctx, streamName))
+
+	for _, query := range strategyPostgres.AggregateProjectorCreateSchema("order_projection", streamName, "events_orders") {
+		s.exec(query)
+	}
+
+	aggregateID := goengine.GenerateUUID()
+	messages := newIndexedMessages(aggregateID, goengine.GenerateUUID(), 3)
+	s.Require().NoError(eventStore.AppendTo(ctx, streamName, messages))
+	s.exec(`INSERT INTO order_projection (aggregate_id, position) VALUES ($1, 3)`, aggregateID)
+
+	changed, err := s.manager.ChangeAggregateIDType(ctx, streamName, strategyPostgres.AggregateIDText, "order_projection")
+	s.Require().NoError(err)
+	s.Equal([]string{"events_orders", "order_projection"}, changed)
+	s.Equal("text", s.columnType("events_orders", "aggregate_id"))
+	s.Equal("text", s.columnType("order_projection", "aggregate_id"))
+
+	// The events of the aggregate can still be loaded
+	stream, err := eventStore.Load(
+		ctx,
+		streamName,
+		0,
+		nil,
+		metadata.WithConstraint(metadata.NewMatcher(), "_aggregate_id", metadata.Equals, aggregateID.String()),
+	)
+	s.Require().NoError(err)
+	loaded, _, err := goengine.ReadEventStream(stream)
+	s.Require().NoError(err)
+	s.NoError(stream.Close())
+	s.Len(loaded, len(messages))
+
+	// Tables that already have the type are not changed
+	changed, err = s.manager.ChangeAggregateIDType(ctx, streamName, strategyPostgres.AggregateIDText, "order_projection")
+	s.Require().NoError(err)
+	s.Empty(changed)
+
+	// UUID aggregate ids can not be converted to BIGINT so none of the tables are changed
+	_, err = s.manager.ChangeAggregateIDType(ctx, streamName, strategyPostgres.AggregateIDBigInt, "order_projection")
+	s.Error(err)
+	s.Equal("text", s.columnType("events_orders", "aggregate_id"))
+	s.Equal("text", s.columnType("order_projection", "aggregate_id"))
+}
+
+func (s *migrationTestSuite) exec(query string, args ...interface{}) {
+	_, err := s.DB().ExecContext(context.Background(), query, args...)
+	s.Require().NoError(err, "failed to execute %s", query)
+}
+
+// columnType returns the data type of the column of a table in the public schema
+func (s *migrationTestSuite) columnType(tableName string, column string) string {
+	var dataType string
+	err := s.DB().QueryRowContext(
+		context.Background(),
+		`SELECT data_type FROM information_schema.columns WHERE table_schema = 'public' AND table_name = $1 AND column_name = $2`,
+		tableName,
+		column,
+	).Scan(&dataType)
+	s.Require().NoError(err, "failed to load the type of %s.%s", tableName, column)
+
+	return dataType
+}
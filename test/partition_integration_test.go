@@ -0,0 +1,147 @@
+// +build integration
+
+package test_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/mocks"
+	"github.com/hellofresh/goengine/strategy/json"
+	strategySQL "github.com/hellofresh/goengine/strategy/json/sql"
+	strategyPostgres "github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	"github.com/hellofresh/goengine/test/internal"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/suite"
+)
+
+type partitionTestSuite struct {
+	internal.PostgresSuite
+
+	strategy   *strategyPostgres.SingleStreamStrategy
+	eventStore goengine.EventStore
+}
+
+func TestPartitionSuite(t *testing.T) {
+	suite.Run(t, new(partitionTestSuite))
+}
+
+func (s *partitionTestSuite) SetupTest() {
+	s.PostgresSuite.SetupTest()
+
+	// Partitioned event streams require postgres 13
+	s.SkipIfPostgresOlderThan(130000)
+
+	transformer := json.NewPayloadTransformer()
+	s.Require().NoError(transformer.RegisterPayload("tests", func() interface{} { return &payloadData{} }))
+
+	persistenceStrategy, err := strategyPostgres.NewSingleStreamStrategy(
+		transformer,
+		strategyPostgres.WithPartitioning(strategyPostgres.Partitioning{Key: strategyPostgres.PartitionByNumber, Size: 5, Premake: 1}),
+	)
+	s.Require().NoError(err)
+	s.strategy = persistenceStrategy.(*strategyPostgres.SingleStreamStrategy)
+
+	messageFactory, err := strategySQL.NewAggregateChangedFactory(transformer)
+	s.Require().NoError(err)
+
+	s.eventStore, err = postgres.NewEventStore(persistenceStrategy, s.DB(), messageFactory, s.GetLogger())
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.eventStore.Create(context.Background(), "orders"))
+}
+
+func (s *partitionTestSuite) TearDownTest() {
+	s.strategy = nil
+	s.eventStore = nil
+
+	s.PostgresSuite.TearDownTest()
+}
+
+func (s *partitionTestSuite) TestCreatePartitions() {
+	ctx := context.Background()
+
+	s.True(s.DBTableExists("events_orders_p0"))
+	s.True(s.DBTableExists("events_orders_p1"))
+	s.True(s.DBTableExists("events_orders_default"))
+
+	// The events after the premade partitions are stored in the default partition
+	messages := newIndexedMessages(goengine.GenerateUUID(), goengine.GenerateUUID(), 12)
+	s.Require().NoError(s.eventStore.AppendTo(ctx, "orders", messages))
+	s.Equal(5, s.countEvents("events_orders_p0"))
+	s.Equal(5, s.countEvents("events_orders_p1"))
+	s.Equal(2, s.countEvents("events_orders_default"))
+
+	// The partition overlapping the events in the default partition is skipped while the next partition is created
+	skipped, err := s.strategy.CreatePartitions(ctx, s.DB(), "orders")
+	s.Require().NoError(err)
+	s.Equal([]string{"events_orders_p2"}, skipped)
+	s.False(s.DBTableExists("events_orders_p2"))
+	s.True(s.DBTableExists("events_orders_p3"))
+
+	// All events can still be loaded
+	stream, err := s.eventStore.Load(ctx, "orders", 0, nil, metadata.NewMatcher())
+	s.Require().NoError(err)
+	loaded, _, err := goengine.ReadEventStream(stream)
+	s.Require().NoError(err)
+	s.NoError(stream.Close())
+	s.Len(loaded, len(messages))
+}
+
+func (s *partitionTestSuite) TestUniqueness() {
+	ctx := context.Background()
+
+	aggregateID := goengine.GenerateUUID()
+	messages := newIndexedMessages(aggregateID, goengine.GenerateUUID(), 7)
+	s.Require().NoError(s.eventStore.AppendTo(ctx, "orders", messages[:6]))
+
+	s.Run("duplicate aggregate version in another partition", func() {
+		duplicate := mocks.NewDummyMessage(
+			goengine.GenerateUUID(),
+			messages[6].Payload(),
+			messages[0].Metadata(),
+			time.Now().UTC(),
+		)
+
+		err := s.eventStore.AppendTo(ctx, "orders", []goengine.Message{duplicate})
+		s.assertUniqueViolation(err)
+	})
+
+	s.Run("duplicate event id in another partition", func() {
+		duplicate := mocks.NewDummyMessage(
+			messages[0].UUID(),
+			messages[6].Payload(),
+			messages[6].Metadata(),
+			time.Now().UTC(),
+		)
+
+		err := s.eventStore.AppendTo(ctx, "orders", []goengine.Message{duplicate})
+		s.assertUniqueViolation(err)
+	})
+
+	s.Run("next aggregate version", func() {
+		s.NoError(s.eventStore.AppendTo(ctx, "orders", messages[6:]))
+	})
+}
+
+func (s *partitionTestSuite) assertUniqueViolation(err error) {
+	if pqErr, ok := err.(*pq.Error); s.True(ok, "expected a postgres error but got %v", err) {
+		s.Equal(pq.ErrorCode("23505"), pqErr.Code)
+	}
+}
+
+// countEvents returns the number of events in the partition
+func (s *partitionTestSuite) countEvents(partition string) int {
+	var count int
+	err := s.DB().QueryRowContext(
+		context.Background(),
+		`SELECT COUNT(*) FROM `+postgres.QuoteTableName(partition),
+	).Scan(&count)
+	s.Require().NoError(err)
+
+	return count
+}
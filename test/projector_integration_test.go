@@ -87,6 +87,9 @@ func (p *DepositedProjection) EncodeState(obj interface{}) ([]byte, error) {
 type projectorSuite struct {
 	internal.PostgresSuite
 
+	// strategyOptions are the options of the persistence strategy of the event stream
+	strategyOptions []strategyPostgres.Option
+
 	eventStream        goengine.StreamName
 	eventStore         *postgres.EventStore
 	eventStoreTable    string
@@ -103,7 +106,7 @@ func (s *projectorSuite) SetupTest() {
 	s.payloadTransformer = strategyJSON.NewPayloadTransformer()
 
 	// Use a persistence strategy
-	persistenceStrategy, err := strategyPostgres.NewSingleStreamStrategy(s.payloadTransformer)
+	persistenceStrategy, err := strategyPostgres.NewSingleStreamStrategy(s.payloadTransformer, s.strategyOptions...)
 	s.Require().NoError(err, "failed initializing persistent strategy")
 
 	// Create message factory
@@ -51,6 +51,22 @@ func TestStreamProjectorSuite(t *testing.T) {
 			},
 		})
 	})
+	t.Run("AdvisoryLock with compressed and indexed event stream", func(t *testing.T) {
+		suite.Run(t, &streamProjectorTestSuite{
+			projectorSuite: projectorSuite{
+				strategyOptions: []strategyPostgres.Option{
+					strategyPostgres.WithCompression(0),
+					strategyPostgres.WithMetadataIndexes(
+						strategyPostgres.MetadataIndex{Key: "channel", Kind: strategyPostgres.MetadataPromotedColumn, ColumnType: strategyPostgres.MetadataText},
+						strategyPostgres.MetadataIndex{Key: "tenant_id", Kind: strategyPostgres.MetadataExpressionIndex},
+					),
+				},
+			},
+			createProjectionStorage: func(eventStoreTable, projectionTable string, serialization driverSQL.ProjectionStateSerialization, logger goengine.Logger) (storage driverSQL.StreamProjectorStorage, e error) {
+				return postgres.NewAdvisoryLockStreamProjectionStorage(eventStoreTable, projectionTable, serialization, true, logger)
+			},
+		})
+	})
 }
 
 func (s *streamProjectorTestSuite) SetupTest() {
@@ -119,6 +135,7 @@ func (s *streamProjectorTestSuite) TestRunAndListen() {
 			return driverSQL.ProjectionFail
 		},
 		s.GetLogger(),
+		driverSQL.WithStreamProjectorMetrics(s.Metrics),
 	)
 	s.Require().NoError(err, "failed to create projector")
 
@@ -191,6 +208,7 @@ func (s *streamProjectorTestSuite) TestRunAndListen() {
 				return driverSQL.ProjectionFail
 			},
 			s.GetLogger(),
+			driverSQL.WithStreamProjectorMetrics(s.Metrics),
 		)
 		s.Require().NoError(err, "failed to create projector")
 
@@ -243,6 +261,7 @@ func (s *streamProjectorTestSuite) TestRun() {
 			return driverSQL.ProjectionFail
 		},
 		s.GetLogger(),
+		driverSQL.WithStreamProjectorMetrics(s.Metrics),
 	)
 	s.Require().NoError(err, "failed to create projector")
 
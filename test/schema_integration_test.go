@@ -0,0 +1,86 @@
+// +build integration
+
+package test_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/strategy/json"
+	strategyPostgres "github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	"github.com/hellofresh/goengine/test/internal"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/suite"
+)
+
+type schemaTestSuite struct {
+	internal.PostgresSuite
+}
+
+func TestSchemaSuite(t *testing.T) {
+	suite.Run(t, new(schemaTestSuite))
+}
+
+func (s *schemaTestSuite) TestNotificationChannelPerSchema() {
+	ctx := context.Background()
+
+	schemas := []string{"billing", "shipping"}
+	managers := make(map[string]*strategyPostgres.SingleStreamManager, len(schemas))
+	channels := make(map[string]string, len(schemas))
+	for _, schema := range schemas {
+		_, err := s.DB().ExecContext(ctx, `CREATE SCHEMA `+schema)
+		s.Require().NoError(err)
+
+		manager, err := strategyPostgres.NewSingleStreamManagerInSchema(s.DB(), schema, s.GetLogger(), nil)
+		s.Require().NoError(err)
+		s.Require().NoError(manager.RegisterPayloads(map[string]json.PayloadInitiator{
+			"tests": func() interface{} { return &payloadData{} },
+		}))
+
+		eventStore, err := manager.NewEventStore()
+		s.Require().NoError(err)
+		s.Require().NoError(eventStore.Create(ctx, "orders"))
+
+		for _, query := range strategyPostgres.StreamProjectorCreateSchema(schema+".projections", "orders", schema+".events_orders") {
+			_, err := s.DB().ExecContext(ctx, query)
+			s.Require().NoError(err)
+		}
+
+		channel, err := manager.NotificationChannel("orders")
+		s.Require().NoError(err)
+
+		managers[schema] = manager
+		channels[schema] = channel
+	}
+	s.Equal("billing.orders", channels["billing"])
+	s.Equal("shipping.orders", channels["shipping"])
+
+	listener := pq.NewListener(s.PostgresDSN, time.Millisecond, time.Second, nil)
+	defer func() {
+		s.NoError(listener.Close())
+	}()
+	for _, channel := range channels {
+		s.Require().NoError(listener.Listen(channel))
+	}
+
+	eventStore, err := managers["billing"].NewEventStore()
+	s.Require().NoError(err)
+	s.Require().NoError(eventStore.AppendTo(ctx, "orders", newIndexedMessages(goengine.GenerateUUID(), goengine.GenerateUUID(), 1)))
+
+	select {
+	case n := <-listener.Notify:
+		s.Require().NotNil(n)
+		s.Equal("billing.orders", n.Channel)
+	case <-time.After(5 * time.Second):
+		s.Fail("no notification received on the billing channel")
+	}
+
+	// The events of the billing stream must not be send on the channel of the shipping stream
+	select {
+	case n := <-listener.Notify:
+		s.Failf("unexpected notification", "%+v", n)
+	case <-time.After(250 * time.Millisecond):
+	}
+}
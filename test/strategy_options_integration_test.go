@@ -0,0 +1,188 @@
+// +build integration
+
+package test_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/goengine"
+	"github.com/hellofresh/goengine/driver/sql/postgres"
+	"github.com/hellofresh/goengine/metadata"
+	"github.com/hellofresh/goengine/mocks"
+	"github.com/hellofresh/goengine/strategy/json"
+	strategySQL "github.com/hellofresh/goengine/strategy/json/sql"
+	strategyPostgres "github.com/hellofresh/goengine/strategy/json/sql/postgres"
+	"github.com/hellofresh/goengine/test/internal"
+	"github.com/stretchr/testify/suite"
+)
+
+type strategyOptionsTestSuite struct {
+	internal.PostgresSuite
+}
+
+func TestStrategyOptionsSuite(t *testing.T) {
+	suite.Run(t, new(strategyOptionsTestSuite))
+}
+
+func (s *strategyOptionsTestSuite) TestCombinedOptions() {
+	ctx := context.Background()
+
+	_, err := s.DB().ExecContext(ctx, `CREATE SCHEMA billing`)
+	s.Require().NoError(err)
+
+	metadataIndexes := strategyPostgres.WithMetadataIndexes(
+		strategyPostgres.MetadataIndex{Key: "tenant_id", Kind: strategyPostgres.MetadataPromotedColumn, ColumnType: strategyPostgres.MetadataUUID},
+		strategyPostgres.MetadataIndex{Key: "channel", Kind: strategyPostgres.MetadataGINIndex},
+	)
+
+	testCases := []struct {
+		title         string
+		streamName    goengine.StreamName
+		serverVersion int
+		options       []strategyPostgres.Option
+	}{
+		{
+			"compression, aggregate id type and metadata indexes",
+			"orders_compressed",
+			0,
+			[]strategyPostgres.Option{
+				strategyPostgres.WithCompression(0),
+				strategyPostgres.WithAggregateIDType(strategyPostgres.AggregateIDText),
+				metadataIndexes,
+			},
+		},
+		{
+			"schema, hash chain and metadata indexes",
+			"orders_hash_chained",
+			110000,
+			[]strategyPostgres.Option{
+				strategyPostgres.WithSchema("billing"),
+				strategyPostgres.WithHashChain(strategyPostgres.HashChainPerAggregate),
+				metadataIndexes,
+			},
+		},
+		{
+			"all options",
+			"orders_all",
+			130000,
+			[]strategyPostgres.Option{
+				strategyPostgres.WithSchema("billing"),
+				strategyPostgres.WithCompression(64),
+				strategyPostgres.WithAggregateIDType(strategyPostgres.AggregateIDText),
+				strategyPostgres.WithHashChain(strategyPostgres.HashChainPerStream),
+				strategyPostgres.WithPartitioning(strategyPostgres.Partitioning{Key: strategyPostgres.PartitionByMonth, Premake: 1}),
+				metadataIndexes,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		s.Run(testCase.title, func() {
+			s.SkipIfPostgresOlderThan(testCase.serverVersion)
+
+			transformer := json.NewPayloadTransformer()
+			s.Require().NoError(transformer.RegisterPayload("tests", func() interface{} { return &payloadData{} }))
+
+			persistenceStrategy, err := strategyPostgres.NewSingleStreamStrategy(transformer, testCase.options...)
+			s.Require().NoError(err)
+
+			messageFactory, err := strategySQL.NewAggregateChangedFactory(transformer)
+			s.Require().NoError(err)
+
+			eventStore, err := postgres.NewEventStore(persistenceStrategy, s.DB(), messageFactory, s.GetLogger())
+			s.Require().NoError(err)
+
+			s.Require().NoError(eventStore.Create(ctx, testCase.streamName))
+
+			tenantID := goengine.GenerateUUID()
+			messages := newIndexedMessages(goengine.GenerateUUID(), tenantID, 5)
+			s.Require().NoError(eventStore.AppendTo(ctx, testCase.streamName, messages))
+
+			// All events can be loaded
+			s.assertLoaded(eventStore, testCase.streamName, metadata.NewMatcher(), messages)
+
+			// The promoted metadata key is stored in it's column and can be searched
+			tableName, err := persistenceStrategy.GenerateTableName(testCase.streamName)
+			s.Require().NoError(err)
+
+			var promoted int
+			err = s.DB().QueryRowContext(
+				ctx,
+				fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE metadata_tenant_id = $1`, postgres.QuoteTableName(tableName)),
+				tenantID,
+			).Scan(&promoted)
+			s.Require().NoError(err)
+			s.Equal(len(messages), promoted)
+
+			s.assertLoaded(
+				eventStore,
+				testCase.streamName,
+				metadata.WithConstraint(metadata.NewMatcher(), "tenant_id", metadata.Equals, tenantID.String()),
+				messages,
+			)
+
+			// The GIN indexed metadata key can be searched
+			s.assertLoaded(
+				eventStore,
+				testCase.streamName,
+				metadata.WithConstraint(metadata.NewMatcher(), "channel", metadata.Equals, "app"),
+				[]goengine.Message{messages[1], messages[3]},
+			)
+		})
+	}
+}
+
+// assertLoaded asserts that loading the stream using the matcher returns the expected messages
+func (s *strategyOptionsTestSuite) assertLoaded(
+	eventStore goengine.EventStore,
+	streamName goengine.StreamName,
+	matcher metadata.Matcher,
+	expected []goengine.Message,
+) {
+	stream, err := eventStore.Load(context.Background(), streamName, 0, nil, matcher)
+	s.Require().NoError(err)
+	defer func() {
+		s.NoError(stream.Close())
+	}()
+
+	loaded, _, err := goengine.ReadEventStream(stream)
+	s.Require().NoError(err)
+	s.Require().Len(loaded, len(expected))
+
+	for i, msg := range loaded {
+		s.Equal(expected[i].UUID(), msg.UUID())
+		s.Equal(expected[i].Payload(), msg.Payload())
+		s.Equal(expected[i].Metadata().Value("channel"), msg.Metadata().Value("channel"))
+	}
+}
+
+// newIndexedMessages returns count versions of the aggregate with the tenant id and a channel in their metadata
+func newIndexedMessages(aggregateID goengine.UUID, tenantID goengine.UUID, count int) []goengine.Message {
+	messages := make([]goengine.Message, count)
+	for i := range messages {
+		channel := "web"
+		if i%2 == 1 {
+			channel = "app"
+		}
+
+		meta := metadata.FromMap(map[string]interface{}{
+			"_aggregate_type":    "basic",
+			"_aggregate_id":      aggregateID.String(),
+			"_aggregate_version": i + 1,
+			"tenant_id":          tenantID.String(),
+			"channel":            channel,
+		})
+
+		messages[i] = mocks.NewDummyMessage(
+			goengine.GenerateUUID(),
+			&payloadData{Name: fmt.Sprintf("alice %d", i), Balance: i * 100},
+			meta,
+			time.Now().UTC(),
+		)
+	}
+
+	return messages
+}
@@ -0,0 +1,51 @@
+package goengine
+
+import "context"
+
+type (
+	// Tracer is used to trace the operations executed by goengine
+	Tracer interface {
+		// StartSpan starts a span for the operation as a child of the span within the context
+		StartSpan(ctx context.Context, operation string) (context.Context, Span)
+
+		// StartMessageSpan starts a span for the operation as a child of the span within the context.
+		// The span is linked to the span that appended the message when the message contains a trace context.
+		StartMessageSpan(ctx context.Context, operation string, message Message) (context.Context, Span)
+
+		// Inject returns the message with the trace context of the span within the context added to it's metadata
+		Inject(ctx context.Context, message Message) Message
+	}
+
+	// Span represents a single traced operation.
+	// The attributes and error of the span can be set until End is called.
+	Span interface {
+		Int64(k string, v int64)
+		String(k, v string)
+
+		// Error marks the span as failed, a nil error is ignored
+		Error(err error)
+
+		// End completes the span
+		End()
+	}
+
+	// tracerContextKey is the key used to store the Tracer within a context
+	tracerContextKey struct{}
+)
+
+// ContextWithTracer returns a copy of the context containing the tracer.
+// The tracer is used by the goengine components that are called with the returned context or a derived context.
+func ContextWithTracer(ctx context.Context, tracer Tracer) context.Context {
+	return context.WithValue(ctx, tracerContextKey{}, tracer)
+}
+
+// TracerFromContext returns the Tracer within the context or the NopTracer when the context contains no tracer
+func TracerFromContext(ctx context.Context) Tracer {
+	if ctx != nil {
+		if tracer, ok := ctx.Value(tracerContextKey{}).(Tracer); ok && tracer != nil {
+			return tracer
+		}
+	}
+
+	return NopTracer
+}
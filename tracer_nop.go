@@ -0,0 +1,39 @@
+package goengine
+
+import "context"
+
+// NopTracer is a no-op Tracer.
+// This tracer is used when the context contains no tracer and avoids the need for `if tracer != nil` everywhere
+var NopTracer Tracer = &nopTracer{}
+
+// nopTracer is no-op Tracer
+type nopTracer struct {
+}
+
+func (nopTracer) StartSpan(ctx context.Context, operation string) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+func (nopTracer) StartMessageSpan(ctx context.Context, operation string, message Message) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+func (nopTracer) Inject(ctx context.Context, message Message) Message {
+	return message
+}
+
+// nopSpan is a no-op Span
+type nopSpan struct {
+}
+
+func (nopSpan) Int64(k string, v int64) {
+}
+
+func (nopSpan) String(k, v string) {
+}
+
+func (nopSpan) Error(err error) {
+}
+
+func (nopSpan) End() {
+}
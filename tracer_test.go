@@ -0,0 +1,33 @@
+// +build unit
+
+package goengine_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hellofresh/goengine"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	stubTracer struct {
+		goengine.Tracer
+	}
+
+	otherContextKey struct{}
+)
+
+func TestTracerFromContext(t *testing.T) {
+	t.Run("Default to the NopTracer", func(t *testing.T) {
+		assert.Equal(t, goengine.NopTracer, goengine.TracerFromContext(context.Background()))
+	})
+
+	t.Run("Tracer within the context", func(t *testing.T) {
+		tracer := &stubTracer{}
+		ctx := goengine.ContextWithTracer(context.Background(), tracer)
+
+		assert.Equal(t, tracer, goengine.TracerFromContext(ctx))
+		assert.Equal(t, tracer, goengine.TracerFromContext(context.WithValue(ctx, otherContextKey{}, "value")))
+	})
+}